@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// AccessTokens はAPIの利用者を認証するトークン（"ユーザー名=トークンの SHA-256 の16進数" の形式）
+// リクエストの Authorization: Bearer のトークンのハッシュが一致した場合、そのユーザーとして扱う
+// 設定ファイルや /api/admin/config にトークンそのものが残らないよう、ハッシュだけを設定する
+var AccessTokens []string
+
+// AdminUsers は公開範囲に関係なくすべてのリポジトリにアクセスできるユーザー
+var AdminUsers []string
+
+// AuthProxyHeader は認証プロキシが認証したユーザー名を渡すヘッダー（X-Forwarded-User など）
+// 空の場合（既定）はヘッダーでの認証を使わない。プロキシがクライアントの送った同じ名前のヘッダーを消さないと偽装できるため、明示的に有効にする
+var AuthProxyHeader = ""
+
+// AuthProxySources は AuthProxyHeader を信用する接続元（CIDR、IP アドレス、または unix ドメインソケットを表す "unix"）
+// レート制限の TrustedProxies とは別に設定する（既定は空で、どこからの接続でもヘッダーを信用しない）
+var AuthProxySources []string
+
+// authProxyNetworks と authProxyUnix は AuthProxySources を解析したもの
+var (
+	authProxyNetworks []*net.IPNet
+	authProxyUnix     bool
+)
+
+// headerNamePattern はHTTPのヘッダー名として使える文字列
+var headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// tokenHashPattern はトークンの SHA-256 の16進数
+var tokenHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// userNamePattern はメンバーや管理者に指定できるユーザー名
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@+-]{0,99}$`)
+
+// getAccessConfig はトークンと管理者のユーザーの設定を返す
+func getAccessConfig() ([]string, []string) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return AccessTokens, AdminUsers
+}
+
+// getAuthProxyConfig は認証プロキシのヘッダーと信用する接続元の設定を返す
+func getAuthProxyConfig() (string, []*net.IPNet, bool) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return AuthProxyHeader, authProxyNetworks, authProxyUnix
+}
+
+// parseAuthProxySources は AuthProxySources を解析する
+func parseAuthProxySources(sources []string) ([]*net.IPNet, bool, error) {
+	networks := []*net.IPNet{}
+	unix := false
+	for _, source := range sources {
+		if source == "unix" {
+			unix = true
+			continue
+		}
+		parsed, err := parseTrustedProxies([]string{source})
+		if err != nil {
+			return nil, false, fmt.Errorf("authProxySources の '%s' は CIDR、IP アドレスまたは unix ではありません", source)
+		}
+		networks = append(networks, parsed...)
+	}
+	return networks, unix, nil
+}
+
+// validateAuthProxy は認証プロキシの設定を確認する
+func validateAuthProxy(header string, sources []string) error {
+	if header != "" && !headerNamePattern.MatchString(header) {
+		return fmt.Errorf("authProxyHeader はHTTPのヘッダー名として使えません: %s", header)
+	}
+	if header != "" && len(sources) == 0 {
+		return fmt.Errorf("authProxyHeader を指定する場合は authProxySources に認証プロキシの接続元を指定してください")
+	}
+	_, _, err := parseAuthProxySources(sources)
+	return err
+}
+
+// validateAccessTokens はトークンの設定の形式を確認する
+func validateAccessTokens(tokens []string) error {
+	for _, entry := range tokens {
+		user, hash, ok := strings.Cut(entry, "=")
+		if !ok || !userNamePattern.MatchString(user) || !tokenHashPattern.MatchString(hash) {
+			return fmt.Errorf("accessTokens は ユーザー名=トークンの SHA-256（16進数64文字） の形式で指定してください: %s", user)
+		}
+	}
+	return nil
+}
+
+// bearerToken は Authorization: Bearer のトークンを返す（ない場合は空）
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// proxyUser は認証プロキシが AuthProxyHeader で渡したユーザー名を返す
+// AuthProxyHeader が空の場合と、AuthProxySources 以外からの接続では、ヘッダーを偽装できるため使わない
+func proxyUser(r *http.Request) string {
+	header, networks, unix := getAuthProxyConfig()
+	if header == "" {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		trusted := false
+		for _, network := range networks {
+			if network.Contains(ip) {
+				trusted = true
+				break
+			}
+		}
+		if !trusted {
+			return ""
+		}
+	} else if !unix {
+		// unix ドメインソケットからの接続（RemoteAddr が空または "@"）
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get(header))
+}
+
+// tokenUser は Bearer のトークンに対応するユーザー名を返す（一致するトークンがない場合は空）
+func tokenUser(r *http.Request) string {
+	token := bearerToken(r)
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	hash := []byte(hex.EncodeToString(sum[:]))
+
+	tokens, _ := getAccessConfig()
+	for _, entry := range tokens {
+		user, expected, _ := strings.Cut(entry, "=")
+		if subtle.ConstantTimeCompare(hash, []byte(expected)) == 1 {
+			return user
+		}
+	}
+	return ""
+}
+
+// requestUser はリクエストを送った認証済みのユーザー名を返す（認証されていない場合は空）
+// トークンを優先し、ない場合はリバースプロキシが認証したユーザーを使う
+func requestUser(r *http.Request) string {
+	if user := tokenUser(r); user != "" {
+		return user
+	}
+	return proxyUser(r)
+}
+
+// isAdminUser はユーザーがすべてのリポジトリにアクセスできるかどうかを返す
+func isAdminUser(user string) bool {
+	_, admins := getAccessConfig()
+	return user != "" && containsString(admins, user)
+}
+
+// requireAdmin は管理者（adminUsers）のユーザーだけが利用できるハンドラーにする
+// 管理者用API（/api/admin/）とゴミ箱のAPIの登録に使う。認証されていない場合は 401、管理者でない場合は 403 を返す
+// CORS のプリフライト（OPTIONS）は認証情報を送らないため、そのまま元のハンドラーに渡す
+func requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := requestUser(r)
+		if r.Method == http.MethodOptions || isAdminUser(user) {
+			handler(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if user == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "このAPIを利用するにはユーザーの認証が必要です"})
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "このAPIは管理者だけが利用できます"})
+	}
+}
+
+// canAccessRepository はユーザー（空の場合は認証されていない利用者）がリポジトリを見られるかどうかを返す
+//
+//	public    だれでも
+//	internal  認証されたユーザー
+//	private   管理者（adminUsers）とリポジトリのメンバー
+func canAccessRepository(user string, meta RepositoryMetadata) bool {
+	switch meta.Visibility {
+	case VisibilityInternal:
+		return user != ""
+	case VisibilityPrivate:
+		return isAdminUser(user) || (user != "" && containsString(meta.Members, user))
+	default:
+		return true
+	}
+}
+
+// canManageRepositoryAccess はユーザーがリポジトリの公開範囲とメンバーを変更できるかどうかを返す
+// 見られるだけの利用者が private にして自分だけをメンバーにし、ほかの利用者を締め出せないよう、管理者と既存のメンバーに限る
+func canManageRepositoryAccess(user string, meta RepositoryMetadata) bool {
+	return isAdminUser(user) || (user != "" && containsString(meta.Members, user))
+}
+
+// repositoryAccessible はリクエストを送った利用者がリポジトリを見られるかどうかを返す
+// Wiki のリポジトリは元のリポジトリの公開範囲に従う
+func repositoryAccessible(r *http.Request, groupName, repoName string) bool {
+	repoName = strings.TrimSuffix(repoName, WikiRepositorySuffix)
+	return canAccessRepository(requestUser(r), getRepositoryMetadata(groupName, repoName))
+}
+
+// findAccessibleRepository はリクエストを送った利用者が見られるリポジトリのパスを返す
+// 見られないリポジトリは存在を知られないよう、存在しない場合と同じく false を返す
+func findAccessibleRepository(r *http.Request, groupName, repoName string) (string, bool) {
+	repoPath, ok := findRepository(groupName, repoName)
+	if !ok || !repositoryAccessible(r, groupName, repoName) {
+		return "", false
+	}
+	return repoPath, true
+}
+
+// filterAccessibleRepositories はリポジトリ一覧から、リクエストを送った利用者が見られないリポジトリを除く
+func filterAccessibleRepositories(r *http.Request, repos []GitRepository) []GitRepository {
+	user := requestUser(r)
+	filtered := make([]GitRepository, 0, len(repos))
+	for _, repo := range repos {
+		if canAccessRepository(user, repo.RepositoryMetadata) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// APIV1Prefix はバージョン付きAPIのパスの接頭辞
+// /api/v1/... は /api/... と同じハンドラーで処理し、レスポンスを共通の形式（エンベロープ）に包む
+const APIV1Prefix = "/api/v1/"
+
+// apiV1RawPaths はエンベロープに包まずにそのまま返すパス（JSON 自体が標準の形式のもの）
+var apiV1RawPaths = []string{"/api/openapi.json"}
+
+// APIV1Response はバージョン付きAPIのレスポンスの共通の形式
+// 成功した場合は data、失敗した場合は error のどちらか一方だけを含む
+type APIV1Response struct {
+	Data       interface{}      `json:"data,omitempty"`
+	Error      *APIV1Error      `json:"error,omitempty"`
+	Pagination *APIV1Pagination `json:"pagination,omitempty"` // ページ単位で取得するAPIの場合のみ
+}
+
+// APIV1Error はバージョン付きAPIのエラーを表す
+type APIV1Error struct {
+	Code    string `json:"code"`             // 機械的に判定するためのエラーコード（not_found など）
+	Message string `json:"message"`          // 英語のメッセージ
+	Detail  string `json:"detail,omitempty"` // サーバーが返した詳細なメッセージ（日本語）
+}
+
+// APIV1Pagination はページ単位で取得するAPIのページの情報を表す
+type APIV1Pagination struct {
+	Page    int  `json:"page"`
+	Limit   int  `json:"limit"`
+	HasMore bool `json:"hasMore"`         // 次のページがあるかもしれない（このページが limit 件ちょうどの場合）
+	Total   *int `json:"total,omitempty"` // 総数が分かるAPI（リポジトリ一覧、ディレクトリの内容）の場合の総数
+}
+
+// apiV1Errors はステータスコードごとのエラーコードと英語のメッセージ
+var apiV1Errors = map[int]APIV1Error{
+	http.StatusBadRequest:            {Code: "invalid_request", Message: "The request is invalid."},
+	http.StatusUnauthorized:          {Code: "unauthorized", Message: "Authentication is required."},
+	http.StatusForbidden:             {Code: "forbidden", Message: "Access to this resource is forbidden."},
+	http.StatusNotFound:              {Code: "not_found", Message: "The requested resource was not found."},
+	http.StatusMethodNotAllowed:      {Code: "method_not_allowed", Message: "The method is not allowed for this resource."},
+	http.StatusConflict:              {Code: "conflict", Message: "The request conflicts with the current state of the resource."},
+	http.StatusRequestEntityTooLarge: {Code: "payload_too_large", Message: "The request body is too large."},
+	http.StatusUnsupportedMediaType:  {Code: "unsupported_media_type", Message: "The media type is not supported."},
+	http.StatusTooManyRequests:       {Code: "too_many_requests", Message: "Too many requests. Please retry later."},
+	http.StatusInternalServerError:   {Code: "internal_error", Message: "An internal server error occurred."},
+	http.StatusBadGateway:            {Code: "bad_gateway", Message: "An upstream service returned an invalid response."},
+	http.StatusServiceUnavailable:    {Code: "service_unavailable", Message: "The service is temporarily unavailable."},
+	http.StatusInsufficientStorage:   {Code: "insufficient_storage", Message: "The storage quota has been exceeded."},
+}
+
+// newAPIV1Error はステータスコードと詳細なメッセージからエラーを作る
+func newAPIV1Error(status int, detail string) *APIV1Error {
+	apiErr, ok := apiV1Errors[status]
+	if !ok {
+		apiErr = APIV1Error{Code: "error", Message: http.StatusText(status)}
+	}
+	apiErr.Detail = detail
+	return &apiErr
+}
+
+// isPaginatedPath はページ単位で取得するAPI（page と limit のパラメータを受け付ける）かどうかを返す
+// 対象はコミット履歴（/api/repository/{group}/{repo}/commits）とファイルの変更履歴（/api/history/{group}/{repo}/{path}）だけ
+// ディレクトリAPIで commits という名前のディレクトリを開いた場合などと区別するため、ルートの形で判定する
+func isPaginatedPath(path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 5 && segments[0] == "api" && segments[1] == "repository" && segments[4] == "commits" {
+		return true
+	}
+	return len(segments) >= 5 && segments[0] == "api" && segments[1] == "history"
+}
+
+// v1ResponseWriter は JSON のレスポンスをバッファに溜め、それ以外（ファイルの内容、バンドル、画像）はそのまま書き出す
+type v1ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffered    bool
+	body        bytes.Buffer
+}
+
+func (w *v1ResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffered = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *v1ResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffered {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// writeAPIV1Response はエンベロープをレスポンスとして書き出す
+func writeAPIV1Response(w http.ResponseWriter, status int, response APIV1Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// apiV1Handler は /api/v1/... のリクエストを /api/... のハンドラーで処理し、レスポンスをエンベロープに包む
+//
+//	成功: {"data": <従来のレスポンス>, "pagination": {"page": 1, "limit": 30, "hasMore": true}}
+//	失敗: {"error": {"code": "not_found", "message": "The requested resource was not found.", "detail": "リポジトリが見つかりません"}}
+func apiV1Handler(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 従来のパスに書き換えたリクエストを作る（エンコードされたパスも書き換える）
+		legacy := r.Clone(r.Context())
+		legacy.URL = &url.URL{}
+		*legacy.URL = *r.URL
+		legacy.URL.Path = "/api/" + strings.TrimPrefix(r.URL.Path, APIV1Prefix)
+		if r.URL.RawPath != "" {
+			legacy.URL.RawPath = "/api/" + strings.TrimPrefix(r.URL.RawPath, APIV1Prefix)
+		}
+		legacy.RequestURI = legacy.URL.RequestURI()
+
+		// パスパラメータ（r.PathValue）はマッチしたときに設定されるため、ハンドラーを直接呼ばずにマルチプレクサを通す
+		_, pattern := mux.Handler(legacy)
+		if !strings.HasPrefix(pattern, "/api/") || strings.HasPrefix(pattern, APIV1Prefix) {
+			// ホームページなど、API以外のハンドラーには渡さない
+			writeAPIV1Response(w, http.StatusNotFound, APIV1Response{Error: newAPIV1Error(http.StatusNotFound, "")})
+			return
+		}
+		if containsString(apiV1RawPaths, legacy.URL.Path) {
+			mux.ServeHTTP(w, legacy)
+			return
+		}
+
+		recorder := &v1ResponseWriter{ResponseWriter: w}
+		mux.ServeHTTP(recorder, legacy)
+		if !recorder.wroteHeader {
+			recorder.WriteHeader(http.StatusOK)
+		}
+		if !recorder.buffered {
+			return
+		}
+
+		var body interface{}
+		if recorder.body.Len() > 0 {
+			if err := json.Unmarshal(recorder.body.Bytes(), &body); err != nil {
+				writeAPIV1Response(w, http.StatusInternalServerError, APIV1Response{Error: newAPIV1Error(http.StatusInternalServerError, err.Error())})
+				return
+			}
+		}
+
+		language := requestLanguage(r)
+		if recorder.status >= http.StatusBadRequest {
+			detail := ""
+			if fields, ok := body.(map[string]interface{}); ok {
+				detail, _ = fields["error"].(string)
+			}
+			// code はステータスコードごとのまま、detail を Accept-Language の言語にする
+			detail, _ = translateMessage(detail, language)
+			writeAPIV1Response(w, recorder.status, APIV1Response{Error: newAPIV1Error(recorder.status, detail)})
+			return
+		}
+
+		if fields, ok := body.(map[string]interface{}); ok {
+			localizeResponse(fields, recorder.status, language)
+		}
+		response := APIV1Response{Data: body}
+		if items, ok := body.([]interface{}); ok && isPaginatedPath(legacy.URL.Path) {
+			// ページの指定は従来のAPIで検証済みだが、解析できない場合は pagination を付けない
+			if skip, limit, err := parseCommitPage(legacy.URL.Query()); err == nil && limit > 0 {
+				response.Pagination = &APIV1Pagination{Page: skip/limit + 1, Limit: limit, HasMore: len(items) == limit}
+			}
+		}
+		if legacy.URL.Path == "/api/repositories" || legacy.URL.Path == "/api/repositories/all" {
+			// ページの指定は従来のAPIで検証済み
+			options, _ := parseRepositoryListOptions(legacy.URL.Query())
+			if total, err := strconv.Atoi(recorder.Header().Get(TotalCountHeader)); err == nil && options.Page > 0 {
+				response.Pagination = &APIV1Pagination{Page: options.Page, Limit: options.PerPage, HasMore: options.Page*options.PerPage < total, Total: &total}
+			}
+		}
+		if items, ok := body.([]interface{}); ok && strings.HasPrefix(legacy.URL.Path, "/api/directory/") {
+			// ディレクトリの内容は常にページに分けて返すため、エントリの総数も付ける
+			offset, limit, _ := parseDirectoryPage(legacy.URL.Query())
+			if total, err := strconv.Atoi(recorder.Header().Get(TotalCountHeader)); err == nil {
+				response.Pagination = &APIV1Pagination{Page: offset/limit + 1, Limit: limit, HasMore: offset+len(items) < total, Total: &total}
+			}
+		}
+		if response.Data == nil {
+			// data がないと成功と失敗を区別できないため、本文がない場合も空のオブジェクトを返す
+			response.Data = map[string]interface{}{}
+		}
+		writeAPIV1Response(w, recorder.status, response)
+	}
+}
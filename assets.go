@@ -0,0 +1,54 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// embeddedAssets は実行ファイルに埋め込んだテンプレートと静的ファイル
+// 実行ファイル1つで配置でき、作業ディレクトリに関係なく動作する
+//
+//go:embed static templates
+var embeddedAssets embed.FS
+
+// AssetDirectory はテンプレートと静的ファイルを上書きするディレクトリ（空の場合は埋め込んだものだけを使う）
+// {AssetDirectory}/templates/index.html や {AssetDirectory}/static/css/style.css のように、同じパスのファイルを置いたものだけが置き換わる
+var AssetDirectory = ""
+
+// overlayFS は override にあるファイルを優先し、ないファイルは base から開く
+type overlayFS struct {
+	override fs.FS
+	base     fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.override.Open(name)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.base.Open(name)
+}
+
+// assetFS はテンプレートと静的ファイルを読み込むファイルシステムを返す
+// パスは templates/index.html や static/js/utils.js のように指定する
+func assetFS() fs.FS {
+	if AssetDirectory == "" {
+		return embeddedAssets
+	}
+	return overlayFS{override: os.DirFS(AssetDirectory), base: embeddedAssets}
+}
+
+// staticFS は /static/ で配信する静的ファイルのファイルシステムを返す
+func staticFS() fs.FS {
+	static, err := fs.Sub(assetFS(), "static")
+	if err != nil {
+		// fs.Sub は static が不正なパスの場合だけ失敗する
+		panic(err)
+	}
+	return static
+}
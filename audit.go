@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditLogPath はリポジトリの作成、削除、名前の変更、復元、設定の変更などを記録する監査ログのファイル（空の場合は記録しない）
+// 1行に1件の JSON を追記するだけで、guilty が書き換えたり削除したりすることはない
+var AuditLogPath = "guilty-audit.log"
+
+// 監査ログの検索で返す件数
+const (
+	defaultAuditQueryLimit = 100
+	maxAuditQueryLimit     = 1000
+)
+
+// 操作した主体の種類
+const (
+	AuditSourceAPI    = "api"    // HTTP のAPI
+	AuditSourceCLI    = "cli"    // guilty のサブコマンド
+	AuditSourceSystem = "system" // ゴミ箱の自動削除など、サーバー自身の処理
+)
+
+// AuditActor は操作した主体を表す
+type AuditActor struct {
+	Source string `json:"source"`          // api、cli、system
+	IP     string `json:"ip,omitempty"`    // クライアントのIPアドレス（リバースプロキシ経由の場合は X-Forwarded-For から求めたもの）
+	User   string `json:"user,omitempty"`  // リバースプロキシが認証したユーザー名、または CLI を実行した OS のユーザー名
+	Token  string `json:"token,omitempty"` // Bearer トークンの SHA-256 の先頭16文字（トークンそのものは記録しない）
+}
+
+// AuditEntry は監査ログの1件の記録
+type AuditEntry struct {
+	ID         int64             `json:"id"` // 1 から始まる通し番号（ファイルの行番号と同じ）
+	Time       time.Time         `json:"time"`
+	Action     string            `json:"action"`               // repository.create、branch.delete など
+	Group      string            `json:"group,omitempty"`      // 対象のグループ
+	Repository string            `json:"repository,omitempty"` // 対象のリポジトリ（グループ名/リポジトリ名）
+	Actor      AuditActor        `json:"actor"`
+	Details    map[string]string `json:"details,omitempty"` // 変更前後の名前、ブランチ名などの操作ごとの情報
+	RequestID  string            `json:"requestId,omitempty"`
+}
+
+// auditLog は開いている監査ログのファイル
+var auditLog struct {
+	mutex  sync.Mutex
+	file   *os.File // 開けなかった場合と記録しない場合は nil
+	path   string
+	lastID int64
+}
+
+// openAuditLog は監査ログのファイルを追記用に開き、これまでの件数から次の通し番号を決める
+func openAuditLog(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("監査ログを開けませんでした: %w", err)
+	}
+
+	var lastID int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.ID > lastID {
+			lastID = entry.ID
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return fmt.Errorf("監査ログを読めませんでした: %w", err)
+	}
+
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	auditLog.file = file
+	auditLog.path = path
+	auditLog.lastID = lastID
+	return nil
+}
+
+// auditLogEnabled は監査ログに記録しているかどうかを返す
+func auditLogEnabled() bool {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	return auditLog.file != nil
+}
+
+// appendAuditEntry は監査ログに1件追記する
+// 記録に失敗しても操作は取り消せないため、警告を出して続ける
+func appendAuditEntry(entry AuditEntry) {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	if auditLog.file == nil {
+		return
+	}
+
+	entry.ID = auditLog.lastID + 1
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("警告: 監査ログに記録できませんでした: %v", err)
+		return
+	}
+	if _, err := auditLog.file.Write(append(line, '\n')); err != nil {
+		log.Printf("警告: 監査ログに記録できませんでした: %v", err)
+		return
+	}
+	auditLog.file.Sync()
+	auditLog.lastID = entry.ID
+}
+
+// newAuditEntry は対象のリポジトリ（repoName が空の場合はグループ）の記録を作る
+// 値が空の details の項目（省略されたブランチの作成元など）は記録しない
+func newAuditEntry(action, groupName, repoName string, details map[string]string) AuditEntry {
+	for key, value := range details {
+		if value == "" {
+			delete(details, key)
+		}
+	}
+	if len(details) == 0 {
+		details = nil
+	}
+	entry := AuditEntry{Action: action, Group: groupName, Details: details}
+	if repoName != "" {
+		entry.Repository = groupName + "/" + repoName
+	}
+	return entry
+}
+
+// requestActor はリクエストを送った主体を返す
+// ユーザー名は accessTokens のトークン、または信用するリバースプロキシが認証したユーザー（requestUser）
+func requestActor(r *http.Request) AuditActor {
+	actor := AuditActor{Source: AuditSourceAPI, IP: clientAddress(r), User: requestUser(r)}
+	if token := bearerToken(r); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		actor.Token = hex.EncodeToString(sum[:])[:16]
+	}
+	return actor
+}
+
+// recordAudit はAPIによる変更を監査ログに記録する
+func recordAudit(r *http.Request, action, groupName, repoName string, details map[string]string) {
+	entry := newAuditEntry(action, groupName, repoName, details)
+	entry.Actor = requestActor(r)
+	entry.RequestID = requestIDFromContext(r.Context())
+	appendAuditEntry(entry)
+}
+
+// recordRepositoryAudit はリポジトリのサブリソース（/api/repository/{groupName}/{repoName}/...）への変更を監査ログに記録する
+func recordRepositoryAudit(r *http.Request, action string, details map[string]string) {
+	recordAudit(r, action, r.PathValue("groupName"), r.PathValue("repoName"), details)
+}
+
+// auditRecorder は操作した主体を決めて監査ログに記録する関数
+// サーバーとサブコマンドで共有する処理（ゴミ箱の削除、バックアップからの復元）に渡す
+type auditRecorder func(action, groupName, repoName string, details map[string]string)
+
+// requestAuditRecorder はリクエストを送った主体として記録する auditRecorder を返す
+func requestAuditRecorder(r *http.Request) auditRecorder {
+	return func(action, groupName, repoName string, details map[string]string) {
+		recordAudit(r, action, groupName, repoName, details)
+	}
+}
+
+// recordRestoreResults はバックアップから復元できたリポジトリを1件ずつ記録する
+func recordRestoreResults(record auditRecorder, backupDir string, results []RestoreResult) {
+	for _, result := range results {
+		if result.Restored {
+			groupName, repoName := splitRepositoryName(result.Repository)
+			record("repository.restore", groupName, repoName, map[string]string{"source": "backup", "backup": filepath.Base(backupDir)})
+		}
+	}
+}
+
+// recordCLIAudit はサブコマンドによる変更を、実行した OS のユーザー名とともに監査ログに記録する
+func recordCLIAudit(action, groupName, repoName string, details map[string]string) {
+	entry := newAuditEntry(action, groupName, repoName, details)
+	entry.Actor = AuditActor{Source: AuditSourceCLI}
+	if current, err := user.Current(); err == nil {
+		entry.Actor.User = current.Username
+	}
+	appendAuditEntry(entry)
+}
+
+// recordSystemAudit はサーバー自身の処理による変更を監査ログに記録する
+func recordSystemAudit(action, groupName, repoName string, details map[string]string) {
+	entry := newAuditEntry(action, groupName, repoName, details)
+	entry.Actor = AuditActor{Source: AuditSourceSystem}
+	appendAuditEntry(entry)
+}
+
+// AuditLogPage は監査ログの検索結果
+type AuditLogPage struct {
+	Entries []AuditEntry `json:"entries"`        // 新しい順
+	Next    int64        `json:"next,omitempty"` // さらに古い記録がある場合に before に指定する通し番号
+}
+
+// AuditQuery は監査ログの検索条件（空の項目は条件にしない）
+type AuditQuery struct {
+	Group      string
+	Repository string // グループ名/リポジトリ名
+	Action     string // 前方一致（repository. はリポジトリの操作すべて）
+	Actor      string // IPアドレスまたはユーザー名
+	Since      time.Time
+	Until      time.Time
+	Before     int64 // この通し番号より前の記録だけを返す（ページ送り）
+	Limit      int
+}
+
+// matches は記録が検索条件に一致するかどうかを返す
+func (q AuditQuery) matches(entry AuditEntry) bool {
+	switch {
+	case q.Group != "" && entry.Group != q.Group:
+		return false
+	case q.Repository != "" && entry.Repository != q.Repository:
+		return false
+	case q.Action != "" && !strings.HasPrefix(entry.Action, q.Action):
+		return false
+	case q.Actor != "" && entry.Actor.IP != q.Actor && entry.Actor.User != q.Actor:
+		return false
+	case !q.Since.IsZero() && entry.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !entry.Time.Before(q.Until):
+		return false
+	case q.Before > 0 && entry.ID >= q.Before:
+		return false
+	}
+	return true
+}
+
+// parseAuditQuery はクエリパラメータから検索条件を作る
+func parseAuditQuery(values map[string][]string) (AuditQuery, error) {
+	get := func(name string) string {
+		if v := values[name]; len(v) > 0 {
+			return strings.TrimSpace(v[0])
+		}
+		return ""
+	}
+
+	query := AuditQuery{
+		Group:      get("group"),
+		Repository: get("repository"),
+		Action:     get("action"),
+		Actor:      get("actor"),
+		Limit:      defaultAuditQueryLimit,
+	}
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if text := get(param.name); text != "" {
+			t, err := time.Parse(time.RFC3339, text)
+			if err != nil {
+				return query, fmt.Errorf("%s は RFC 3339 形式の日時で指定してください: %s", param.name, text)
+			}
+			*param.value = t
+		}
+	}
+	if text := get("before"); text != "" {
+		before, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || before < 1 {
+			return query, fmt.Errorf("before は1以上の数値で指定してください: %s", text)
+		}
+		query.Before = before
+	}
+	if text := get("limit"); text != "" {
+		limit, err := strconv.Atoi(text)
+		if err != nil || limit < 1 || limit > maxAuditQueryLimit {
+			return query, fmt.Errorf("limit は1から%dの整数で指定してください", maxAuditQueryLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+// queryAuditLog は条件に一致する記録を新しい順に最大 query.Limit 件返す
+// more はさらに古い記録があるかどうか
+func queryAuditLog(query AuditQuery) (entries []AuditEntry, more bool, err error) {
+	auditLog.mutex.Lock()
+	path := auditLog.path
+	auditLog.mutex.Unlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("監査ログを開けませんでした: %w", err)
+	}
+	defer file.Close()
+
+	// 一致した記録のうち新しいものだけを残す（Limit+1 件目は続きがあるかどうかの判定に使う）
+	ring := make([]AuditEntry, 0, query.Limit+1)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || !query.matches(entry) {
+			continue
+		}
+		if len(ring) == query.Limit+1 {
+			ring = append(ring[1:], entry)
+		} else {
+			ring = append(ring, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("監査ログを読めませんでした: %w", err)
+	}
+
+	if len(ring) > query.Limit {
+		more = true
+		ring = ring[1:]
+	}
+	entries = make([]AuditEntry, len(ring))
+	for i, entry := range ring {
+		entries[len(ring)-1-i] = entry
+	}
+	return entries, more, nil
+}
+
+// auditLogHandler は監査ログを新しい順に返す管理者用ハンドラー
+//
+//	GET /api/admin/audit?repository=git/demo&action=repository.&since=2026-01-01T00:00:00Z&limit=50
+//
+// 続きは next（最後の記録の通し番号）を before に指定して取得する
+func auditLogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	if !auditLogEnabled() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "監査ログが有効になっていません"})
+		return
+	}
+
+	query, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	entries, more, err := queryAuditLog(query)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	page := AuditLogPage{Entries: entries}
+	if more && len(entries) > 0 {
+		page.Next = entries[len(entries)-1].ID
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
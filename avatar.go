@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvatarURLTemplate はアバター画像を取得するサービスのURL（%s にハッシュ、%d に画像の大きさが入る）
+// libravatar を使う場合は "https://seccdn.libravatar.org/avatar/%s?d=identicon&s=%d"
+var AvatarURLTemplate = "https://www.gravatar.com/avatar/%s?d=identicon&s=%d"
+
+// AvatarProxyEnabled はアバター画像をguilty経由で取得するかどうか
+// ブラウザからインターネットに直接接続できないネットワークで有効にする
+var AvatarProxyEnabled = false
+
+// DefaultAvatarSize はアバター画像の大きさ（ピクセル）の既定値
+var DefaultAvatarSize = 40
+
+// MaxAvatarSize はアバター画像の大きさの上限
+const MaxAvatarSize = 512
+
+// maxAvatarImageSize はプロキシで中継するアバター画像の最大サイズ
+const maxAvatarImageSize = 1024 * 1024
+
+// AvatarCacheTTL はプロキシで取得したアバター画像をキャッシュする期間
+var AvatarCacheTTL = 24 * time.Hour
+
+// MaxAvatarCacheEntries はプロキシでキャッシュするアバター画像の件数の上限
+var MaxAvatarCacheEntries = 1000
+
+// avatarHashPattern は MD5（32桁）または SHA-256（64桁）のハッシュ
+var avatarHashPattern = regexp.MustCompile(`^([0-9a-f]{32}|[0-9a-f]{64})$`)
+
+// AuthorAvatar はメールアドレスから求めたアバターの情報を表す
+type AuthorAvatar struct {
+	AvatarHash    string `json:"avatarHash"`    // 小文字にしたメールアドレスの SHA-256（Gravatar・libravatar 用）
+	AvatarHashMD5 string `json:"avatarHashMd5"` // 小文字にしたメールアドレスの MD5（SHA-256 に対応していないサービス用）
+	AvatarURL     string `json:"avatarUrl"`     // アバター画像のURL（プロキシが有効な場合はguiltyのURL）
+}
+
+// avatarCacheEntry はプロキシでキャッシュしたアバター画像
+type avatarCacheEntry struct {
+	ContentType string
+	Data        []byte
+	FetchedAt   time.Time
+}
+
+// avatarCache はプロキシで取得したアバター画像（キーはハッシュと大きさ）
+var (
+	avatarCacheMutex sync.Mutex
+	avatarCache      = map[string]avatarCacheEntry{}
+)
+
+// newAuthorAvatar はメールアドレスからアバターの情報を作る（メールアドレスが空の場合は空）
+func newAuthorAvatar(email string) AuthorAvatar {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return AuthorAvatar{}
+	}
+
+	sha := sha256.Sum256([]byte(email))
+	md := md5.Sum([]byte(email))
+	avatar := AuthorAvatar{
+		AvatarHash:    hex.EncodeToString(sha[:]),
+		AvatarHashMD5: hex.EncodeToString(md[:]),
+	}
+	if AvatarProxyEnabled {
+		avatar.AvatarURL = BasePath + "/api/avatar/" + avatar.AvatarHash
+	} else {
+		avatar.AvatarURL = fmt.Sprintf(AvatarURLTemplate, avatar.AvatarHash, DefaultAvatarSize)
+	}
+	return avatar
+}
+
+// getCachedAvatar はキャッシュからアバター画像を取得する（期限切れの場合は削除する）
+func getCachedAvatar(key string) (avatarCacheEntry, bool) {
+	avatarCacheMutex.Lock()
+	defer avatarCacheMutex.Unlock()
+
+	entry, ok := avatarCache[key]
+	if ok && time.Since(entry.FetchedAt) > AvatarCacheTTL {
+		delete(avatarCache, key)
+		return avatarCacheEntry{}, false
+	}
+	return entry, ok
+}
+
+// putCachedAvatar はアバター画像をキャッシュする
+// 上限に達した場合は最も古いものから削除する
+func putCachedAvatar(key string, entry avatarCacheEntry) {
+	avatarCacheMutex.Lock()
+	defer avatarCacheMutex.Unlock()
+
+	for len(avatarCache) >= MaxAvatarCacheEntries && len(avatarCache) > 0 {
+		oldestKey := ""
+		for k, e := range avatarCache {
+			if oldestKey == "" || e.FetchedAt.Before(avatarCache[oldestKey].FetchedAt) {
+				oldestKey = k
+			}
+		}
+		delete(avatarCache, oldestKey)
+	}
+	avatarCache[key] = entry
+}
+
+// fetchAvatar は AvatarURLTemplate のサービスからアバター画像を取得する
+func fetchAvatar(hash string, size int) (avatarCacheEntry, error) {
+	key := hash + "/" + strconv.Itoa(size)
+	if entry, ok := getCachedAvatar(key); ok {
+		return entry, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf(AvatarURLTemplate, hash, size))
+	if err != nil {
+		return avatarCacheEntry{}, fmt.Errorf("アバター画像の取得に失敗しました: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return avatarCacheEntry{}, fmt.Errorf("アバター画像の取得に失敗しました: %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return avatarCacheEntry{}, fmt.Errorf("アバター画像の形式 '%s' は不正です", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarImageSize+1))
+	if err != nil {
+		return avatarCacheEntry{}, fmt.Errorf("アバター画像の取得に失敗しました: %w", err)
+	}
+	if len(data) > maxAvatarImageSize {
+		return avatarCacheEntry{}, fmt.Errorf("アバター画像が大きすぎます")
+	}
+
+	entry := avatarCacheEntry{ContentType: contentType, Data: data, FetchedAt: time.Now()}
+	putCachedAvatar(key, entry)
+	return entry, nil
+}
+
+// avatarHandler はアバター画像をguilty経由で返す（AvatarProxyEnabled が有効な場合のみ）
+//
+//	GET /api/avatar/{hash}?s=40
+func avatarHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !AvatarProxyEnabled {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "アバターのプロキシは無効です"})
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	hash := strings.ToLower(r.PathValue("hash"))
+	if !avatarHashPattern.MatchString(hash) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なハッシュ"})
+		return
+	}
+
+	size := DefaultAvatarSize
+	if s := r.URL.Query().Get("s"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxAvatarSize {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("s は 1 から %d の数値で指定してください", MaxAvatarSize)})
+			return
+		}
+		size = n
+	}
+
+	entry, err := fetchAvatar(hash, size)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", entry.ContentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(AvatarCacheTTL.Seconds())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(entry.Data)
+}
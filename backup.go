@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackupDirectory は管理者APIやディレクトリ指定なしの backup コマンドでバックアップを作成するディレクトリ
+// 実行ごとに日時のサブディレクトリが作られる
+var BackupDirectory = "/home/git-backup"
+
+// backupManifestName はバックアップ先に書き出すマニフェストのファイル名
+const backupManifestName = "manifest.json"
+
+// backupTimeFormat はバックアップごとのサブディレクトリ名の書式
+const backupTimeFormat = "20060102-150405"
+
+// BackupRepository はマニフェストに記録するリポジトリの情報
+type BackupRepository struct {
+	Group             string             `json:"group"`
+	Name              string             `json:"name"`
+	Bundle            string             `json:"bundle"`     // バックアップ先からの相対パス（空のリポジトリの場合は空文字）
+	Empty             bool               `json:"empty"`      // コミットがなくバンドルを作成しなかったかどうか
+	HeadBranch        string             `json:"headBranch"` // HEAD が指すブランチ
+	Description       string             `json:"description"`
+	Metadata          RepositoryMetadata `json:"metadata"`
+	ProtectedBranches []string           `json:"protectedBranches"`
+	Size              int64              `json:"size"` // バンドルのサイズ（バイト）
+}
+
+// BackupManifest はバックアップ全体の内容を表し、バックアップ先の manifest.json に保存される
+type BackupManifest struct {
+	CreatedAt    time.Time          `json:"createdAt"`
+	Groups       []string           `json:"groups"`
+	Repositories []BackupRepository `json:"repositories"`
+}
+
+// backupMutex はバックアップの同時実行を防ぐ
+var backupMutex sync.Mutex
+
+// backupRepositories はすべてのリポジトリを git bundle --all でバックアップ先に書き出し、マニフェストを保存する
+// バンドルは {targetDir}/{group}/{name}.bundle に作成される
+func backupRepositories(ctx context.Context, targetDir string) (*BackupManifest, error) {
+	if _, err := os.Stat(filepath.Join(targetDir, backupManifestName)); err == nil {
+		return nil, fmt.Errorf("バックアップ先に既にバックアップがあります: %s", targetDir)
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("バックアップ先の作成に失敗しました: %w", err)
+	}
+
+	groups, err := getGroupList()
+	if err != nil {
+		return nil, fmt.Errorf("グループ一覧の取得に失敗しました: %w", err)
+	}
+
+	manifest := &BackupManifest{
+		CreatedAt:    time.Now(),
+		Groups:       groups,
+		Repositories: []BackupRepository{},
+	}
+
+	for _, groupName := range groups {
+		repos, err := getGitRepositories(ctx, groupName)
+		if err != nil {
+			return nil, fmt.Errorf("グループ '%s' のリポジトリ一覧の取得に失敗しました: %w", groupName, err)
+		}
+
+		for _, repo := range repos {
+			repoPath, ok := findRepository(groupName, repo.Name)
+			if !ok {
+				continue
+			}
+
+			entry, err := backupRepository(ctx, targetDir, groupName, repo.Name, repoPath)
+			if err != nil {
+				return nil, err
+			}
+			manifest.Repositories = append(manifest.Repositories, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, backupManifestName), data, 0644); err != nil {
+		return nil, fmt.Errorf("マニフェストの保存に失敗しました: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// backupRepository は1つのリポジトリのバンドルを作成し、マニフェストの項目を返す
+func backupRepository(ctx context.Context, targetDir, groupName, repoName, repoPath string) (BackupRepository, error) {
+	entry := BackupRepository{
+		Group:             groupName,
+		Name:              repoName,
+		Description:       getRepositoryDescription(repoPath),
+		Metadata:          getRepositoryMetadata(groupName, repoName),
+		ProtectedBranches: getProtectedBranches(ctx, repoPath),
+	}
+	entry.HeadBranch, _ = getCurrentHeadBranch(repoPath)
+
+	// 参照が1つもないリポジトリは git bundle が失敗するため、マニフェストにのみ記録する
+	ok, err := hasRefs(ctx, repoPath)
+	if err != nil {
+		return entry, fmt.Errorf("'%s/%s' の参照の取得に失敗しました: %w", groupName, repoName, err)
+	}
+	if !ok {
+		entry.Empty = true
+		return entry, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(targetDir, groupName), 0755); err != nil {
+		return entry, fmt.Errorf("バックアップ先の作成に失敗しました: %w", err)
+	}
+
+	entry.Bundle = filepath.ToSlash(filepath.Join(groupName, repoName+".bundle"))
+	bundlePath, err := filepath.Abs(filepath.Join(targetDir, entry.Bundle))
+	if err != nil {
+		return entry, err
+	}
+
+	cmd, cancel := gitMaintenanceCommand(ctx, "--git-dir="+repoPath, "bundle", "create", "--quiet", bundlePath, "--all")
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return entry, fmt.Errorf("'%s/%s' のバンドルの作成に失敗しました: %s", groupName, repoName, strings.TrimSpace(string(output)))
+	}
+
+	if info, err := os.Stat(bundlePath); err == nil {
+		entry.Size = info.Size()
+	}
+
+	return entry, nil
+}
+
+// hasRefs はリポジトリにブランチやタグなどの参照が1つ以上あるか確認する
+func hasRefs(ctx context.Context, repoPath string) (bool, error) {
+	return getGitBackend().HasRefs(ctx, repoPath)
+}
+
+func (commandGitBackend) HasRefs(ctx context.Context, repoPath string) (bool, error) {
+	output, err := gitOutput(ctx, "--git-dir="+repoPath, "for-each-ref", "--count=1")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// newBackupDirectory は BackupDirectory の下に現在日時のバックアップ先を返す
+func newBackupDirectory() string {
+	return filepath.Join(BackupDirectory, time.Now().Format(backupTimeFormat))
+}
+
+// requireMetadataStore はサブコマンドがメタデータストアを使えるか確認する
+// メタデータが抜け落ちないよう、ストアがあるのに開けなかった場合（サーバーの起動中など）は中止させる
+func requireMetadataStore() bool {
+	if _, err := os.Stat(MetadataStorePath); err == nil && metadataStore == nil {
+		log.Printf("エラー: メタデータストアを開けません（サーバーの起動中は管理者用APIを使用してください）")
+		return false
+	}
+	return true
+}
+
+// runBackupCommand は `guilty backup [ディレクトリ]` としてバックアップを実行し、終了コードを返す
+func runBackupCommand(args []string) int {
+	if !requireMetadataStore() {
+		return 1
+	}
+
+	targetDir := newBackupDirectory()
+	if len(args) > 0 {
+		targetDir = args[0]
+	}
+
+	manifest, err := backupRepositories(serverContext, targetDir)
+	if err != nil {
+		log.Printf("エラー: %v", err)
+		return 1
+	}
+
+	recordCLIAudit("backup.create", "", "", map[string]string{"directory": targetDir, "repositories": strconv.Itoa(len(manifest.Repositories))})
+
+	log.Printf("%d 個のリポジトリを %s にバックアップしました", len(manifest.Repositories), targetDir)
+	return 0
+}
+
+// backupHandler はすべてのリポジトリのバックアップを作成する管理者用ハンドラー
+//
+//	POST /api/admin/backup  BackupDirectory の下に日時のディレクトリを作成してバックアップする
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	if !backupMutex.TryLock() {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "バックアップが既に実行中です"})
+		return
+	}
+	defer backupMutex.Unlock()
+
+	targetDir := newBackupDirectory()
+	manifest, err := backupRepositories(r.Context(), targetDir)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "バックアップに失敗しました: " + err.Error()})
+		return
+	}
+
+	recordAudit(r, "backup.create", "", "", map[string]string{"directory": targetDir, "repositories": strconv.Itoa(len(manifest.Repositories))})
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "バックアップを作成しました",
+		"directory": targetDir,
+		"manifest":  manifest,
+	})
+}
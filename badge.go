@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// BadgeCacheMaxAge はバッジをブラウザや画像プロキシにキャッシュさせる秒数
+var BadgeCacheMaxAge = 300
+
+// バッジの色（shields.io と同じ）
+const (
+	BadgeColorBrightGreen = "#4c1"
+	BadgeColorGreen       = "#97ca00"
+	BadgeColorYellow      = "#dfb317"
+	BadgeColorOrange      = "#fe7d37"
+	BadgeColorRed         = "#e05d44"
+	BadgeColorBlue        = "#007ec6"
+	BadgeColorGrey        = "#9f9f9f"
+)
+
+// Badge はバッジに表示する内容を表す
+type Badge struct {
+	Label string // 左側の灰色の部分
+	Value string // 右側の色の付いた部分
+	Color string
+}
+
+// repositoryBadges はリポジトリのバッジの名前（{name}.svg）と作成する関数
+var repositoryBadges = map[string]func(ctx context.Context, repoPath string) Badge{
+	"last-commit": lastCommitBadge,
+	"branches":    branchesBadge,
+	"tags":        tagsBadge,
+}
+
+// badgeTextWidth はバッジの文字列の幅をおおよそのピクセル数で返す（Verdana 11px 相当）
+func badgeTextWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case r >= 0x2E80:
+			// 漢字・かななどの全角文字
+			width += 11
+		case strings.ContainsRune("ilj.,:;|!'", r):
+			width += 4
+		case r >= 'A' && r <= 'Z', strings.ContainsRune("mw", r):
+			width += 9
+		default:
+			width += 7
+		}
+	}
+	return width
+}
+
+// renderBadge は shields.io の flat スタイルと同じ形の SVG を作る
+func renderBadge(badge Badge) string {
+	labelWidth := badgeTextWidth(badge.Label) + 10
+	valueWidth := badgeTextWidth(badge.Value) + 10
+	width := labelWidth + valueWidth
+	label := html.EscapeString(badge.Label)
+	value := html.EscapeString(badge.Value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, value)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, value)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, valueWidth, html.EscapeString(badge.Color), width)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, part := range []struct {
+		x    int
+		text string
+	}{{labelWidth / 2, label}, {labelWidth + valueWidth/2, value}} {
+		// 影を1ピクセル下にずらして重ねる
+		fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, part.x, part.text, part.x, part.text)
+	}
+	b.WriteString(`</g></svg>`)
+
+	return b.String()
+}
+
+// formatBadgeAge は経過時間を shields.io と同じような英語の相対表記にする
+func formatBadgeAge(t time.Time) string {
+	days := int(time.Since(t).Hours() / 24)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return strconv.Itoa(n) + " " + unit + "s ago"
+	}
+
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 7:
+		return plural(days, "day")
+	case days < 30:
+		return plural(days/7, "week")
+	case days < 365:
+		return plural(days/30, "month")
+	default:
+		return plural(days/365, "year")
+	}
+}
+
+// lastCommitBadge は最新のコミットの日時を表すバッジを作る（古いほど赤に近い色）
+func lastCommitBadge(ctx context.Context, repoPath string) Badge {
+	badge := Badge{Label: "last commit", Value: "none", Color: BadgeColorGrey}
+	commit := getLastCommit(ctx, repoPath)
+	if commit == nil {
+		return badge
+	}
+
+	badge.Value = formatBadgeAge(commit.Date)
+	switch age := time.Since(commit.Date); {
+	case age < 7*24*time.Hour:
+		badge.Color = BadgeColorBrightGreen
+	case age < 30*24*time.Hour:
+		badge.Color = BadgeColorGreen
+	case age < 180*24*time.Hour:
+		badge.Color = BadgeColorYellow
+	case age < 365*24*time.Hour:
+		badge.Color = BadgeColorOrange
+	default:
+		badge.Color = BadgeColorRed
+	}
+	return badge
+}
+
+// refCountBadge は指定した種類の参照の数を表すバッジを作る
+func refCountBadge(ctx context.Context, repoPath, refType, label string) Badge {
+	names, err := getRefNames(ctx, repoPath, refType)
+	if err != nil {
+		return Badge{Label: label, Value: "error", Color: BadgeColorRed}
+	}
+	return Badge{Label: label, Value: strconv.Itoa(len(names)), Color: BadgeColorBlue}
+}
+
+func branchesBadge(ctx context.Context, repoPath string) Badge {
+	return refCountBadge(ctx, repoPath, "branch", "branches")
+}
+
+func tagsBadge(ctx context.Context, repoPath string) Badge {
+	return refCountBadge(ctx, repoPath, "tag", "tags")
+}
+
+// repositoriesBadge はグループのリポジトリ数を表すバッジを作る（Wiki のリポジトリは数えない）
+func repositoriesBadge(r *http.Request, groupName string) Badge {
+	repos, err := getGitRepositories(r.Context(), groupName)
+	if err != nil {
+		return Badge{Label: "repositories", Value: "not found", Color: BadgeColorGrey}
+	}
+	repos = filterAccessibleRepositories(r, excludeWikiRepositories(repos))
+	return Badge{Label: "repositories", Value: strconv.Itoa(len(repos)), Color: BadgeColorBlue}
+}
+
+// badgeHandler は README やダッシュボードに埋め込むための SVG のバッジを返す
+// 画像として表示されるよう、リポジトリが見つからない場合もエラーのバッジを 200 で返す
+//
+//	GET /badge/{group}/{repo}/last-commit.svg
+//	GET /badge/{group}/{repo}/branches.svg
+//	GET /badge/{group}/{repo}/tags.svg
+//	GET /badge/{group}/repositories.svg
+//
+// label パラメータで左側の文字列を変更できる
+func badgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintln(w, "サポートされていないメソッドです")
+		return
+	}
+
+	badgeName, ok := strings.CutSuffix(r.PathValue("badge"), ".svg")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// リポジトリ名がない場合はグループのバッジ
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+
+	var badge Badge
+	switch {
+	case repoName == "" && badgeName == "repositories":
+		if !isValidGroupName(groupName) {
+			badge = Badge{Label: "repositories", Value: "not found", Color: BadgeColorGrey}
+		} else {
+			badge = repositoriesBadge(r, groupName)
+		}
+
+	case repoName != "" && repositoryBadges[badgeName] != nil:
+		if repoPath, ok := findAccessibleRepository(r, groupName, repoName); ok {
+			badge = repositoryBadges[badgeName](r.Context(), repoPath)
+		} else {
+			badge = Badge{Label: strings.ReplaceAll(badgeName, "-", " "), Value: "repo not found", Color: BadgeColorGrey}
+		}
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if label := r.URL.Query().Get("label"); label != "" && utf8.RuneCountInString(label) <= 50 {
+		badge.Label = label
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", BadgeCacheMaxAge))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, renderBadge(badge))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BasePath はguiltyを公開するパスの接頭辞（/git など、空の場合はルート）
+// 既存のサーバーのサブパスにリバースプロキシで組み込む場合に、パスを書き換えずに転送させて使う
+var BasePath = ""
+
+// normalizeBasePath は接頭辞を / で始まり / で終わらない形にする（/ だけの場合は空）
+func normalizeBasePath(path string) (string, error) {
+	path = strings.TrimRight(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if strings.ContainsAny(path, "?#%\\ ") || strings.Contains(path, "//") || strings.Contains(path, "/../") || strings.HasSuffix(path, "/..") {
+		return "", fmt.Errorf("basePath '%s' は不正です", path)
+	}
+	return path, nil
+}
+
+// basePathHandler は BasePath の下へのリクエストから接頭辞を取り除いて handler に渡す
+// 接頭辞そのもの（/git）は末尾に / を付けたURLにリダイレクトし、接頭辞の外は 404 を返す
+func basePathHandler(handler http.Handler) http.Handler {
+	if BasePath == "" {
+		return handler
+	}
+
+	stripped := http.StripPrefix(BasePath, handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == BasePath:
+			target := BasePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, BasePath+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BranchInfo はブランチの先端コミットとデフォルトブランチとの差分を表す
+type BranchInfo struct {
+	Name       string      `json:"name"`
+	Commit     string      `json:"commit"` // 先端コミットのSHA
+	LastCommit *CommitInfo `json:"lastCommit"`
+	Ahead      int         `json:"ahead"`  // デフォルトブランチにないコミット数
+	Behind     int         `json:"behind"` // デフォルトブランチにあってこのブランチにないコミット数
+	IsDefault  bool        `json:"isDefault"`
+}
+
+// getBranchInfos はブランチごとの先端コミットと ahead/behind を取得する
+func getBranchInfos(ctx context.Context, repoPath string) ([]BranchInfo, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "for-each-ref",
+		"--format=%(refname:short)%00%(objectname)%00%(authorname)%00%(authoremail:trim)%00%(authordate:unix)%00%(contents:subject)",
+		"refs/heads")
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	defaultBranch, _ := getDefaultBranch(ctx, repoPath)
+
+	branches := []BranchInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 6 {
+			continue
+		}
+
+		branch := BranchInfo{
+			Name:      fields[0],
+			Commit:    fields[1],
+			IsDefault: fields[0] == defaultBranch,
+		}
+
+		if unixTime, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			branch.LastCommit = &CommitInfo{
+				Author:       fields[2],
+				AuthorEmail:  fields[3],
+				Date:         time.Unix(unixTime, 0),
+				Message:      fields[5],
+				AuthorAvatar: newAuthorAvatar(fields[3]),
+			}
+		}
+
+		// デフォルトブランチとの差分を数える
+		if defaultBranch != "" && !branch.IsDefault {
+			branch.Ahead, branch.Behind = getAheadBehind(ctx, repoPath, defaultBranch, branch.Commit)
+		}
+
+		branches = append(branches, branch)
+	}
+
+	return branches, nil
+}
+
+// getAheadBehind は git rev-list --left-right --count で base と commit の差分コミット数を数える
+func getAheadBehind(ctx context.Context, repoPath, base, commit string) (ahead int, behind int) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "rev-list", "--left-right", "--count",
+		"refs/heads/"+base+"..."+commit)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0
+	}
+
+	// 出力形式: <baseにのみあるコミット数>\t<commitにのみあるコミット数>
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0
+	}
+
+	behind, _ = strconv.Atoi(fields[0])
+	ahead, _ = strconv.Atoi(fields[1])
+	return ahead, behind
+}
+
+// getDefaultBranch は git symbolic-ref HEAD でリポジトリのデフォルトブランチを取得する
+func getDefaultBranch(ctx context.Context, repoPath string) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "symbolic-ref", "--short", "HEAD")
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("デフォルトブランチの取得に失敗しました: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// branchExists はブランチが存在するか確認する（packed-refs にあるブランチも対象）
+func branchExists(ctx context.Context, repoPath, branchName string) bool {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+branchName)
+	defer cancel()
+	return cmd.Run() == nil
+}
+
+// DefaultBranchName は新しく作成するリポジトリの HEAD が指すブランチ（最初のプッシュで作成される）
+// ホストの git の init.defaultBranch に関係なく、この名前で git init する
+var DefaultBranchName = "main"
+
+// getDefaultBranchName は新しく作成するリポジトリのデフォルトブランチの設定を返す
+func getDefaultBranchName() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return DefaultBranchName
+}
+
+// isValidBranchName は git check-ref-format でブランチ名として有効か確認する
+func isValidBranchName(ctx context.Context, branchName string) bool {
+	if branchName == "" || strings.HasPrefix(branchName, "-") {
+		return false
+	}
+
+	cmd, cancel := gitCommand(ctx, "check-ref-format", "--branch", branchName)
+	defer cancel()
+	return cmd.Run() == nil
+}
+
+// setDefaultBranch は git symbolic-ref HEAD refs/heads/<name> でデフォルトブランチを変更する
+// コミットがまだないリポジトリでは、存在しないブランチも指定できる
+func setDefaultBranch(ctx context.Context, repoPath, branchName string) error {
+	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		return fmt.Errorf("リポジトリが見つかりません")
+	}
+
+	if !isValidBranchName(ctx, branchName) {
+		return fmt.Errorf("ブランチ名 '%s' は不正です", branchName)
+	}
+
+	if hasCommits(ctx, repoPath) && !branchExists(ctx, repoPath, branchName) {
+		return fmt.Errorf("ブランチ '%s' が見つかりません", branchName)
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "symbolic-ref", "HEAD", "refs/heads/"+branchName)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("デフォルトブランチの変更に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	return nil
+}
+
+// CreateBranchRequest はブランチ作成リクエスト用の構造体
+type CreateBranchRequest struct {
+	Name string `json:"name"`
+	From string `json:"from"` // 作成元のref（省略時はデフォルトブランチ）
+}
+
+// branchesHandler はブランチの一覧・作成・削除を行う
+//
+//	GET    /api/repository/{group}/{repo}/branches         ブランチ一覧
+//	POST   /api/repository/{group}/{repo}/branches         {"name": "...", "from": "..."}
+//	DELETE /api/repository/{group}/{repo}/branches/{name}  ブランチ削除
+func branchesHandler(w http.ResponseWriter, r *http.Request, repoPath, branchName string) {
+	switch {
+	case r.Method == http.MethodGet && branchName == "":
+		branches, err := getBranchInfos(r.Context(), repoPath)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "ブランチ一覧の取得に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(branches)
+
+	case r.Method == http.MethodPost && branchName == "":
+		var req CreateBranchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := createBranch(r.Context(), repoPath, req.Name, req.From); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "branch.create", map[string]string{"branch": req.Name, "from": req.From})
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": "ブランチが作成されました"})
+
+	case r.Method == http.MethodDelete && branchName != "":
+		if err := deleteBranch(r.Context(), repoPath, branchName); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "branch.delete", map[string]string{"branch": branchName})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "ブランチが削除されました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// resolveCommit は ref をコミットのSHAに解決する
+func resolveCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("ref '%s' は不正です", ref)
+	}
+
+	commit, err := getGitBackend().ResolveCommit(ctx, repoPath, ref)
+	if err != nil {
+		return "", fmt.Errorf("ref '%s' が見つかりません", ref)
+	}
+
+	return commit, nil
+}
+
+func (commandGitBackend) ResolveCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// resolveRevParam はリクエストの ref パラメータをコミットのSHAに解決する
+// 指定されていない場合は HEAD を返す
+func resolveRevParam(repoPath string, r *http.Request) (string, error) {
+	ref := r.URL.Query().Get("ref")
+	if ref == "" {
+		return "HEAD", nil
+	}
+	return resolveCommit(r.Context(), repoPath, ref)
+}
+
+// createBranch は git branch <new> <from> で既存のrefから新しいブランチを作成する
+func createBranch(ctx context.Context, repoPath, branchName, from string) error {
+	if !isValidBranchName(ctx, branchName) {
+		return fmt.Errorf("ブランチ名 '%s' は不正です", branchName)
+	}
+
+	if branchExists(ctx, repoPath, branchName) {
+		return fmt.Errorf("ブランチ '%s' は既に存在します", branchName)
+	}
+
+	// 作成元が指定されていない場合はデフォルトブランチから作成
+	if from == "" {
+		defaultBranch, err := getDefaultBranch(ctx, repoPath)
+		if err != nil {
+			return err
+		}
+		from = defaultBranch
+	}
+
+	commit, err := resolveCommit(ctx, repoPath, from)
+	if err != nil {
+		return err
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "branch", branchName, commit)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ブランチの作成に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	return nil
+}
+
+// deleteBranch はブランチを削除する（デフォルトブランチは削除できない）
+func deleteBranch(ctx context.Context, repoPath, branchName string) error {
+	if !branchExists(ctx, repoPath, branchName) {
+		return fmt.Errorf("ブランチ '%s' が見つかりません", branchName)
+	}
+
+	if defaultBranch, err := getDefaultBranch(ctx, repoPath); err == nil && defaultBranch == branchName {
+		return fmt.Errorf("デフォルトブランチ '%s' は削除できません", branchName)
+	}
+
+	if isProtectedBranch(ctx, repoPath, branchName) {
+		return fmt.Errorf("保護ブランチ '%s' は削除できません", branchName)
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "branch", "-D", branchName)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ブランチの削除に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	return nil
+}
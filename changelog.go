@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conventionalCommitPattern は Conventional Commits 形式（type(scope)!: subject）のパターン
+var conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// changelogTypeTitles は Conventional Commits の種別ごとの見出し（この順に並べる）
+var changelogTypeTitles = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"chore", "Chores"},
+	{"other", "Other Changes"},
+}
+
+// ChangelogCommit は変更履歴に含まれるコミット
+type ChangelogCommit struct {
+	SHA      string    `json:"sha"`
+	Author   string    `json:"author"`
+	Date     time.Time `json:"date"`
+	Subject  string    `json:"subject"`
+	Type     string    `json:"type"`  // Conventional Commits の種別（該当しない場合は "other"）
+	Scope    string    `json:"scope"` // Conventional Commits のスコープ
+	Breaking bool      `json:"breaking"`
+}
+
+// ChangelogAuthor は変更履歴の作者とコミット数
+type ChangelogAuthor struct {
+	Name    string `json:"name"`
+	Commits int    `json:"commits"`
+}
+
+// ChangelogGroup は種別ごとにまとめたコミット
+type ChangelogGroup struct {
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Commits []ChangelogCommit `json:"commits"`
+}
+
+// Changelog は2つのref間の変更履歴
+type Changelog struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Commits  []ChangelogCommit `json:"commits"`
+	Authors  []ChangelogAuthor `json:"authors"`
+	Groups   []ChangelogGroup  `json:"groups,omitempty"` // group=type を指定した場合のみ
+	Markdown string            `json:"markdown"`         // リリースノートに貼り付けられる形式
+}
+
+// changelogHandler は2つのタグ（またはタグとHEAD）の間の変更履歴を返す
+//
+//	GET /api/repository/{group}/{repo}/changelog?from=v1.0&to=v1.1&group=type
+func changelogHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	query := r.URL.Query()
+	from := query.Get("from")
+	to := query.Get("to")
+	if to == "" {
+		to = "HEAD"
+	}
+
+	if from == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "fromが指定されていません"})
+		return
+	}
+
+	changelog, err := generateChangelog(r.Context(), repoPath, from, to, query.Get("group") == "type")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(changelog)
+}
+
+// generateChangelog は git log from..to から変更履歴を生成する
+func generateChangelog(ctx context.Context, repoPath, from, to string, groupByType bool) (*Changelog, error) {
+	fromCommit, err := resolveCommit(ctx, repoPath, from)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := resolveCommit(ctx, repoPath, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--no-merges",
+		"--format=%H%x00%an%x00%at%x00%s%x1e", fromCommit+".."+toCommit)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("コミット履歴の取得に失敗しました: %w", err)
+	}
+
+	changelog := &Changelog{
+		From:    from,
+		To:      to,
+		Commits: []ChangelogCommit{},
+		Authors: []ChangelogAuthor{},
+	}
+
+	authorCounts := map[string]int{}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+
+		commit := ChangelogCommit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Subject: fields[3],
+			Type:    "other",
+		}
+		if unixTime, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			commit.Date = time.Unix(unixTime, 0)
+		}
+
+		// Conventional Commits 形式であれば種別とスコープを取り出す
+		if m := conventionalCommitPattern.FindStringSubmatch(commit.Subject); m != nil {
+			commit.Type = strings.ToLower(m[1])
+			commit.Scope = m[2]
+			commit.Breaking = m[3] == "!"
+			commit.Subject = m[4]
+		}
+
+		changelog.Commits = append(changelog.Commits, commit)
+		authorCounts[commit.Author]++
+	}
+
+	for name, count := range authorCounts {
+		changelog.Authors = append(changelog.Authors, ChangelogAuthor{Name: name, Commits: count})
+	}
+	sort.Slice(changelog.Authors, func(i, j int) bool {
+		if changelog.Authors[i].Commits != changelog.Authors[j].Commits {
+			return changelog.Authors[i].Commits > changelog.Authors[j].Commits
+		}
+		return changelog.Authors[i].Name < changelog.Authors[j].Name
+	})
+
+	if groupByType {
+		changelog.Groups = groupChangelogCommits(changelog.Commits)
+	}
+	changelog.Markdown = formatChangelogMarkdown(changelog, groupByType)
+
+	return changelog, nil
+}
+
+// groupChangelogCommits はコミットを Conventional Commits の種別ごとにまとめる
+func groupChangelogCommits(commits []ChangelogCommit) []ChangelogGroup {
+	byType := map[string][]ChangelogCommit{}
+	for _, commit := range commits {
+		byType[commit.Type] = append(byType[commit.Type], commit)
+	}
+
+	var groups []ChangelogGroup
+	for _, t := range changelogTypeTitles {
+		if len(byType[t.Type]) > 0 {
+			groups = append(groups, ChangelogGroup{Type: t.Type, Title: t.Title, Commits: byType[t.Type]})
+			delete(byType, t.Type)
+		}
+	}
+
+	// 見出しが定義されていない種別はまとめて末尾に追加
+	var unknownTypes []string
+	for t := range byType {
+		unknownTypes = append(unknownTypes, t)
+	}
+	sort.Strings(unknownTypes)
+	for _, t := range unknownTypes {
+		groups = append(groups, ChangelogGroup{Type: t, Title: t, Commits: byType[t]})
+	}
+
+	return groups
+}
+
+// formatChangelogMarkdown は変更履歴をMarkdownのリストに整形する
+func formatChangelogMarkdown(changelog *Changelog, groupByType bool) string {
+	var b strings.Builder
+
+	formatCommit := func(commit ChangelogCommit) {
+		b.WriteString("- ")
+		if commit.Breaking {
+			b.WriteString("**BREAKING** ")
+		}
+		if commit.Scope != "" {
+			fmt.Fprintf(&b, "**%s:** ", commit.Scope)
+		}
+		shortSHA := commit.SHA
+		if len(shortSHA) > 7 {
+			shortSHA = shortSHA[:7]
+		}
+		fmt.Fprintf(&b, "%s (%s, %s)\n", commit.Subject, shortSHA, commit.Author)
+	}
+
+	fmt.Fprintf(&b, "## %s...%s\n\n", changelog.From, changelog.To)
+
+	if groupByType {
+		for _, group := range changelog.Groups {
+			fmt.Fprintf(&b, "### %s\n\n", group.Title)
+			for _, commit := range group.Commits {
+				formatCommit(commit)
+			}
+			b.WriteString("\n")
+		}
+	} else {
+		for _, commit := range changelog.Commits {
+			formatCommit(commit)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(changelog.Authors) > 0 {
+		names := make([]string, len(changelog.Authors))
+		for i, author := range changelog.Authors {
+			names[i] = author.Name
+		}
+		fmt.Fprintf(&b, "Contributors: %s\n", strings.Join(names, ", "))
+	}
+
+	return b.String()
+}
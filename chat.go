@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ChatNotificationTimeout はチャットへの通知1件あたりのタイムアウト
+var ChatNotificationTimeout = 10 * time.Second
+
+// MaxChatNotifiers は1つのグループに設定できる通知先の上限
+var MaxChatNotifiers = 20
+
+// maxChatCommits はチャットの通知に載せる1つの参照あたりのコミット数
+const maxChatCommits = 10
+
+// notifiersBucket はグループごとのチャット通知の設定を保存するバケット名
+var notifiersBucket = []byte("notifiers")
+
+// 通知先の種類
+const (
+	ChatNotifierSlack   = "slack"   // Slack の Incoming Webhook
+	ChatNotifierDiscord = "discord" // Discord の Webhook
+	ChatNotifierGeneric = "generic" // ChatEvent をそのまま JSON で送る
+)
+
+// 通知するイベント
+const (
+	ChatEventPush              = "push"
+	ChatEventRepositoryCreated = "repository-created"
+	ChatEventRepositoryDeleted = "repository-deleted"
+)
+
+var chatNotifierTypes = []string{ChatNotifierSlack, ChatNotifierDiscord, ChatNotifierGeneric}
+var chatEvents = []string{ChatEventPush, ChatEventRepositoryCreated, ChatEventRepositoryDeleted}
+
+// ChatNotifier はグループのイベントを通知するチャットの Webhook 1つを表す
+// イベント・リポジトリ・ブランチで絞り込むことで、通知先のチャンネルを振り分ける
+type ChatNotifier struct {
+	Name         string   `json:"name"`         // 表示用の名前
+	Type         string   `json:"type"`         // "slack"、"discord"、"generic"
+	URL          string   `json:"url"`          // Webhook の URL
+	Channel      string   `json:"channel"`      // Slack の投稿先チャンネル（空の場合は Webhook の既定のチャンネル）
+	Events       []string `json:"events"`       // 通知するイベント（空の場合はすべて）
+	Repositories []string `json:"repositories"` // 通知するリポジトリ名のパターン（空の場合はすべて）
+	Branches     []string `json:"branches"`     // プッシュを通知するブランチ名のパターン（空の場合はすべて、タグは対象外）
+}
+
+// ChatNotifiersRequest はグループのチャット通知の設定の更新リクエスト用の構造体
+type ChatNotifiersRequest struct {
+	Notifiers []ChatNotifier `json:"notifiers"`
+}
+
+// ChatEvent はチャットに通知するイベントを表す（generic の場合はこのまま送る）
+type ChatEvent struct {
+	Event      string     `json:"event"` // "push"、"repository-created"、"repository-deleted"
+	Group      string     `json:"group"`
+	Repository string     `json:"repository"`
+	Date       time.Time  `json:"date"`
+	Push       *PushEvent `json:"push,omitempty"` // プッシュの場合のみ
+}
+
+// getChatNotifiers はグループのチャット通知の設定を取得する
+func getChatNotifiers(groupName string) []ChatNotifier {
+	notifiers := []ChatNotifier{}
+	if metadataStore == nil {
+		return notifiers
+	}
+
+	metadataStore.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(notifiersBucket).Get([]byte(groupName))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &notifiers); err != nil {
+			log.Printf("警告: グループ '%s' のチャット通知の設定の読み込みに失敗しました: %v", groupName, err)
+		}
+		return nil
+	})
+
+	return notifiers
+}
+
+// normalizePatterns はパターンの前後の空白と空のパターンを除き、パターンとして正しいか確認する
+func normalizePatterns(patterns []string) ([]string, error) {
+	normalized := []string{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("パターン '%s' は不正です", pattern)
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized, nil
+}
+
+// validateChatNotifier は通知先の設定を確認し、正規化する
+func validateChatNotifier(notifier *ChatNotifier) error {
+	notifier.Name = strings.TrimSpace(notifier.Name)
+	notifier.Channel = strings.TrimSpace(notifier.Channel)
+
+	if !containsString(chatNotifierTypes, notifier.Type) {
+		return fmt.Errorf("通知先の種類 '%s' は不正です（%s）", notifier.Type, strings.Join(chatNotifierTypes, "、"))
+	}
+
+	u, err := url.Parse(strings.TrimSpace(notifier.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Webhook の URL '%s' は不正です", notifier.URL)
+	}
+	notifier.URL = u.String()
+
+	events := []string{}
+	for _, event := range notifier.Events {
+		if !containsString(chatEvents, event) {
+			return fmt.Errorf("イベント '%s' は不正です（%s）", event, strings.Join(chatEvents, "、"))
+		}
+		if !containsString(events, event) {
+			events = append(events, event)
+		}
+	}
+	notifier.Events = events
+
+	if notifier.Repositories, err = normalizePatterns(notifier.Repositories); err != nil {
+		return err
+	}
+	if notifier.Branches, err = normalizePatterns(notifier.Branches); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setChatNotifiers はグループのチャット通知の設定を保存し、グループ内のリポジトリの post-receive フックを設置または削除する
+func setChatNotifiers(ctx context.Context, groupName string, notifiers []ChatNotifier) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+	if len(notifiers) > MaxChatNotifiers {
+		return fmt.Errorf("通知先は %d 件までです", MaxChatNotifiers)
+	}
+	if notifiers == nil {
+		notifiers = []ChatNotifier{}
+	}
+	for i := range notifiers {
+		if err := validateChatNotifier(&notifiers[i]); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(notifiers)
+	if err != nil {
+		return err
+	}
+	err = metadataStore.Update(func(tx *bolt.Tx) error {
+		if len(notifiers) == 0 {
+			return tx.Bucket(notifiersBucket).Delete([]byte(groupName))
+		}
+		return tx.Bucket(notifiersBucket).Put([]byte(groupName), data)
+	})
+	if err != nil {
+		return fmt.Errorf("チャット通知の設定の保存に失敗しました: %w", err)
+	}
+
+	// プッシュの通知は post-receive フックから届くため、グループ内のリポジトリのフックを設定に合わせる
+	repos, _ := getGitRepositories(ctx, groupName)
+	for _, repo := range repos {
+		if repoPath, ok := findRepository(groupName, repo.Name); ok {
+			if err := syncPostReceiveHook(ctx, repoPath); err != nil {
+				log.Printf("警告: %s/%s: %v", groupName, repo.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesAnyPattern はパターンが空か、いずれかのパターンに一致するかどうかを返す
+func matchesAnyPattern(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// hasChatPushNotifiers はグループにプッシュを通知する通知先があるかどうかを返す
+func hasChatPushNotifiers(groupName string) bool {
+	for _, notifier := range getChatNotifiers(groupName) {
+		if len(notifier.Events) == 0 || containsString(notifier.Events, ChatEventPush) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterChatEvent は通知先の絞り込み条件に合うイベントだけを返す（通知しない場合は nil）
+// プッシュの場合は、ブランチのパターンに合う参照の更新だけを残す
+func filterChatEvent(notifier ChatNotifier, event ChatEvent) *ChatEvent {
+	if len(notifier.Events) > 0 && !containsString(notifier.Events, event.Event) {
+		return nil
+	}
+	if !matchesAnyPattern(notifier.Repositories, event.Repository) {
+		return nil
+	}
+	if event.Push == nil {
+		return &event
+	}
+
+	push := *event.Push
+	push.Updates = []RefUpdate{}
+	for _, update := range event.Push.Updates {
+		if len(notifier.Branches) > 0 && (update.Type != RefTypeBranch || !matchesAnyPattern(notifier.Branches, update.Name)) {
+			continue
+		}
+		push.Updates = append(push.Updates, update)
+	}
+	if len(push.Updates) == 0 {
+		return nil
+	}
+	event.Push = &push
+	return &event
+}
+
+// formatChatMessage はイベントをチャットに投稿する Markdown 形式のテキストにする
+// Slack の mrkdwn と Discord の Markdown の共通部分（太字の記法以外）だけを使う
+func formatChatMessage(event ChatEvent, bold func(string) string) string {
+	fullName := event.Group + "/" + event.Repository
+
+	switch event.Event {
+	case ChatEventRepositoryCreated:
+		return fmt.Sprintf("%s リポジトリ %s を作成しました", bold("["+fullName+"]"), fullName)
+	case ChatEventRepositoryDeleted:
+		return fmt.Sprintf("%s リポジトリ %s を削除しました", bold("["+fullName+"]"), fullName)
+	}
+
+	var b strings.Builder
+	for i, update := range event.Push.Updates {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "%s %s", bold("["+fullName+"]"), refUpdateSummary(update))
+		for j, commit := range update.Commits {
+			if j == maxChatCommits {
+				fmt.Fprintf(&b, "\n... ほか %d 件", update.TotalCommits-maxChatCommits)
+				break
+			}
+			fmt.Fprintf(&b, "\n`%s` %s - %s", shortCommit(commit.SHA), commit.Subject, commit.Author)
+		}
+	}
+	return b.String()
+}
+
+// buildChatPayload は通知先の種類に合わせた Webhook の本文を作る
+func buildChatPayload(notifier ChatNotifier, event ChatEvent) ([]byte, error) {
+	switch notifier.Type {
+	case ChatNotifierSlack:
+		payload := map[string]string{
+			"username": "guilty",
+			"text":     formatChatMessage(event, func(s string) string { return "*" + s + "*" }),
+		}
+		if notifier.Channel != "" {
+			payload["channel"] = notifier.Channel
+		}
+		return json.Marshal(payload)
+
+	case ChatNotifierDiscord:
+		// Discord のメッセージは2000文字まで
+		content := []rune(formatChatMessage(event, func(s string) string { return "**" + s + "**" }))
+		if len(content) > 2000 {
+			content = append(content[:1999], '…')
+		}
+		return json.Marshal(map[string]string{"username": "guilty", "content": string(content)})
+
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// sendChatNotification は通知先の Webhook にイベントを送る
+func sendChatNotification(notifier ChatNotifier, event ChatEvent) error {
+	payload, err := buildChatPayload(notifier, event)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: ChatNotificationTimeout}
+	resp, err := client.Post(notifier.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook が %s を返しました", resp.Status)
+	}
+	return nil
+}
+
+// notifyChat はグループの通知先のうち、絞り込み条件に合うものにイベントを送る
+func notifyChat(event ChatEvent) {
+	for _, notifier := range getChatNotifiers(event.Group) {
+		filtered := filterChatEvent(notifier, event)
+		if filtered == nil {
+			continue
+		}
+		if err := sendChatNotification(notifier, *filtered); err != nil {
+			log.Printf("警告: %s/%s のチャット通知（%s）の送信に失敗しました: %v", event.Group, event.Repository, notifier.Name, err)
+		}
+	}
+}
+
+// notifyRepositoryEvent はリポジトリの作成・削除をバックグラウンドでチャットに通知する
+func notifyRepositoryEvent(eventName, groupName, repoName string) {
+	go notifyChat(ChatEvent{Event: eventName, Group: groupName, Repository: repoName, Date: time.Now()})
+}
+
+// notifiersHandler はグループのチャット通知の設定を管理する管理者用ハンドラー
+//
+//	GET /api/admin/notifiers/{group}
+//	PUT /api/admin/notifiers/{group}  {"notifiers": [{"type": "slack", "url": "...", "channel": "#dev", "events": ["push"]}]}
+func notifiersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	groupName := r.PathValue("groupName")
+	if !isValidGroupName(groupName) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なグループ名です"})
+		return
+	}
+
+	if metadataStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "メタデータストアが利用できません"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string][]ChatNotifier{"notifiers": getChatNotifiers(groupName)})
+
+	case http.MethodPut:
+		var req ChatNotifiersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := setChatNotifiers(r.Context(), groupName, req.Notifiers); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		// Webhook の URL は秘密の値を含むため、名前と種類だけを記録する
+		var names []string
+		for _, notifier := range req.Notifiers {
+			names = append(names, notifier.Name+" ("+notifier.Type+")")
+		}
+		recordAudit(r, "notifiers.update", groupName, "", map[string]string{"notifiers": strings.Join(names, ", ")})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string][]ChatNotifier{"notifiers": getChatNotifiers(groupName)})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
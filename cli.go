@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cliUsage は `guilty help` で表示する使い方
+const cliUsage = `使い方: guilty [オプション] [コマンド] [引数]
+
+コマンド:
+  serve                                          サーバーを起動する（コマンドを省略した場合の既定）
+  list [-json] [グループ]                        リポジトリの一覧を表示する（グループを省略するとすべてのグループ）
+  create [-description 説明] [-branch ブランチ] [-readme] [-gitignore 名前] [-license SPDX識別子] [-copyright 著作権者] {group}/{name}
+                                                 リポジトリを作成する（-readme などで最初のコミットを作成する）
+  gc [-task gc|repack] [-auto] [{group}/{name}...]
+                                                 git gc / git repack を実行する（リポジトリを省略するとすべて）
+  backup [ディレクトリ]                          すべてのリポジトリをバックアップする
+  restore [-force] {ディレクトリ}                バックアップからすべてのリポジトリを復元する
+  restore [-force] {バンドルファイル} {group}/{name}
+                                                 バンドルから1つのリポジトリを復元する
+  purge-trash [-all] [{group}/{name}...]         ゴミ箱のリポジトリを完全に削除する（既定は保持期間を過ぎたもののみ）
+  config                                         設定ファイル、環境変数、オプションを反映した設定を表示する
+  help                                           この使い方を表示する（オプションの一覧は guilty -help）
+
+サーバーの起動中はメタデータストアがロックされるため、create・backup・restore・purge-trash は
+サーバーを停止してから実行するか、管理者用APIを使用してください。
+`
+
+// runCLICommand はサブコマンドを実行して終了コードを返す
+// serve の場合は ok に false を返し、呼び出し元でサーバーを起動する
+func runCLICommand(args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	switch args[0] {
+	case "serve":
+		return 0, false
+	case "list":
+		return runListCommand(args[1:]), true
+	case "create":
+		return runCreateCommand(args[1:]), true
+	case "gc":
+		return runGCCommand(args[1:]), true
+	case "backup":
+		return runBackupCommand(args[1:]), true
+	case "restore":
+		return runRestoreCommand(args[1:]), true
+	case "purge-trash":
+		return runPurgeTrashCommand(args[1:]), true
+	case "config":
+		return runConfigCommand(args[1:]), true
+	case "help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0, true
+	default:
+		fmt.Fprintf(os.Stderr, "不明なコマンドです: %s\n\n%s", args[0], cliUsage)
+		return 2, true
+	}
+}
+
+// newCommandFlagSet はサブコマンドの引数を解析する FlagSet を作る
+func newCommandFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "使い方: guilty %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// runListCommand は `guilty list [-json] [グループ]` としてリポジトリの一覧を表示する
+func runListCommand(args []string) int {
+	flags := newCommandFlagSet("list", "list [-json] [グループ]")
+	asJSON := flags.Bool("json", false, "JSON 形式で出力する")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var groups []string
+	switch flags.NArg() {
+	case 0:
+		var err error
+		groups, err = getGroupList()
+		if err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+	case 1:
+		if !isValidGroupName(flags.Arg(0)) {
+			log.Printf("エラー: 無効なグループ名です: %s", flags.Arg(0))
+			return 1
+		}
+		groups = []string{flags.Arg(0)}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	repositories := []GitRepository{}
+	for _, groupName := range groups {
+		repos, err := getGitRepositories(serverContext, groupName)
+		if err != nil {
+			log.Printf("エラー: グループ '%s' のリポジトリ取得に失敗しました: %v", groupName, err)
+			return 1
+		}
+		repositories = append(repositories, excludeWikiRepositories(repos)...)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(repositories); err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+		return 0
+	}
+
+	printRepositoryList(os.Stdout, repositories)
+	return 0
+}
+
+// printRepositoryList はリポジトリの一覧を列を揃えて出力する
+func printRepositoryList(out io.Writer, repositories []GitRepository) {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "REPOSITORY\tLAST COMMIT\tAUTHOR\tDESCRIPTION")
+	for _, repo := range repositories {
+		lastCommit, author := "-", "-"
+		if repo.LastCommit != nil {
+			lastCommit = repo.LastCommit.Date.Format("2006-01-02 15:04")
+			author = repo.LastCommit.Author
+		}
+		fmt.Fprintf(writer, "%s/%s\t%s\t%s\t%s\n", repo.Group, repo.Name, lastCommit, author, repo.Description)
+	}
+	writer.Flush()
+}
+
+// runCreateCommand は `guilty create [-description 説明] [-branch ブランチ] [-readme] [-gitignore 名前] [-license SPDX識別子] [-copyright 著作権者] {group}/{name}` としてリポジトリを作成する
+func runCreateCommand(args []string) int {
+	flags := newCommandFlagSet("create", "create [-description 説明] [-branch ブランチ] [-readme] [-gitignore 名前] [-license SPDX識別子] [-copyright 著作権者] {group}/{name}")
+	description := flags.String("description", "", "リポジトリの説明")
+	defaultBranch := flags.String("branch", "", "HEAD が指すデフォルトブランチ（省略した場合は defaultBranch の設定）")
+	var template RepositoryTemplate
+	flags.BoolVar(&template.Readme, "readme", false, "README.md を含む最初のコミットを作成する")
+	flags.StringVar(&template.Gitignore, "gitignore", "", ".gitignore のテンプレート（"+strings.Join(gitignoreTemplateNames(), "、")+"）")
+	flags.StringVar(&template.License, "license", "", "LICENSE のライセンス（"+strings.Join(licenseTextIDs(), "、")+"）")
+	flags.StringVar(&template.CopyrightHolder, "copyright", "", "LICENSE に記載する著作権者（省略した場合はグループ名）")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	if !requireMetadataStore() {
+		return 1
+	}
+
+	groupName, repoName := splitRepositoryName(flags.Arg(0))
+	if err := validateRepositoryName(repoName, groupName); err != nil {
+		log.Printf("エラー: %v", err)
+		return 1
+	}
+	if *defaultBranch != "" && !isValidBranchName(serverContext, *defaultBranch) {
+		log.Printf("エラー: ブランチ名 '%s' は不正です", *defaultBranch)
+		return 1
+	}
+	if err := validateRepositoryTemplate(template); err != nil {
+		log.Printf("エラー: %v", err)
+		return 1
+	}
+	if err := checkGroupQuota(groupName); err != nil {
+		log.Printf("エラー: %v", err)
+		return 1
+	}
+
+	if err := createRepository(serverContext, repoName, groupName, *defaultBranch, template); err != nil {
+		log.Printf("エラー: %v", err)
+		return 1
+	}
+	if *description != "" {
+		repoPath, _ := findRepository(groupName, repoName)
+		if err := setRepositoryDescription(repoPath, *description); err != nil {
+			log.Printf("警告: %v", err)
+		}
+	}
+	notifyRepositoryEvent(ChatEventRepositoryCreated, groupName, repoName)
+	recordCLIAudit("repository.create", groupName, repoName, repositoryCreateDetails(*defaultBranch, template))
+
+	log.Printf("%s/%s を作成しました", groupName, repoName)
+	return 0
+}
+
+// runGCCommand は `guilty gc [-task gc|repack] [-auto] [{group}/{name}...]` としてメンテナンスを実行する
+// リポジトリを省略した場合はすべてのグループのリポジトリが対象になる
+func runGCCommand(args []string) int {
+	flags := newCommandFlagSet("gc", "gc [-task gc|repack] [-auto] [{group}/{name}...]")
+	task := flags.String("task", "gc", "実行するメンテナンス（gc または repack）")
+	auto := flags.Bool("auto", false, "緩いオブジェクトやパックが多いリポジトリのみを対象にする")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if _, ok := maintenanceTasks[*task]; !ok {
+		log.Printf("エラー: 不明なメンテナンスです: %s", *task)
+		return 2
+	}
+
+	var repoPaths []string
+	if flags.NArg() == 0 {
+		groups, err := getGroupList()
+		if err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+		for _, groupName := range groups {
+			repos, err := getGitRepositories(serverContext, groupName)
+			if err != nil {
+				log.Printf("警告: グループ '%s' のリポジトリ取得に失敗しました: %v", groupName, err)
+				continue
+			}
+			for _, repo := range repos {
+				repoPaths = append(repoPaths, repo.Path)
+			}
+		}
+	} else {
+		for _, name := range flags.Args() {
+			groupName, repoName := splitRepositoryName(name)
+			repoPath, ok := findRepository(groupName, repoName)
+			if !ok {
+				log.Printf("エラー: リポジトリ '%s/%s' は存在しません", groupName, repoName)
+				return 1
+			}
+			repoPaths = append(repoPaths, repoPath)
+		}
+	}
+
+	done, failed := 0, 0
+	for _, repoPath := range repoPaths {
+		if *auto && !needsMaintenance(serverContext, repoPath) {
+			continue
+		}
+		// 失敗した場合は runMaintenance が警告を記録する
+		if err := runMaintenance(serverContext, repoPath, *task); err != nil {
+			failed++
+			continue
+		}
+		recordCLIAudit("maintenance.run", filepath.Base(filepath.Dir(repoPath)), strings.TrimSuffix(filepath.Base(repoPath), ".git"), map[string]string{"task": *task})
+		done++
+	}
+
+	log.Printf("%d 個のリポジトリのメンテナンス（%s）を実行しました", done, *task)
+	if failed > 0 {
+		log.Printf("エラー: %d 個のリポジトリのメンテナンスに失敗しました", failed)
+		return 1
+	}
+	return 0
+}
+
+// runPurgeTrashCommand は `guilty purge-trash [-all] [{group}/{name}...]` としてゴミ箱のリポジトリを完全に削除する
+// 既定では DeletedRepositoryRetention の保持期間を過ぎたもののみを削除する
+func runPurgeTrashCommand(args []string) int {
+	flags := newCommandFlagSet("purge-trash", "purge-trash [-all] [{group}/{name}...]")
+	all := flags.Bool("all", false, "保持期間に関係なくゴミ箱のすべてのリポジトリを削除する")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !requireMetadataStore() {
+		return 1
+	}
+
+	if flags.NArg() > 0 {
+		failed := 0
+		for _, name := range flags.Args() {
+			if err := purgeRepository(name); err != nil {
+				log.Printf("エラー: %v", err)
+				failed++
+				continue
+			}
+			log.Printf("削除済みリポジトリ '%s' を完全に削除しました", name)
+			groupName, repoName := splitRepositoryName(name)
+			recordCLIAudit("repository.purge", groupName, repoName, nil)
+		}
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	cutoff := time.Now()
+	if !*all {
+		if DeletedRepositoryRetention <= 0 {
+			log.Printf("保持期間が設定されていないため削除しません（すべて削除する場合は -all を指定してください）")
+			return 0
+		}
+		cutoff = cutoff.Add(-DeletedRepositoryRetention)
+	}
+
+	purged, failed := purgeExpiredRepositories(cutoff, recordCLIAudit)
+	log.Printf("%d 個の削除済みリポジトリを完全に削除しました", purged)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
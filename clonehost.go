@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// isValidHostName はクローンURLに埋め込めるホスト名かどうかを返す
+// ヘッダーの値をそのままURLに入れないよう、英数字と . - _ だけのホスト名と [ ] で囲んだ IPv6 アドレスに限る
+func isValidHostName(host string) bool {
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return net.ParseIP(host[1:len(host)-1]) != nil
+	}
+	for _, c := range host {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// stripPort はホスト名からポート番号を取り除く（クローンURLは SSH のため、HTTP のポートは使わない）
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		if strings.Contains(h, ":") {
+			// IPv6 アドレスは [ ] で囲んだままにする
+			return "[" + h + "]"
+		}
+		return h
+	}
+	return host
+}
+
+// cloneHostName はクローンURLに使うホスト名を返す
+// hostName が設定されている場合はそれを使い、空の場合はアクセスされたホスト名を使う
+// IPアドレス、社内のDNS名、外部のFQDNのどれでアクセスしても、そのままクローンできるURLになる
+// リバースプロキシを経由する場合は X-Forwarded-Host（複数の場合は最初の値）を Host より優先する
+// リクエストがない場合（CLI など）や、ホスト名が不正な場合はサーバーのホスト名を使う
+func cloneHostName(r *http.Request) string {
+	if hostName := getGitHostName(); hostName != "" {
+		return hostName
+	}
+
+	if r != nil {
+		host := r.Host
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if host = stripPort(host); isValidHostName(host) {
+			return host
+		}
+	}
+
+	if hostName, err := os.Hostname(); err == nil && isValidHostName(hostName) {
+		return hostName
+	}
+	return "localhost"
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCommitsPerPage はコミット履歴で1ページに返す件数の既定値
+var DefaultCommitsPerPage = 30
+
+// MaxCommitsPerPage はコミット履歴で1ページに返す件数の上限
+var MaxCommitsPerPage = 100
+
+// Commit はコミット履歴の1件を表す
+type Commit struct {
+	SHA            string         `json:"sha"`
+	Parents        []string       `json:"parents"`
+	Author         string         `json:"author"`
+	AuthorEmail    string         `json:"authorEmail"`
+	Date           time.Time      `json:"date"` // 作成日時（author date）
+	Committer      string         `json:"committer"`
+	CommitterEmail string         `json:"committerEmail"`
+	CommitDate     time.Time      `json:"commitDate"`
+	Subject        string         `json:"subject"`   // メッセージの1行目
+	Message        string         `json:"message"`   // メッセージ全体
+	Signature      *SignatureInfo `json:"signature"` // 署名の検証結果（署名のないコミットは null）
+	AuthorAvatar                  // 作成者のアバター
+}
+
+// ChangedFile はコミットで変更されたファイルを表す
+type ChangedFile struct {
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"` // 名前変更・コピー元のパス
+	Status  string `json:"status"`            // "added"、"modified"、"deleted"、"renamed"、"copied"、"typechange"
+}
+
+// CommitDetail はコミットの詳細を表す
+type CommitDetail struct {
+	Commit
+	Files []ChangedFile `json:"files"` // 最初の親との差分
+	Notes string        `json:"notes"` // refs/notes/commits に保存されたノート（ない場合は空文字）
+}
+
+// commitFieldsFormat は git log でコミット情報を取得するためのフォーマット
+// フィールドはNUL区切り（%G? 以降は署名の検証結果）
+const commitFieldsFormat = "%H%x00%P%x00%an%x00%ae%x00%at%x00%cn%x00%ce%x00%ct%x00%G?%x00%GS%x00%GK%x00%B"
+
+// commitFieldCount は commitFieldsFormat のフィールド数
+const commitFieldCount = 12
+
+// commitFormat はレコードを0x1e区切りにしたコミット情報のフォーマット
+const commitFormat = commitFieldsFormat + "%x1e"
+
+// changedFileStatuses は git diff-tree --name-status の状態と名前の対応
+var changedFileStatuses = map[byte]string{
+	'A': "added",
+	'M': "modified",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'T': "typechange",
+}
+
+// getCommits は rev から辿れるコミットを新しい順に取得する
+func getCommits(ctx context.Context, repoPath, rev string, skip, limit int) ([]Commit, error) {
+	return getGitBackend().Log(ctx, repoPath, rev, skip, limit)
+}
+
+func (commandGitBackend) Log(ctx context.Context, repoPath, rev string, skip, limit int) ([]Commit, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--format="+commitFormat,
+		"--skip="+strconv.Itoa(skip), "--max-count="+strconv.Itoa(limit), rev, "--")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("コミット履歴の取得に失敗しました: %w", err)
+	}
+
+	return parseCommits(string(output)), nil
+}
+
+// parseCommits は commitFormat 形式の git log の出力を解析する
+func parseCommits(output string) []Commit {
+	commits := []Commit{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) != commitFieldCount {
+			continue
+		}
+
+		commits = append(commits, parseCommitFields(fields))
+	}
+
+	return commits
+}
+
+// parseCommitFields は commitFieldsFormat の各フィールドからコミット情報を作る
+func parseCommitFields(fields []string) Commit {
+	commit := Commit{
+		SHA:            fields[0],
+		Parents:        strings.Fields(fields[1]),
+		Author:         fields[2],
+		AuthorEmail:    fields[3],
+		Committer:      fields[5],
+		CommitterEmail: fields[6],
+		Message:        strings.TrimSpace(fields[11]),
+		Signature:      parseCommitSignature(fields[8], fields[9], fields[10]),
+		AuthorAvatar:   newAuthorAvatar(fields[3]),
+	}
+	commit.Subject, _, _ = strings.Cut(commit.Message, "\n")
+	if unixTime, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+		commit.Date = time.Unix(unixTime, 0)
+	}
+	if unixTime, err := strconv.ParseInt(fields[7], 10, 64); err == nil {
+		commit.CommitDate = time.Unix(unixTime, 0)
+	}
+
+	return commit
+}
+
+// getCommitDetail はコミットの情報と変更されたファイルを取得する
+func getCommitDetail(ctx context.Context, repoPath, sha string) (*CommitDetail, error) {
+	commits, err := getCommits(ctx, repoPath, sha, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("コミット '%s' が見つかりません", sha)
+	}
+
+	files, err := getChangedFiles(ctx, repoPath, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommitDetail{Commit: commits[0], Files: files, Notes: getCommitNotes(ctx, repoPath, sha)}, nil
+}
+
+// getCommitNotes は git notes show でコミットのノートを取得する
+// レビューツールなどがメタデータを保存するために使う（ノートがない場合は空文字）
+func getCommitNotes(ctx context.Context, repoPath, sha string) string {
+	output, err := gitOutput(ctx, "--git-dir="+repoPath, "notes", "--ref=commits", "show", sha)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// getChangedFiles は git diff-tree でコミットが最初の親から変更したファイルを取得する
+// 最初のコミットの場合は空のツリーとの差分になる
+// 2つのコミットを指定した場合はその間の差分になる
+func getChangedFiles(ctx context.Context, repoPath string, revs ...string) ([]ChangedFile, error) {
+	args := []string{"--git-dir=" + repoPath, "diff-tree", "-r", "-z", "-M", "--root", "--no-commit-id", "--name-status"}
+	cmd, cancel := gitCommand(ctx, append(args, revs...)...)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("変更されたファイルの取得に失敗しました: %w", err)
+	}
+
+	// -z の場合は「状態 NUL パス NUL」、名前変更・コピーは「状態 NUL 元のパス NUL パス NUL」の並びになる
+	files := []ChangedFile{}
+	fields := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			break
+		}
+
+		file := ChangedFile{Status: changedFileStatuses[fields[i][0]], Path: fields[i+1]}
+		if file.Status == "renamed" || file.Status == "copied" {
+			if i+2 >= len(fields) {
+				break
+			}
+			file.OldPath = fields[i+1]
+			file.Path = fields[i+2]
+			i++
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// parseCommitPage は page と limit のパラメータから読み飛ばす件数と取得する件数を求める
+func parseCommitPage(query url.Values) (skip int, limit int, err error) {
+	page := 1
+	if value := query.Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page は1以上の整数で指定してください")
+		}
+	}
+
+	limit = DefaultCommitsPerPage
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxCommitsPerPage {
+			return 0, 0, fmt.Errorf("limit は1から%dの整数で指定してください", MaxCommitsPerPage)
+		}
+	}
+
+	return (page - 1) * limit, limit, nil
+}
+
+// commitsHandler はコミット履歴とコミットの詳細を返す
+//
+//	GET /api/repository/{group}/{repo}/commits?ref=main&page=1&limit=30  コミット履歴（Commitの配列）
+//	GET /api/repository/{group}/{repo}/commits/{sha}                     コミットの詳細（CommitDetail）
+func commitsHandler(w http.ResponseWriter, r *http.Request, repoPath, sha string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	if sha != "" {
+		commit, err := resolveCommit(r.Context(), repoPath, sha)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "コミットが見つかりません"})
+			return
+		}
+
+		detail, err := getCommitDetail(r.Context(), repoPath, commit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(detail)
+		return
+	}
+
+	query := r.URL.Query()
+
+	skip, limit, err := parseCommitPage(query)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	ref := query.Get("ref")
+	if ref == "" {
+		// コミットのないリポジトリは空の履歴を返す
+		if ok, _ := hasRefs(r.Context(), repoPath); !ok {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode([]Commit{})
+			return
+		}
+		ref = "HEAD"
+	}
+
+	commit, err := resolveCommit(r.Context(), repoPath, ref)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	commits, err := getCommits(r.Context(), repoPath, commit, skip, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(commits)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressResponses は JSON、HTML などのレスポンスを gzip で圧縮するかどうか（クライアントが Accept-Encoding: gzip を送った場合のみ）
+var CompressResponses = true
+
+// compressionMinSize はこれより小さいレスポンスを圧縮しない（圧縮しても小さくならないため）
+const compressionMinSize = 1024
+
+// compressibleContentTypes は圧縮する Content-Type（前方一致）
+// 画像やバンドルなど、すでに圧縮されている形式は圧縮しない
+var compressibleContentTypes = []string{
+	"application/json",
+	"application/javascript",
+	"text/",
+	"image/svg+xml",
+}
+
+// getCompressResponses はレスポンスを圧縮するかどうかを返す
+func getCompressResponses() bool {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return CompressResponses
+}
+
+// acceptsGzip は Accept-Encoding に gzip（q=0 以外）が含まれるかどうかを返す
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.TrimSpace(name) != "*" {
+				continue
+			}
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// isCompressibleContentType は Content-Type が圧縮する形式かどうかを返す
+func isCompressibleContentType(contentType string) bool {
+	for _, prefix := range compressibleContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter は圧縮する形式のレスポンスを compressionMinSize までためてから、圧縮するかどうかを決める
+// 実際に圧縮を始めるまでヘッダーを変更しないため、途中でパニックした場合も recoverHandler が通常のエラーを返せる
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	pending     bytes.Buffer
+	gzip        *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if status == http.StatusNotModified {
+		// 圧縮した 200 のレスポンスと同じ ETag を返す
+		weakenETag(w.Header())
+	}
+	if status != http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	// 本文を書き始めるまで、圧縮するかどうかを決めない（Content-Type は本文から判定される場合がある）
+	w.buffering = true
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gzip != nil {
+		return w.gzip.Write(data)
+	}
+	if !w.buffering {
+		return w.ResponseWriter.Write(data)
+	}
+
+	if w.pending.Len() == 0 && !w.decide(data) {
+		return w.ResponseWriter.Write(data)
+	}
+	w.pending.Write(data)
+	if w.pending.Len() >= compressionMinSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// decide は最初に書く本文とヘッダーから圧縮できる形式かどうかを決める
+// 圧縮しない場合はヘッダーをそのまま書き、以降は元の ResponseWriter に書く
+func (w *gzipResponseWriter) decide(data []byte) bool {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		// net/http と同じく本文から判定する
+		header.Set("Content-Type", http.DetectContentType(data))
+	}
+	compressible := isCompressibleContentType(header.Get("Content-Type"))
+	if compressible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	small := err == nil && length < compressionMinSize
+	if !compressible || small || header.Get("Content-Encoding") != "" {
+		w.buffering = false
+		w.ResponseWriter.WriteHeader(w.status)
+		return false
+	}
+	return true
+}
+
+// startGzip はヘッダーを圧縮用に書き換え、ためていた本文から圧縮を始める
+func (w *gzipResponseWriter) startGzip() error {
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	// 圧縮したレスポンスはバイト列が変わるため、ETag を弱い比較用にする（If-None-Match は同じ値で一致する）
+	weakenETag(header)
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.buffering = false
+	w.gzip = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gzip.Write(w.pending.Bytes())
+	w.pending.Reset()
+	return err
+}
+
+// Flush は圧縮中のデータをクライアントに送る
+func (w *gzipResponseWriter) Flush() {
+	if w.buffering {
+		w.startGzip()
+	}
+	if w.gzip != nil {
+		w.gzip.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap は http.ResponseController が元の ResponseWriter の機能を使えるようにする
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close は圧縮を終える。compressionMinSize に満たなかった本文は圧縮せずにそのまま書く
+func (w *gzipResponseWriter) close() {
+	if w.gzip != nil {
+		w.gzip.Close()
+		return
+	}
+	if w.buffering {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.pending.Bytes())
+	}
+}
+
+// weakenETag は強い ETag を弱い ETag（W/"..."）にする
+func weakenETag(header http.Header) {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+}
+
+// compressHandler は JSON、HTML、JavaScript、CSS などのレスポンスを gzip で圧縮する
+// 遅い回線（VPN など）でもリポジトリ一覧やファイルの内容を速く表示できるようにする
+// 範囲指定のリクエスト（Range）と HEAD、小さいレスポンス、すでに圧縮されている形式は圧縮しない
+func compressHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" || !acceptsGzip(r) || !getCompressResponses() {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &gzipResponseWriter{ResponseWriter: w}
+		handler.ServeHTTP(writer, r)
+		if !writer.wroteHeader {
+			// 本文を書かなかった場合
+			writer.WriteHeader(http.StatusOK)
+		}
+		writer.close()
+	})
+}
@@ -0,0 +1,655 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFilePath は既定の設定ファイル（作業ディレクトリからの相対パス、存在しない場合は読み込まない）
+const DefaultConfigFilePath = "guilty.yaml"
+
+// ConfigFileEnv は設定ファイルのパスを指定する環境変数
+const ConfigFileEnv = "GUILTY_CONFIG"
+
+// Config は設定ファイル（YAML）、環境変数、コマンドラインのフラグで変更できる設定を表す
+// 優先順位は フラグ > 環境変数 > 設定ファイル > 既定値
+type Config struct {
+	Port                      int              `yaml:"port"`
+	UnixSocket                string           `yaml:"unixSocket"`     // 指定した場合は port の代わりに unix ドメインソケットで待ち受ける
+	UnixSocketMode            string           `yaml:"unixSocketMode"` // 8進数のアクセス権（0660 など）
+	BasePath                  string           `yaml:"basePath"`
+	RepositoryHome            string           `yaml:"repositoryHome"`
+	HostName                  string           `yaml:"hostName"`
+	CloneURLTemplate          string           `yaml:"cloneUrlTemplate"`      // %s にホスト名、グループ名、リポジトリ名が順に入る
+	HTTPSCloneURLTemplate     string           `yaml:"httpsCloneUrlTemplate"` // 空の場合は https のクローンURLを返さない
+	GitDaemonCloneURLTemplate string           `yaml:"gitCloneUrlTemplate"`   // 空の場合は git:// のクローンURLを返さない
+	GroupNameBlacklist        []string         `yaml:"groupNameBlacklist"`    // 既定のパターンに追加する正規表現
+	MetadataStore             string           `yaml:"metadataStore"`
+	BackupDirectory           string           `yaml:"backupDirectory"`
+	LFSStore                  string           `yaml:"lfsStore"`
+	AssetDirectory            string           `yaml:"assetDirectory"` // 埋め込んだテンプレートと静的ファイルを上書きするディレクトリ
+	TLSCertFile               string           `yaml:"tlsCertFile"`
+	TLSKeyFile                string           `yaml:"tlsKeyFile"`
+	AutocertDomains           []string         `yaml:"autocertDomains"`
+	AutocertCacheDir          string           `yaml:"autocertCacheDir"`
+	AutocertEmail             string           `yaml:"autocertEmail"`
+	HTTPRedirectPort          int              `yaml:"httpRedirectPort"` // HTTPS を有効にした場合に HTTPS にリダイレクトする HTTP のポート（0 は待ち受けない）
+	SMTPHost                  string           `yaml:"smtpHost"`
+	SMTPPort                  int              `yaml:"smtpPort"`
+	NotificationFrom          string           `yaml:"notificationFrom"`
+	AvatarURLTemplate         string           `yaml:"avatarUrlTemplate"`
+	AvatarProxy               bool             `yaml:"avatarProxy"`
+	DefaultGroupQuota         int64            `yaml:"defaultGroupQuota"` // バイト単位（0 は無制限）
+	GroupQuotas               map[string]int64 `yaml:"groupQuotas"`
+	EnforceQuotaOnPush        bool             `yaml:"enforceQuotaOnPush"`
+	TrashRetention            time.Duration    `yaml:"trashRetention"`     // 720h のような形式（0 は自動で削除しない）
+	RateLimit                 int              `yaml:"rateLimit"`          // クライアントごとの1分間のAPIのリクエスト数（0 は制限しない）
+	ExpensiveRateLimit        int              `yaml:"expensiveRateLimit"` // リポジトリ一覧とエクスポートの1分間のリクエスト数（0 は制限しない）
+	TrustedProxies            []string         `yaml:"trustedProxies"`     // X-Forwarded-For を信用するリバースプロキシ（CIDR または IP アドレス）
+	LogFormat                 string           `yaml:"logFormat"`          // text または json
+	ReadTimeout               time.Duration    `yaml:"readTimeout"`        // 0 は無制限
+	WriteTimeout              time.Duration    `yaml:"writeTimeout"`       // 0 は無制限
+	IdleTimeout               time.Duration    `yaml:"idleTimeout"`        // 0 は readTimeout と同じ
+	ShutdownTimeout           time.Duration    `yaml:"shutdownTimeout"`    // 終了時に処理中のリクエストを待つ時間
+	Pprof                     bool             `yaml:"pprof"`              // pprofAddress でプロファイルを提供する
+	PprofAddress              string           `yaml:"pprofAddress"`
+	GitCommandTimeout         time.Duration    `yaml:"gitCommandTimeout"`     // 0 は無制限
+	GitMaintenanceTimeout     time.Duration    `yaml:"gitMaintenanceTimeout"` // 0 は無制限
+	ScanConcurrency           int              `yaml:"scanConcurrency"`       // リポジトリ一覧で並列に読むリポジトリ数
+	RepositoryCacheTTL        time.Duration    `yaml:"repositoryCacheTtl"`    // 0 はキャッシュしない
+	CompressResponses         bool             `yaml:"compressResponses"`     // JSON、HTML などを gzip で圧縮する
+	GitBackend                string           `yaml:"gitBackend"`            // git または go-git
+	RepositoryIndex           bool             `yaml:"repositoryIndex"`       // リポジトリ一覧の索引をメタデータストアに保存する
+	WatchRepositories         bool             `yaml:"watchRepositories"`     // リポジトリのディレクトリを監視して変更をすぐに反映する
+	Language                  string           `yaml:"language"`              // APIのメッセージの既定の言語（ja または en）
+	AuditLog                  string           `yaml:"auditLog"`              // 空の場合は監査ログを記録しない
+	AccessTokens              []string         `yaml:"accessTokens"`          // ユーザー名=トークンの SHA-256
+	AdminUsers                []string         `yaml:"adminUsers"`            // すべてのリポジトリにアクセスできるユーザー
+	AuthProxyHeader           string           `yaml:"authProxyHeader"`       // 認証プロキシがユーザー名を渡すヘッダー（空の場合は使わない）
+	AuthProxySources          []string         `yaml:"authProxySources"`      // authProxyHeader を信用する接続元（CIDR、IP アドレス、unix）
+	DefaultBranch             string           `yaml:"defaultBranch"`         // 新しいリポジトリの HEAD が指すブランチ
+}
+
+// configOption は環境変数とフラグで指定できる設定項目を表す
+type configOption struct {
+	Name  string                      // フラグ名（設定ファイルのキーと同じ）
+	Env   string                      // 環境変数名
+	Usage string                      // フラグの説明
+	Field func(c *Config) interface{} // 値を設定する Config のフィールドのポインタ
+	// Reloadable が true の項目は SIGHUP または管理者用APIで再起動せずに変更できる
+	Reloadable bool
+}
+
+// configOptions は環境変数とフラグで指定できる設定項目の一覧
+var configOptions = []configOption{
+	{"port", "GUILTY_PORT", "待ち受けるポート番号", func(c *Config) interface{} { return &c.Port }, false},
+	{"unixSocket", "GUILTY_UNIX_SOCKET", "port の代わりに待ち受ける unix ドメインソケットのパス", func(c *Config) interface{} { return &c.UnixSocket }, false},
+	{"unixSocketMode", "GUILTY_UNIX_SOCKET_MODE", "unix ドメインソケットのアクセス権（8進数）", func(c *Config) interface{} { return &c.UnixSocketMode }, false},
+	{"basePath", "GUILTY_BASE_PATH", "guiltyを公開するパスの接頭辞（/git など）", func(c *Config) interface{} { return &c.BasePath }, false},
+	{"repositoryHome", "GUILTY_REPOSITORY_HOME", "Gitリポジトリのホームディレクトリ", func(c *Config) interface{} { return &c.RepositoryHome }, false},
+	{"hostName", "GUILTY_HOST_NAME", "クローンURLのホスト名（空の場合はアクセスされたホスト名）", func(c *Config) interface{} { return &c.HostName }, true},
+	{"cloneUrlTemplate", "GUILTY_CLONE_URL_TEMPLATE", "クローンURLのテンプレート（%s にホスト名、グループ名、リポジトリ名）", func(c *Config) interface{} { return &c.CloneURLTemplate }, true},
+	{"httpsCloneUrlTemplate", "GUILTY_HTTPS_CLONE_URL_TEMPLATE", "https（スマートHTTP）のクローンURLのテンプレート（空の場合は返さない）", func(c *Config) interface{} { return &c.HTTPSCloneURLTemplate }, true},
+	{"gitCloneUrlTemplate", "GUILTY_GIT_CLONE_URL_TEMPLATE", "git:// のクローンURLのテンプレート（空の場合は返さない）", func(c *Config) interface{} { return &c.GitDaemonCloneURLTemplate }, true},
+	{"groupNameBlacklist", "GUILTY_GROUP_NAME_BLACKLIST", "除外するグループ名の正規表現（カンマ区切り）", func(c *Config) interface{} { return &c.GroupNameBlacklist }, true},
+	{"metadataStore", "GUILTY_METADATA_STORE", "メタデータストアのファイル", func(c *Config) interface{} { return &c.MetadataStore }, false},
+	{"backupDirectory", "GUILTY_BACKUP_DIRECTORY", "バックアップを作成するディレクトリ", func(c *Config) interface{} { return &c.BackupDirectory }, false},
+	{"lfsStore", "GUILTY_LFS_STORE", "Git LFS のオブジェクトを置くディレクトリ", func(c *Config) interface{} { return &c.LFSStore }, false},
+	{"assetDirectory", "GUILTY_ASSET_DIRECTORY", "テンプレートと静的ファイルを上書きするディレクトリ（templates/、static/ と同じ構成）", func(c *Config) interface{} { return &c.AssetDirectory }, false},
+	{"tlsCertFile", "GUILTY_TLS_CERT_FILE", "HTTPS の証明書ファイル（PEM）", func(c *Config) interface{} { return &c.TLSCertFile }, false},
+	{"tlsKeyFile", "GUILTY_TLS_KEY_FILE", "HTTPS の秘密鍵ファイル（PEM）", func(c *Config) interface{} { return &c.TLSKeyFile }, false},
+	{"autocertDomains", "GUILTY_AUTOCERT_DOMAINS", "ACME（Let's Encrypt）で証明書を自動で取得するドメイン（カンマ区切り）", func(c *Config) interface{} { return &c.AutocertDomains }, false},
+	{"autocertCacheDir", "GUILTY_AUTOCERT_CACHE_DIR", "ACME で取得した証明書を保存するディレクトリ", func(c *Config) interface{} { return &c.AutocertCacheDir }, false},
+	{"autocertEmail", "GUILTY_AUTOCERT_EMAIL", "ACME のアカウントの連絡先メールアドレス", func(c *Config) interface{} { return &c.AutocertEmail }, false},
+	{"httpRedirectPort", "GUILTY_HTTP_REDIRECT_PORT", "HTTPS にリダイレクトする HTTP のポート（0 は待ち受けない）", func(c *Config) interface{} { return &c.HTTPRedirectPort }, false},
+	{"smtpHost", "GUILTY_SMTP_HOST", "通知メールを送信する SMTP サーバー（空の場合は送信しない）", func(c *Config) interface{} { return &c.SMTPHost }, true},
+	{"smtpPort", "GUILTY_SMTP_PORT", "SMTP サーバーのポート番号", func(c *Config) interface{} { return &c.SMTPPort }, true},
+	{"notificationFrom", "GUILTY_NOTIFICATION_FROM", "通知メールの送信元アドレス", func(c *Config) interface{} { return &c.NotificationFrom }, true},
+	{"avatarUrlTemplate", "GUILTY_AVATAR_URL_TEMPLATE", "アバター画像のURLのテンプレート（%s にハッシュ、%d に大きさ）", func(c *Config) interface{} { return &c.AvatarURLTemplate }, false},
+	{"avatarProxy", "GUILTY_AVATAR_PROXY", "アバター画像をguilty経由で取得する", func(c *Config) interface{} { return &c.AvatarProxy }, false},
+	{"defaultGroupQuota", "GUILTY_DEFAULT_GROUP_QUOTA", "グループのディスク使用量の上限（バイト、0 は無制限）", func(c *Config) interface{} { return &c.DefaultGroupQuota }, true},
+	{"groupQuotas", "GUILTY_GROUP_QUOTAS", "グループごとのディスク使用量の上限（group=バイト のカンマ区切り）", func(c *Config) interface{} { return &c.GroupQuotas }, true},
+	{"enforceQuotaOnPush", "GUILTY_ENFORCE_QUOTA_ON_PUSH", "上限を超えたグループへのプッシュを拒否する", func(c *Config) interface{} { return &c.EnforceQuotaOnPush }, true},
+	{"trashRetention", "GUILTY_TRASH_RETENTION", "削除したリポジトリをゴミ箱に残す期間（0 は自動で削除しない）", func(c *Config) interface{} { return &c.TrashRetention }, false},
+	{"rateLimit", "GUILTY_RATE_LIMIT", "クライアントごとに1分間に受け付けるAPIのリクエスト数（0 は制限しない）", func(c *Config) interface{} { return &c.RateLimit }, true},
+	{"expensiveRateLimit", "GUILTY_EXPENSIVE_RATE_LIMIT", "リポジトリ一覧とエクスポートにクライアントごとに1分間に受け付けるリクエスト数（0 は制限しない）", func(c *Config) interface{} { return &c.ExpensiveRateLimit }, true},
+	{"trustedProxies", "GUILTY_TRUSTED_PROXIES", "X-Forwarded-For を信用するリバースプロキシ（CIDR または IP アドレスのカンマ区切り）", func(c *Config) interface{} { return &c.TrustedProxies }, true},
+	{"logFormat", "GUILTY_LOG_FORMAT", "サーバーのログの形式（text または json）", func(c *Config) interface{} { return &c.LogFormat }, false},
+	{"readTimeout", "GUILTY_READ_TIMEOUT", "リクエスト全体を読む時間の上限（0 は無制限）", func(c *Config) interface{} { return &c.ReadTimeout }, false},
+	{"writeTimeout", "GUILTY_WRITE_TIMEOUT", "レスポンス全体を書く時間の上限（0 は無制限）", func(c *Config) interface{} { return &c.WriteTimeout }, false},
+	{"idleTimeout", "GUILTY_IDLE_TIMEOUT", "キープアライブの接続で次のリクエストを待つ時間の上限", func(c *Config) interface{} { return &c.IdleTimeout }, false},
+	{"shutdownTimeout", "GUILTY_SHUTDOWN_TIMEOUT", "終了時に処理中のリクエストが終わるのを待つ時間の上限", func(c *Config) interface{} { return &c.ShutdownTimeout }, false},
+	{"pprof", "GUILTY_PPROF", "net/http/pprof のプロファイルを pprofAddress で提供する", func(c *Config) interface{} { return &c.Pprof }, false},
+	{"pprofAddress", "GUILTY_PPROF_ADDRESS", "プロファイルを提供する管理者用のアドレス（host:port）", func(c *Config) interface{} { return &c.PprofAddress }, false},
+	{"gitCommandTimeout", "GUILTY_GIT_COMMAND_TIMEOUT", "git のコマンド1回の実行時間の上限（0 は無制限）", func(c *Config) interface{} { return &c.GitCommandTimeout }, true},
+	{"gitMaintenanceTimeout", "GUILTY_GIT_MAINTENANCE_TIMEOUT", "git gc、fsck、バンドルの作成などの実行時間の上限（0 は無制限）", func(c *Config) interface{} { return &c.GitMaintenanceTimeout }, true},
+	{"scanConcurrency", "GUILTY_SCAN_CONCURRENCY", "リポジトリ一覧で最新コミットなどを並列に読むリポジトリ数の上限（1 は順に読む）", func(c *Config) interface{} { return &c.ScanConcurrency }, true},
+	{"repositoryCacheTtl", "GUILTY_REPOSITORY_CACHE_TTL", "リポジトリの最新コミット、ブランチ、タグをメモリにキャッシュする期間（0 はキャッシュしない）", func(c *Config) interface{} { return &c.RepositoryCacheTTL }, true},
+	{"compressResponses", "GUILTY_COMPRESS_RESPONSES", "JSON、HTML などのレスポンスを gzip で圧縮する", func(c *Config) interface{} { return &c.CompressResponses }, true},
+	{"gitBackend", "GUILTY_GIT_BACKEND", "参照、ツリー、blob、コミット履歴の読み方（git はコマンドを実行する、go-git はプロセスを起動せずに読む）", func(c *Config) interface{} { return &c.GitBackend }, true},
+	{"repositoryIndex", "GUILTY_REPOSITORY_INDEX", "リポジトリ一覧の最新コミット、ライセンス、サイズをメタデータストアに保存し、バックグラウンドで更新する", func(c *Config) interface{} { return &c.RepositoryIndex }, false},
+	{"watchRepositories", "GUILTY_WATCH_REPOSITORIES", "リポジトリのディレクトリを監視し、APIを通さないプッシュや新しいリポジトリをすぐにキャッシュと索引に反映する", func(c *Config) interface{} { return &c.WatchRepositories }, false},
+	{"language", "GUILTY_LANGUAGE", "APIのエラーと成功のメッセージの既定の言語（ja または en。リクエストの Accept-Language を優先する）", func(c *Config) interface{} { return &c.Language }, true},
+	{"auditLog", "GUILTY_AUDIT_LOG", "リポジトリの作成、削除、名前の変更、復元、設定の変更などを追記する監査ログのファイル（空の場合は記録しない）", func(c *Config) interface{} { return &c.AuditLog }, false},
+	{"accessTokens", "GUILTY_ACCESS_TOKENS", "APIの利用者を認証するトークン（ユーザー名=トークンの SHA-256 の16進数 のカンマ区切り）", func(c *Config) interface{} { return &c.AccessTokens }, true},
+	{"adminUsers", "GUILTY_ADMIN_USERS", "公開範囲に関係なくすべてのリポジトリにアクセスできるユーザー（カンマ区切り）", func(c *Config) interface{} { return &c.AdminUsers }, true},
+	{"authProxyHeader", "GUILTY_AUTH_PROXY_HEADER", "認証プロキシが認証したユーザー名を渡すヘッダー（X-Forwarded-User など。空の場合はヘッダーで認証しない）", func(c *Config) interface{} { return &c.AuthProxyHeader }, true},
+	{"authProxySources", "GUILTY_AUTH_PROXY_SOURCES", "authProxyHeader を信用する認証プロキシの接続元（CIDR、IP アドレス、unix ドメインソケットを表す unix のカンマ区切り）", func(c *Config) interface{} { return &c.AuthProxySources }, true},
+	{"defaultBranch", "GUILTY_DEFAULT_BRANCH", "新しく作成するリポジトリの HEAD が指すブランチ（ホストの git の init.defaultBranch より優先する）", func(c *Config) interface{} { return &c.DefaultBranch }, true},
+}
+
+// builtinGroupNameBlacklist は設定に関係なく常に除外するグループ名のパターン
+var builtinGroupNameBlacklist = GroupNameBlacklist
+
+// defaultConfig は起動時のパッケージ変数の値（設定を読み込む前の既定値）
+var defaultConfig = configFromVariables()
+
+// configMutex は再起動せずに変更できる設定のパッケージ変数を保護する
+var configMutex sync.RWMutex
+
+// 起動時に指定された設定の読み込み元
+var (
+	configFilePath     = DefaultConfigFilePath
+	configFileExplicit bool              // 設定ファイルが明示的に指定された（存在しない場合はエラーにする）
+	configFlagValues   map[string]string // コマンドラインで指定されたフラグの値
+)
+
+// configFromVariables は現在のパッケージ変数から Config を作る
+func configFromVariables() Config {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	patterns := []string{}
+	for _, re := range GroupNameBlacklist[len(builtinGroupNameBlacklist):] {
+		patterns = append(patterns, re.String())
+	}
+	quotas := make(map[string]int64, len(GroupQuotas))
+	for group, quota := range GroupQuotas {
+		quotas[group] = quota
+	}
+
+	return Config{
+		Port:                      ServerPort,
+		UnixSocket:                UnixSocketPath,
+		UnixSocketMode:            fmt.Sprintf("%04o", UnixSocketMode),
+		BasePath:                  BasePath,
+		RepositoryHome:            GitRepositoryHome,
+		HostName:                  GitHostName,
+		CloneURLTemplate:          GitCloneURLTemplate,
+		HTTPSCloneURLTemplate:     GitHTTPSCloneURLTemplate,
+		GitDaemonCloneURLTemplate: GitDaemonCloneURLTemplate,
+		GroupNameBlacklist:        patterns,
+		MetadataStore:             MetadataStorePath,
+		BackupDirectory:           BackupDirectory,
+		LFSStore:                  LFSStorePath,
+		AssetDirectory:            AssetDirectory,
+		TLSCertFile:               TLSCertFile,
+		TLSKeyFile:                TLSKeyFile,
+		AutocertDomains:           append([]string{}, AutocertDomains...),
+		AutocertCacheDir:          AutocertCacheDir,
+		AutocertEmail:             AutocertEmail,
+		HTTPRedirectPort:          HTTPRedirectPort,
+		SMTPHost:                  SMTPHost,
+		SMTPPort:                  SMTPPort,
+		NotificationFrom:          NotificationFromAddress,
+		AvatarURLTemplate:         AvatarURLTemplate,
+		AvatarProxy:               AvatarProxyEnabled,
+		DefaultGroupQuota:         DefaultGroupQuota,
+		GroupQuotas:               quotas,
+		EnforceQuotaOnPush:        EnforceQuotaOnPush,
+		TrashRetention:            DeletedRepositoryRetention,
+		RateLimit:                 RateLimit,
+		ExpensiveRateLimit:        ExpensiveRateLimit,
+		TrustedProxies:            TrustedProxies,
+		LogFormat:                 LogFormat,
+		ReadTimeout:               ReadTimeout,
+		WriteTimeout:              WriteTimeout,
+		IdleTimeout:               IdleTimeout,
+		ShutdownTimeout:           ShutdownTimeout,
+		Pprof:                     PprofEnabled,
+		PprofAddress:              PprofAddress,
+		GitCommandTimeout:         GitCommandTimeout,
+		GitMaintenanceTimeout:     GitMaintenanceTimeout,
+		ScanConcurrency:           ScanConcurrency,
+		RepositoryCacheTTL:        RepositoryCacheTTL,
+		CompressResponses:         CompressResponses,
+		GitBackend:                GitBackendName,
+		RepositoryIndex:           RepositoryIndexEnabled,
+		WatchRepositories:         WatchRepositories,
+		Language:                  Language,
+		AuditLog:                  AuditLogPath,
+		AccessTokens:              append([]string{}, AccessTokens...),
+		AdminUsers:                append([]string{}, AdminUsers...),
+		AuthProxyHeader:           AuthProxyHeader,
+		AuthProxySources:          append([]string{}, AuthProxySources...),
+		DefaultBranch:             DefaultBranchName,
+	}
+}
+
+// setConfigValue は環境変数やフラグの文字列をフィールドの型に変換して設定する
+func setConfigValue(field interface{}, value string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("数値ではありません: %s", value)
+		}
+		*field = n
+	case *int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("数値ではありません: %s", value)
+		}
+		*field = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("true または false を指定してください: %s", value)
+		}
+		*field = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("期間の形式が不正です（例: 720h）: %s", value)
+		}
+		*field = d
+	case *[]string:
+		*field = []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	case *map[string]int64:
+		*field = map[string]int64{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, n, ok := strings.Cut(item, "=")
+			quota, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+			if !ok || err != nil {
+				return fmt.Errorf("group=バイト の形式で指定してください: %s", item)
+			}
+			(*field)[strings.TrimSpace(key)] = quota
+		}
+	default:
+		return fmt.Errorf("サポートされていない設定の型です: %T", field)
+	}
+	return nil
+}
+
+// parseGlobalFlags はサブコマンドより前のフラグを解析し、残りの引数を返す
+//
+//	guilty [-config guilty.yaml] [-port 1080] ... [コマンド] [引数]
+func parseGlobalFlags(args []string) ([]string, error) {
+	flags := flag.NewFlagSet("guilty", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), cliUsage)
+		fmt.Fprintln(flags.Output(), "\nオプション:")
+		flags.PrintDefaults()
+	}
+
+	configFile := flags.String("config", "", "設定ファイル（YAML）のパス（環境変数 "+ConfigFileEnv+"、既定は "+DefaultConfigFilePath+"）")
+	values := map[string]string{}
+	for _, option := range configOptions {
+		option := option
+		flags.Func(option.Name, option.Usage+"（環境変数 "+option.Env+"）", func(value string) error {
+			var scratch Config
+			if err := setConfigValue(option.Field(&scratch), value); err != nil {
+				return err
+			}
+			values[option.Name] = value
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case *configFile != "":
+		configFilePath, configFileExplicit = *configFile, true
+	case os.Getenv(ConfigFileEnv) != "":
+		configFilePath, configFileExplicit = os.Getenv(ConfigFileEnv), true
+	}
+	configFlagValues = values
+
+	return flags.Args(), nil
+}
+
+// loadConfig は既定値に設定ファイル、環境変数、フラグの順に値を重ねて設定を作る
+func loadConfig() (*Config, error) {
+	config := defaultConfig
+	config.GroupQuotas = make(map[string]int64, len(defaultConfig.GroupQuotas))
+	for group, quota := range defaultConfig.GroupQuotas {
+		config.GroupQuotas[group] = quota
+	}
+
+	file, err := os.Open(configFilePath)
+	switch {
+	case err == nil:
+		defer file.Close()
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("設定ファイル '%s' の読み込みに失敗しました: %w", configFilePath, err)
+		}
+	case os.IsNotExist(err) && !configFileExplicit:
+		// 既定の設定ファイルがない場合は既定値のまま
+	default:
+		return nil, fmt.Errorf("設定ファイル '%s' を開けません: %w", configFilePath, err)
+	}
+
+	for _, option := range configOptions {
+		if value := os.Getenv(option.Env); value != "" {
+			if err := setConfigValue(option.Field(&config), value); err != nil {
+				return nil, fmt.Errorf("環境変数 %s: %w", option.Env, err)
+			}
+		}
+	}
+
+	for _, option := range configOptions {
+		if value, ok := configFlagValues[option.Name]; ok {
+			if err := setConfigValue(option.Field(&config), value); err != nil {
+				return nil, fmt.Errorf("-%s: %w", option.Name, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+// compileGroupNameBlacklist は既定のパターンに設定のパターンを追加したブラックリストを作る
+func compileGroupNameBlacklist(patterns []string) ([]*regexp.Regexp, error) {
+	blacklist := append([]*regexp.Regexp{}, builtinGroupNameBlacklist...)
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("groupNameBlacklist の正規表現 '%s' が不正です: %w", pattern, err)
+		}
+		blacklist = append(blacklist, re)
+	}
+	return blacklist, nil
+}
+
+// validateConfig は設定を検証し、グループ名のブラックリストをコンパイルして返す
+func validateConfig(config *Config) ([]*regexp.Regexp, error) {
+	if config.Port < 1 || config.Port > 65535 {
+		return nil, fmt.Errorf("port は 1 から 65535 の数値で指定してください: %d", config.Port)
+	}
+	if mode, err := strconv.ParseUint(config.UnixSocketMode, 8, 32); err != nil || mode > 0777 {
+		return nil, fmt.Errorf("unixSocketMode は 0660 のような8進数で指定してください: %s", config.UnixSocketMode)
+	}
+	if _, err := normalizeBasePath(config.BasePath); err != nil {
+		return nil, err
+	}
+	if config.HostName != "" && !isValidHostName(config.HostName) {
+		return nil, fmt.Errorf("hostName に使えない文字が含まれています: %s", config.HostName)
+	}
+	if config.RepositoryHome == "" {
+		return nil, fmt.Errorf("repositoryHome を空にすることはできません")
+	}
+	for name, template := range map[string]string{
+		"cloneUrlTemplate":      config.CloneURLTemplate,
+		"httpsCloneUrlTemplate": config.HTTPSCloneURLTemplate,
+		"gitCloneUrlTemplate":   config.GitDaemonCloneURLTemplate,
+	} {
+		if name != "cloneUrlTemplate" && template == "" {
+			continue
+		}
+		if strings.Count(template, "%s") != 3 || strings.Count(template, "%") != 3 {
+			return nil, fmt.Errorf("%s には %%s を3つ（ホスト名、グループ名、リポジトリ名）含めてください: %s", name, template)
+		}
+	}
+	if config.SMTPPort < 1 || config.SMTPPort > 65535 {
+		return nil, fmt.Errorf("smtpPort は 1 から 65535 の数値で指定してください: %d", config.SMTPPort)
+	}
+	if config.DefaultGroupQuota < 0 || config.TrashRetention < 0 {
+		return nil, fmt.Errorf("defaultGroupQuota と trashRetention に負の値は指定できません")
+	}
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, fmt.Errorf("tlsCertFile と tlsKeyFile は両方指定してください")
+	}
+	if config.TLSCertFile != "" && len(config.AutocertDomains) > 0 {
+		return nil, fmt.Errorf("tlsCertFile と autocertDomains は同時に指定できません")
+	}
+	if config.HTTPRedirectPort != 0 {
+		if config.HTTPRedirectPort < 0 || config.HTTPRedirectPort > 65535 || config.HTTPRedirectPort == config.Port {
+			return nil, fmt.Errorf("httpRedirectPort は port 以外の 1 から 65535 の数値で指定してください: %d", config.HTTPRedirectPort)
+		}
+		if config.TLSCertFile == "" && len(config.AutocertDomains) == 0 {
+			return nil, fmt.Errorf("httpRedirectPort は tlsCertFile または autocertDomains と一緒に指定してください")
+		}
+	}
+	if config.AssetDirectory != "" {
+		if info, err := os.Stat(config.AssetDirectory); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("assetDirectory '%s' はディレクトリではありません", config.AssetDirectory)
+		}
+	}
+	for group, quota := range config.GroupQuotas {
+		if quota < 0 {
+			return nil, fmt.Errorf("グループ '%s' のディスク使用量の上限に負の値は指定できません", group)
+		}
+	}
+	if config.RateLimit < 0 || config.ExpensiveRateLimit < 0 {
+		return nil, fmt.Errorf("rateLimit と expensiveRateLimit に負の値は指定できません")
+	}
+	if _, err := parseTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if err := validateLogFormat(config.LogFormat); err != nil {
+		return nil, err
+	}
+	if config.ReadTimeout < 0 || config.WriteTimeout < 0 || config.IdleTimeout < 0 || config.ShutdownTimeout < 0 {
+		return nil, fmt.Errorf("readTimeout、writeTimeout、idleTimeout、shutdownTimeout に負の値は指定できません")
+	}
+	if err := validatePprofAddress(config.PprofAddress); err != nil {
+		return nil, err
+	}
+	if config.GitCommandTimeout < 0 || config.GitMaintenanceTimeout < 0 {
+		return nil, fmt.Errorf("gitCommandTimeout と gitMaintenanceTimeout に負の値は指定できません")
+	}
+	if config.ScanConcurrency < 1 {
+		return nil, fmt.Errorf("scanConcurrency には 1 以上の値を指定してください")
+	}
+	if config.RepositoryCacheTTL < 0 {
+		return nil, fmt.Errorf("repositoryCacheTtl に負の値は指定できません")
+	}
+	if err := validateGitBackendName(config.GitBackend); err != nil {
+		return nil, err
+	}
+	if err := validateLanguage(config.Language); err != nil {
+		return nil, err
+	}
+	if err := validateAccessTokens(config.AccessTokens); err != nil {
+		return nil, err
+	}
+	for _, user := range config.AdminUsers {
+		if !userNamePattern.MatchString(user) {
+			return nil, fmt.Errorf("adminUsers の '%s' はユーザー名として使えません", user)
+		}
+	}
+	if err := validateAuthProxy(config.AuthProxyHeader, config.AuthProxySources); err != nil {
+		return nil, err
+	}
+	if !isValidBranchName(serverContext, config.DefaultBranch) {
+		return nil, fmt.Errorf("defaultBranch はブランチ名として使えません: %s", config.DefaultBranch)
+	}
+	return compileGroupNameBlacklist(config.GroupNameBlacklist)
+}
+
+// applyConfig は起動時に設定を検証し、パッケージ変数に反映する
+// 検証に失敗した場合は何も変更しない
+func applyConfig(config *Config) error {
+	blacklist, err := validateConfig(config)
+	if err != nil {
+		return err
+	}
+
+	ServerPort = config.Port
+	UnixSocketPath = ""
+	if config.UnixSocket != "" {
+		// post-receive フックはリポジトリのディレクトリで実行されるため、絶対パスにする
+		if UnixSocketPath, err = filepath.Abs(config.UnixSocket); err != nil {
+			return err
+		}
+	}
+	mode, _ := strconv.ParseUint(config.UnixSocketMode, 8, 32)
+	UnixSocketMode = os.FileMode(mode)
+	BasePath, _ = normalizeBasePath(config.BasePath)
+	GitRepositoryHome = config.RepositoryHome
+	MetadataStorePath = config.MetadataStore
+	BackupDirectory = config.BackupDirectory
+	LFSStorePath = config.LFSStore
+	AssetDirectory = config.AssetDirectory
+	TLSCertFile = config.TLSCertFile
+	TLSKeyFile = config.TLSKeyFile
+	AutocertDomains = config.AutocertDomains
+	AutocertCacheDir = config.AutocertCacheDir
+	AutocertEmail = config.AutocertEmail
+	HTTPRedirectPort = config.HTTPRedirectPort
+	AvatarURLTemplate = config.AvatarURLTemplate
+	AvatarProxyEnabled = config.AvatarProxy
+	DeletedRepositoryRetention = config.TrashRetention
+	LogFormat = config.LogFormat
+	ReadTimeout = config.ReadTimeout
+	WriteTimeout = config.WriteTimeout
+	IdleTimeout = config.IdleTimeout
+	ShutdownTimeout = config.ShutdownTimeout
+	PprofEnabled = config.Pprof
+	PprofAddress = config.PprofAddress
+	RepositoryIndexEnabled = config.RepositoryIndex
+	WatchRepositories = config.WatchRepositories
+	AuditLogPath = config.AuditLog
+	applyReloadableConfig(config, blacklist)
+
+	return nil
+}
+
+// applyReloadableConfig は再起動せずに変更できる設定（configOption の Reloadable）をパッケージ変数に反映する
+// 処理中のリクエストと競合しないよう、これらの変数は configMutex で保護し、読み取りには get〜 の関数を使う
+func applyReloadableConfig(config *Config, blacklist []*regexp.Regexp) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	GitHostName = config.HostName
+	GitCloneURLTemplate = config.CloneURLTemplate
+	GitHTTPSCloneURLTemplate = config.HTTPSCloneURLTemplate
+	GitDaemonCloneURLTemplate = config.GitDaemonCloneURLTemplate
+	GroupNameBlacklist = blacklist
+	SMTPHost = config.SMTPHost
+	SMTPPort = config.SMTPPort
+	NotificationFromAddress = config.NotificationFrom
+	DefaultGroupQuota = config.DefaultGroupQuota
+	GroupQuotas = config.GroupQuotas
+	EnforceQuotaOnPush = config.EnforceQuotaOnPush
+	RateLimit = config.RateLimit
+	ExpensiveRateLimit = config.ExpensiveRateLimit
+	TrustedProxies = config.TrustedProxies
+	trustedProxyNetworks = mustParseTrustedProxies(config.TrustedProxies)
+	GitCommandTimeout = config.GitCommandTimeout
+	GitMaintenanceTimeout = config.GitMaintenanceTimeout
+	ScanConcurrency = config.ScanConcurrency
+	RepositoryCacheTTL = config.RepositoryCacheTTL
+	CompressResponses = config.CompressResponses
+	GitBackendName = config.GitBackend
+	Language = config.Language
+	AccessTokens = config.AccessTokens
+	AdminUsers = config.AdminUsers
+	AuthProxyHeader = config.AuthProxyHeader
+	AuthProxySources = config.AuthProxySources
+	authProxyNetworks, authProxyUnix, _ = parseAuthProxySources(config.AuthProxySources)
+	DefaultBranchName = config.DefaultBranch
+}
+
+// getGitHostName はクローンURLのホスト名の設定を返す（空の場合はリクエストから決める）
+func getGitHostName() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return GitHostName
+}
+
+// repositoryCloneURL は GitCloneURLTemplate からリポジトリのクローンURL（SSH）を作る
+// hostName は cloneHostName で決めたホスト名
+func repositoryCloneURL(hostName, groupName, repoName string) string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return fmt.Sprintf(GitCloneURLTemplate, hostName, groupName, repoName)
+}
+
+// repositoryCloneURLs はテンプレートが設定されているプロトコル（ssh、https、git の順）のクローンURLを作る
+func repositoryCloneURLs(hostName, groupName, repoName string) []CloneURL {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	urls := []CloneURL{}
+	for _, clone := range []struct{ protocol, template string }{
+		{"ssh", GitCloneURLTemplate},
+		{"https", GitHTTPSCloneURLTemplate},
+		{"git", GitDaemonCloneURLTemplate},
+	} {
+		if clone.template == "" {
+			continue
+		}
+		urls = append(urls, CloneURL{Protocol: clone.protocol, URL: fmt.Sprintf(clone.template, hostName, groupName, repoName)})
+	}
+	return urls
+}
+
+// getGroupNameBlacklist は除外するグループ名のパターンを返す
+func getGroupNameBlacklist() []*regexp.Regexp {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return GroupNameBlacklist
+}
+
+// initConfig はコマンドラインの引数から設定を読み込んで反映し、サブコマンドの引数を返す
+func initConfig(args []string) ([]string, error) {
+	rest, err := parseGlobalFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := applyConfig(config); err != nil {
+		return nil, err
+	}
+
+	return rest, nil
+}
+
+// runConfigCommand は `guilty config` として現在の設定を設定ファイルと同じ形式で表示する
+func runConfigCommand(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "使い方: guilty config")
+		return 2
+	}
+
+	config := configFromVariables()
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		return 1
+	}
+	encoder.Close()
+	return 0
+}
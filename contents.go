@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// MaxContentsRequestSize はファイル作成・更新APIで受け付けるリクエストボディの最大サイズ（バイト単位）
+var MaxContentsRequestSize int64 = 10 * 1024 * 1024
+
+// ファイルの変更の種類
+const (
+	FileChangeCreate = "create" // 新しいファイルを作成する（既にある場合はエラー）
+	FileChangeUpdate = "update" // 既存のファイルを更新する（ない場合はエラー）
+	FileChangeDelete = "delete" // ファイルを削除する
+)
+
+// errStaleContents はファイルやブランチが指定された状態から変わっていることを表す
+var errStaleContents = errors.New("ファイルまたはブランチが更新されています")
+
+// CommitAuthor はコミットの作成者を表す
+type CommitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// FileChange はコミットに含めるファイルの変更1件を表す
+type FileChange struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`   // "create"、"update"、"delete"
+	Content  string `json:"content"`  // ファイルの内容（削除の場合は不要）
+	Encoding string `json:"encoding"` // "text"（デフォルト）または "base64"
+	SHA      string `json:"sha"`      // 更新・削除する場合の現在の blob の SHA（変更の競合を防ぐ）
+}
+
+// PutContentsRequest はファイル作成・更新リクエスト用の構造体
+type PutContentsRequest struct {
+	Content  string        `json:"content"`
+	Encoding string        `json:"encoding"` // "text"（デフォルト）または "base64"
+	Message  string        `json:"message"`
+	Author   *CommitAuthor `json:"author"` // 省略時はサーバーのコミッター
+	Branch   string        `json:"branch"` // 省略時はデフォルトブランチ
+	SHA      string        `json:"sha"`    // 更新する場合の現在の blob の SHA（作成する場合は省略）
+}
+
+// CommitContentsRequest は複数のファイルの変更を1つのコミットにするリクエスト用の構造体
+type CommitContentsRequest struct {
+	Files   []FileChange  `json:"files"`
+	Message string        `json:"message"`
+	Author  *CommitAuthor `json:"author"`
+	Branch  string        `json:"branch"`
+	Parent  string        `json:"parent"` // 変更の前提とするブランチのコミット（指定した場合は各ファイルの sha を省略できる）
+}
+
+// DeleteContentsRequest はファイル削除リクエスト用の構造体
+type DeleteContentsRequest struct {
+	Message string        `json:"message"`
+	Author  *CommitAuthor `json:"author"`
+	Branch  string        `json:"branch"`
+	SHA     string        `json:"sha"` // 削除するファイルの現在の blob の SHA
+}
+
+// ContentsCommitResult はファイルの変更を含むコミットの作成結果を表す
+type ContentsCommitResult struct {
+	Branch string            `json:"branch"`
+	Commit string            `json:"commit"`           // 作成したコミット
+	Parent string            `json:"parent,omitempty"` // 変更前のブランチのコミット（最初のコミットの場合は空）
+	Files  map[string]string `json:"files"`            // 変更したファイルのパスと新しい blob の SHA（削除した場合は空文字）
+}
+
+// treeChange はツリーを書き換えるときの1エントリの変更（blob が空の場合は削除）
+type treeChange struct {
+	mode string
+	blob string
+}
+
+// validateContentsPath はコミットに含めるファイルのパスとして有効か確認する
+func validateContentsPath(filePath string) error {
+	if filePath == "" || strings.HasPrefix(filePath, "/") || strings.HasSuffix(filePath, "/") {
+		return fmt.Errorf("ファイルパス '%s' は不正です", filePath)
+	}
+
+	for _, part := range strings.Split(filePath, "/") {
+		if part == "" || part == "." || part == ".." || isDotGitComponent(part) || strings.ContainsRune(part, 0) {
+			return fmt.Errorf("ファイルパス '%s' は不正です", filePath)
+		}
+	}
+
+	return nil
+}
+
+// isDotGitComponent はパスの要素がチェックアウトしたときに .git ディレクトリを指すかどうかを返す
+// git の verify_path と同じく、Windows のパスの区切り（\）、同じ名前になる末尾の . と空白、
+// 代替データストリーム（.git::$INDEX_ALLOCATION）、短い名前の git~1、macOS で無視される幅のない文字も考慮する
+func isDotGitComponent(part string) bool {
+	part = strings.Map(func(r rune) rune {
+		if isHFSIgnorableRune(r) {
+			return -1
+		}
+		return r
+	}, part)
+	for _, name := range strings.Split(part, "\\") {
+		name, _, _ = strings.Cut(name, ":")
+		if name = strings.TrimRight(name, ". "); strings.EqualFold(name, ".git") || strings.EqualFold(name, "git~1") {
+			return true
+		}
+	}
+	return false
+}
+
+// isHFSIgnorableRune は macOS のファイルシステム（HFS+）がファイル名の比較で無視する文字かどうかを返す
+func isHFSIgnorableRune(r rune) bool {
+	return r >= 0x200c && r <= 0x200f || r >= 0x202a && r <= 0x202e || r >= 0x206a && r <= 0x206f || r == 0xfeff
+}
+
+// decodeContent は encoding に従ってファイルの内容をデコードする
+func decodeContent(content, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "text":
+		return []byte(content), nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("base64 のデコードに失敗しました: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("encoding には text または base64 を指定してください")
+	}
+}
+
+// hashObject は git hash-object -w で内容を blob としてリポジトリに書き込む
+func hashObject(ctx context.Context, repoPath string, data []byte) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "hash-object", "-w", "--stdin")
+	defer cancel()
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ファイルの書き込みに失敗しました: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// writeTreeWithChanges は baseTree に変更を適用したツリーを git mktree で作成する
+// 変更のパスは baseTree からの相対パス。サブディレクトリは再帰的に作り直し、空になったディレクトリは削除する
+// ツリーが空になった場合は空文字を返す
+func writeTreeWithChanges(ctx context.Context, repoPath, baseTree string, changes map[string]treeChange) (string, error) {
+	entries := map[string]TreeEntry{}
+	if baseTree != "" {
+		list, err := listTree(ctx, repoPath, baseTree)
+		if err != nil {
+			return "", fmt.Errorf("ツリーの読み込みに失敗しました: %w", err)
+		}
+		for _, entry := range list {
+			entries[entry.Path] = entry
+		}
+	}
+
+	// 直下のエントリの変更と、サブディレクトリごとの変更に分ける
+	subChanges := map[string]map[string]treeChange{}
+	for changePath, change := range changes {
+		dir, rest, nested := strings.Cut(changePath, "/")
+		if nested {
+			if subChanges[dir] == nil {
+				subChanges[dir] = map[string]treeChange{}
+			}
+			subChanges[dir][rest] = change
+			continue
+		}
+
+		if entry, ok := entries[changePath]; ok && entry.Type == "tree" {
+			return "", fmt.Errorf("'%s' はディレクトリです", changePath)
+		}
+		if change.blob == "" {
+			delete(entries, changePath)
+		} else {
+			entries[changePath] = TreeEntry{Mode: change.mode, Type: "blob", SHA: change.blob, Path: changePath}
+		}
+	}
+
+	for dir, changes := range subChanges {
+		subTree := ""
+		if entry, ok := entries[dir]; ok {
+			if entry.Type != "tree" {
+				return "", fmt.Errorf("'%s' はディレクトリではありません", dir)
+			}
+			subTree = entry.SHA
+		}
+
+		tree, err := writeTreeWithChanges(ctx, repoPath, subTree, changes)
+		if err != nil {
+			return "", err
+		}
+		if tree == "" {
+			delete(entries, dir)
+		} else {
+			entries[dir] = TreeEntry{Mode: "040000", Type: "tree", SHA: tree, Path: dir}
+		}
+	}
+
+	if len(entries) == 0 {
+		return "", nil
+	}
+
+	// mktree -z の入力は ls-tree -z と同じ形式（順序は mktree が並べ替える）
+	var input bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&input, "%s %s %s\t%s\x00", entry.Mode, entry.Type, entry.SHA, entry.Path)
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "mktree", "-z")
+	defer cancel()
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ツリーの作成に失敗しました: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// commitFileChanges はブランチにファイルの変更を1つのコミットとして追加する
+// 作業ツリーを使わず hash-object、mktree、commit-tree、update-ref で行うため、ベアリポジトリのまま実行できる
+// ブランチがない場合は、コミットのないリポジトリに限り最初のコミットとして作成する
+// expectedParent を指定した場合は、ブランチがそのコミットのときだけ変更する（各ファイルの sha は省略できる）
+func commitFileChanges(ctx context.Context, repoPath, branch string, files []FileChange, message string, author *CommitAuthor, expectedParent string) (*ContentsCommitResult, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("コミットメッセージを入力してください")
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("変更するファイルを指定してください")
+	}
+	if author != nil && (strings.TrimSpace(author.Name) == "" || strings.TrimSpace(author.Email) == "") {
+		return nil, fmt.Errorf("作成者の名前とメールアドレスを指定してください")
+	}
+
+	if branch == "" {
+		defaultBranch, err := getDefaultBranch(ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		branch = defaultBranch
+	}
+	if !isValidBranchName(ctx, branch) {
+		return nil, fmt.Errorf("ブランチ名 '%s' は不正です", branch)
+	}
+
+	parent := ""
+	if branchExists(ctx, repoPath, branch) {
+		commit, err := resolveCommit(ctx, repoPath, "refs/heads/"+branch)
+		if err != nil {
+			return nil, err
+		}
+		parent = commit
+	} else if hasCommits(ctx, repoPath) {
+		return nil, fmt.Errorf("ブランチ '%s' が見つかりません", branch)
+	}
+	if expectedParent != "" {
+		if commit, err := resolveCommit(ctx, repoPath, expectedParent); err != nil || commit != parent {
+			return nil, fmt.Errorf("ブランチ '%s' は parent のコミットから更新されています: %w", branch, errStaleContents)
+		}
+	}
+
+	result := &ContentsCommitResult{Branch: branch, Parent: parent, Files: map[string]string{}}
+	changes := map[string]treeChange{}
+
+	for _, file := range files {
+		if err := validateContentsPath(file.Path); err != nil {
+			return nil, err
+		}
+		if _, ok := changes[file.Path]; ok {
+			return nil, fmt.Errorf("'%s' が複数回指定されています", file.Path)
+		}
+
+		// 現在のファイルを確認する（作成・更新・削除の前提条件）
+		var current *TreeEntry
+		if parent != "" {
+			if entry, err := getTreeEntry(ctx, repoPath, parent, file.Path); err == nil {
+				current = entry
+			}
+		}
+		if current != nil && current.Type != "blob" {
+			return nil, fmt.Errorf("'%s' はファイルではありません", file.Path)
+		}
+
+		switch file.Action {
+		case FileChangeCreate:
+			if current != nil {
+				return nil, fmt.Errorf("'%s' は既に存在します: %w", file.Path, errStaleContents)
+			}
+		case FileChangeUpdate, FileChangeDelete:
+			if current == nil {
+				return nil, fmt.Errorf("'%s' が見つかりません", file.Path)
+			}
+			if file.SHA == "" && expectedParent == "" {
+				return nil, fmt.Errorf("'%s' を変更するには現在の sha を指定してください", file.Path)
+			}
+			if file.SHA != "" && file.SHA != current.SHA {
+				return nil, fmt.Errorf("'%s' の sha が一致しません: %w", file.Path, errStaleContents)
+			}
+		default:
+			return nil, fmt.Errorf("action には create、update、delete のいずれかを指定してください")
+		}
+
+		if file.Action == FileChangeDelete {
+			changes[file.Path] = treeChange{}
+			result.Files[file.Path] = ""
+			continue
+		}
+
+		data, err := decodeContent(file.Content, file.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		blob, err := hashObject(ctx, repoPath, data)
+		if err != nil {
+			return nil, err
+		}
+
+		// 更新の場合は実行権限などのモードを引き継ぐ
+		mode := "100644"
+		if current != nil {
+			mode = current.Mode
+		}
+		changes[file.Path] = treeChange{mode: mode, blob: blob}
+		result.Files[file.Path] = blob
+	}
+
+	baseTree := ""
+	if parent != "" {
+		baseTree = parent + "^{tree}"
+	}
+	tree, err := writeTreeWithChanges(ctx, repoPath, baseTree, changes)
+	if err != nil {
+		return nil, err
+	}
+	if tree == "" {
+		// すべてのファイルを削除した場合は空のツリーのコミットにする
+		if tree, err = hashEmptyTree(ctx, repoPath); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []string{"--git-dir=" + repoPath, "commit-tree", tree, "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+	cmd.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME="+ServerCommitterName,
+		"GIT_COMMITTER_EMAIL="+ServerCommitterEmail,
+	)
+	if author != nil {
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_NAME="+author.Name, "GIT_AUTHOR_EMAIL="+author.Email)
+	} else {
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_NAME="+ServerCommitterName, "GIT_AUTHOR_EMAIL="+ServerCommitterEmail)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("コミットの作成に失敗しました: %w", err)
+	}
+	result.Commit = strings.TrimSpace(string(output))
+
+	// 確認してから更新するまでの間にプッシュされた場合は失敗させる（parent が空の場合はブランチがないことを確認する）
+	if err := updateBranch(ctx, repoPath, branch, result.Commit, parent, "contents: "+firstLine(message)); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, errStaleContents)
+	}
+
+	return result, nil
+}
+
+// hashEmptyTree は空のツリーをリポジトリに書き込む
+func hashEmptyTree(ctx context.Context, repoPath string) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "mktree")
+	defer cancel()
+	cmd.Stdin = strings.NewReader("")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ツリーの作成に失敗しました: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// firstLine は文字列の1行目を返す
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// writeContentsError はファイルの変更のエラーを状態に応じたステータスコードで書き込む
+func writeContentsError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errStaleContents) {
+		w.WriteHeader(http.StatusConflict)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// contentsHandler はWebからファイルを作成・更新・削除し、コミットを作成する
+//
+//	POST   /api/repository/{group}/{repo}/contents         {"files": [{"path": "...", "action": "create", "content": "..."}], "message": "..."}
+//	PUT    /api/repository/{group}/{repo}/contents/{path}  {"content": "...", "message": "...", "branch": "main", "sha": "..."}
+//	DELETE /api/repository/{group}/{repo}/contents/{path}  {"message": "...", "branch": "main", "sha": "..."}
+func contentsHandler(w http.ResponseWriter, r *http.Request, groupName, repoPath, filePath string) {
+	// パスを指定した場合は1つのファイル、指定しない場合は複数のファイルの変更
+	if (filePath == "" && r.Method != http.MethodPost) ||
+		(filePath != "" && r.Method != http.MethodPut && r.Method != http.MethodDelete) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	// プッシュと同様に、容量制限を超えたグループでは新しいコミットを作成しない
+	if isQuotaEnforcedOnPush(groupName) {
+		if err := checkGroupQuota(groupName); err != nil {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	body := io.LimitReader(r.Body, MaxContentsRequestSize)
+
+	var (
+		result *ContentsCommitResult
+		err    error
+		status = http.StatusOK
+	)
+	switch r.Method {
+	case http.MethodPost:
+		var req CommitContentsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		result, err = commitFileChanges(r.Context(), repoPath, req.Branch, req.Files, req.Message, req.Author, req.Parent)
+		status = http.StatusCreated
+
+	case http.MethodPut:
+		var req PutContentsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		// sha がない場合は新しいファイルの作成とする
+		change := FileChange{Path: filePath, Action: FileChangeUpdate, Content: req.Content, Encoding: req.Encoding, SHA: req.SHA}
+		if req.SHA == "" {
+			change.Action = FileChangeCreate
+			status = http.StatusCreated
+		}
+		result, err = commitFileChanges(r.Context(), repoPath, req.Branch, []FileChange{change}, req.Message, req.Author, "")
+
+	default:
+		var req DeleteContentsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		change := FileChange{Path: filePath, Action: FileChangeDelete, SHA: req.SHA}
+		result, err = commitFileChanges(r.Context(), repoPath, req.Branch, []FileChange{change}, req.Message, req.Author, "")
+	}
+	if err != nil {
+		writeContentsError(w, err)
+		return
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
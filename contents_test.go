@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestValidateContentsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"README.md", true},
+		{"docs/guide/intro.md", true},
+		{"my file.txt", true},
+		{"日本語/ファイル.md", true},
+		{".github/workflows/ci.yml", true},
+		{".gitignore", true},
+		{".gitmodules", true},
+		{"a/.git-keep", true},
+		{"git", true},
+		{"a..b", true},
+		{"...", true},
+
+		{"", false},
+		{"/etc/passwd", false},
+		{"docs/", false},
+		{"a//b", false},
+		{"./a", false},
+		{"a/./b", false},
+		{"..", false},
+		{"../a", false},
+		{"a/../../b", false},
+		{"a\x00b", false},
+
+		{".git", false},
+		{".git/config", false},
+		{"a/.git/hooks/pre-receive", false},
+		{".GIT/config", false},
+		{".Git", false},
+		{".git./config", false},
+		{".git /config", false},
+		{".git. . /config", false},
+		{"git~1/config", false},
+		{"GIT~1", false},
+		{".git::$INDEX_ALLOCATION/config", false},
+		{"a\\.git\\config", false},
+		{".g\u200cit/config", false},
+		{"\ufeff.git/config", false},
+	}
+
+	for _, tt := range tests {
+		err := validateContentsPath(tt.path)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateContentsPath(%q) error = %v, want ok = %v", tt.path, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Contributor はコミット作者ごとのコミット数を表す
+type Contributor struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Commits      int    `json:"commits"`
+	AuthorAvatar        // メールアドレスから求めたアバター
+}
+
+// getContributors は git shortlog -sne で作者ごとのコミット数を取得する（コミット数の多い順）
+// since / until は git log の --since / --until と同じ形式（"2024-01-01"、"3 months ago" など）
+func getContributors(ctx context.Context, repoPath, ref, since, until string) ([]Contributor, error) {
+	contributors := []Contributor{}
+	if !hasCommits(ctx, repoPath) {
+		return contributors, nil
+	}
+
+	commit, err := resolveCommit(ctx, repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--git-dir=" + repoPath, "shortlog", "-sne"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if until != "" {
+		args = append(args, "--until="+until)
+	}
+	// ベアリポジトリでは rev を省略すると標準入力を読むため、必ず指定する
+	args = append(args, commit)
+
+	output, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// 各行の形式: <コミット数>\t<名前> <<メールアドレス>>
+	for _, line := range strings.Split(string(output), "\n") {
+		count, author, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+
+		commits, err := strconv.Atoi(count)
+		if err != nil {
+			continue
+		}
+
+		contributor := Contributor{Name: author, Commits: commits}
+		if start := strings.LastIndex(author, " <"); start >= 0 && strings.HasSuffix(author, ">") {
+			contributor.Name = author[:start]
+			contributor.Email = author[start+2 : len(author)-1]
+		}
+		contributor.AuthorAvatar = newAuthorAvatar(contributor.Email)
+
+		contributors = append(contributors, contributor)
+	}
+
+	return contributors, nil
+}
+
+// contributorsHandler はリポジトリのコントリビューター一覧を返すハンドラー
+//
+//	GET /api/contributors/{group}/{repo}?ref=...&since=...&until=...
+func contributorsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, r.PathValue("groupName"), r.PathValue("repoName"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	// ref を省略した場合は HEAD
+	query := r.URL.Query()
+	ref := query.Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	contributors, err := getContributors(r.Context(), repoPath, ref, query.Get("since"), query.Get("until"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "コントリビューターの取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(contributors)
+}
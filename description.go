@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultGitDescription は git init が作成する description ファイルの初期内容
+const defaultGitDescription = "Unnamed repository; edit this file 'description' to name the repository."
+
+// getRepositoryDescription はベアリポジトリの description ファイルを読み込む
+// ファイルが存在しない場合や git の初期値のままの場合は空文字列を返す
+func getRepositoryDescription(repoPath string) string {
+	content, err := os.ReadFile(filepath.Join(repoPath, "description"))
+	if err != nil {
+		return ""
+	}
+
+	description := strings.TrimSpace(string(content))
+	if description == defaultGitDescription {
+		return ""
+	}
+
+	return description
+}
+
+// setRepositoryDescription はベアリポジトリの description ファイルを更新する
+func setRepositoryDescription(repoPath string, description string) error {
+	// 改行はgitwebなどのツールが1行目しか使わないため、1行にまとめる
+	description = strings.Join(strings.Fields(description), " ")
+
+	err := os.WriteFile(filepath.Join(repoPath, "description"), []byte(description+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("descriptionファイルの更新に失敗しました: %w", err)
+	}
+
+	return nil
+}
+
+// updateRepositoryDescription はリクエストボディの description でリポジトリの説明を更新し、結果を書き込む
+func updateRepositoryDescription(w http.ResponseWriter, r *http.Request, groupName, repoName string, requestBody map[string]string) {
+	description, ok := requestBody["description"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "descriptionが指定されていません"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if err := setRepositoryDescription(repoPath, description); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	recordAudit(r, "repository.update", groupName, repoName, map[string]string{"fields": "description"})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリの説明が更新されました"})
+}
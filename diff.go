@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FileDiff は2つのコミットの間での1つのファイルの差分を表す
+type FileDiff struct {
+	From      string     `json:"from"`              // 比較元のコミット
+	To        string     `json:"to"`                // 比較先のコミット
+	Path      string     `json:"path"`              // 比較先でのファイルのパス
+	OldPath   string     `json:"oldPath,omitempty"` // 名前変更・コピー元のパス
+	Status    string     `json:"status"`            // "added"、"modified"、"deleted"、"renamed"、"copied"、"typechange"、"unchanged"
+	Binary    bool       `json:"binary"`            // バイナリファイルの場合は patch と hunks が空
+	Patch     string     `json:"patch"`             // unified diff 形式の差分
+	Hunks     []DiffHunk `json:"hunks"`
+	Truncated bool       `json:"truncated"` // 差分が MaxFileContentSize を超えたため切り詰めたかどうか
+}
+
+// DiffHunk は差分の1つのまとまり（@@ -oldStart,oldLines +newStart,newLines @@ header）
+type DiffHunk struct {
+	OldStart int        `json:"oldStart"`
+	OldLines int        `json:"oldLines"`
+	NewStart int        `json:"newStart"`
+	NewLines int        `json:"newLines"`
+	Header   string     `json:"header"` // @@ の後の関数名など（ない場合は空文字）
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine は差分の1行
+type DiffLine struct {
+	Type      string `json:"type"`                // "context"、"add"、"delete"
+	Content   string `json:"content"`             // 先頭の記号を除いた行の内容
+	OldLine   int    `json:"oldLine,omitempty"`   // 比較元での行番号（追加した行は省略）
+	NewLine   int    `json:"newLine,omitempty"`   // 比較先での行番号（削除した行は省略）
+	NoNewline bool   `json:"noNewline,omitempty"` // ファイルの最後の行で、末尾に改行がない場合は true
+}
+
+// errDiffFileNotFound は比較元でも比較先でもファイルがないことを表す
+var errDiffFileNotFound = errors.New("ファイルが見つかりません")
+
+// diffLineTypes は unified diff の行の先頭の記号と DiffLine の種類の対応
+var diffLineTypes = map[byte]string{' ': "context", '+': "add", '-': "delete"}
+
+// getFileDiff は from から to までの filePath の差分を取得する
+// 名前が変更されたファイルは、変更前のパス（from 側の名前を指定した場合は変更後のパス）との差分になる
+func getFileDiff(ctx context.Context, repoPath, from, to, filePath string) (*FileDiff, error) {
+	diff := &FileDiff{From: from, To: to, Path: filePath, Status: "unchanged", Hunks: []DiffHunk{}}
+
+	// パスを指定して git diff を実行すると名前の変更を検出できないため、先に変更されたファイルの一覧から探す
+	files, err := getChangedFiles(ctx, repoPath, from, to)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, file := range files {
+		if file.Path == filePath || (file.OldPath == filePath && file.Status == "renamed") {
+			diff.Path, diff.OldPath, diff.Status = file.Path, file.OldPath, file.Status
+			paths = append(paths, file.Path)
+			if file.OldPath != "" {
+				paths = append(paths, file.OldPath)
+			}
+			break
+		}
+	}
+
+	if len(paths) == 0 {
+		// 変更されていない場合は、比較先にファイルがあることを確かめる
+		if _, err := gitOutput(ctx, "--git-dir="+repoPath, "cat-file", "-e", to+":"+filePath); err != nil {
+			return nil, errDiffFileNotFound
+		}
+		return diff, nil
+	}
+
+	args := append([]string{"--git-dir=" + repoPath, "diff", "--no-color", "--no-ext-diff", "-M", from, to, "--"}, paths...)
+	patch, truncated, err := gitLimitedOutput(ctx, MaxFileContentSize, args...)
+	if err != nil {
+		return nil, fmt.Errorf("差分の取得に失敗しました: %w", err)
+	}
+	diff.Patch, diff.Truncated = string(patch), truncated
+	diff.Hunks, diff.Binary = parseDiffHunks(diff.Patch)
+	if diff.Binary {
+		diff.Patch = ""
+	}
+
+	return diff, nil
+}
+
+// parseDiffHunks は1つのファイルの unified diff をまとまりごとに解析する
+// 「Binary files ... differ」の場合は binary が true になる
+// まとまりの行はヘッダーの行数だけ読み、その後の行（次のファイルの --- など）は削除した行として扱わない
+func parseDiffHunks(patch string) (hunks []DiffHunk, binary bool) {
+	hunks = []DiffHunk{}
+	var hunk *DiffHunk
+	oldLine, newLine := 0, 0
+	oldRemaining, newRemaining := 0, 0
+
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		if strings.HasPrefix(line, `\`) {
+			// 「\ No newline at end of file」は直前の行に付ける
+			if hunk != nil && len(hunk.Lines) > 0 {
+				hunk.Lines[len(hunk.Lines)-1].NoNewline = true
+			}
+			continue
+		}
+
+		if oldRemaining <= 0 && newRemaining <= 0 {
+			if strings.HasPrefix(line, "@@ ") {
+				parsed, ok := parseHunkHeader(line)
+				if !ok {
+					continue
+				}
+				hunks = append(hunks, parsed)
+				hunk = &hunks[len(hunks)-1]
+				oldLine, newLine = hunk.OldStart, hunk.NewStart
+				oldRemaining, newRemaining = hunk.OldLines, hunk.NewLines
+				continue
+			}
+
+			// まとまりの外はファイルのヘッダー（diff --git、index、---、+++ など）
+			if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+				binary = true
+			}
+			continue
+		}
+
+		// git apply と同じく、空の行は空白が削られた変更のない行とみなす
+		if line == "" {
+			line = " "
+		}
+		lineType, ok := diffLineTypes[line[0]]
+		if !ok {
+			continue
+		}
+
+		diffLine := DiffLine{Type: lineType, Content: line[1:]}
+		switch lineType {
+		case "context":
+			diffLine.OldLine, diffLine.NewLine = oldLine, newLine
+			oldLine++
+			newLine++
+			oldRemaining--
+			newRemaining--
+		case "delete":
+			diffLine.OldLine = oldLine
+			oldLine++
+			oldRemaining--
+		case "add":
+			diffLine.NewLine = newLine
+			newLine++
+			newRemaining--
+		}
+		hunk.Lines = append(hunk.Lines, diffLine)
+	}
+
+	return hunks, binary
+}
+
+// parseHunkHeader は「@@ -1,5 +1,7 @@ func main()」の形式の行を解析する（行数を省略した場合は1）
+func parseHunkHeader(line string) (DiffHunk, bool) {
+	hunk := DiffHunk{Lines: []DiffLine{}}
+	ranges, header, ok := strings.Cut(strings.TrimPrefix(line, "@@ "), " @@")
+	if !ok {
+		return hunk, false
+	}
+	hunk.Header = strings.TrimSpace(header)
+
+	oldRange, newRange, ok := strings.Cut(ranges, " ")
+	if !ok || !strings.HasPrefix(oldRange, "-") || !strings.HasPrefix(newRange, "+") {
+		return hunk, false
+	}
+	var oldOK, newOK bool
+	hunk.OldStart, hunk.OldLines, oldOK = parseHunkRange(oldRange[1:])
+	hunk.NewStart, hunk.NewLines, newOK = parseHunkRange(newRange[1:])
+	return hunk, oldOK && newOK
+}
+
+// parseHunkRange は「開始行,行数」または「開始行」を解析する（符号の付いた数は受け付けない）
+func parseHunkRange(value string) (start int, lines int, ok bool) {
+	startValue, linesValue, hasLines := strings.Cut(value, ",")
+	startNumber, err := strconv.ParseUint(startValue, 10, 31)
+	if err != nil {
+		return 0, 0, false
+	}
+	linesNumber := uint64(1)
+	if hasLines {
+		if linesNumber, err = strconv.ParseUint(linesValue, 10, 31); err != nil {
+			return 0, 0, false
+		}
+	}
+	return int(startNumber), int(linesNumber), true
+}
+
+// diffHandler は2つのコミットの間での1つのファイルの差分を返す
+// ファイル画面で「タグ X からの変更」を、比較全体を取得せずに表示するために使う
+//
+//	GET /api/diff/{group}/{repo}/{path}?from=v1.0&to=main
+func diffHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	filePath := strings.Trim(r.PathValue("filePath"), "/")
+	if filePath == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なパス形式です（ファイルパスがありません）"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("from") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from に比較元のブランチ、タグまたはコミットを指定してください"})
+		return
+	}
+	to := query.Get("to")
+	if to == "" {
+		to = "HEAD"
+	}
+
+	fromCommit, err := resolveCommit(r.Context(), repoPath, query.Get("from"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	toCommit, err := resolveCommit(r.Context(), repoPath, to)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	diff, err := getFileDiff(r.Context(), repoPath, fromCommit, toCommit, filePath)
+	if err != nil {
+		if errors.Is(err, errDiffFileNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(diff)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHunkRange(t *testing.T) {
+	tests := []struct {
+		value string
+		start int
+		lines int
+		ok    bool
+	}{
+		{"1,5", 1, 5, true},
+		{"12", 12, 1, true},
+		{"0,0", 0, 0, true},
+		{"3,0", 3, 0, true},
+
+		{"", 0, 0, false},
+		{",5", 0, 0, false},
+		{"1,", 0, 0, false},
+		{"a,1", 0, 0, false},
+		{"1,b", 0, 0, false},
+		{"-1,2", 0, 0, false},
+		{"+1,2", 0, 0, false},
+		{"1,-2", 0, 0, false},
+		{"99999999999", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, lines, ok := parseHunkRange(tt.value)
+		if ok != tt.ok || start != tt.start || lines != tt.lines {
+			t.Errorf("parseHunkRange(%q) = (%d, %d, %v), want (%d, %d, %v)", tt.value, start, lines, ok, tt.start, tt.lines, tt.ok)
+		}
+	}
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		want DiffHunk
+	}{
+		{"@@ -1,5 +1,7 @@", true, DiffHunk{OldStart: 1, OldLines: 5, NewStart: 1, NewLines: 7}},
+		{"@@ -10,3 +12,4 @@ func main() {", true, DiffHunk{OldStart: 10, OldLines: 3, NewStart: 12, NewLines: 4, Header: "func main() {"}},
+		{"@@ -1 +1 @@", true, DiffHunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}},
+		{"@@ -0,0 +1,3 @@", true, DiffHunk{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 3}},
+		{"@@ -1,3 +0,0 @@", true, DiffHunk{OldStart: 1, OldLines: 3, NewStart: 0, NewLines: 0}},
+		{"@@ -1,2 +1,2 @@ a @@ b", true, DiffHunk{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Header: "a @@ b"}},
+
+		{"@@ -1,2 +1,2", false, DiffHunk{}},
+		{"@@ +1,2 -1,2 @@", false, DiffHunk{}},
+		{"@@ -1,2 @@", false, DiffHunk{}},
+		{"@@ -x +1 @@", false, DiffHunk{}},
+		{"@@ --1 ++1 @@", false, DiffHunk{}},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseHunkHeader(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseHunkHeader(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.Lines = []DiffLine{}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseHunkHeader(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseDiffHunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		patch  string
+		binary bool
+		want   []DiffHunk
+	}{
+		{"変更", `diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@ header
+ one
+-two
++TWO
+ three
+`, false, []DiffHunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Header: "header", Lines: []DiffLine{
+			{Type: "context", Content: "one", OldLine: 1, NewLine: 1},
+			{Type: "delete", Content: "two", OldLine: 2},
+			{Type: "add", Content: "TWO", NewLine: 2},
+			{Type: "context", Content: "three", OldLine: 3, NewLine: 3},
+		}}}},
+
+		{"複数のまとまり", `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+-a
++A
+ b
+@@ -10 +10,2 @@
+ x
++y
+`, false, []DiffHunk{
+			{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []DiffLine{
+				{Type: "delete", Content: "a", OldLine: 1},
+				{Type: "add", Content: "A", NewLine: 1},
+				{Type: "context", Content: "b", OldLine: 2, NewLine: 2},
+			}},
+			{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 2, Lines: []DiffLine{
+				{Type: "context", Content: "x", OldLine: 10, NewLine: 10},
+				{Type: "add", Content: "y", NewLine: 11},
+			}},
+		}},
+
+		{"末尾に改行がない", `--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-old
+\ No newline at end of file
++new
+\ No newline at end of file
+`, false, []DiffHunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: []DiffLine{
+			{Type: "delete", Content: "old", OldLine: 1, NoNewline: true},
+			{Type: "add", Content: "new", NewLine: 1, NoNewline: true},
+		}}}},
+
+		{"新しいファイル", `--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++a
++b
+`, false, []DiffHunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: []DiffLine{
+			{Type: "add", Content: "a", NewLine: 1},
+			{Type: "add", Content: "b", NewLine: 2},
+		}}}},
+
+		{"変更した行の内容が記号で始まる", `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+---- dashes
++++++ pluses
+ @@ -1 +1 @@
+`, false, []DiffHunk{{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []DiffLine{
+			{Type: "delete", Content: "--- dashes", OldLine: 1},
+			{Type: "add", Content: "++++ pluses", NewLine: 1},
+			{Type: "context", Content: "@@ -1 +1 @@", OldLine: 2, NewLine: 2},
+		}}}},
+
+		{"空白が削られた空の行", "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n\n-c\n+C\n", false, []DiffHunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Lines: []DiffLine{
+			{Type: "context", Content: "a", OldLine: 1, NewLine: 1},
+			{Type: "context", Content: "", OldLine: 2, NewLine: 2},
+			{Type: "delete", Content: "c", OldLine: 3},
+			{Type: "add", Content: "C", NewLine: 3},
+		}}}},
+
+		{"まとまりの後の別のファイル", `--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-a
++b
+diff --git a/b.txt b/b.txt
+--- a/b.txt
++++ b/b.txt
+`, false, []DiffHunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: []DiffLine{
+			{Type: "delete", Content: "a", OldLine: 1},
+			{Type: "add", Content: "b", NewLine: 1},
+		}}}},
+
+		{"不正なヘッダーのまとまりは読み飛ばす", `--- a/a.txt
++++ b/a.txt
+@@ -x +1 @@
+-ignored
+@@ -1 +1 @@
+-a
++b
+`, false, []DiffHunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1, Lines: []DiffLine{
+			{Type: "delete", Content: "a", OldLine: 1},
+			{Type: "add", Content: "b", NewLine: 1},
+		}}}},
+
+		{"バイナリ", "diff --git a/a.png b/a.png\nindex 1111111..2222222 100644\nBinary files a/a.png and b/a.png differ\n", true, []DiffHunk{}},
+		{"バイナリのパッチ", "diff --git a/a.png b/a.png\nGIT binary patch\nliteral 3\nKcmZ?\n", true, []DiffHunk{}},
+		{"空の差分", "", false, []DiffHunk{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks, binary := parseDiffHunks(tt.patch)
+			if binary != tt.binary {
+				t.Errorf("parseDiffHunks binary = %v, want %v", binary, tt.binary)
+			}
+			if !reflect.DeepEqual(hunks, tt.want) {
+				t.Errorf("parseDiffHunks hunks = %+v, want %+v", hunks, tt.want)
+			}
+		})
+	}
+}
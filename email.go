@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPHost はプッシュ通知メールを送る SMTP サーバー（空の場合はメールを送らない）
+var SMTPHost = ""
+
+// SMTPPort は SMTP サーバーのポート番号
+var SMTPPort = 25
+
+// SMTPUsername と SMTPPassword は SMTP 認証に使う（空の場合は認証しない）
+// 認証情報を平文で送らないよう、net/smtp はサーバーが STARTTLS に対応していない場合は localhost 以外で認証しない
+var (
+	SMTPUsername = ""
+	SMTPPassword = ""
+)
+
+// NotificationFromAddress は通知メールの差出人
+var NotificationFromAddress = "guilty@localhost"
+
+// MaxSubscribers は1つのリポジトリに登録できる通知メールの宛先の上限
+var MaxSubscribers = 100
+
+// subscriberConfigKey は通知メールの宛先を保存するgit configのキー
+// 設定はリポジトリ自身の config ファイルに保存される
+const subscriberConfigKey = "guilty.subscriber"
+
+// SubscribersRequest は通知メールの宛先の更新リクエスト用の構造体
+type SubscribersRequest struct {
+	Subscribers []string `json:"subscribers"`
+}
+
+// getSubscribers はリポジトリの config から通知メールの宛先を取得する
+func getSubscribers(ctx context.Context, repoPath string) []string {
+	subscribers := []string{}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--get-all", subscriberConfigKey)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		// キーが存在しない場合もエラーになる
+		return subscribers
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subscribers = append(subscribers, line)
+		}
+	}
+
+	return subscribers
+}
+
+// setSubscribers は通知メールの宛先を保存し、post-receive フックを設置または削除する
+func setSubscribers(ctx context.Context, repoPath string, addresses []string) error {
+	normalized := []string{}
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddress(address)
+		if err != nil || strings.ContainsAny(parsed.Address, "\r\n") {
+			return fmt.Errorf("メールアドレス '%s' は不正です", address)
+		}
+		if !containsString(normalized, parsed.Address) {
+			normalized = append(normalized, parsed.Address)
+		}
+	}
+	if len(normalized) > MaxSubscribers {
+		return fmt.Errorf("宛先は %d 件までです", MaxSubscribers)
+	}
+
+	// 既存の設定を削除してから追加し直す（キーがない場合のエラーは無視）
+	gitRun(ctx, "--git-dir="+repoPath, "config", "--unset-all", subscriberConfigKey)
+	for _, address := range normalized {
+		cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--add", subscriberConfigKey, address)
+		defer cancel()
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("通知メールの宛先の保存に失敗しました: %s", strings.TrimSpace(string(output)))
+		}
+	}
+
+	return syncPostReceiveHook(ctx, repoPath)
+}
+
+// shortCommit は表示用に短くしたコミットSHAを返す
+func shortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// formatShortlog は git shortlog と同じ形式で作成者ごとのコミットの件名をまとめる
+// 作成者は名前の順、コミットは古い順に並べる
+func formatShortlog(commits []Commit) string {
+	subjects := map[string][]string{}
+	authors := []string{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		author := commits[i].Author
+		if _, ok := subjects[author]; !ok {
+			authors = append(authors, author)
+		}
+		subjects[author] = append(subjects[author], commits[i].Subject)
+	}
+	sort.Strings(authors)
+
+	var b strings.Builder
+	for _, author := range authors {
+		fmt.Fprintf(&b, "%s (%d):\n", author, len(subjects[author]))
+		for _, subject := range subjects[author] {
+			fmt.Fprintf(&b, "      %s\n", subject)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// refUpdateSummary は参照の更新を1行で説明する（メールの件名やチャットの通知に使う）
+func refUpdateSummary(update RefUpdate) string {
+	kind := map[string]string{RefTypeBranch: "ブランチ", RefTypeTag: "タグ", RefTypeOther: "参照"}[update.Type]
+
+	switch {
+	case update.Created && update.Type == RefTypeBranch:
+		return fmt.Sprintf("%s %s を作成しました（%d 件のコミット）", kind, update.Name, update.TotalCommits)
+	case update.Created:
+		return fmt.Sprintf("%s %s を作成しました", kind, update.Name)
+	case update.Deleted:
+		return fmt.Sprintf("%s %s を削除しました", kind, update.Name)
+	case update.Forced:
+		return fmt.Sprintf("%s %s を強制プッシュで更新しました（%d 件のコミット）", kind, update.Name, update.TotalCommits)
+	case update.Type == RefTypeBranch:
+		return fmt.Sprintf("%s %s に %d 件のコミットをプッシュしました", kind, update.Name, update.TotalCommits)
+	default:
+		return fmt.Sprintf("%s %s を更新しました", kind, update.Name)
+	}
+}
+
+// buildPushEmail は参照の更新1つについて、git multimail の参照変更メールに倣った件名と本文を作る
+func buildPushEmail(event *PushEvent, update RefUpdate) (string, string) {
+	fullName := event.Group + "/" + event.Repository
+	subject := fmt.Sprintf("[%s] %s", fullName, refUpdateSummary(update))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "リポジトリ %s で%s。\n\n", fullName, refUpdateSummary(update))
+	fmt.Fprintf(&b, "  参照:     %s\n", update.Ref)
+	if update.OldCommit != "" {
+		fmt.Fprintf(&b, "  変更前:   %s\n", update.OldCommit)
+	}
+	if update.NewCommit != "" {
+		fmt.Fprintf(&b, "  変更後:   %s\n", update.NewCommit)
+	}
+	fmt.Fprintf(&b, "  日時:     %s\n", event.PushedAt.Format(time.RFC3339))
+	if update.Forced {
+		b.WriteString("\n強制プッシュのため、変更前のコミットの一部はこのブランチから辿れなくなっています。\n")
+	}
+
+	if len(update.Commits) > 0 {
+		b.WriteString("\n- ログ -----------------------------------------------------------------\n")
+		b.WriteString(formatShortlog(update.Commits))
+
+		b.WriteString("- コミット -------------------------------------------------------------\n")
+		for _, commit := range update.Commits {
+			fmt.Fprintf(&b, "%s %s (%s)\n", shortCommit(commit.SHA), commit.Subject, commit.Author)
+		}
+		if update.TotalCommits > len(update.Commits) {
+			fmt.Fprintf(&b, "... ほか %d 件\n", update.TotalCommits-len(update.Commits))
+		}
+	}
+
+	b.WriteString("\n-- \nこのメールはguiltyから送信されています。配信の停止はリポジトリの通知メールの設定から行えます。\n")
+
+	return subject, b.String()
+}
+
+// buildMailMessage は UTF-8 の件名と本文からメールのメッセージを作る
+// 宛先は Bcc と同じく封筒にだけ入れ、To ヘッダーには書かない
+func buildMailMessage(subject, body string, headers map[string]string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", getSMTPSettings().From)
+	msg.WriteString("To: undisclosed-recipients:;\r\n")
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if headers[name] != "" {
+			fmt.Fprintf(&msg, "%s: %s\r\n", name, headers[name])
+		}
+	}
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+
+	return msg.Bytes()
+}
+
+// smtpSettings は通知メールの送信に使う設定
+type smtpSettings struct {
+	Host     string
+	Port     int
+	From     string
+	Username string
+	Password string
+}
+
+// getSMTPSettings は通知メールの送信に使う設定を返す（設定の再読み込みで変わることがある）
+func getSMTPSettings() smtpSettings {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return smtpSettings{Host: SMTPHost, Port: SMTPPort, From: NotificationFromAddress, Username: SMTPUsername, Password: SMTPPassword}
+}
+
+// sendMail は設定された SMTP サーバーでメールを送る
+func sendMail(to []string, message []byte) error {
+	settings := getSMTPSettings()
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	from := settings.From
+	if parsed, err := mail.ParseAddress(settings.From); err == nil {
+		from = parsed.Address
+	}
+
+	addr := settings.Host + ":" + strconv.Itoa(settings.Port)
+	return smtp.SendMail(addr, auth, from, to, message)
+}
+
+// sendPushEmails はプッシュされた参照ごとに通知メールを送る
+func sendPushEmails(ctx context.Context, repoPath string, event *PushEvent) error {
+	subscribers := getSubscribers(ctx, repoPath)
+	if getSMTPSettings().Host == "" || len(subscribers) == 0 {
+		return nil
+	}
+
+	for _, update := range event.Updates {
+		subject, body := buildPushEmail(event, update)
+		// git multimail と同じヘッダーを付け、メールソフトで振り分けられるようにする
+		message := buildMailMessage(subject, body, map[string]string{
+			"X-Git-Repo":    event.Group + "/" + event.Repository,
+			"X-Git-Refname": update.Ref,
+			"X-Git-Oldrev":  update.OldCommit,
+			"X-Git-Newrev":  update.NewCommit,
+		})
+		if err := sendMail(subscribers, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// subscribersHandler はプッシュ通知メールの宛先を取得・更新する
+//
+//	GET /api/repository/{group}/{repo}/subscribers
+//	PUT /api/repository/{group}/{repo}/subscribers  {"subscribers": ["dev@example.com"]}
+func subscribersHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"subscribers":    getSubscribers(r.Context(), repoPath),
+			"smtpConfigured": getSMTPSettings().Host != "",
+		})
+
+	case http.MethodPut:
+		var req SubscribersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := setSubscribers(r.Context(), repoPath, req.Subscribers); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "subscribers.update", map[string]string{"count": strconv.Itoa(len(req.Subscribers))})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "通知メールの宛先が更新されました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
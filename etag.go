@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// etagResponseWriter は成功した JSON のレスポンスの本文をためて、ETag を計算できるようにする
+type etagResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffered    bool
+	body        bytes.Buffer
+}
+
+func (w *etagResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && w.Header().Get("ETag") == "" {
+		w.buffered = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *etagResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffered {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap は http.ResponseController が元の ResponseWriter の機能（Flush など）を使えるようにする
+func (w *etagResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// responseETag はレスポンスの本文から ETag を作る
+func responseETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches は If-None-Match のいずれかの ETag が etag と一致するかどうかを返す（弱い比較）
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagHandler はAPIの GET の成功した JSON のレスポンスに本文のハッシュの ETag を付け、If-None-Match が一致する場合は本文を返さずに 304 を返す
+// 一覧や詳細をポーリングするフロントエンドやスクリプトが、変わっていない JSON を毎回ダウンロードしないようにする
+// ブラウザが毎回 If-None-Match で確認するよう、Cache-Control が指定されていない場合は no-cache を付ける
+func etagHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/api/internal/") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &etagResponseWriter{ResponseWriter: w}
+		handler.ServeHTTP(recorder, r)
+		if !recorder.buffered {
+			return
+		}
+
+		etag := responseETag(recorder.body.Bytes())
+		w.Header().Set("ETag", etag)
+		if w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// exportHandler はリポジトリ全体を git bundle --all で作成し、ダウンロードさせる
+// クローンできない環境へ持ち込む場合などに使う（復元は git clone {name}.bundle）
+//
+//	GET /api/export/{group}/{repo}
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	// 参照が1つもないリポジトリは git bundle が失敗する
+	if ok, err := hasRefs(r.Context(), repoPath); err != nil || !ok {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "コミットのないリポジトリはエクスポートできません"})
+		return
+	}
+
+	cmd, cancel := gitMaintenanceCommand(r.Context(), "--git-dir="+repoPath, "bundle", "create", "--quiet", "-", "--all")
+	defer cancel()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "バンドルの作成に失敗しました"})
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "バンドルの作成に失敗しました"})
+		return
+	}
+
+	// 大きなリポジトリでもメモリに載せないよう、作成しながらそのまま送信する
+	filename := repoName + ".bundle"
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q; filename*=UTF-8''%s", filename, url.PathEscape(filename)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, stdout); err != nil {
+		// クライアントが切断した場合などは git を終了させる
+		cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil {
+		log.Printf("警告: %s/%s のエクスポートに失敗しました: %v", groupName, repoName, err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FsckResult はリポジトリの整合性チェックの結果を表す
+type FsckResult struct {
+	Repository string    `json:"repository"` // group/name
+	Healthy    bool      `json:"healthy"`    // 問題が見つからなかったかどうか
+	Problems   []string  `json:"problems"`   // git fsck が報告した問題（missing、broken link など）
+	CheckedAt  time.Time `json:"checkedAt"`
+	Duration   float64   `json:"duration"` // 実行時間（秒）
+}
+
+// runFsck は git fsck --connectivity-only でリポジトリのオブジェクトの欠落や破損を確認する
+func runFsck(ctx context.Context, groupName, repoName, repoPath string) FsckResult {
+	result := FsckResult{
+		Repository: groupName + "/" + repoName,
+		Problems:   []string{},
+		CheckedAt:  time.Now(),
+	}
+
+	cmd, cancel := gitMaintenanceCommand(ctx, "--git-dir="+repoPath, "fsck", "--connectivity-only", "--no-dangling", "--no-progress")
+	defer cancel()
+	output, err := cmd.CombinedOutput()
+	result.Duration = time.Since(result.CheckedAt).Seconds()
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result.Problems = append(result.Problems, line)
+		}
+	}
+
+	// 出力がなくても終了コードが0以外なら問題ありとする
+	if err != nil && len(result.Problems) == 0 {
+		result.Problems = append(result.Problems, err.Error())
+	}
+	result.Healthy = err == nil && len(result.Problems) == 0
+
+	return result
+}
+
+// fsckAllRepositories はすべてのグループのリポジトリに git fsck を実行する
+func fsckAllRepositories(ctx context.Context) ([]FsckResult, error) {
+	groups, err := getGroupList()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []FsckResult{}
+	for _, groupName := range groups {
+		repos, err := getGitRepositories(ctx, groupName)
+		if err != nil {
+			continue
+		}
+
+		for _, repo := range repos {
+			if repoPath, ok := findRepository(groupName, repo.Name); ok {
+				results = append(results, runFsck(ctx, groupName, repo.Name, repoPath))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// fsckHandler はリポジトリの整合性チェックを実行する管理者用ハンドラー
+//
+//	POST /api/admin/fsck                  すべてのリポジトリ
+//	POST /api/admin/fsck/{group}/{repo}   指定したリポジトリ
+func fsckHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+
+	// パスがない場合はすべてのリポジトリを確認する
+	if groupName == "" {
+		results, err := fsckAllRepositories(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリ一覧の取得に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(runFsck(r.Context(), groupName, repoName, repoPath))
+}
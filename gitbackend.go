@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GitBackendName はリポジトリを読む方法（git または go-git）
+// go-git の場合、参照、ツリー、blob、コミット履歴の読み取りを git のプロセスを起動せずに行う
+// 書き込み、差分、マージ、署名の検証などは、どちらの場合も git のコマンドを使う
+var GitBackendName = "git"
+
+// gitBackendNames は gitBackend に指定できる値
+var gitBackendNames = []string{"git", "go-git"}
+
+// GitBackend はリポジトリの読み取りの操作
+// repoPath はベアリポジトリのディレクトリ（git の --git-dir と同じ）
+type GitBackend interface {
+	// HasCommits はいずれかの参照からコミットをたどれるかどうかを返す
+	HasCommits(ctx context.Context, repoPath string) bool
+	// LastCommit は HEAD のコミットを返す（コミットがない場合は nil）
+	LastCommit(ctx context.Context, repoPath string) *CommitInfo
+	// HasRefs は参照（ブランチ、タグ、ノートなど）が1つ以上あるかどうかを返す
+	HasRefs(ctx context.Context, repoPath string) (bool, error)
+	// ResolveCommit は ref（ブランチ、タグ、SHA、HEAD~1 など）をコミットのSHAに解決する
+	ResolveCommit(ctx context.Context, repoPath, ref string) (string, error)
+	// Refs はブランチとタグをコミット日時の新しい順に返す
+	Refs(ctx context.Context, repoPath string) ([]RefInfo, error)
+	// ListTree は treeish（rev または rev:path）のツリー直下のエントリを返す
+	ListTree(ctx context.Context, repoPath, treeish string) ([]TreeEntry, error)
+	// TreeEntry は rev 時点の filePath のエントリを返す
+	TreeEntry(ctx context.Context, repoPath, rev, filePath string) (*TreeEntry, error)
+	// ReadBlob は blob の内容を返す
+	ReadBlob(ctx context.Context, repoPath, objectHash string) (string, error)
+	// ObjectSize はオブジェクト（SHA または rev:path）の大きさを返す
+	ObjectSize(ctx context.Context, repoPath, object string) (int64, error)
+	// Log は rev（a..b の範囲も指定できる）からたどれるコミットを新しい順に skip 件飛ばして最大 limit 件返す
+	Log(ctx context.Context, repoPath, rev string, skip, limit int) ([]Commit, error)
+	// FileLastModified は rev 時点の filePath を最後に変更したコミットの作成日時を返す
+	FileLastModified(ctx context.Context, repoPath, rev, filePath string) (time.Time, error)
+	// LastModifiedTimes は rev の履歴を新しい順にたどり、prefix（空またはスラッシュで終わるディレクトリ）直下の remaining のエントリを
+	// 最初に変更したコミットの作成日時を times に記録し、remaining から削除する
+	LastModifiedTimes(ctx context.Context, repoPath, rev, prefix string, remaining map[string]bool, times map[string]time.Time)
+}
+
+// commandGitBackend は git のコマンドを実行してリポジトリを読む
+type commandGitBackend struct{}
+
+// gitBackends は名前ごとの GitBackend
+var gitBackends = map[string]GitBackend{
+	"git":    commandGitBackend{},
+	"go-git": goGitBackend{},
+}
+
+// validateGitBackendName は gitBackend に指定できる値かどうかを確認する
+func validateGitBackendName(name string) error {
+	if !containsString(gitBackendNames, name) {
+		return fmt.Errorf("gitBackend は git または go-git で指定してください: %s", name)
+	}
+	return nil
+}
+
+// getGitBackend は設定されたリポジトリの読み取りの方法を返す
+func getGitBackend() GitBackend {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return gitBackends[GitBackendName]
+}
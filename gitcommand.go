@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// GitCommandTimeout は git のコマンド1回の実行時間の上限（0 は無制限）
+// 壊れたリポジトリで git log が止まった場合などに、プロセスと goroutine が残り続けないようにする
+var GitCommandTimeout = time.Minute
+
+// GitMaintenanceTimeout は git gc、fsck、バンドルの作成などの時間のかかるコマンドの実行時間の上限（0 は無制限）
+var GitMaintenanceTimeout = time.Hour
+
+// getGitCommandTimeouts は git のコマンドの実行時間の上限を返す
+func getGitCommandTimeouts() (time.Duration, time.Duration) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return GitCommandTimeout, GitMaintenanceTimeout
+}
+
+// newGitCommand は ctx がキャンセルされるか timeout を過ぎると停止する git のコマンドを作る
+// 実行が終わったら返した関数を呼んで context を解放する
+func newGitCommand(ctx context.Context, timeout time.Duration, args ...string) (*exec.Cmd, context.CancelFunc) {
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return exec.CommandContext(ctx, "git", args...), cancel
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return exec.CommandContext(ctx, "git", args...), cancel
+}
+
+// gitCommand は ctx（ハンドラーではリクエストの context）がキャンセルされるか GitCommandTimeout を過ぎると停止する git のコマンドを作る
+// クライアントが切断した場合やサーバーの終了時にも停止する
+func gitCommand(ctx context.Context, args ...string) (*exec.Cmd, context.CancelFunc) {
+	timeout, _ := getGitCommandTimeouts()
+	return newGitCommand(ctx, timeout, args...)
+}
+
+// gitMaintenanceCommand は GitMaintenanceTimeout を上限にして git のコマンドを作る（gc、fsck、バンドルの作成など）
+func gitMaintenanceCommand(ctx context.Context, args ...string) (*exec.Cmd, context.CancelFunc) {
+	_, timeout := getGitCommandTimeouts()
+	return newGitCommand(ctx, timeout, args...)
+}
+
+// gitOutput は git のコマンドを実行して標準出力を返す
+func gitOutput(ctx context.Context, args ...string) ([]byte, error) {
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+	return cmd.Output()
+}
+
+// gitLimitedOutput は git のコマンドを実行して標準出力を limit バイトまで返す
+// 大きな差分などをすべてメモリに読み込まないよう、上限を1バイト超えるところまでだけ読み、超えた場合はコマンドを止めて UTF-8 の文字の途中で切らないように切り詰める
+// 切り詰めた場合は truncated が true になり、コマンドの終了状態は無視する
+func gitLimitedOutput(ctx context.Context, limit int64, args ...string) (output []byte, truncated bool, err error) {
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, false, err
+	}
+
+	output, err = io.ReadAll(io.LimitReader(stdout, limit+1))
+	if int64(len(output)) > limit {
+		cmd.Process.Kill()
+		output = truncateUTF8(output, limit)
+		truncated = true
+	}
+	waitErr := cmd.Wait()
+	if err != nil {
+		return nil, false, err
+	}
+	if waitErr != nil && !truncated {
+		return nil, false, waitErr
+	}
+	return output, truncated, nil
+}
+
+// gitCombinedOutput は git のコマンドを実行して標準出力と標準エラー出力を返す
+func gitCombinedOutput(ctx context.Context, args ...string) ([]byte, error) {
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+	return cmd.CombinedOutput()
+}
+
+// gitRun は git のコマンドを実行する
+func gitRun(ctx context.Context, args ...string) error {
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+	return cmd.Run()
+}
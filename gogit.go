@@ -0,0 +1,686 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// goGitBackend は go-git でリポジトリのファイルを直接読む
+// git のプロセスを起動しないため、オブジェクトを1つずつ読む操作が速く、git のコマンドがない環境でも閲覧できる
+type goGitBackend struct{}
+
+// goGitMaxOpenRepositories は開いたままにしておくリポジトリの数の上限
+const goGitMaxOpenRepositories = 64
+
+// goGitObjectCacheSize は開いたリポジトリごとに読んだオブジェクトをキャッシュする大きさ
+const goGitObjectCacheSize = 8 * cache.MiByte
+
+// goGitRepository は開いたままにしておくリポジトリ
+type goGitRepository struct {
+	Repository *git.Repository
+	PacksTime  time.Time // 開いたときの objects/pack の更新日時
+}
+
+// goGitRepositories はリポジトリのパスごとの開いたリポジトリ
+// パックのインデックスを読み直さずに済むよう、使っていない間はここに置く（同時に複数のリクエストでは使わない）
+var (
+	goGitRepositoriesMutex sync.Mutex
+	goGitRepositories      = map[string]goGitRepository{}
+)
+
+// openGoGitRepository はリポジトリを開き、使い終わったら呼ぶ関数を返す
+// 参照とルーズオブジェクトは毎回ファイルから読むが、パックの一覧は開いたときのままのため、
+// プッシュや git gc でパックが変わった（objects/pack が更新された）場合は開き直す
+func openGoGitRepository(repoPath string) (*git.Repository, func(), error) {
+	key := filepath.Clean(repoPath)
+	var packsTime time.Time
+	if info, err := os.Stat(filepath.Join(key, "objects", "pack")); err == nil {
+		packsTime = info.ModTime()
+	}
+
+	goGitRepositoriesMutex.Lock()
+	cached, ok := goGitRepositories[key]
+	delete(goGitRepositories, key)
+	goGitRepositoriesMutex.Unlock()
+
+	repo := cached.Repository
+	if !ok || !cached.PacksTime.Equal(packsTime) {
+		storage := filesystem.NewStorage(osfs.New(key), cache.NewObjectLRU(goGitObjectCacheSize))
+		var err error
+		if repo, err = git.Open(storage, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	release := func() {
+		goGitRepositoriesMutex.Lock()
+		defer goGitRepositoriesMutex.Unlock()
+		if _, ok := goGitRepositories[key]; ok {
+			return
+		}
+		if len(goGitRepositories) >= goGitMaxOpenRepositories {
+			for other := range goGitRepositories {
+				delete(goGitRepositories, other)
+				break
+			}
+		}
+		goGitRepositories[key] = goGitRepository{Repository: repo, PacksTime: packsTime}
+	}
+	return repo, release, nil
+}
+
+// goGitTreeEntry は go-git のツリーのエントリを git ls-tree と同じ形式にする
+func goGitTreeEntry(entry object.TreeEntry, entryPath string) TreeEntry {
+	entryType := "blob"
+	switch entry.Mode {
+	case filemode.Dir:
+		entryType = "tree"
+	case filemode.Submodule:
+		entryType = "commit"
+	}
+	return TreeEntry{
+		Mode: fmt.Sprintf("%06o", uint32(entry.Mode)),
+		Type: entryType,
+		SHA:  entry.Hash.String(),
+		Path: entryPath,
+	}
+}
+
+// goGitCommitTime は git の %at などと同じく、コミットの日時をローカルのタイムゾーンにする
+func goGitCommitTime(signature object.Signature) time.Time {
+	return time.Unix(signature.When.Unix(), 0)
+}
+
+// goGitCommitSubject は git の %s と同じく、メッセージの最初の段落を1行にした件名を返す
+func goGitCommitSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+	return strings.Join(strings.Fields(strings.ReplaceAll(paragraph, "\n", " ")), " ")
+}
+
+// resolveGoGitCommit は rev（ブランチ、タグ、SHA、HEAD~1 など）をコミットに解決する
+func resolveGoGitCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("リビジョン '%s' が見つかりません: %w", rev, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// resolveGoGitTree は treeish（rev、rev^{tree}、ツリーのSHA、rev:path）をツリーに解決する
+func resolveGoGitTree(repo *git.Repository, treeish string) (*object.Tree, error) {
+	rev, treePath, _ := strings.Cut(treeish, ":")
+	rev = strings.TrimSuffix(rev, "^{tree}")
+
+	var tree *object.Tree
+	if plumbing.IsHash(rev) {
+		tree, _ = repo.TreeObject(plumbing.NewHash(rev))
+	}
+	if tree == nil {
+		commit, err := resolveGoGitCommit(repo, rev)
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	if treePath = strings.Trim(treePath, "/"); treePath != "" {
+		return tree.Tree(treePath)
+	}
+	return tree, nil
+}
+
+func (goGitBackend) HasCommits(ctx context.Context, repoPath string) bool {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return false
+	}
+	defer release()
+
+	if head, err := repo.Head(); err == nil {
+		if _, err := repo.CommitObject(head.Hash()); err == nil {
+			return true
+		}
+	}
+
+	// HEAD が存在しないブランチを指している場合も、ほかの参照にコミットがあれば true にする（git log --all と同じ）
+	refs, err := repo.References()
+	if err != nil {
+		return false
+	}
+	found := false
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(ref.Name().String(), "refs/") {
+			return nil
+		}
+		if _, err := resolveGoGitCommit(repo, ref.Hash().String()); err == nil {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return found
+}
+
+func (goGitBackend) HasRefs(ctx context.Context, repoPath string) (bool, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	refs, err := repo.References()
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), "refs/") {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (goGitBackend) ResolveCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	commit, err := resolveGoGitCommit(repo, ref)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+func (goGitBackend) LastCommit(ctx context.Context, repoPath string) *CommitInfo {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return nil
+	}
+	defer release()
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil
+	}
+
+	return &CommitInfo{
+		Author:       commit.Author.Name,
+		AuthorEmail:  commit.Author.Email,
+		Date:         goGitCommitTime(commit.Author),
+		Message:      goGitCommitSubject(commit.Message),
+		AuthorAvatar: newAuthorAvatar(commit.Author.Email),
+	}
+}
+
+func (goGitBackend) Refs(ctx context.Context, repoPath string) ([]RefInfo, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	iter, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []RefInfo{}
+	err = iter.ForEach(func(reference *plumbing.Reference) error {
+		name := reference.Name().String()
+		if reference.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		ref := RefInfo{Ref: name, Object: reference.Hash().String(), Target: reference.Hash().String()}
+		if short, ok := strings.CutPrefix(name, "refs/heads/"); ok {
+			ref.Type, ref.Name = "branch", short
+		} else if short, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+			ref.Type, ref.Name = "tag", short
+		} else {
+			return nil
+		}
+
+		// 注釈付きタグは展開後のコミットの情報を使う
+		hash := reference.Hash()
+		if tag, err := repo.TagObject(hash); err == nil {
+			target, err := tag.Object()
+			for err == nil {
+				nested, ok := target.(*object.Tag)
+				if !ok {
+					break
+				}
+				target, err = nested.Object()
+			}
+			if err != nil {
+				return nil
+			}
+			hash = target.ID()
+			ref.Target = hash.String()
+		}
+		if commit, err := repo.CommitObject(hash); err == nil {
+			ref.Date = goGitCommitTime(commit.Committer)
+		}
+
+		refs = append(refs, ref)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// git for-each-ref --sort=-committerdate と同じく、日時の新しい順（同じ日時は参照名の順）にする
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Ref < refs[j].Ref
+	})
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Date.After(refs[j].Date)
+	})
+
+	return refs, nil
+}
+
+func (goGitBackend) ListTree(ctx context.Context, repoPath, treeish string) ([]TreeEntry, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tree, err := resolveGoGitTree(repo, treeish)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TreeEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		entries = append(entries, goGitTreeEntry(entry, entry.Name))
+	}
+	return entries, nil
+}
+
+func (goGitBackend) TreeEntry(ctx context.Context, repoPath, rev, filePath string) (*TreeEntry, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tree, err := resolveGoGitTree(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath = strings.Trim(filePath, "/")
+	entry, err := tree.FindEntry(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' が見つかりません", filePath)
+	}
+
+	result := goGitTreeEntry(*entry, filePath)
+	return &result, nil
+}
+
+func (goGitBackend) ReadBlob(ctx context.Context, repoPath, objectHash string) (string, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	if !plumbing.IsHash(objectHash) {
+		return "", fmt.Errorf("blob '%s' が見つかりません", objectHash)
+	}
+	blob, err := repo.BlobObject(plumbing.NewHash(objectHash))
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (goGitBackend) ObjectSize(ctx context.Context, repoPath, object string) (int64, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	var hash plumbing.Hash
+	if rev, filePath, ok := strings.Cut(object, ":"); ok {
+		tree, err := resolveGoGitTree(repo, rev)
+		if err != nil {
+			return 0, err
+		}
+		entry, err := tree.FindEntry(strings.Trim(filePath, "/"))
+		if err != nil {
+			return 0, err
+		}
+		hash = entry.Hash
+	} else if plumbing.IsHash(object) {
+		hash = plumbing.NewHash(object)
+	} else {
+		return 0, fmt.Errorf("オブジェクト '%s' が見つかりません", object)
+	}
+
+	return repo.Storer.EncodedObjectSize(hash)
+}
+
+func (backend goGitBackend) Log(ctx context.Context, repoPath, rev string, skip, limit int) ([]Commit, error) {
+	commits, signed, err := backend.readLog(ctx, repoPath, rev, skip, limit)
+	if err != nil {
+		return nil, fmt.Errorf("コミット履歴の取得に失敗しました: %w", err)
+	}
+
+	// 署名の検証には gpg などが必要なため、署名付きのコミットがある場合は git のコマンドで読み直す
+	// git のコマンドがない環境では、検証できない署名として返す
+	if signed {
+		if verified, err := (commandGitBackend{}).Log(ctx, repoPath, rev, skip, limit); err == nil {
+			return verified, nil
+		}
+	}
+	return commits, nil
+}
+
+// readLog は git log と同じくコミット日時の新しい順にコミットを読む（a..b の場合は a からたどれるコミットを除く）
+// 署名付きのコミットが含まれていたかどうかも返す
+func (goGitBackend) readLog(ctx context.Context, repoPath, rev string, skip, limit int) ([]Commit, bool, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
+	excluded := map[plumbing.Hash]bool{}
+	if base, head, ok := strings.Cut(rev, ".."); ok {
+		if base == "" {
+			base = "HEAD"
+		}
+		if head == "" {
+			head = "HEAD"
+		}
+		baseCommit, err := resolveGoGitCommit(repo, base)
+		if err != nil {
+			return nil, false, err
+		}
+		err = object.NewCommitPreorderIter(baseCommit, nil, nil).ForEach(func(commit *object.Commit) error {
+			excluded[commit.Hash] = true
+			return ctx.Err()
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		rev = head
+	}
+
+	start, err := resolveGoGitCommit(repo, rev)
+	if err != nil {
+		return nil, false, err
+	}
+
+	commits := []Commit{}
+	signed := false
+	iter := object.NewCommitIterCTime(start, nil, nil)
+	defer iter.Close()
+	for len(commits) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		commit, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if excluded[commit.Hash] {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		parents := []string{}
+		for _, parent := range commit.ParentHashes {
+			parents = append(parents, parent.String())
+		}
+		entry := Commit{
+			SHA:            commit.Hash.String(),
+			Parents:        parents,
+			Author:         commit.Author.Name,
+			AuthorEmail:    commit.Author.Email,
+			Date:           goGitCommitTime(commit.Author),
+			Committer:      commit.Committer.Name,
+			CommitterEmail: commit.Committer.Email,
+			CommitDate:     goGitCommitTime(commit.Committer),
+			Message:        strings.TrimSpace(commit.Message),
+			AuthorAvatar:   newAuthorAvatar(commit.Author.Email),
+		}
+		entry.Subject, _, _ = strings.Cut(entry.Message, "\n")
+		if commit.PGPSignature != "" {
+			signed = true
+			entry.Signature = &SignatureInfo{Status: SignatureUnknown}
+		}
+		commits = append(commits, entry)
+	}
+
+	return commits, signed, nil
+}
+
+// goGitPathHash は commit の filePath（空の場合はルート）のオブジェクトのSHAを返す（存在しない場合は ZeroHash）
+func goGitPathHash(commit *object.Commit, filePath string) plumbing.Hash {
+	if filePath == "" {
+		return commit.TreeHash
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	entry, err := tree.FindEntry(filePath)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return entry.Hash
+}
+
+// FileLastModified は git log -1 -- <path> と同じく、filePath が親と異なるコミットまで履歴をたどる
+// マージで親のいずれかと同じ場合はその親だけをたどる（git の履歴の単純化と同じ）
+func (goGitBackend) FileLastModified(ctx context.Context, repoPath, rev, filePath string) (time.Time, error) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer release()
+
+	commit, err := resolveGoGitCommit(repo, rev)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	filePath = strings.Trim(filePath, "/")
+	hash := goGitPathHash(commit, filePath)
+	for {
+		if err := ctx.Err(); err != nil {
+			return time.Time{}, err
+		}
+
+		var same *object.Commit
+		err := commit.Parents().ForEach(func(parent *object.Commit) error {
+			if goGitPathHash(parent, filePath) == hash {
+				same = parent
+				return storer.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if same == nil {
+			if hash == plumbing.ZeroHash {
+				return time.Time{}, errors.New("履歴にファイルが見つかりません")
+			}
+			return goGitCommitTime(commit.Author), nil
+		}
+		commit = same
+	}
+}
+
+// goGitCommitHeap はコミット日時の新しい順にコミットを取り出すヒープ
+type goGitCommitHeap []*object.Commit
+
+func (h goGitCommitHeap) Len() int { return len(h) }
+func (h goGitCommitHeap) Less(i, j int) bool {
+	return h[i].Committer.When.After(h[j].Committer.When)
+}
+func (h goGitCommitHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *goGitCommitHeap) Push(x interface{}) { *h = append(*h, x.(*object.Commit)) }
+func (h *goGitCommitHeap) Pop() interface{} {
+	old := *h
+	commit := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return commit
+}
+
+// goGitDirectoryEntries は commit の dirPath 直下のエントリ名とSHAの対応を返す（ディレクトリがない場合は空）
+func goGitDirectoryEntries(commit *object.Commit, dirPath string) map[string]plumbing.Hash {
+	entries := map[string]plumbing.Hash{}
+	tree, err := commit.Tree()
+	if err == nil && dirPath != "" {
+		tree, err = tree.Tree(dirPath)
+	}
+	if err != nil {
+		return entries
+	}
+	for _, entry := range tree.Entries {
+		entries[entry.Name] = entry.Hash
+	}
+	return entries
+}
+
+// LastModifiedTimes は git log --name-only -- <prefix> と同じ順に履歴をたどり、親とSHAが異なるエントリを変更されたものとする
+// ディレクトリのSHAが親と同じコミットはエントリを比べずに飛ばす
+// マージは（git log と同じく）変更したファイルに数えず、親のいずれかとディレクトリが同じ場合はその親だけをたどる
+func (goGitBackend) LastModifiedTimes(ctx context.Context, repoPath, rev, prefix string, remaining map[string]bool, times map[string]time.Time) {
+	repo, release, err := openGoGitRepository(repoPath)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	start, err := resolveGoGitCommit(repo, rev)
+	if err != nil {
+		return
+	}
+
+	dirPath := strings.TrimSuffix(prefix, "/")
+	dirHashes := map[plumbing.Hash]plumbing.Hash{}
+	dirHash := func(commit *object.Commit) plumbing.Hash {
+		hash, ok := dirHashes[commit.Hash]
+		if !ok {
+			hash = goGitPathHash(commit, dirPath)
+			dirHashes[commit.Hash] = hash
+		}
+		return hash
+	}
+
+	queue := &goGitCommitHeap{start}
+	seen := map[plumbing.Hash]bool{start.Hash: true}
+	push := func(commit *object.Commit) {
+		if !seen[commit.Hash] {
+			seen[commit.Hash] = true
+			heap.Push(queue, commit)
+		}
+	}
+
+	for queue.Len() > 0 && len(remaining) > 0 && ctx.Err() == nil {
+		commit := heap.Pop(queue).(*object.Commit)
+		hash := dirHash(commit)
+
+		parents := []*object.Commit{}
+		if commit.Parents().ForEach(func(parent *object.Commit) error {
+			parents = append(parents, parent)
+			return nil
+		}) != nil {
+			return
+		}
+
+		switch {
+		case len(parents) == 0:
+			if hash != plumbing.ZeroHash {
+				recordGoGitChanges(commit, goGitDirectoryEntries(commit, dirPath), nil, remaining, times)
+			}
+		case len(parents) == 1:
+			if dirHash(parents[0]) != hash {
+				recordGoGitChanges(commit, goGitDirectoryEntries(commit, dirPath), goGitDirectoryEntries(parents[0], dirPath), remaining, times)
+			}
+			push(parents[0])
+		default:
+			followed := false
+			for _, parent := range parents {
+				if dirHash(parent) == hash {
+					push(parent)
+					followed = true
+					break
+				}
+			}
+			if !followed {
+				for _, parent := range parents {
+					push(parent)
+				}
+			}
+		}
+	}
+}
+
+// recordGoGitChanges は current と parent で SHA が異なる remaining のエントリに commit の作成日時を記録する
+func recordGoGitChanges(commit *object.Commit, current, parent map[string]plumbing.Hash, remaining map[string]bool, times map[string]time.Time) {
+	for name := range remaining {
+		currentHash, inCurrent := current[name]
+		parentHash, inParent := parent[name]
+		if inCurrent != inParent || currentHash != parentHash {
+			times[name] = goGitCommitTime(commit.Author)
+			delete(remaining, name)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGraphCommits はコミットグラフで limit を省略した場合に返すコミット数
+var DefaultGraphCommits = 100
+
+// MaxGraphCommits はコミットグラフで返すコミット数の上限
+var MaxGraphCommits = 1000
+
+// CommitGraph はブランチとマージのグラフを描くためのコミットの一覧
+type CommitGraph struct {
+	Commits []GraphCommit `json:"commits"` // 新しい順（子は必ず親より前に並ぶ）
+	Columns int           `json:"columns"` // グラフの列数（column の最大値 + 1）
+	HasMore bool          `json:"hasMore"` // limit より古いコミットがあるかどうか
+}
+
+// GraphCommit はコミットグラフの1件を表す
+type GraphCommit struct {
+	SHA          string     `json:"sha"`
+	Parents      []string   `json:"parents"` // 一覧にない（limit より古い）親も含む
+	Author       string     `json:"author"`
+	AuthorEmail  string     `json:"authorEmail"`
+	Date         time.Time  `json:"date"`
+	Subject      string     `json:"subject"`
+	Refs         []GraphRef `json:"refs"`   // このコミットを指すブランチとタグ
+	Column       int        `json:"column"` // グラフで描く列（0から）
+	AuthorAvatar            // 作成者のアバター
+}
+
+// GraphRef はコミットを指すブランチまたはタグ
+type GraphRef struct {
+	Name string `json:"name"`           // 短い名前（main, v1.0 など）
+	Type string `json:"type"`           // "branch" または "tag"
+	Head bool   `json:"head,omitempty"` // HEAD が指すブランチかどうか
+}
+
+// graphFormat は git log でコミットグラフを取得するためのフォーマット
+// フィールドはNUL区切り、レコードは0x1e区切り（%D は --decorate=full の参照名）
+const graphFormat = "%H%x00%P%x00%an%x00%ae%x00%at%x00%s%x00%D%x1e"
+
+// graphFieldCount は graphFormat のフィールド数
+const graphFieldCount = 7
+
+// getCommitGraph はすべてのブランチとタグから辿れるコミットを、子が親より前になる順に limit 件取得し、列を割り当てる
+// --all はノート（refs/notes）やマージリクエストの参照も含むため、ブランチとタグだけを辿る
+func getCommitGraph(ctx context.Context, repoPath string, limit int) (*CommitGraph, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--branches", "--tags", "--topo-order",
+		"--decorate=full", "--format="+graphFormat, "--max-count="+strconv.Itoa(limit+1), "--")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("コミットグラフの取得に失敗しました: %w", err)
+	}
+
+	graph := &CommitGraph{Commits: []GraphCommit{}}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) != graphFieldCount {
+			continue
+		}
+
+		commit := GraphCommit{
+			SHA:          fields[0],
+			Parents:      strings.Fields(fields[1]),
+			Author:       fields[2],
+			AuthorEmail:  fields[3],
+			Subject:      fields[5],
+			Refs:         parseGraphRefs(fields[6]),
+			AuthorAvatar: newAuthorAvatar(fields[3]),
+		}
+		if unixTime, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			commit.Date = time.Unix(unixTime, 0)
+		}
+		graph.Commits = append(graph.Commits, commit)
+	}
+
+	// 1件多く取得して、さらに古いコミットがあるかどうかを調べる
+	if len(graph.Commits) > limit {
+		graph.Commits = graph.Commits[:limit]
+		graph.HasMore = true
+	}
+
+	graph.Columns = assignGraphColumns(graph.Commits)
+	return graph, nil
+}
+
+// parseGraphRefs は %D の参照名（"HEAD -> refs/heads/main, tag: refs/tags/v1.0, refs/heads/dev" の形式）を解析する
+// ブランチとタグ以外の参照と、ブランチを指していない HEAD は含めない
+func parseGraphRefs(decoration string) []GraphRef {
+	refs := []GraphRef{}
+	if decoration == "" {
+		return refs
+	}
+
+	// 参照名には空白を使えないため、", " で区切れる
+	for _, name := range strings.Split(decoration, ", ") {
+		head := false
+		if branch, ok := strings.CutPrefix(name, "HEAD -> "); ok {
+			name, head = branch, true
+		}
+		name = strings.TrimPrefix(name, "tag: ")
+
+		if branch, ok := strings.CutPrefix(name, "refs/heads/"); ok {
+			refs = append(refs, GraphRef{Name: branch, Type: "branch", Head: head})
+		} else if tag, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+			refs = append(refs, GraphRef{Name: tag, Type: "tag"})
+		}
+	}
+	return refs
+}
+
+// assignGraphColumns は各コミットにグラフの列を割り当て、列数を返す
+// 列ごとに次に現れるはずのコミットを覚えておき、コミットは自分を待っている最も左の列に置く
+// 最初の親はコミットと同じ列を引き継ぎ、マージした他の親は空いている列（なければ右端の新しい列）に置くため、
+// 子から親への線は、最初の親へは同じ列をまっすぐ下りて親の行で曲がり、他の親へは子の次の行で曲がってから下りれば他のコミットと重ならない
+func assignGraphColumns(commits []GraphCommit) int {
+	lanes := []string{} // 列ごとに次に現れるはずのコミット（空いている列は空文字）
+	columns := 0
+
+	// freeLane は空いている列（なければ右端の新しい列）を返す
+	freeLane := func() int {
+		for i, sha := range lanes {
+			if sha == "" {
+				return i
+			}
+		}
+		lanes = append(lanes, "")
+		return len(lanes) - 1
+	}
+
+	for i := range commits {
+		commit := &commits[i]
+
+		// 自分を待っている最も左の列に置き、同じコミットを待っていた他の列（枝分かれした子の列）は空ける
+		commit.Column = -1
+		for lane, sha := range lanes {
+			if sha != commit.SHA {
+				continue
+			}
+			if commit.Column == -1 {
+				commit.Column = lane
+			} else {
+				lanes[lane] = ""
+			}
+		}
+		if commit.Column == -1 {
+			// ブランチやタグの先頭のコミット
+			commit.Column = freeLane()
+		}
+
+		lanes[commit.Column] = ""
+		for j, parent := range commit.Parents {
+			if j == 0 {
+				lanes[commit.Column] = parent
+				continue
+			}
+			if !containsString(lanes, parent) {
+				lanes[freeLane()] = parent
+			}
+		}
+
+		if commit.Column+1 > columns {
+			columns = commit.Column + 1
+		}
+		for lane, sha := range lanes {
+			if sha != "" && lane+1 > columns {
+				columns = lane + 1
+			}
+		}
+
+		// 右端の空いた列は詰める
+		for len(lanes) > 0 && lanes[len(lanes)-1] == "" {
+			lanes = lanes[:len(lanes)-1]
+		}
+	}
+
+	return columns
+}
+
+// graphHandler はブランチとマージのグラフを描くためのコミットの一覧を返す
+//
+//	GET /api/graph/{group}/{repo}?limit=100
+func graphHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, r.PathValue("groupName"), r.PathValue("repoName"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	limit := DefaultGraphCommits
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxGraphCommits {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("limit は1から%dの整数で指定してください", MaxGraphCommits)})
+			return
+		}
+	}
+
+	graph, err := getCommitGraph(r.Context(), repoPath, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(graph)
+}
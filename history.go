@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FileHistoryEntry はファイルの変更履歴の1件を表す
+type FileHistoryEntry struct {
+	Commit
+	Path    string `json:"path"`              // このコミットでのファイルのパス
+	OldPath string `json:"oldPath,omitempty"` // 名前変更・コピー元のパス
+	Status  string `json:"status"`            // "added"、"modified"、"deleted"、"renamed"、"copied"、"typechange"
+}
+
+// getFileHistory は git log --follow でファイルを変更したコミットを新しい順に取得する
+// 名前が変更されたファイルは変更前のパスの履歴も辿る
+func getFileHistory(ctx context.Context, repoPath, rev, filePath string, skip, limit int) ([]FileHistoryEntry, error) {
+	// --skip を使うと読み飛ばしたコミットでの名前変更を辿れなくなるため、先頭から取得して読み飛ばす
+	// -z の場合、コミット情報のあとに「NUL 改行 状態 NUL パス NUL」が続くため、レコードの先頭に区切りを置く
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--follow", "-M", "--name-status", "-z",
+		"--format=%x1e"+commitFieldsFormat, "--max-count="+strconv.Itoa(skip+limit), rev, "--", filePath)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ファイルの履歴の取得に失敗しました: %w", err)
+	}
+
+	history := []FileHistoryEntry{}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(record, "\x00")
+		if len(fields) < commitFieldCount {
+			continue
+		}
+
+		entry := FileHistoryEntry{Commit: parseCommitFields(fields[:commitFieldCount])}
+
+		// 残りは状態とパス（名前変更・コピーの場合は元のパスと新しいパス）
+		var changes []string
+		for _, field := range fields[commitFieldCount:] {
+			if field = strings.Trim(field, "\n"); field != "" {
+				changes = append(changes, field)
+			}
+		}
+		if len(changes) >= 2 {
+			entry.Status = changedFileStatuses[changes[0][0]]
+			entry.Path = changes[1]
+			if len(changes) >= 3 {
+				entry.OldPath = changes[1]
+				entry.Path = changes[2]
+			}
+		}
+
+		history = append(history, entry)
+	}
+
+	if skip >= len(history) {
+		return []FileHistoryEntry{}, nil
+	}
+	return history[skip:], nil
+}
+
+// historyHandler はファイルの変更履歴を返す
+//
+//	GET /api/history/{group}/{repo}/{path}?ref=main&page=1&limit=30
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	filePath := strings.Trim(r.PathValue("filePath"), "/")
+	if filePath == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なパス形式です（ファイルパスがありません）"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	skip, limit, err := parseCommitPage(query)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	ref := query.Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commit, err := resolveCommit(r.Context(), repoPath, ref)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	history, err := getFileHistory(r.Context(), repoPath, commit, filePath, skip, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
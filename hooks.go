@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// serverHookNames はベアリポジトリでプッシュ時に実行されるフックの名前
+var serverHookNames = []string{"pre-receive", "update", "post-receive", "post-update", "reference-transaction"}
+
+// hookTemplateMarker はテンプレートから設置したフックであることを示す目印（後ろにテンプレート名が続く）
+const hookTemplateMarker = "# guilty-hook-template: "
+
+// HookTemplate は管理者APIから設置できるフックのテンプレート
+// 任意のスクリプトを実行させないよう、設置できるのはここに定義したものに限る
+type HookTemplate struct {
+	Name        string `json:"name"`
+	Hook        string `json:"hook"` // 設置先のフック名
+	Description string `json:"description"`
+	script      string
+}
+
+// hookTemplates は設置できるフックのテンプレート
+// pre-receive は保護ブランチと容量制限で使うため、テンプレートでは扱わない
+var hookTemplates = []HookTemplate{
+	{
+		Name:        "deny-non-fast-forward",
+		Hook:        "update",
+		Description: "すべてのブランチへの強制プッシュを拒否する",
+		script: `# SHA-1/SHA-256 どちらでも、すべて 0 のオブジェクト名は「存在しない」を表す
+is_zero() { [ -z "$(echo "$1" | tr -d 0)" ]; }
+
+refname=$1 oldrev=$2 newrev=$3
+case "$refname" in
+refs/heads/*)
+	if ! is_zero "$oldrev" && ! is_zero "$newrev" && ! git merge-base --is-ancestor "$oldrev" "$newrev"; then
+		echo "guilty: '$refname' への強制プッシュは拒否されました" >&2
+		exit 1
+	fi
+	;;
+esac
+exit 0
+`,
+	},
+	{
+		Name:        "deny-branch-deletion",
+		Hook:        "update",
+		Description: "プッシュによるブランチの削除を拒否する",
+		script: `is_zero() { [ -z "$(echo "$1" | tr -d 0)" ]; }
+
+refname=$1 newrev=$3
+case "$refname" in
+refs/heads/*)
+	if is_zero "$newrev"; then
+		echo "guilty: ブランチ '${refname#refs/heads/}' は削除できません" >&2
+		exit 1
+	fi
+	;;
+esac
+exit 0
+`,
+	},
+	{
+		Name:        "push-log",
+		Hook:        "post-receive",
+		Description: "プッシュされた参照を日時とともにリポジトリの guilty-push.log に記録する",
+		script: `date=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+while read oldrev newrev refname; do
+	echo "$date $oldrev $newrev $refname" >> guilty-push.log
+done
+`,
+	},
+	{
+		Name:        "update-server-info",
+		Hook:        "post-update",
+		Description: "プッシュ後に git update-server-info を実行する（dumb HTTP で公開する場合）",
+		script: `exec git update-server-info
+`,
+	},
+}
+
+// HookInfo はリポジトリの hooks/ ディレクトリにあるフックの状態を表す
+type HookInfo struct {
+	Name       string `json:"name"`
+	Installed  bool   `json:"installed"`          // ファイルがあるかどうか
+	Executable bool   `json:"executable"`         // 実行権限があり、git から実行されるかどうか
+	Mode       string `json:"mode"`               // パーミッション（例: -rwxr-xr-x）
+	Size       int64  `json:"size"`               // ファイルサイズ（バイト）
+	Managed    bool   `json:"managed"`            // 保護ブランチ・容量制限・通知のためにguiltyが設置したフックかどうか
+	Template   string `json:"template,omitempty"` // テンプレートから設置した場合のテンプレート名
+}
+
+// InstallHookRequest はフックのテンプレート設置リクエスト用の構造体
+type InstallHookRequest struct {
+	Template string `json:"template"`
+}
+
+// UpdateHookRequest はフックの有効・無効の切り替えリクエスト用の構造体
+type UpdateHookRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// findHookTemplate は名前からフックのテンプレートを探す
+func findHookTemplate(name string) (HookTemplate, bool) {
+	for _, template := range hookTemplates {
+		if template.Name == name {
+			return template, true
+		}
+	}
+	return HookTemplate{}, false
+}
+
+// hookTemplateScript はテンプレートから設置するフックのスクリプト全体を返す
+func hookTemplateScript(template HookTemplate) string {
+	return "#!/bin/sh\n" + hookTemplateMarker + template.Name + "\n" +
+		"# このファイルはguiltyがテンプレートから設置しています。\n\n" + template.script
+}
+
+// shellQuote は値をシェルの単一引用符で囲む（フックのスクリプトにパスなどを埋め込むときに使う）
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// isValidHookName はフック名として扱える名前か確認する
+func isValidHookName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`) && !strings.HasSuffix(name, ".sample")
+}
+
+// getHookInfo は1つのフックの状態を取得する
+func getHookInfo(repoPath, name string) HookInfo {
+	hook := HookInfo{Name: name}
+
+	hookPath := filepath.Join(repoPath, "hooks", name)
+	info, err := os.Stat(hookPath)
+	if err != nil || info.IsDir() {
+		return hook
+	}
+
+	hook.Installed = true
+	hook.Executable = info.Mode().Perm()&0111 != 0
+	hook.Mode = info.Mode().String()
+	hook.Size = info.Size()
+
+	if content, err := os.ReadFile(hookPath); err == nil {
+		text := string(content)
+		hook.Managed = strings.Contains(text, managedHookMarker) || strings.Contains(text, managedPostReceiveHookMarker)
+		if _, rest, found := strings.Cut(text, hookTemplateMarker); found {
+			hook.Template, _, _ = strings.Cut(rest, "\n")
+		}
+	}
+
+	return hook
+}
+
+// getHooks はサーバー側のフックと、hooks/ ディレクトリにあるその他のフックの状態を取得する
+func getHooks(repoPath string) []HookInfo {
+	names := append([]string{}, serverHookNames...)
+
+	if entries, err := os.ReadDir(filepath.Join(repoPath, "hooks")); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && isValidHookName(entry.Name()) && !containsString(names, entry.Name()) {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names[len(serverHookNames):])
+
+	hooks := []HookInfo{}
+	for _, name := range names {
+		hooks = append(hooks, getHookInfo(repoPath, name))
+	}
+
+	return hooks
+}
+
+// installHookTemplate はテンプレートのフックを設置する
+// guiltyのテンプレート以外から設置されたフックは上書きしない
+func installHookTemplate(repoPath string, template HookTemplate) error {
+	current := getHookInfo(repoPath, template.Hook)
+	if current.Installed && current.Template == "" {
+		return fmt.Errorf("テンプレート以外から設置された %s フックが既に存在します", template.Hook)
+	}
+
+	hookPath := filepath.Join(repoPath, "hooks", template.Hook)
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("hooksディレクトリの作成に失敗しました: %w", err)
+	}
+
+	if err := os.WriteFile(hookPath, []byte(hookTemplateScript(template)), 0755); err != nil {
+		return fmt.Errorf("%s フックの設置に失敗しました: %w", template.Hook, err)
+	}
+
+	// 既存ファイルを上書きした場合はパーミッションが変わらないため明示的に設定
+	return os.Chmod(hookPath, 0755)
+}
+
+// hooksHandler はリポジトリのサーバー側フックを管理する管理者用ハンドラー
+//
+//	GET    /api/admin/hooks                         設置できるテンプレートの一覧
+//	GET    /api/admin/hooks/{group}/{repo}          フックの一覧
+//	POST   /api/admin/hooks/{group}/{repo}          {"template": "..."} テンプレートからフックを設置
+//	PATCH  /api/admin/hooks/{group}/{repo}/{hook}   {"enabled": true | false} 実行権限の切り替え
+//	DELETE /api/admin/hooks/{group}/{repo}/{hook}   テンプレートから設置したフックの削除
+func hooksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE")
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+
+	// パスがない場合はテンプレートの一覧を返す
+	if groupName == "" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(hookTemplates)
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if hookName := r.PathValue("hookName"); hookName != "" {
+		hookHandler(w, r, repoPath, hookName)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(getHooks(repoPath))
+
+	case http.MethodPost:
+		var req InstallHookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		template, ok := findHookTemplate(req.Template)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不明なテンプレートです: " + req.Template})
+			return
+		}
+
+		if err := installHookTemplate(repoPath, template); err != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "hook.install", map[string]string{"hook": template.Hook, "template": req.Template})
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(getHookInfo(repoPath, template.Hook))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// hookHandler は1つのフックの有効・無効の切り替えと削除を行う
+func hookHandler(w http.ResponseWriter, r *http.Request, repoPath, name string) {
+	if !isValidHookName(name) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なフック名です"})
+		return
+	}
+
+	hook := getHookInfo(repoPath, name)
+	if !hook.Installed {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "フックが見つかりません"})
+		return
+	}
+
+	// 保護ブランチと容量制限の pre-receive フック、通知の post-receive フックはそれぞれの設定から管理する
+	if hook.Managed {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "このフックは保護ブランチ・容量制限・通知の設定で管理されています"})
+		return
+	}
+
+	hookPath := filepath.Join(repoPath, "hooks", name)
+
+	switch r.Method {
+	case http.MethodPatch:
+		var req UpdateHookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		// git は実行権限のないフックを無視するため、実行権限の有無で有効・無効を切り替える
+		var mode os.FileMode = 0644
+		if req.Enabled {
+			mode = 0755
+		}
+		if err := os.Chmod(hookPath, mode); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "パーミッションの変更に失敗しました: " + err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "hook.update", map[string]string{"hook": name, "enabled": strconv.FormatBool(req.Enabled)})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(getHookInfo(repoPath, name))
+
+	case http.MethodDelete:
+		if hook.Template == "" {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "テンプレート以外から設置されたフックは削除できません（無効にすることはできます）"})
+			return
+		}
+
+		if err := os.Remove(hookPath); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "フックの削除に失敗しました: " + err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "hook.delete", map[string]string{"hook": name})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "フックを削除しました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
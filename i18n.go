@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Language はAPIのメッセージの既定の言語
+// リクエストの Accept-Language に対応している言語が含まれない場合に使う
+var Language = "ja"
+
+// supportedLanguages は対応しているメッセージの言語
+// ハンドラーは先頭の言語（日本語）でメッセージを返し、それ以外の言語には messageCatalog で翻訳する
+var supportedLanguages = []string{"ja", "en"}
+
+// getLanguage は既定の言語を返す
+func getLanguage() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return Language
+}
+
+// validateLanguage は言語の設定が対応している言語かどうかを確認する
+func validateLanguage(language string) error {
+	if !containsString(supportedLanguages, language) {
+		return fmt.Errorf("language には %s のいずれかを指定してください: %s", strings.Join(supportedLanguages, "、"), language)
+	}
+	return nil
+}
+
+// requestLanguage は Accept-Language に含まれる言語のうち、対応していて最も優先度（q）の高い言語を返す
+// en-US や ja-JP は主言語で判定する。対応している言語がない場合は既定の言語を返す
+func requestLanguage(r *http.Request) string {
+	best, bestWeight := "", 0.0
+	for _, value := range r.Header.Values("Accept-Language") {
+		for _, item := range strings.Split(value, ",") {
+			tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+			weight := 1.0
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				parsed, err := strconv.ParseFloat(q, 64)
+				if err != nil {
+					continue
+				}
+				weight = parsed
+			}
+			primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+			if containsString(supportedLanguages, primary) && weight > bestWeight {
+				best, bestWeight = primary, weight
+			}
+		}
+	}
+	if best == "" {
+		return getLanguage()
+	}
+	return best
+}
+
+// catalogMessage は翻訳できるメッセージを表す
+type catalogMessage struct {
+	Code string // 機械的に判定するためのコード（エラーのレスポンスの code）
+	Ja   string // ハンドラーが返す日本語のメッセージ（%s、%d、%w などは可変部分）
+	En   string // 英語のメッセージ（可変部分はすべて %s。順序が異なる場合は %[2]s のように指定する）
+}
+
+// compiledMessage は可変部分を含むメッセージと、原文に一致する正規表現
+type compiledMessage struct {
+	*catalogMessage
+	pattern *regexp.Regexp
+	literal int // 可変部分以外の長さ（複数のメッセージに一致する場合は長いほうを優先する）
+}
+
+var (
+	messageCatalogOnce     sync.Once
+	messageCatalogExact    map[string]*catalogMessage // 可変部分のないメッセージ
+	messageCatalogPatterns []compiledMessage
+)
+
+// messageVerb は原文の可変部分（fmt の書式指定）と、% そのものを表す %%
+var messageVerb = regexp.MustCompile(`%%|%[sdvwq]`)
+
+// compileMessageCatalog は messageCatalog を検索できる形にする
+func compileMessageCatalog() {
+	messageCatalogExact = map[string]*catalogMessage{}
+	for i := range messageCatalog {
+		entry := &messageCatalog[i]
+		if !strings.ContainsAny(strings.ReplaceAll(entry.Ja, "%%", ""), "%") {
+			messageCatalogExact[entry.Ja] = entry
+			continue
+		}
+
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		literal := 0
+		last := 0
+		for _, loc := range messageVerb.FindAllStringIndex(entry.Ja, -1) {
+			pattern.WriteString(regexp.QuoteMeta(entry.Ja[last:loc[0]]))
+			literal += loc[0] - last
+			last = loc[1]
+			switch entry.Ja[loc[1]-1] {
+			case '%':
+				pattern.WriteString("%")
+				literal++
+				continue
+			case 'd':
+				pattern.WriteString(`(-?\d+)`)
+			default:
+				pattern.WriteString(`(.*?)`)
+			}
+		}
+		pattern.WriteString(regexp.QuoteMeta(entry.Ja[last:]))
+		pattern.WriteString("$")
+		literal += len(entry.Ja) - last
+
+		messageCatalogPatterns = append(messageCatalogPatterns, compiledMessage{
+			catalogMessage: entry,
+			pattern:        regexp.MustCompile(pattern.String()),
+			literal:        literal,
+		})
+	}
+	sort.SliceStable(messageCatalogPatterns, func(i, j int) bool {
+		return messageCatalogPatterns[i].literal > messageCatalogPatterns[j].literal
+	})
+}
+
+// lookupMessage は原文に一致するメッセージと、可変部分の値を返す
+func lookupMessage(text string) (*catalogMessage, []string) {
+	messageCatalogOnce.Do(compileMessageCatalog)
+
+	if entry, ok := messageCatalogExact[text]; ok {
+		return entry, nil
+	}
+	for _, compiled := range messageCatalogPatterns {
+		if match := compiled.pattern.FindStringSubmatch(text); match != nil {
+			return compiled.catalogMessage, match[1:]
+		}
+	}
+	return nil, nil
+}
+
+// translateMessage はハンドラーが返したメッセージを language の言語に翻訳する
+// 可変部分がエラーのメッセージ（%w）の場合はそれも翻訳する。カタログにないメッセージはそのまま返し、entry は nil になる
+func translateMessage(text, language string) (string, *catalogMessage) {
+	entry, args := lookupMessage(text)
+	if entry == nil || language == supportedLanguages[0] {
+		return text, entry
+	}
+
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i], _ = translateMessage(arg, language)
+	}
+	return fmt.Sprintf(entry.En, values...), entry
+}
+
+// containsJapanese は文字列に日本語（ひらがな、カタカナ、漢字）が含まれるかどうかを返す
+func containsJapanese(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) {
+			return true
+		}
+	}
+	return false
+}
+
+// localizeResponse は JSON のレスポンスのオブジェクトの error と message を language の言語にし、エラーには code を付ける
+// message はカタログのメッセージと一致する場合だけ翻訳する（コミットのメッセージなどはそのまま返す）
+// 変更した場合は true を返す
+func localizeResponse(fields map[string]interface{}, status int, language string) bool {
+	changed := false
+
+	if text, ok := fields["error"].(string); ok && status >= http.StatusBadRequest {
+		translated, entry := translateMessage(text, language)
+		code := newAPIV1Error(status, "").Code
+		if entry != nil {
+			code = entry.Code
+		} else if language != supportedLanguages[0] && containsJapanese(text) {
+			// 翻訳できないメッセージは、ステータスコードの一般的なメッセージにして原文を detail に残す
+			translated = newAPIV1Error(status, "").Message
+			fields["detail"] = text
+		}
+		fields["error"] = translated
+		if _, ok := fields["code"]; !ok {
+			fields["code"] = code
+		}
+		changed = true
+	}
+
+	if text, ok := fields["message"].(string); ok {
+		if translated, entry := translateMessage(text, language); entry != nil && translated != text {
+			fields["message"] = translated
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// localizedError はミドルウェアなど、localizeHandler を通らずに書くエラーのレスポンスを作る
+func localizedError(r *http.Request, status int, message string) map[string]interface{} {
+	fields := map[string]interface{}{"error": message}
+	localizeResponse(fields, status, requestLanguage(r))
+	return fields
+}
+
+// localizeResponseWriter は JSON のレスポンスの本文をためて、メッセージを翻訳できるようにする
+type localizeResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffered    bool
+	body        bytes.Buffer
+}
+
+func (w *localizeResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffered = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *localizeResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffered {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap は http.ResponseController が元の ResponseWriter の機能（Flush など）を使えるようにする
+func (w *localizeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// localizeHandler はAPIの JSON のレスポンスの error と message を Accept-Language（または language の設定）の言語にし、
+// エラーには機械的に判定するための code を付ける
+//
+//	{"error": "Repository not found", "code": "repository_not_found"}
+//
+// バージョン付きAPIは apiV1Handler がエンベロープに包むときに翻訳する
+func localizeHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/api/internal/") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Language")
+		if strings.HasPrefix(r.URL.Path, APIV1Prefix) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &localizeResponseWriter{ResponseWriter: w}
+		handler.ServeHTTP(recorder, r)
+		if !recorder.buffered {
+			return
+		}
+
+		body := recorder.body.Bytes()
+		// 一覧などの大きなレスポンスは、メッセージを含む場合だけ読む
+		if recorder.status >= http.StatusBadRequest || bytes.Contains(body, []byte(`"message"`)) {
+			var fields map[string]interface{}
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			if decoder.Decode(&fields) == nil && fields != nil {
+				language := requestLanguage(r)
+				if localizeResponse(fields, recorder.status, language) {
+					var localized bytes.Buffer
+					json.NewEncoder(&localized).Encode(fields)
+					body = localized.Bytes()
+					w.Header().Del("Content-Length")
+					w.Header().Set("Content-Language", language)
+				}
+			}
+		}
+
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	})
+}
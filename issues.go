@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// issuesBucket はイシューを保存するバケット名
+// リポジトリ（group/name）ごとの入れ子のバケットに、番号をキーとして保存する
+var issuesBucket = []byte("issues")
+
+// イシューの状態
+const (
+	IssueOpen   = "open"
+	IssueClosed = "closed"
+)
+
+// maxIssueLabelLength はラベル名の最大文字数
+const maxIssueLabelLength = 30
+
+// Issue はリポジトリのイシュー（不具合や要望）を表す
+type Issue struct {
+	ID        int        `json:"id"` // リポジトリごとの通し番号（1から）
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`               // Markdown 形式の本文
+	BodyHTML  string     `json:"bodyHtml,omitempty"` // 本文を HTML に変換したもの（保存はしない）
+	State     string     `json:"state"`              // "open" または "closed"
+	Labels    []string   `json:"labels"`
+	Author    string     `json:"author"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	ClosedAt  *time.Time `json:"closedAt"`
+}
+
+// CreateIssueRequest はイシュー作成用の構造体
+type CreateIssueRequest struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels"`
+	Author string   `json:"author"`
+}
+
+// UpdateIssueRequest はイシューの部分更新（PATCH）用の構造体
+type UpdateIssueRequest struct {
+	Title  *string   `json:"title"`
+	Body   *string   `json:"body"`
+	State  *string   `json:"state"` // "open" または "closed"
+	Labels *[]string `json:"labels"`
+}
+
+// normalizeLabels はラベルの前後の空白を除き、重複を除去・検証してソートする
+func normalizeLabels(labels []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		if utf8.RuneCountInString(label) > maxIssueLabelLength || strings.ContainsAny(label, ",\n") {
+			return nil, fmt.Errorf("ラベル '%s' は不正です（カンマと改行は使えません、%d文字以内）", label, maxIssueLabelLength)
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// getIssues はリポジトリのイシューを新しい順に取得する
+// state、label が空の場合はその条件で絞り込まない
+func getIssues(groupName, repoName, state, label string) ([]Issue, error) {
+	issues := []Issue{}
+	if metadataStore == nil {
+		return issues, nil
+	}
+
+	err := metadataStore.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(issuesBucket).Bucket(metadataKey(groupName, repoName))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var issue Issue
+			if err := json.Unmarshal(v, &issue); err != nil {
+				return err
+			}
+			if (state == "" || issue.State == state) && (label == "" || containsString(issue.Labels, label)) {
+				issues = append(issues, issue)
+			}
+		}
+		return nil
+	})
+
+	return issues, err
+}
+
+// getIssue は番号を指定してイシューを取得する（見つからない場合は nil）
+func getIssue(groupName, repoName string, id int) (*Issue, error) {
+	if metadataStore == nil {
+		return nil, fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	var issue *Issue
+	err := metadataStore.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(issuesBucket).Bucket(metadataKey(groupName, repoName))
+		if bucket == nil {
+			return nil
+		}
+
+		data := bucket.Get(sequenceKey(id))
+		if data == nil {
+			return nil
+		}
+		issue = &Issue{}
+		return json.Unmarshal(data, issue)
+	})
+
+	return issue, err
+}
+
+// saveIssue はイシューを保存する
+// 番号が 0 の場合は新しい番号を割り当てる
+func saveIssue(groupName, repoName string, issue *Issue) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	// 変換した HTML は表示のたびに作り直すため保存しない
+	stored := *issue
+	stored.BodyHTML = ""
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(issuesBucket).CreateBucketIfNotExists(metadataKey(groupName, repoName))
+		if err != nil {
+			return err
+		}
+
+		if stored.ID == 0 {
+			id, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			stored.ID = int(id)
+			issue.ID = stored.ID
+		}
+
+		data, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(sequenceKey(stored.ID), data)
+	})
+}
+
+// deleteIssue はイシューを削除する
+func deleteIssue(groupName, repoName string, id int) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(issuesBucket).Bucket(metadataKey(groupName, repoName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(sequenceKey(id))
+	})
+}
+
+// deleteIssues はリポジトリのイシューをすべて削除する
+func deleteIssues(tx *bolt.Tx, groupName, repoName string) error {
+	err := tx.Bucket(issuesBucket).DeleteBucket(metadataKey(groupName, repoName))
+	if err == bolt.ErrBucketNotFound {
+		return nil
+	}
+	return err
+}
+
+// applyIssueUpdate は部分更新リクエストの内容をイシューに反映する
+func applyIssueUpdate(issue *Issue, req UpdateIssueRequest) error {
+	now := time.Now()
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return fmt.Errorf("タイトルを入力してください")
+		}
+		issue.Title = title
+	}
+
+	if req.Body != nil {
+		issue.Body = *req.Body
+	}
+
+	if req.Labels != nil {
+		labels, err := normalizeLabels(*req.Labels)
+		if err != nil {
+			return err
+		}
+		issue.Labels = labels
+	}
+
+	if req.State != nil && *req.State != issue.State {
+		switch *req.State {
+		case IssueOpen:
+			issue.ClosedAt = nil
+		case IssueClosed:
+			issue.ClosedAt = &now
+		default:
+			return fmt.Errorf("state には open または closed を指定してください")
+		}
+		issue.State = *req.State
+	}
+
+	issue.UpdatedAt = now
+	return nil
+}
+
+// issuesHandler はリポジトリのイシューの一覧・作成・更新・削除を行う
+//
+//	GET    /api/issues/{group}/{repo}?state=open&label=bug  一覧（state は open、closed、all）
+//	POST   /api/issues/{group}/{repo}                       {"title": "...", "body": "...", "labels": ["bug"]}
+//	GET    /api/issues/{group}/{repo}/{id}                  詳細
+//	PATCH  /api/issues/{group}/{repo}/{id}                  {"title": "...", "state": "closed"}
+//	DELETE /api/issues/{group}/{repo}/{id}                  削除
+func issuesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	// OPTIONSリクエスト（プリフライト）に対する応答
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+
+	if _, ok := findAccessibleRepository(r, groupName, repoName); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if metadataStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "メタデータストアが利用できません"})
+		return
+	}
+
+	if r.PathValue("id") == "" {
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			state := query.Get("state")
+			switch state {
+			case "":
+				state = IssueOpen
+			case "all":
+				state = ""
+			case IssueOpen, IssueClosed:
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "state には open、closed、all のいずれかを指定してください"})
+				return
+			}
+
+			issues, err := getIssues(groupName, repoName, state, query.Get("label"))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "イシューの取得に失敗しました: " + err.Error()})
+				return
+			}
+
+			for i := range issues {
+				issues[i].BodyHTML = renderMarkdown(issues[i].Body)
+			}
+
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(issues)
+
+		case http.MethodPost:
+			var req CreateIssueRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+				return
+			}
+
+			title := strings.TrimSpace(req.Title)
+			if title == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "タイトルを入力してください"})
+				return
+			}
+
+			labels, err := normalizeLabels(req.Labels)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+
+			now := time.Now()
+			issue := &Issue{
+				Title:     title,
+				Body:      req.Body,
+				State:     IssueOpen,
+				Labels:    labels,
+				Author:    strings.TrimSpace(req.Author),
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := saveIssue(groupName, repoName, issue); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "イシューの保存に失敗しました: " + err.Error()})
+				return
+			}
+
+			issue.BodyHTML = renderMarkdown(issue.Body)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(issue)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "イシューの番号が不正です"})
+		return
+	}
+
+	issue, err := getIssue(groupName, repoName, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "イシューの取得に失敗しました: " + err.Error()})
+		return
+	}
+	if issue == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "イシューが見つかりません"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		issue.BodyHTML = renderMarkdown(issue.Body)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(issue)
+
+	case http.MethodPatch:
+		var req UpdateIssueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := applyIssueUpdate(issue, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if err := saveIssue(groupName, repoName, issue); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "イシューの保存に失敗しました: " + err.Error()})
+			return
+		}
+
+		issue.BodyHTML = renderMarkdown(issue.Body)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(issue)
+
+	case http.MethodDelete:
+		if err := deleteIssue(groupName, repoName, id); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "イシューの削除に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "イシューが削除されました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// languageExtensions はファイルの拡張子と言語名の対応
+// ここにない拡張子のファイルは集計に含めない
+var languageExtensions = map[string]string{
+	".go":    "Go",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".cxx":   "C++",
+	".hpp":   "C++",
+	".hh":    "C++",
+	".cs":    "C#",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".kts":   "Kotlin",
+	".scala": "Scala",
+	".swift": "Swift",
+	".m":     "Objective-C",
+	".mm":    "Objective-C++",
+	".rs":    "Rust",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".pl":    "Perl",
+	".pm":    "Perl",
+	".lua":   "Lua",
+	".r":     "R",
+	".dart":  "Dart",
+	".hs":    "Haskell",
+	".ml":    "OCaml",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".erl":   "Erlang",
+	".clj":   "Clojure",
+	".js":    "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".jsx":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".vue":   "Vue",
+	".html":  "HTML",
+	".htm":   "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".sass":  "Sass",
+	".less":  "Less",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".ps1":   "PowerShell",
+	".bat":   "Batchfile",
+	".cmd":   "Batchfile",
+	".sql":   "SQL",
+	".pro":   "QMake",
+	".cmake": "CMake",
+	".ui":    "Qt Designer",
+	".qml":   "QML",
+	".tex":   "TeX",
+	".md":    "Markdown",
+}
+
+// languageFileNames は拡張子ではなくファイル名で判定する言語の対応
+var languageFileNames = map[string]string{
+	"Makefile":       "Makefile",
+	"makefile":       "Makefile",
+	"GNUmakefile":    "Makefile",
+	"Dockerfile":     "Dockerfile",
+	"CMakeLists.txt": "CMake",
+}
+
+// LanguageStat は言語ごとのファイルサイズの合計を表す
+type LanguageStat struct {
+	Language   string  `json:"language"`
+	Bytes      int64   `json:"bytes"`
+	Percentage float64 `json:"percentage"` // 全体に占める割合（%、小数点以下1桁）
+}
+
+// LanguageStats は言語統計の集計結果を表す
+type LanguageStats struct {
+	Total     int64          `json:"total"`     // 集計対象のファイルサイズの合計
+	Languages []LanguageStat `json:"languages"` // サイズの大きい順
+}
+
+// detectLanguage はファイルパスから言語名を判定する（判定できない場合は空文字列）
+func detectLanguage(filePath string) string {
+	name := path.Base(filePath)
+	if language, ok := languageFileNames[name]; ok {
+		return language
+	}
+	return languageExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// getLanguageStats は git ls-tree -r -l で HEAD のツリーを走査し、blob のサイズを言語ごとに集計する
+func getLanguageStats(ctx context.Context, repoPath string) (*LanguageStats, error) {
+	stats := &LanguageStats{Languages: []LanguageStat{}}
+	if !hasCommits(ctx, repoPath) {
+		return stats, nil
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "ls-tree", "-r", "-l", "-z", "HEAD")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	bytesByLanguage := map[string]int64{}
+	for _, record := range strings.Split(string(output), "\x00") {
+		// レコードの形式: <mode> SP <type> SP <object> SP+ <size> TAB <file>
+		meta, filePath, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+
+		parts := strings.Fields(meta)
+		if len(parts) != 4 || parts[1] != "blob" || parts[0] == SymlinkMode {
+			continue
+		}
+
+		size, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		language := detectLanguage(filePath)
+		if language == "" {
+			continue
+		}
+
+		bytesByLanguage[language] += size
+		stats.Total += size
+	}
+
+	for language, size := range bytesByLanguage {
+		stat := LanguageStat{Language: language, Bytes: size}
+		if stats.Total > 0 {
+			stat.Percentage = math.Round(float64(size)*1000/float64(stats.Total)) / 10
+		}
+		stats.Languages = append(stats.Languages, stat)
+	}
+
+	sort.Slice(stats.Languages, func(i, j int) bool {
+		if stats.Languages[i].Bytes != stats.Languages[j].Bytes {
+			return stats.Languages[i].Bytes > stats.Languages[j].Bytes
+		}
+		return stats.Languages[i].Language < stats.Languages[j].Language
+	})
+
+	return stats, nil
+}
+
+// languageStatsHandler は言語統計を返す
+func languageStatsHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	stats, err := getLanguageStats(r.Context(), repoPath)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "言語統計の取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
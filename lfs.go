@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LFSStorePath は Git LFS オブジェクトの保存先ディレクトリ
+// 空の場合はリポジトリ内の lfs/objects のみを参照する
+var LFSStorePath = ""
+
+// LFSPointerMaxSize は LFS ポインタファイルとして扱う最大サイズ
+const LFSPointerMaxSize = 1024
+
+// LFSPointer は Git LFS のポインタファイルの内容を表す
+type LFSPointer struct {
+	OID  string `json:"oid"`  // sha256 のオブジェクトID
+	Size int64  `json:"size"` // 実体のサイズ（バイト単位）
+}
+
+var lfsOIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// parseLFSPointer はファイル内容が LFS ポインタであれば解析して返す
+//
+//	version https://git-lfs.github.com/spec/v1
+//	oid sha256:<64桁の16進数>
+//	size <バイト数>
+func parseLFSPointer(content string) (*LFSPointer, bool) {
+	if len(content) > LFSPointerMaxSize || !strings.HasPrefix(content, "version https://git-lfs.github.com/spec/") {
+		return nil, false
+	}
+
+	pointer := &LFSPointer{}
+	hasSize := false
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "oid":
+			oid, found := strings.CutPrefix(value, "sha256:")
+			if !found || !lfsOIDPattern.MatchString(oid) {
+				return nil, false
+			}
+			pointer.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, false
+			}
+			pointer.Size = size
+			hasSize = true
+		}
+	}
+
+	if pointer.OID == "" || !hasSize {
+		return nil, false
+	}
+
+	return pointer, true
+}
+
+// findLFSObject は LFS オブジェクトの実体のパスを探す
+// リポジトリ内の lfs/objects、LFSStorePath の順に参照する
+func findLFSObject(repoPath, oid string) (string, bool) {
+	dirs := []string{filepath.Join(repoPath, "lfs", "objects")}
+	if LFSStorePath != "" {
+		dirs = append(dirs, LFSStorePath)
+	}
+
+	for _, dir := range dirs {
+		// git-lfs と同じく <oid[0:2]>/<oid[2:4]>/<oid> に格納される
+		objectPath := filepath.Join(dir, oid[0:2], oid[2:4], oid)
+		if info, err := os.Stat(objectPath); err == nil && info.Mode().IsRegular() {
+			return objectPath, true
+		}
+	}
+
+	return "", false
+}
+
+// readLFSObject は LFS オブジェクトの実体を MaxFileContentSize まで読み込む
+func readLFSObject(repoPath string, pointer *LFSPointer) (data []byte, truncated bool, err error) {
+	objectPath, ok := findLFSObject(repoPath, pointer.OID)
+	if !ok {
+		return nil, false, fmt.Errorf("LFSオブジェクト %s が見つかりません", pointer.OID)
+	}
+
+	file, err := os.Open(objectPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	data, err = io.ReadAll(io.LimitReader(file, MaxFileContentSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if int64(len(data)) > MaxFileContentSize {
+		return truncateUTF8(data, MaxFileContentSize), true, nil
+	}
+
+	return data, false, nil
+}
+
+// isBinaryData は git と同様に先頭 8000 バイトに NUL が含まれるかでバイナリかどうかを判定する
+func isBinaryData(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
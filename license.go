@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MaxLicenseFileSize はライセンス判定のために読み込むファイルの最大サイズ
+const MaxLicenseFileSize = 256 * 1024
+
+// LicenseInfo はリポジトリのライセンスを表す
+type LicenseInfo struct {
+	SPDXID string `json:"spdxId"` // SPDX識別子（判定できない場合は "NOASSERTION"）
+	Name   string `json:"name"`
+	Path   string `json:"path"` // ライセンスファイルのパス
+}
+
+// licenseFileNames はライセンスファイルとして扱うファイル名（拡張子を除き、小文字）
+var licenseFileNames = []string{"license", "licence", "copying", "unlicense"}
+
+// licenseTemplate は SPDX ライセンスを判定するための特徴的な文言
+// すべての phrases を含み、excludes をどれも含まない場合にそのライセンスと判定する
+type licenseTemplate struct {
+	SPDXID   string
+	Name     string
+	Phrases  []string
+	Excludes []string
+}
+
+// licenseTemplates は判定対象のライセンス（より限定的なものを先に並べる）
+var licenseTemplates = []licenseTemplate{
+	{"AGPL-3.0", "GNU Affero General Public License v3.0", []string{"gnu affero general public license version 3"}, nil},
+	{"LGPL-3.0", "GNU Lesser General Public License v3.0", []string{"gnu lesser general public license version 3"}, nil},
+	{"LGPL-2.1", "GNU Lesser General Public License v2.1", []string{"gnu lesser general public license version 2.1"}, nil},
+	{"GPL-3.0", "GNU General Public License v3.0", []string{"gnu general public license version 3"}, nil},
+	{"GPL-2.0", "GNU General Public License v2.0", []string{"gnu general public license version 2"}, nil},
+	{"Apache-2.0", "Apache License 2.0", []string{"apache license version 2.0"}, nil},
+	{"MPL-2.0", "Mozilla Public License 2.0", []string{"mozilla public license version 2.0"}, nil},
+	{"BSL-1.0", "Boost Software License 1.0", []string{"boost software license - version 1.0"}, nil},
+	{"Unlicense", "The Unlicense", []string{"this is free and unencumbered software released into the public domain"}, nil},
+	{"CC0-1.0", "Creative Commons Zero v1.0 Universal", []string{"cc0 1.0 universal"}, nil},
+	{"ISC", "ISC License", []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted"}, nil},
+	{"MIT", "MIT License", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}, nil},
+	{"BSD-3-Clause", "BSD 3-Clause \"New\" or \"Revised\" License", []string{"redistribution and use in source and binary forms", "neither the name"}, nil},
+	{"BSD-2-Clause", "BSD 2-Clause \"Simplified\" License", []string{"redistribution and use in source and binary forms"}, []string{"neither the name"}},
+}
+
+var licenseWhitespace = regexp.MustCompile(`\s+`)
+
+// classifyLicense はライセンス本文を SPDX ライセンスのテンプレートと照合する
+func classifyLicense(content string) (spdxID string, name string) {
+	// 改行位置や大文字小文字の違いを無視して照合する
+	text := strings.ToLower(licenseWhitespace.ReplaceAllString(content, " "))
+
+	for _, template := range licenseTemplates {
+		if matchesLicenseTemplate(text, template) {
+			return template.SPDXID, template.Name
+		}
+	}
+
+	return "NOASSERTION", "Other"
+}
+
+// matchesLicenseTemplate は正規化済みの本文がテンプレートに一致するか確認する
+func matchesLicenseTemplate(text string, template licenseTemplate) bool {
+	for _, phrase := range template.Phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+	for _, exclude := range template.Excludes {
+		if strings.Contains(text, exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLicenseFileName はファイル名がライセンスファイルのものか確認する（LICENSE、LICENSE.md、COPYING など）
+func isLicenseFileName(fileName string) bool {
+	base := strings.ToLower(strings.TrimSuffix(fileName, path.Ext(fileName)))
+	return containsString(licenseFileNames, base)
+}
+
+// detectLicense は HEAD のルートにあるライセンスファイルからライセンスを判定する
+// ライセンスファイルがない場合は nil を返す
+func detectLicense(ctx context.Context, repoPath string) *LicenseInfo {
+	if !hasCommits(ctx, repoPath) {
+		return nil
+	}
+
+	entries, err := listTree(ctx, repoPath, "HEAD")
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "blob" || entry.Mode == SymlinkMode || !isLicenseFileName(entry.Path) {
+			continue
+		}
+
+		if getGitObjectSize(ctx, repoPath, entry.SHA, true) > MaxLicenseFileSize {
+			continue
+		}
+
+		content, err := readBlob(ctx, repoPath, entry.SHA)
+		if err != nil {
+			continue
+		}
+
+		spdxID, name := classifyLicense(content)
+		return &LicenseInfo{SPDXID: spdxID, Name: name, Path: entry.Path}
+	}
+
+	return nil
+}
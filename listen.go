@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// UnixSocketPath は待ち受ける unix ドメインソケットのパス（空の場合は port の TCP で待ち受ける）
+// nginx などのリバースプロキシから proxy_pass http://unix:/run/guilty/guilty.sock: のように接続する
+var UnixSocketPath = ""
+
+// UnixSocketMode は unix ドメインソケットのアクセス権（リバースプロキシのユーザーが接続できるようにする）
+var UnixSocketMode os.FileMode = 0660
+
+// systemdListenFDsStart は systemd のソケットアクティベーションで渡される最初のファイルディスクリプタ
+const systemdListenFDsStart = 3
+
+// systemdListener は systemd のソケットアクティベーション（LISTEN_PID と LISTEN_FDS）で渡されたソケットを返す
+// ソケットが渡されていない場合は nil を返す
+func systemdListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	// git などの子プロセスが自分宛てと誤解しないよう、環境変数を削除する
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	// 複数のソケットが渡された場合も最初のソケットだけを使う
+	file := os.NewFile(uintptr(systemdListenFDsStart), "LISTEN_FD_3")
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("systemd から渡されたソケットを使用できません: %w", err)
+	}
+	return listener, nil
+}
+
+// unixSocketListener は UnixSocketPath で unix ドメインソケットを作成して待ち受ける
+// 前回の起動で残ったソケットファイルは削除する
+func unixSocketListener() (net.Listener, error) {
+	if info, err := os.Lstat(UnixSocketPath); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("'%s' はソケットではないため削除できません", UnixSocketPath)
+		}
+		if err := os.Remove(UnixSocketPath); err != nil {
+			return nil, fmt.Errorf("古いソケット '%s' を削除できません: %w", UnixSocketPath, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", UnixSocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("unix ドメインソケット '%s' で待ち受けできません: %w", UnixSocketPath, err)
+	}
+	if err := os.Chmod(UnixSocketPath, UnixSocketMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("unix ドメインソケット '%s' のアクセス権を変更できません: %w", UnixSocketPath, err)
+	}
+	return listener, nil
+}
+
+// newListener はサーバーが待ち受けるソケットを作る
+// 優先順位は systemd のソケットアクティベーション > unixSocket > port
+func newListener() (net.Listener, error) {
+	listener, err := systemdListener()
+	if err != nil || listener != nil {
+		return listener, err
+	}
+
+	if UnixSocketPath != "" {
+		return unixSocketListener()
+	}
+
+	listener, err = net.Listen("tcp", fmt.Sprintf(":%d", ServerPort))
+	if err != nil {
+		return nil, fmt.Errorf("ポート %d で待ち受けできません: %w", ServerPort, err)
+	}
+	return listener, nil
+}
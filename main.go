@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,18 +18,33 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-const ServerPort = 1080
+// ServerPort はサーバーが待ち受けるポート番号
+var ServerPort = 1080
 
 // GitRepositoryHome はGitリポジトリのホームディレクトリを定義します
-const GitRepositoryHome= "/home/git"
+var GitRepositoryHome = "/home/git"
 
 // GitHostName はGitリポジトリのホスト名を定義します（git clone用）
-var GitHostName = "git"
+// 空の場合はリクエストの Host（X-Forwarded-Host）ヘッダーのホスト名を使います
+var GitHostName = ""
 
 // GitCloneURLTemplate はクローンURLのテンプレートを定義します
-const GitCloneURLTemplate = "git@%s:%s/%s.git"
+var GitCloneURLTemplate = "git@%s:%s/%s.git"
+
+// GitHTTPSCloneURLTemplate は https（スマートHTTP）のクローンURLのテンプレートを定義します（空の場合は使いません）
+// 例: "https://%s/git/%s/%s.git"（git http-backend はリバースプロキシなどで別に用意します）
+var GitHTTPSCloneURLTemplate = ""
+
+// GitDaemonCloneURLTemplate は git:// のクローンURLのテンプレートを定義します（空の場合は使いません）
+// 例: "git://%s/%s/%s.git"（git daemon は別に起動します）
+var GitDaemonCloneURLTemplate = ""
+
+// MaxFileContentSize はファイル内容APIで返すテキストの最大サイズ（バイト単位）
+// これを超えるファイルは先頭部分だけを返し、truncated フラグを立てる
+var MaxFileContentSize int64 = 1024 * 1024
 
 // 除外すべきグループ名のパターンを定義
 var GroupNameBlacklist = []*regexp.Regexp{
@@ -38,72 +56,222 @@ type PageData struct {
 	Message      string
 	HostName     string
 	BuildVersion string // キャッシュ回避用のビルドバージョン
+	BasePath     string // 静的ファイルとAPIのURLの接頭辞（BasePath）
 }
 
 type GitRepository struct {
-	Path       string      `json:"path"`
-	Group      string      `json:"group"`
-	Name       string      `json:"name"`
-	Type       string      `json:"type"`
-	CloneURL   string      `json:"cloneUrl"` // クローン用URLを追加
-	LastCommit *CommitInfo `json:"lastCommit"`
+	Path        string      `json:"path"`
+	Group       string      `json:"group"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"` // descriptionファイルの内容
+	CloneURL    string      `json:"cloneUrl"`    // クローン用URLを追加
+	CloneURLs   []CloneURL  `json:"cloneUrls"`   // プロトコルごとのクローン用URL（先頭は cloneUrl と同じ SSH）
+	LastCommit  *CommitInfo `json:"lastCommit"`
+	License     *LicenseInfo `json:"license"` // HEAD のライセンスファイルから判定したライセンス
+	DiskSize    int64       `json:"diskSize,omitempty"` // ディスク上の合計サイズ（一覧APIで size=true または sort=size を指定した場合のみ）
+	Stars       int         `json:"stars"`   // スターを付けたユーザーの数
+	Starred     bool        `json:"starred"` // リクエストを送ったユーザーがスターを付けているか
+	Pinned      bool        `json:"pinned"`  // グループでピン留めされているか
+	RepositoryMetadata             // トピックや公開範囲などのメタデータ
+}
+
+// CloneURL はプロトコル（ssh、https、git）ごとのクローン用URLを表す
+type CloneURL struct {
+	Protocol string `json:"protocol"`
+	URL      string `json:"url"`
 }
 
 type CommitInfo struct {
-	Author  string    `json:"author"`
-	Date    time.Time `json:"date"`
-	Message string    `json:"message"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Date        time.Time `json:"date"`
+	Message     string    `json:"message"`
+	AuthorAvatar          // 作成者のアバター
 }
 
 // GitFile はリポジトリ内のファイル/ディレクトリを表す
 type GitFile struct {
 	Name         string    `json:"name"`
 	Path         string    `json:"path"`
-	Type         string    `json:"type"` // "file" または "dir"
+	Type         string    `json:"type"` // "file"、"dir"、"submodule" または "symlink"
 	Size         int64     `json:"size"`
 	LastModified time.Time `json:"lastModified"`
+	Mode         string    `json:"mode,omitempty"`   // git のファイルモード（100644, 100755, 120000, 040000, 160000）
+	SHA          string    `json:"sha,omitempty"`    // ls-tree が返すオブジェクトのSHA
+	Commit       string    `json:"commit,omitempty"` // サブモジュールが固定しているコミットのSHA
+	URL          string    `json:"url,omitempty"`    // サブモジュールの取得元URL（.gitmodules より）
+	Target       string    `json:"target,omitempty"` // シンボリックリンクのリンク先
 }
 
 // RepositoryDetails はリポジトリの詳細情報を含む
 type RepositoryDetails struct {
-	Repository   GitRepository `json:"repository"`
-	Files        []GitFile     `json:"files"`
-	Branches     []string      `json:"branches"`
-	Tags         []string      `json:"tags"`
-	CurrentHead  string        `json:"currentHead"`  // 現在のHEADブランチ
+	Repository    GitRepository `json:"repository"`
+	Files         []GitFile     `json:"files"`
+	Branches      []BranchInfo  `json:"branches"`
+	Tags          []string      `json:"tags"`
+	CurrentHead   string        `json:"currentHead"`   // 現在のHEADブランチ
+	DefaultBranch string        `json:"defaultBranch"` // git symbolic-ref HEAD が指すデフォルトブランチ
+	UnbornBranch  string        `json:"unbornBranch,omitempty"` // デフォルトブランチにまだコミットがない場合のブランチ名（最初のプッシュで作成される）
+	License       *LicenseInfo  `json:"license"`
+	Size          *RepositorySize `json:"size"` // git count-objects -v の結果
+	Maintenance   *MaintenanceStatus `json:"maintenance"` // 最後の git gc / git repack の結果
 }
 
 // リポジトリ作成リクエスト用の構造体
 type CreateRepositoryRequest struct {
-	Name  string `json:"name"`
-	Group string `json:"group"`
+	Name     string             `json:"name"`
+	Group    string             `json:"group"`
+	DefaultBranch string         `json:"defaultBranch"` // HEAD が指すブランチ（省略した場合は defaultBranch の設定）
+	Template RepositoryTemplate `json:"template"` // 最初のコミットに含めるファイル（省略した場合は空のリポジトリ）
 }
 
 func main() {
+	// 設定ファイル、環境変数、フラグから設定を読み込む
+	args, err := initConfig(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Printf("エラー: %v", err)
+		os.Exit(2)
+	}
+
+	// メタデータストアを開く（失敗した場合はメタデータなしで動作する）
+	if err := openMetadataStore(MetadataStorePath); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
+	// 監査ログを開く（失敗した場合は記録せずに動作する）
+	if err := openAuditLog(AuditLogPath); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
+	// サブコマンド（list、create、gc など）の場合はサーバーを起動せずに実行して終了する
+	if code, ok := runCLICommand(args); ok {
+		os.Exit(code)
+	}
+
 	// 静的ファイルのルーティング
-	fs := http.FileServer(http.Dir("static"))
+	// 埋め込んだファイル（AssetDirectory にあるファイルを優先）を配信する
+	fs := http.FileServer(http.FS(staticFS()))
 	http.Handle("/static/", http.StripPrefix("/static/", fs))
 
 	// ホームページのルーティング
 	http.HandleFunc("/", homeHandler)
 
+	// バージョン付きAPI（/api/... と同じ処理をし、レスポンスを共通の形式に包む）
+	http.HandleFunc(APIV1Prefix, apiV1Handler(http.DefaultServeMux))
+
+	// OpenAPI ドキュメント
+	http.HandleFunc("/api/openapi.json", openAPIHandler)
+
 	// Gitリポジトリ一覧API
 	http.HandleFunc("/api/repositories", repositoriesHandler)
 
+	// リポジトリの最新コミット情報をまとめて取得するAPI（一覧を lastCommit=false で取得した後に使う）
+	http.HandleFunc("/api/repositories/summaries", repositorySummariesHandler)
+
+	// すべてのグループのリポジトリ一覧API
+	http.HandleFunc("/api/repositories/all", allRepositoriesHandler)
+
 	// グループ一覧API
 	http.HandleFunc("/api/groups", groupsHandler)
 
-	// リポジトリ詳細API
-	http.HandleFunc("/api/repository/", repositoryDetailsHandler)
+	// グループでピン留めするリポジトリのAPI
+	http.HandleFunc("/api/groups/{groupName}/pins", pinsHandler)
+
+	// リポジトリ作成時に選べるテンプレートの一覧API
+	http.HandleFunc("/api/repository-templates", repositoryTemplatesHandler)
+
+	// リポジトリ詳細API（GET で詳細、PATCH で説明・メタデータ・デフォルトブランチ・名前の変更、DELETE でゴミ箱へ移動）
+	http.HandleFunc("/api/repository/{groupName}/{repoName}", repositoryDetailsHandler)
+
+	// リポジトリのサブリソースAPI（branches、tags、commits、contents など）
+	http.HandleFunc("/api/repository/{groupName}/{repoName}/{resource}", repositorySubresourceHandler)
+	http.HandleFunc("/api/repository/{groupName}/{repoName}/{resource}/{rest...}", repositorySubresourceHandler)
 
 	// ディレクトリ内容取得API
-	http.HandleFunc("/api/directory/", directoryContentsHandler)
+	http.HandleFunc("/api/directory/{groupName}/{repoName}", directoryContentsHandler)
+	http.HandleFunc("/api/directory/{groupName}/{repoName}/{dirPath...}", directoryContentsHandler)
 
 	// ファイル内容取得API
-	http.HandleFunc("/api/file/", fileContentsHandler)
+	http.HandleFunc("/api/file/{groupName}/{repoName}/{filePath...}", fileContentsHandler)
+
+	// HEADブランチ変更API（非推奨: PATCH /api/repository/{groupName}/{repoName} の defaultBranch を使う）
+	http.HandleFunc("/api/head/{groupName}/{repoName}", changeHeadBranchHandler)
+
+	// ファイル変更履歴API
+	http.HandleFunc("/api/history/{groupName}/{repoName}/{filePath...}", historyHandler)
+
+	// コミットグラフAPI
+	http.HandleFunc("/api/graph/{groupName}/{repoName}", graphHandler)
+
+	// 2つのコミットの間での1つのファイルの差分API
+	http.HandleFunc("/api/diff/{groupName}/{repoName}/{filePath...}", diffHandler)
+
+	// ブランチ・タグ参照一覧API
+	http.HandleFunc("/api/refs/{groupName}/{repoName}", refsHandler)
+
+	// コントリビューター一覧API
+	http.HandleFunc("/api/contributors/{groupName}/{repoName}", contributorsHandler)
+
+	// リポジトリ統計API
+	http.HandleFunc("/api/stats/{groupName}/{repoName}/{stat}", statsHandler)
+
+	// リポジトリのバンドルエクスポートAPI
+	http.HandleFunc("/api/export/{groupName}/{repoName}", exportHandler)
+
+	// リポジトリ整合性チェックAPI（管理者用）
+	http.HandleFunc("/api/admin/fsck", requireAdmin(fsckHandler))
+	http.HandleFunc("/api/admin/fsck/{groupName}/{repoName}", requireAdmin(fsckHandler))
+
+	// サーバー側フック管理API（管理者用）
+	http.HandleFunc("/api/admin/hooks", requireAdmin(hooksHandler))
+	http.HandleFunc("/api/admin/hooks/{groupName}/{repoName}", requireAdmin(hooksHandler))
+	http.HandleFunc("/api/admin/hooks/{groupName}/{repoName}/{hookName}", requireAdmin(hooksHandler))
 
-	// HEADブランチ変更API
-	http.HandleFunc("/api/head/", changeHeadBranchHandler)
+	// post-receive フックからのプッシュ通知（サーバー内部用）
+	http.HandleFunc("/api/internal/post-receive", postReceiveHandler)
+
+	// アバター画像のプロキシAPI（AvatarProxyEnabled が有効な場合のみ）
+	http.HandleFunc("/api/avatar/{hash}", avatarHandler)
+
+	// イシューAPI
+	http.HandleFunc("/api/issues/{groupName}/{repoName}", issuesHandler)
+	http.HandleFunc("/api/issues/{groupName}/{repoName}/{id}", issuesHandler)
+
+	// グループのチャット通知の設定API（管理者用）
+	http.HandleFunc("/api/admin/notifiers/{groupName}", requireAdmin(notifiersHandler))
+
+	// reflog 閲覧API（管理者用）
+	http.HandleFunc("/api/admin/reflog/{groupName}/{repoName}", requireAdmin(reflogHandler))
+
+	// 全リポジトリのバックアップ作成API（管理者用）
+	http.HandleFunc("/api/admin/backup", requireAdmin(backupHandler))
+
+	// バンドルからのリポジトリ復元API（管理者用）
+	http.HandleFunc("/api/admin/restore", requireAdmin(restoreHandler))
+	http.HandleFunc("/api/admin/restore/{groupName}/{repoName}", requireAdmin(restoreHandler))
+
+	// 設定の確認・再読み込みAPI（管理者用）
+	http.HandleFunc("/api/admin/config", requireAdmin(configHandler))
+	http.HandleFunc("/api/admin/config/reload", requireAdmin(configReloadHandler))
+
+	// 監査ログの検索API（管理者用）
+	http.HandleFunc("/api/admin/audit", requireAdmin(auditLogHandler))
+
+	// ゴミ箱（論理削除済みリポジトリ）API
+	http.HandleFunc("/api/trash", requireAdmin(trashHandler))
+	http.HandleFunc("/api/trash/{groupName}/{repoName}", requireAdmin(trashedRepositoryHandler))
+	http.HandleFunc("/api/trash/{groupName}/{repoName}/restore", requireAdmin(restoreTrashedRepositoryHandler))
+
+	// どのAPIにも一致しない場合（ホームページのHTMLではなくJSONのエラーを返す）
+	http.HandleFunc("/api/", apiNotFoundHandler)
+
+	// README などに埋め込む SVG のバッジ
+	http.HandleFunc("/badge/{groupName}/{badge}", badgeHandler)
+	http.HandleFunc("/badge/{groupName}/{repoName}/{badge}", badgeHandler)
 
 	// リポジトリ詳細ページのルーティング
 	http.HandleFunc("/repository/", repositoryPageHandler)
@@ -111,9 +279,47 @@ func main() {
 	// 新規リポジトリ作成ページのルーティング
 	http.HandleFunc("/create-repository", createRepositoryPageHandler)
 
-	// サーバー起動
-	fmt.Printf("サーバーを起動しています。http://localhost:%d にアクセスしてください\n", ServerPort)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", ServerPort), nil))
+	// サーバーのログ（リクエストごとのログを含む）を logFormat の形式で出力する
+	setupLogger()
+
+	// post-receive フックと共有する秘密の値を読み込み、既存のフックを現在の設定で書き直す
+	if err := initPostReceiveSecret(); err != nil {
+		log.Printf("警告: %v", err)
+	}
+	go refreshPostReceiveHooks()
+
+	// 保持期間を過ぎた削除済みリポジトリの自動削除
+	startTrashPurger()
+
+	// グループごとのディスク使用量の定期集計
+	startQuotaMonitor()
+
+	// リポジトリ一覧の索引の定期更新
+	startRepositoryIndexer()
+
+	// APIを通さないプッシュや新しいリポジトリの監視
+	startRepositoryWatcher()
+
+	// 最近メンテナンスされていないリポジトリの定期 git gc
+	startMaintenanceScheduler()
+
+	// SIGHUP で設定を再読み込みする
+	startConfigReloader()
+
+	// サーバー起動（SIGINT または SIGTERM を受け取ると、処理中のリクエストを待って終了する）
+	if err := listenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+	closeMetadataStore()
+	log.Printf("サーバーを終了しました")
+}
+
+// apiNotFoundHandler はどのAPIにも一致しない /api/ 以下のリクエストに JSON のエラーを返す
+func apiNotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": "不明なAPIです: " + r.URL.Path})
 }
 
 func homeHandler(w http.ResponseWriter, r *http.Request) {
@@ -132,12 +338,13 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	data := PageData{
 		Title:        "Gitリポジトリ一覧",
 		Message:      groupName + " グループにあるGitリポジトリ一覧",
-		HostName:     GitHostName,
+		HostName:     cloneHostName(r),
 		BuildVersion: fmt.Sprintf("%d", time.Now().Unix()), // Unixタイムスタンプをバージョンとして使用
+		BasePath:     BasePath,
 	}
 
 	// テンプレートを解析
-	tmpl, err := template.ParseFiles("templates/index.html")
+	tmpl, err := template.ParseFS(assetFS(), "templates/index.html")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -158,12 +365,13 @@ func repositoryPageHandler(w http.ResponseWriter, r *http.Request) {
 	data := PageData{
 		Title:        "リポジトリ詳細",
 		Message:      "リポジトリ: " + repoPath,
-		HostName:     GitHostName,
+		HostName:     cloneHostName(r),
 		BuildVersion: fmt.Sprintf("%d", time.Now().Unix()), // Unixタイムスタンプをバージョンとして使用
+		BasePath:     BasePath,
 	}
 
 	// テンプレートを解析
-	tmpl, err := template.ParseFiles("templates/repository.html")
+	tmpl, err := template.ParseFS(assetFS(), "templates/repository.html")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -182,12 +390,13 @@ func createRepositoryPageHandler(w http.ResponseWriter, r *http.Request) {
 	data := PageData{
 		Title:        "新規リポジトリの作成",
 		Message:      "新しいGitリポジトリを作成します",
-		HostName:     GitHostName,
+		HostName:     cloneHostName(r),
 		BuildVersion: fmt.Sprintf("%d", time.Now().Unix()), // Unixタイムスタンプをバージョンとして使用
+		BasePath:     BasePath,
 	}
 
 	// テンプレートを解析
-	tmpl, err := template.ParseFiles("templates/create-repository.html")
+	tmpl, err := template.ParseFS(assetFS(), "templates/create-repository.html")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -233,14 +442,42 @@ func repositoriesHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// デフォルトブランチのバリデーション
+		if req.DefaultBranch != "" && !isValidBranchName(r.Context(), req.DefaultBranch) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("ブランチ名 '%s' は不正です", req.DefaultBranch)})
+			return
+		}
+
+		// テンプレートのバリデーション
+		if err := validateRepositoryTemplate(req.Template); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		// グループのディスク使用量の上限を確認
+		if err := checkGroupQuota(req.Group); err != nil {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
 		// リポジトリの作成
-		err = createRepository(req.Name, req.Group)
+		err = createRepository(r.Context(), req.Name, req.Group, req.DefaultBranch, req.Template)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
 
+		groupName := req.Group
+		if groupName == "" {
+			groupName = "git"
+		}
+		notifyRepositoryEvent(ChatEventRepositoryCreated, groupName, req.Name)
+		recordAudit(r, "repository.create", groupName, req.Name, repositoryCreateDetails(req.DefaultBranch, req.Template))
+
 		// 成功レスポンス
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリが作成されました"})
@@ -256,17 +493,28 @@ func repositoriesHandler(w http.ResponseWriter, r *http.Request) {
 			groupName = "git"
 		}
 
-		// Gitリポジトリを取得
-		repos, err := getGitRepositories(groupName)
+		// ページと並び順の指定を確認
+		options, err := parseRepositoryListOptions(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		// Gitリポジトリを取得（lastCommit=false の場合は git を実行せずにディレクトリだけ読む）
+		var repos []GitRepository
+		if options.LastCommit {
+			repos, err = getGitRepositories(r.Context(), groupName)
+		} else {
+			repos, err = listGitRepositories(groupName)
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
 
-		// 結果をJSONとして返す
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(repos)
+		writeRepositoryList(w, r, repos, options)
 		return
 	}
 
@@ -295,6 +543,18 @@ func groupsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// quota=true の場合はグループごとのディスク使用量と上限を返す
+	if r.URL.Query().Get("quota") == "true" {
+		statuses := []GroupQuotaStatus{}
+		for _, groupName := range groups {
+			statuses = append(statuses, getGroupQuotaStatus(groupName))
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(statuses)
+		return
+	}
+
 	// 結果をJSONとして返す
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(groups)
@@ -314,7 +574,7 @@ func splitRepositoryName(path string) (group string, name string) {
 func repositoryDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	// OPTIONSリクエスト（プリフライト）に対する応答
@@ -323,19 +583,22 @@ func repositoryDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// リポジトリパスを取得（/api/repository/以降の部分）
-	encodedPath := strings.TrimPrefix(r.URL.Path, "/api/repository/")
-	// URLエンコードされたパスをデコード
-	decodedPath, err := url.PathUnescape(encodedPath)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリパス"})
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+
+	// PATCHリクエストの場合はリポジトリの説明、メタデータ、デフォルトブランチ、名前を更新する
+	if r.Method == http.MethodPatch {
+		patchRepository(w, r, groupName, repoName)
 		return
 	}
 
-	groupName, repoName := splitRepositoryName(decodedPath)
+	// DELETEリクエストの場合はリポジトリをゴミ箱へ移動する
+	if r.Method == http.MethodDelete {
+		deleteRepositoryRequest(w, r, groupName, repoName)
+		return
+	}
 
-	// POSTリクエストの場合はリポジトリを削除する
+	// POSTリクエストの場合はリポジトリに対する操作を実行する
+	// 非推奨: PATCH と DELETE を使う（以前のクライアントのために残している）
 	if r.Method == http.MethodPost {
 		// リクエストボディから操作タイプを取得
 		var requestBody map[string]string
@@ -344,40 +607,42 @@ func repositoryDetailsHandler(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
 			return
 		}
-		
-		// 操作タイプが "delete" の場合のみ削除を実行
+
+		// 操作タイプが "description" の場合は説明を更新
+		if requestBody["operation"] == "description" {
+			updateRepositoryDescription(w, r, groupName, repoName, requestBody)
+			return
+		}
+
+		// 操作タイプが "default-branch" の場合はデフォルトブランチを変更
+		if requestBody["operation"] == "default-branch" {
+			if err := changeRepositoryHead(r.Context(), groupName, repoName, requestBody["branch"]); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			recordAudit(r, "repository.update", groupName, repoName, map[string]string{"defaultBranch": requestBody["branch"]})
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"message": "デフォルトブランチが変更されました"})
+			return
+		}
+
+		// それ以外は "delete" の場合のみ削除を実行
 		if requestBody["operation"] != "delete" {
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(map[string]string{"error": "不正な操作タイプ"})
 			return
 		}
-		
-		// パスから取得したグループ名とリポジトリ名を使用して削除処理を行う
-		fullPath := filepath.Join(groupName, repoName)
-		err := deleteRepository(fullPath)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-			return
-		}
 
-		// 成功レスポンス
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリが削除されました"})
+		deleteRepositoryRequest(w, r, groupName, repoName)
 		return
 	}
 
 	// GETリクエストの場合はリポジトリの詳細を返す
 	if r.Method == http.MethodGet {
-		repoPath, err := filepath.Abs(filepath.Join(GitRepositoryHome, groupName, repoName) + ".git")
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリパス"})
-			return
-		}
-
 		// リポジトリの存在確認
-		if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+		if !ok {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
 			return
@@ -386,48 +651,69 @@ func repositoryDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		repo := GitRepository{
 			//Path: repoPath,
 			Path: filepath.Join(groupName, repoName),
+			Group: groupName,
 			Name: repoName,
 			// クローンURLを生成
-			CloneURL: fmt.Sprintf(GitCloneURLTemplate, GitHostName, groupName, repoName),
+			CloneURL: repositoryCloneURL(cloneHostName(r), groupName, repoName),
+			CloneURLs: repositoryCloneURLs(cloneHostName(r), groupName, repoName),
+			Description: getRepositoryDescription(repoPath),
+			RepositoryMetadata: getRepositoryMetadata(groupName, repoName),
 		}
 
-		// 最新のコミット情報を取得
-		repo.LastCommit = getLastCommit(repoPath)
+		// 最新のコミット情報とライセンスを取得（参照が変わっていなければキャッシュを使う）
+		repo.LastCommit, repo.License = getCachedRepositorySummary(r.Context(), repoPath)
+
+		// スターの数とピン留めを設定する
+		starred := []GitRepository{repo}
+		setRepositoryStars(r, starred)
+		repo = starred[0]
 
 		// ファイル一覧を取得
-		files, err := getRepositoryFiles(repoPath)
+		files, err := getRepositoryFiles(r.Context(), repoPath, "HEAD")
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": "ファイル一覧の取得に失敗しました: " + err.Error()})
 			return
 		}
 
-		// ブランチリストを取得
-		branches, err := getRepositoryBranches(repoPath)
+		// ブランチリストとタグリストを取得（参照が変わっていなければキャッシュを使う）
+		branches, tags := getCachedRepositoryRefs(r.Context(), repoPath)
+
+		// 現在のHEADブランチを取得
+		currentHead, err := getCurrentHeadBranch(repoPath)
 		if err != nil {
-			branches = []string{}
+			currentHead = "" // エラーの場合は空文字列
 		}
 
-		// タグリストを取得
-		tags, err := getRepositoryTags(repoPath)
+		// デフォルトブランチを取得
+		defaultBranch, err := getDefaultBranch(r.Context(), repoPath)
 		if err != nil {
-			tags = []string{}
+			defaultBranch = "" // エラーの場合は空文字列
 		}
 
-		// 現在のHEADブランチを取得
-		currentHead, err := getCurrentHeadBranch(repoPath)
-		if err != nil {
-			currentHead = "" // エラーの場合は空文字列
+		// デフォルトブランチがまだない場合は、最初にプッシュするブランチとして返す
+		unbornBranch := ""
+		if defaultBranch != "" && !branchExists(r.Context(), repoPath, defaultBranch) {
+			unbornBranch = defaultBranch
 		}
 
 		// リポジトリ詳細を組み立て
 		details := RepositoryDetails{
-			Repository:  repo,
-			Files:       files,
-			Branches:    branches,
-			Tags:        tags,
-			CurrentHead: currentHead,
+			Repository:    repo,
+			Files:         files,
+			Branches:      branches,
+			Tags:          tags,
+			CurrentHead:   currentHead,
+			DefaultBranch: defaultBranch,
+			UnbornBranch:  unbornBranch,
+			License:       repo.License,
+		}
+
+		// オブジェクト数とディスク使用量を取得
+		if size, err := getRepositorySize(r.Context(), repoPath); err == nil {
+			details.Size = size
 		}
+		details.Maintenance = getMaintenanceStatus(r.Context(), repoPath)
 
 		// 結果をJSONとして返す
 		w.WriteHeader(http.StatusOK)
@@ -497,7 +783,35 @@ func getDirectories(path string) ([]string, error) {
 	return entries, nil
 }
 
-func getGitRepositories(groupName string) ([]GitRepository, error) {
+func getGitRepositories(ctx context.Context, groupName string) ([]GitRepository, error) {
+	repositories, err := listGitRepositories(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最新のコミット情報とライセンスを取得（変わっていないリポジトリは索引を使い、それ以外は並列に git を実行して読む）
+	scanInParallel(ctx, len(repositories), func(i int) {
+		repositories[i].LastCommit, repositories[i].License = getIndexedRepositorySummary(ctx, groupName, repositories[i].Name, repositories[i].Path)
+	})
+
+	// 最終コミット日時の降順でソート（新しい順）
+	sort.Slice(repositories, func(i, j int) bool {
+		// コミット情報がない場合は最後に表示
+		if repositories[i].LastCommit == nil {
+			return false
+		}
+		if repositories[j].LastCommit == nil {
+			return true
+		}
+		// 日時を比較して降順にソート
+		return repositories[i].LastCommit.Date.After(repositories[j].LastCommit.Date)
+	})
+
+	return repositories, nil
+}
+
+// listGitRepositories はグループのリポジトリをディレクトリから読む（git を実行しないため、最新のコミット情報とライセンスは含まない）
+func listGitRepositories(groupName string) ([]GitRepository, error) {
 	if groupName == "" {
 		return nil, fmt.Errorf("グループ名を空にすることはできません")
 	}
@@ -541,11 +855,12 @@ func getGitRepositories(groupName string) ([]GitRepository, error) {
 				Name: repoName,
 				Type: "bare",
 				// クローンURLを生成
-				CloneURL: fmt.Sprintf(GitCloneURLTemplate, GitHostName, groupName, repoName),
+				CloneURL: repositoryCloneURL(cloneHostName(nil), groupName, repoName),
+				CloneURLs: repositoryCloneURLs(cloneHostName(nil), groupName, repoName),
+				Description: getRepositoryDescription(path),
+				RepositoryMetadata: getRepositoryMetadata(groupName, repoName),
 			}
 
-			// 最新のコミット情報を取得
-			repo.LastCommit = getLastCommit(path)
 			repositories = append(repositories, repo)
 		}
 	}
@@ -559,19 +874,6 @@ func getGitRepositories(groupName string) ([]GitRepository, error) {
 		}
 	}
 
-	// 最終コミット日時の降順でソート（新しい順）
-	sort.Slice(repositories, func(i, j int) bool {
-		// コミット情報がない場合は最後に表示
-		if repositories[i].LastCommit == nil {
-			return false
-		}
-		if repositories[j].LastCommit == nil {
-			return true
-		}
-		// 日時を比較して降順にソート
-		return repositories[i].LastCommit.Date.After(repositories[j].LastCommit.Date)
-	})
-
 	return repositories, nil
 }
 
@@ -584,7 +886,7 @@ func isValidGroupName(name string) bool {
 	}
 
 	// ブラックリストに一致するものは除外
-	for _, pattern := range GroupNameBlacklist {
+	for _, pattern := range getGroupNameBlacklist() {
 		if pattern.MatchString(name) {
 			return false
 		}
@@ -639,39 +941,49 @@ func getGroupList() ([]string, error) {
 	return groups, nil
 }
 
-func getLastCommit(repoPath string) *CommitInfo {
-	var cmd *exec.Cmd
+// getLastCommit はリポジトリの HEAD のコミットを取得する（コミットがない場合は nil）
+func getLastCommit(ctx context.Context, repoPath string) *CommitInfo {
+	return getGitBackend().LastCommit(ctx, repoPath)
+}
 
-	cmd = exec.Command("git", "--git-dir="+repoPath, "log", "-1", "--format=%an|%at|%s")
+func (commandGitBackend) LastCommit(ctx context.Context, repoPath string) *CommitInfo {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "-1", "--format=%an|%ae|%at|%s")
+	defer cancel()
 
 	output, err := cmd.Output()
 	if err != nil {
 		return nil
 	}
 
-	parts := strings.Split(strings.TrimSpace(string(output)), "|")
-	if len(parts) != 3 {
+	// 件名に | が含まれていても分割しないよう、分割数を指定する
+	parts := strings.SplitN(strings.TrimSpace(string(output)), "|", 4)
+	if len(parts) != 4 {
 		return nil
 	}
 
-	timestamp := parts[1]
+	timestamp := parts[2]
 	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
 	if err != nil {
 		return nil
 	}
 
 	return &CommitInfo{
-		Author:  parts[0],
-		Date:    time.Unix(unixTime, 0),
-		Message: parts[2],
+		Author:       parts[0],
+		AuthorEmail:  parts[1],
+		Date:         time.Unix(unixTime, 0),
+		Message:      parts[3],
+		AuthorAvatar: newAuthorAvatar(parts[1]),
 	}
 }
 
 // hasCommits はリポジトリにコミットが1件以上あるか確認する
-func hasCommits(repoPath string) bool {
-	var cmd *exec.Cmd
+func hasCommits(ctx context.Context, repoPath string) bool {
+	return getGitBackend().HasCommits(ctx, repoPath)
+}
 
-	cmd = exec.Command("git", "--git-dir="+repoPath, "log", "--all", "-1", "--oneline")
+func (commandGitBackend) HasCommits(ctx context.Context, repoPath string) bool {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--all", "-1", "--oneline")
+	defer cancel()
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -684,204 +996,158 @@ func hasCommits(repoPath string) bool {
 }
 
 // リポジトリ内のファイル一覧を取得（ルートディレクトリの1階層のみ）
-func getRepositoryFiles(repoPath string) ([]GitFile, error) {
-	// コミットが存在しない場合は特別な処理
-	if !hasCommits(repoPath) {
-		// コミットがない場合は、空の配列を返す
-		// フロントエンド側で適切に表示する
-		return []GitFile{}, nil
-	}
-
-	var files []GitFile
-	var cmd *exec.Cmd
-
-	cmd = exec.Command("git", "--git-dir="+repoPath, "ls-tree", "HEAD")
-
-	output, err := cmd.Output()
-	if err != nil {
-		// git ls-tree が失敗した場合でも、コミットがないという確認は済んでいるので
-		// 空の配列を返す
-		return []GitFile{}, nil
-	}
+func getRepositoryFiles(ctx context.Context, repoPath, rev string) ([]GitFile, error) {
+	files, _, err := getDirectoryPage(ctx, repoPath, rev, "", 0, 0)
+	return files, err
+}
 
-	// git ls-tree の出力を解析
-	// 各行の形式: <mode> <type> <object> <file>
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
+// getDirectoryPage は rev の dirPath（空の場合はルート）のエントリを並べた順で offset 番目から limit 件（0 の場合はすべて）と、エントリの総数を返す
+// 並べ替えてから切り出すため、サイズや最終更新日時はページのエントリだけ求める
+func getDirectoryPage(ctx context.Context, repoPath, rev, dirPath string, offset, limit int) ([]GitFile, int, error) {
+	var entries []TreeEntry
+	if dirPath == "" {
+		// コミットが存在しない場合は特別な処理
+		if !hasCommits(ctx, repoPath) {
+			// コミットがない場合は、空の配列を返す
+			// フロントエンド側で適切に表示する
+			return []GitFile{}, 0, nil
 		}
 
-		parts := strings.Fields(line)
-		if len(parts) < 4 {
-			continue
+		var err error
+		entries, err = listTree(ctx, repoPath, rev)
+		if err != nil {
+			// git ls-tree が失敗した場合でも、コミットがないという確認は済んでいるので
+			// 空の配列を返す
+			return []GitFile{}, 0, nil
 		}
-
-		fileType := "file"
-		if parts[1] == "tree" {
-			fileType = "dir"
+	} else {
+		var err error
+		entries, err = listTree(ctx, repoPath, rev+":"+dirPath)
+		if err != nil {
+			return nil, 0, err
 		}
+	}
 
-		// ファイル名を取得（最後のフィールド、複数単語の場合もある）
-		fileName := strings.Join(parts[3:], " ")
+	total := len(entries)
+	sortTreeEntries(entries)
+	if offset >= len(entries) {
+		entries = nil
+	} else {
+		entries = entries[offset:]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
 
-		var fileSize int64 = 0
-		if fileType == "file" {
-			// ファイルサイズを取得（blob の場合のみ）
-			fileSize = getGitObjectSize(repoPath, parts[2], true)
-		}
+	return treeEntriesToGitFiles(ctx, repoPath, rev, dirPath, entries), total, nil
+}
 
-		files = append(files, GitFile{
-			Name:         fileName,
-			Path:         fileName,
-			Type:         fileType,
-			Size:         fileSize,
-			LastModified: getFileLastModified(repoPath, fileName),
-		})
+// treeEntryFileType は ls-tree のエントリの GitFile の種類を返す
+func treeEntryFileType(entry TreeEntry) string {
+	if entry.Type == "tree" {
+		return "dir"
+	} else if entry.Type == "commit" {
+		return "submodule"
+	} else if entry.Mode == SymlinkMode {
+		return "symlink"
 	}
+	return "file"
+}
 
-	// ファイル一覧をソート
-	// 1. ディレクトリを先に
-	// 2. 大文字小文字を区別せずに名前順に
-	sort.Slice(files, func(i, j int) bool {
-		// タイプが異なる場合はディレクトリが先
-		if files[i].Type != files[j].Type {
-			return files[i].Type == "dir"
+// sortTreeEntries は ls-tree のエントリを sortGitFiles と同じ順に並べる
+func sortTreeEntries(entries []TreeEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if orderI, orderJ := fileTypeOrder(treeEntryFileType(entries[i])), fileTypeOrder(treeEntryFileType(entries[j])); orderI != orderJ {
+			return orderI < orderJ
 		}
-		// タイプが同じ場合は名前の昇順（大文字小文字区別なし）
-		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
+		return strings.ToLower(entries[i].Path) < strings.ToLower(entries[j].Path)
 	})
-
-	return files, nil
 }
 
-// 特定のディレクトリ内のファイル一覧を取得する
-func getDirectoryContents(repoPath, dirPath string) ([]GitFile, error) {
-	var files []GitFile
-	var cmd *exec.Cmd
+// treeEntriesToGitFiles は rev の ls-tree のエントリを dirPath 配下の GitFile の一覧に変換してソートする
+func treeEntriesToGitFiles(ctx context.Context, repoPath, rev, dirPath string, entries []TreeEntry) []GitFile {
+	files := []GitFile{}
 
-	cmd = exec.Command("git", "--git-dir="+repoPath, "ls-tree", "HEAD:"+dirPath)
+	// サブモジュールのURLを .gitmodules から取得
+	submoduleURLs := getSubmoduleURLs(ctx, repoPath, rev)
 
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+	// 各エントリの最終更新日時を1回の git log でまとめて取得
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Path)
 	}
+	lastModified := getLastModifiedTimes(ctx, repoPath, rev, dirPath, names)
 
-	// git ls-tree の出力を解析
-	// 各行の形式: <mode> <type> <object> <file>
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		parts := strings.Fields(line)
-		if len(parts) < 4 {
-			continue
-		}
-
-		fileType := "file"
-		if parts[1] == "tree" {
-			fileType = "dir"
-		}
-
-		// ファイル名を取得（最後のフィールド、複数単語の場合もある）
-		fileName := strings.Join(parts[3:], " ")
+	for _, entry := range entries {
+		fileType := treeEntryFileType(entry)
 
 		var fileSize int64 = 0
 		if fileType == "file" {
 			// ファイルサイズを取得（blob の場合のみ）
-			fileSize = getGitObjectSize(repoPath, parts[2], true)
+			fileSize = getGitObjectSize(ctx, repoPath, entry.SHA, true)
 		}
 
-		files = append(files, GitFile{
-			Name:         fileName,
-			Path:         filepath.Join(dirPath, fileName),
+		file := GitFile{
+			Name:         entry.Path,
+			Path:         filepath.Join(dirPath, entry.Path),
 			Type:         fileType,
 			Size:         fileSize,
-			LastModified: getFileLastModified(repoPath, filepath.Join(dirPath, fileName)),
-		})
-	}
-
-	// ファイル一覧をソート
-	// 1. ディレクトリを先に
-	// 2. 大文字小文字を区別せずに名前順に
-	sort.Slice(files, func(i, j int) bool {
-		// タイプが異なる場合はディレクトリが先
-		if files[i].Type != files[j].Type {
-			return files[i].Type == "dir"
+			LastModified: lastModified[entry.Path],
+			Mode:         entry.Mode,
+			SHA:          entry.SHA,
+		}
+		if fileType == "submodule" {
+			file.Commit = entry.SHA
+			file.URL = submoduleURLs[file.Path]
+		}
+		if fileType == "symlink" {
+			file.Target, _ = getSymlinkTarget(ctx, repoPath, entry.SHA)
 		}
-		// タイプが同じ場合は名前の昇順（大文字小文字区別なし）
-		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
-	})
-
-	return files, nil
-}
-
-// ファイルシステムから直接ファイル一覧を取得（git ls-tree が使えない場合のフォールバック）
-func getDirectoryFilesFromFilesystem(dirPath string) ([]GitFile, error) {
-	var files []GitFile
 
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return nil, err
+		files = append(files, file)
 	}
 
-	for _, entry := range entries {
-		// .gitディレクトリはスキップ
-		if entry.Name() == ".git" {
-			continue
-		}
-
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
+	sortGitFiles(files)
 
-		fileType := "file"
-		if entry.IsDir() {
-			fileType = "dir"
-		}
+	return files
+}
 
-		files = append(files, GitFile{
-			Name:         entry.Name(),
-			Path:         entry.Name(),
-			Type:         fileType,
-			Size:         info.Size(),
-			LastModified: info.ModTime(),
-		})
+// fileTypeOrder はファイル一覧での種類ごとの並び順を返す（ディレクトリ、サブモジュール、ファイルの順）
+func fileTypeOrder(fileType string) int {
+	switch fileType {
+	case "dir":
+		return 0
+	case "submodule":
+		return 1
+	default:
+		return 2
 	}
+}
 
-	// ファイル一覧をソート
+// sortGitFiles はファイル一覧をソートする
+// 1. ディレクトリ、サブモジュールを先に
+// 2. 大文字小文字を区別せずに名前順に
+func sortGitFiles(files []GitFile) {
 	sort.Slice(files, func(i, j int) bool {
-		// タイプが異なる場合はディレクトリが先
-		if files[i].Type != files[j].Type {
-			return files[i].Type == "dir"
+		// 並び順が異なる種類の場合は種類の順
+		if orderI, orderJ := fileTypeOrder(files[i].Type), fileTypeOrder(files[j].Type); orderI != orderJ {
+			return orderI < orderJ
 		}
-		// タイプが同じ場合は名前の昇順（大文字小文字区別なし）
+		// 同じ並び順の場合は名前の昇順（大文字小文字区別なし）
 		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
 	})
-
-	return files, nil
 }
 
 // Gitオブジェクトのサイズを取得
-func getGitObjectSize(repoPath, objectHash string, isBare bool) int64 {
-	var cmd *exec.Cmd
-
+// 取得できない場合は 0 を返す
+func getGitObjectSize(ctx context.Context, repoPath, objectHash string, isBare bool) int64 {
+	var size int64
+	var err error
 	if isBare {
-		cmd = exec.Command("git", "--git-dir="+repoPath, "cat-file", "-s", objectHash)
+		size, err = getGitBackend().ObjectSize(ctx, repoPath, objectHash)
 	} else {
-		cmd = exec.Command("git", "-C", repoPath, "cat-file", "-s", objectHash)
+		size, err = catFileSize(ctx, objectHash, "-C", repoPath)
 	}
-
-	output, err := cmd.Output()
-	if err != nil {
-		return 0
-	}
-
-	// 出力を整数に変換
-	size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
 	if err != nil {
 		return 0
 	}
@@ -889,58 +1155,19 @@ func getGitObjectSize(repoPath, objectHash string, isBare bool) int64 {
 	return size
 }
 
-// リポジトリのブランチ一覧を取得
-func getRepositoryBranches(repoPath string) ([]string, error) {
-	var cmd *exec.Cmd
-
-	cmd = exec.Command("git", "--git-dir="+repoPath, "branch", "--list")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-
-	var branches []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		// '*'で始まる場合は現在のブランチ
-		branch := strings.TrimSpace(line)
-		if strings.HasPrefix(branch, "* ") {
-			branch = strings.TrimPrefix(branch, "* ")
-		}
-
-		branches = append(branches, branch)
-	}
-
-	return branches, nil
+func (commandGitBackend) ObjectSize(ctx context.Context, repoPath, object string) (int64, error) {
+	return catFileSize(ctx, object, "--git-dir="+repoPath)
 }
 
-// リポジトリのタグ一覧を取得
-func getRepositoryTags(repoPath string) ([]string, error) {
-	var cmd *exec.Cmd
-
-	cmd = exec.Command("git", "--git-dir="+repoPath, "tag", "--list")
-
-	output, err := cmd.Output()
+// catFileSize は git cat-file -s でオブジェクトの大きさを取得する（repoOptions は --git-dir=... または -C とパス）
+func catFileSize(ctx context.Context, object string, repoOptions ...string) (int64, error) {
+	output, err := gitOutput(ctx, append(repoOptions, "cat-file", "-s", object)...)
 	if err != nil {
-		return nil, err
-	}
-
-	var tags []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		tags = append(tags, strings.TrimSpace(line))
+		return 0, err
 	}
 
-	return tags, nil
+	// 出力を整数に変換
+	return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
 }
 
 // directoryContentsHandler はリポジトリ内の特定のディレクトリの内容を返す
@@ -949,83 +1176,45 @@ func directoryContentsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
 
-	// URLからパラメータを取得
-	encodedPath := strings.TrimPrefix(r.URL.Path, "/api/directory/")
-	
-	// 最初の2つのスラッシュの位置を特定
-	firstSlashPos := strings.Index(encodedPath, "/")
-	if firstSlashPos < 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なパス形式です（グループ名がありません）"})
+	// グループ名、リポジトリ名、ディレクトリパス（省略時はルート）を取得
+	groupName, repoName, dirPath := r.PathValue("groupName"), r.PathValue("repoName"), r.PathValue("dirPath")
+
+	// リポジトリの存在確認
+	fullRepoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
 		return
 	}
-	
-	// リポジトリ名のスラッシュ位置を特定
-	secondSlashPos := strings.Index(encodedPath[firstSlashPos+1:], "/")
-	
-	// グループ名とリポジトリ名を取得
-	encodedGroupName := encodedPath[:firstSlashPos]
-	var encodedRepoName, encodedDirPath string
-	
-	if secondSlashPos < 0 {
-		// ディレクトリパスが指定されていない場合
-		encodedRepoName = encodedPath[firstSlashPos+1:]
-		encodedDirPath = ""
-	} else {
-		// ディレクトリパスが指定されている場合
-		secondSlashPos += firstSlashPos + 1 // path全体の中での位置に調整
-		encodedRepoName = encodedPath[firstSlashPos+1:secondSlashPos]
-		encodedDirPath = encodedPath[secondSlashPos+1:]
-	}
-	
-	// デコード
-	groupName, err := url.PathUnescape(encodedGroupName)
+
+	// ref が指定された場合はそのコミット時点の内容を返す（固定リンク用）
+	rev, err := resolveRevParam(fullRepoPath, r)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なグループ名"})
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
-	
-	repoName, err := url.PathUnescape(encodedRepoName)
+
+	// offset 番目から limit 件（省略時は DefaultDirectoryEntriesPerPage 件）のエントリだけ返す（総数はヘッダーで返す）
+	offset, limit, err := parseDirectoryPage(r.URL.Query())
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリ名"})
-		return
-	}
-	
-	// ディレクトリパス部分のデコード - %2Fもデコードされるように
-	var dirPath string
-	if encodedDirPath != "" {
-		dirPath, err = url.PathUnescape(encodedDirPath)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "無効なディレクトリパス"})
-			return
-		}
-	} else {
-		dirPath = ""
-	}
-
-	// リポジトリの完全パスを構築
-	fullRepoPath := filepath.Join(filepath.Join(GitRepositoryHome, groupName), repoName+".git")
-
-	// リポジトリの存在確認
-	if _, err := os.Stat(fullRepoPath); os.IsNotExist(err) {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	// ベアリポジトリの場合は、特別な処理
 	if dirPath == "" {
 		// ベアリポジトリのルートディレクトリは既に処理済み
-		files, err := getRepositoryFiles(fullRepoPath)
+		files, total, err := getDirectoryPage(r.Context(), fullRepoPath, rev, "", offset, limit)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"error": "ディレクトリ内容の取得に失敗しました: " + err.Error()})
 			return
 		}
 
+		w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+		w.Header().Set("Access-Control-Expose-Headers", TotalCountHeader)
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(files)
 		return
@@ -1044,13 +1233,15 @@ func directoryContentsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// ディレクトリの内容を取得（git ls-treeを使用）
-	files, err := getDirectoryContents(fullRepoPath, dirPath)
+	files, total, err := getDirectoryPage(r.Context(), fullRepoPath, rev, dirPath, offset, limit)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "ディレクトリ内容の取得に失敗しました: " + err.Error()})
 		return
 	}
 
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	w.Header().Set("Access-Control-Expose-Headers", TotalCountHeader)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(files)
 }
@@ -1061,58 +1252,12 @@ func fileContentsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
 
-	// URLからパラメータを取得
-	encodedPath := strings.TrimPrefix(r.URL.Path, "/api/file/")
-	
-	// 最初の2つのスラッシュの位置を特定
-	firstSlashPos := strings.Index(encodedPath, "/")
-	if firstSlashPos < 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なパス形式です（グループ名がありません）"})
-		return
-	}
-	
-	secondSlashPos := strings.Index(encodedPath[firstSlashPos+1:], "/")
-	if secondSlashPos < 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なパス形式です（リポジトリ名がありません）"})
-		return
-	}
-	secondSlashPos += firstSlashPos + 1 // path全体の中での位置に調整
-	
-	// グループ名とリポジトリ名部分を取得
-	encodedGroupName := encodedPath[:firstSlashPos]
-	encodedRepoName := encodedPath[firstSlashPos+1:secondSlashPos]
-	encodedFilePath := encodedPath[secondSlashPos+1:]
-	
-	// デコード
-	groupName, err := url.PathUnescape(encodedGroupName)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なグループ名"})
-		return
-	}
-	
-	repoName, err := url.PathUnescape(encodedRepoName)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリ名"})
-		return
-	}
-	
-	// ファイルパス部分のデコード - %2Fもデコードされるように
-	filePath, err := url.PathUnescape(encodedFilePath)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なファイルパス"})
-		return
-	}
-	
-	// リポジトリの完全パスを構築
-	fullRepoPath := filepath.Join(filepath.Join(GitRepositoryHome, groupName), repoName+".git")
+	// グループ名、リポジトリ名、ファイルパスを取得
+	groupName, repoName, filePath := r.PathValue("groupName"), r.PathValue("repoName"), r.PathValue("filePath")
 
 	// リポジトリの存在確認
-	if _, err := os.Stat(fullRepoPath); os.IsNotExist(err) {
+	fullRepoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
 		return
@@ -1138,14 +1283,88 @@ func fileContentsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ref が指定された場合はそのコミット時点の内容を返す（固定リンク用）
+	rev, err := resolveRevParam(fullRepoPath, r)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// シンボリックリンクの場合はリンク先を返す
+	if entry, err := getTreeEntry(r.Context(), fullRepoPath, rev, filePath); err == nil && entry.Mode == SymlinkMode {
+		target, err := getSymlinkTarget(r.Context(), fullRepoPath, entry.SHA)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "リンク先の取得に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"isBinary":  false,
+			"isSymlink": true,
+			"target":    target,
+			"content":   "",
+			"message":   "シンボリックリンクです: " + target,
+		})
+		return
+	}
+
+	// raw=1 の場合は画像・PDFを Content-Type 付きでそのまま返す
+	if r.URL.Query().Get("raw") != "" {
+		serveRawBlob(r.Context(), w, fullRepoPath, rev, filePath)
+		return
+	}
+
+	// 画像・PDFはインライン表示用に種別だけを返す（内容は raw=1 で取得する）
+	if previewType, _ := getPreviewType(filePath); previewType != "" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"isBinary": true,
+			"preview":  previewType,
+			"content":  "",
+		})
+		return
+	}
+
 	// ファイル内容の取得
-	content, isBinary, err := getFileContent(fullRepoPath, filePath, isNormal, isBare)
+	content, isBinary, truncated, err := getFileContent(r.Context(), fullRepoPath, rev, filePath, isNormal, isBare)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "ファイル内容の取得に失敗しました: " + err.Error()})
 		return
 	}
 
+	// Git LFS のポインタファイルの場合は保存先から実体を読み込む
+	if pointer, ok := parseLFSPointer(content); ok && !isBinary {
+		response := map[string]interface{}{
+			"isBinary": false,
+			"isLfs":    true,
+			"lfs":      pointer,
+			"content":  "",
+		}
+
+		data, lfsTruncated, err := readLFSObject(fullRepoPath, pointer)
+		switch {
+		case err != nil:
+			response["message"] = "LFSオブジェクトが保存先にないため表示できません"
+		case isBinaryData(data):
+			response["isBinary"] = true
+			response["message"] = "バイナリファイルのため表示できません"
+		default:
+			response["content"] = string(data)
+			if lfsTruncated {
+				response["truncated"] = true
+				response["size"] = pointer.Size
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
 	// バイナリファイルの場合は特別な処理
 	if isBinary {
 		w.WriteHeader(http.StatusOK)
@@ -1157,6 +1376,18 @@ func fileContentsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// サイズ上限を超えたファイルは先頭部分と全体のサイズを返す
+	if truncated {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"isBinary":  false,
+			"content":   content,
+			"truncated": true,
+			"size":      getGitObjectSize(r.Context(), fullRepoPath, rev+":"+filePath, true),
+		})
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"isBinary": false,
@@ -1165,70 +1396,144 @@ func fileContentsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // ファイル内容を取得する
-func getFileContent(repoPath, filePath string, isNormal, isBare bool) (string, bool, error) {
+// 内容は MaxFileContentSize までしか読み込まず、超えた場合は truncated を true にする
+func getFileContent(ctx context.Context, repoPath, rev, filePath string, isNormal, isBare bool) (content string, isBinary bool, truncated bool, err error) {
 	var cmd *exec.Cmd
-	var cmdCheck *exec.Cmd
+	var cancel context.CancelFunc
 
 	// ファイルタイプの確認（バイナリかどうか）
+	var checkOutput []byte
 	if isBare {
-		cmdCheck = exec.Command("git", "--git-dir="+repoPath, "check-attr", "binary", "HEAD:"+filePath)
+		checkOutput, err = gitOutput(ctx, "--git-dir="+repoPath, "check-attr", "binary", rev+":"+filePath)
 	} else {
-		cmdCheck = exec.Command("git", "-C", repoPath, "check-attr", "binary", "--", filePath)
+		checkOutput, err = gitOutput(ctx, "-C", repoPath, "check-attr", "binary", "--", filePath)
 	}
-
-	checkOutput, err := cmdCheck.Output()
 	if err != nil {
-		return "", false, err
+		return "", false, false, err
 	}
 
 	// バイナリファイルかどうかのチェック
-	isBinary := strings.Contains(string(checkOutput), "binary: set")
+	isBinary = strings.Contains(string(checkOutput), "binary: set")
 
 	// バイナリファイルの場合は空を返す
 	if isBinary {
-		return "", true, nil
+		return "", true, false, nil
 	}
 
 	// ファイル内容の取得
 	if isBare {
-		cmd = exec.Command("git", "--git-dir="+repoPath, "show", "HEAD:"+filePath)
+		cmd, cancel = gitCommand(ctx, "--git-dir="+repoPath, "show", rev+":"+filePath)
 	} else {
-		cmd = exec.Command("git", "-C", repoPath, "show", "HEAD:"+filePath)
+		cmd, cancel = gitCommand(ctx, "-C", repoPath, "show", rev+":"+filePath)
 	}
+	defer cancel()
 
-	output, err := cmd.Output()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", false, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", false, false, err
+	}
+
+	// 全体をメモリに読み込まないよう、上限を1バイト超えるところまでだけ読む
+	output, err := io.ReadAll(io.LimitReader(stdout, MaxFileContentSize+1))
 	if err != nil {
-		return "", false, err
+		cmd.Process.Kill()
+		cmd.Wait()
+		return "", false, false, err
 	}
 
-	return string(output), false, nil
+	if int64(len(output)) > MaxFileContentSize {
+		// 残りの出力は不要なのでプロセスを終了させる
+		cmd.Process.Kill()
+		cmd.Wait()
+		return string(truncateUTF8(output, MaxFileContentSize)), false, true, nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return "", false, false, err
+	}
+
+	return string(output), false, false, nil
 }
 
-// ファイルの最終更新日時を取得する
-func getFileLastModified(repoPath string, filePath string) time.Time {
-	var cmd *exec.Cmd
+// truncateUTF8 は data を最大 size バイトに切り詰める（マルチバイト文字の途中では切らない）
+func truncateUTF8(data []byte, size int64) []byte {
+	if int64(len(data)) <= size {
+		return data
+	}
+
+	data = data[:size]
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				data = data[:i]
+			}
+			break
+		}
+	}
 
-	// git logコマンドでファイルの最終更新日時を取得
-	cmd = exec.Command("git", "--git-dir="+repoPath, "log", "-1", "--format=%at", "--", filePath)
+	return data
+}
 
-	output, err := cmd.Output()
+// ファイルの最終更新日時を取得する
+func getFileLastModified(ctx context.Context, repoPath, rev, filePath string) time.Time {
+	modified, err := getGitBackend().FileLastModified(ctx, repoPath, rev, filePath)
 	if err != nil {
 		// エラーの場合は現在時刻を返す
 		return time.Now()
 	}
 
+	return modified
+}
+
+func (commandGitBackend) FileLastModified(ctx context.Context, repoPath, rev, filePath string) (time.Time, error) {
+	// git logコマンドで rev 時点のファイルの最終更新日時を取得
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "-1", "--format=%at", rev, "--", filePath)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+
 	timestamp := strings.TrimSpace(string(output))
 	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
 	if err != nil {
-		// 解析エラーの場合は現在時刻を返す
-		return time.Now()
+		return time.Time{}, err
 	}
 
-	return time.Unix(unixTime, 0)
+	return time.Unix(unixTime, 0), nil
 }
 
 // validateRepositoryName は新規リポジトリ名のバリデーション
 func validateRepositoryName(name string, group string) error {
+	if err := validateRepositoryNameFormat(name); err != nil {
+		return err
+	}
+
+	// {name}.wiki は Wiki 用のリポジトリの名前として予約されている（バックアップからの復元では使える）
+	if isWikiRepositoryName(name) {
+		return fmt.Errorf("リポジトリ名の末尾に '%s' は使用できません", WikiRepositorySuffix)
+	}
+
+	// グループ名が指定されていない場合はデフォルトの "git" を使用
+	if group == "" {
+		group = "git"
+	}
+	
+	// 既存のリポジトリと名前が重複していないかチェック
+	repoPath := filepath.Join(filepath.Join(GitRepositoryHome, group), name+".git")
+	if _, err := os.Stat(repoPath); err == nil {
+		return fmt.Errorf("リポジトリ '%s' は既に存在します", name)
+	}
+
+	return nil
+}
+
+// validateRepositoryNameFormat はリポジトリ名に使用できない文字が含まれていないか確認する
+func validateRepositoryNameFormat(name string) error {
 	// 空のチェック
 	if name == "" {
 		return fmt.Errorf("リポジトリ名が指定されていません")
@@ -1246,23 +1551,14 @@ func validateRepositoryName(name string, group string) error {
 		strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
 		return fmt.Errorf("リポジトリ名の先頭や末尾にスペースやドットは使用できません")
 	}
-	
-	// グループ名が指定されていない場合はデフォルトの "git" を使用
-	if group == "" {
-		group = "git"
-	}
-	
-	// 既存のリポジトリと名前が重複していないかチェック
-	repoPath := filepath.Join(filepath.Join(GitRepositoryHome, group), name+".git")
-	if _, err := os.Stat(repoPath); err == nil {
-		return fmt.Errorf("リポジトリ '%s' は既に存在します", name)
-	}
 
 	return nil
 }
 
 // createRepository は新規ベアリポジトリを作成する
-func createRepository(name string, group string) error {
+// defaultBranch は HEAD が指すブランチ（空の場合は DefaultBranchName）
+// テンプレートでファイルを選んだ場合は、それらを含む最初のコミットも作成する
+func createRepository(ctx context.Context, name string, group string, defaultBranch string, template RepositoryTemplate) error {
 	// グループ名が指定されていない場合はsplitRepositoryNameでグループ名を取得してみる
 	// これは後方互換性のためと、name内にグループパスが含まれている場合の対応
 	var groupName, baseName string
@@ -1276,14 +1572,24 @@ func createRepository(name string, group string) error {
 	// リポジトリのパスを構築
 	repoPath := filepath.Join(filepath.Join(GitRepositoryHome, groupName), baseName+".git")
 
+	// 同じ名前のリポジトリのメタデータが残っていれば、引き継がないよう削除する
+	if err := deleteRepositoryMetadata(groupName, baseName); err != nil {
+		return fmt.Errorf("古いメタデータの削除に失敗しました: %w", err)
+	}
+
 	// ディレクトリを作成
 	err := os.MkdirAll(repoPath, 0755)
 	if err != nil {
 		return fmt.Errorf("ディレクトリの作成に失敗しました: %w", err)
 	}
 
-	// git init --bare コマンドを実行
-	cmd := exec.Command("git", "init", "--bare", repoPath, "-b", "main")
+	if defaultBranch == "" {
+		defaultBranch = getDefaultBranchName()
+	}
+
+	// git init --bare コマンドを実行（ホストの init.defaultBranch ではなく指定したブランチにする）
+	cmd, cancel := gitCommand(ctx, "init", "--bare", "--initial-branch="+defaultBranch, repoPath)
+	defer cancel()
 	err = cmd.Run()
 	if err != nil {
 		// 失敗した場合はディレクトリを削除してクリーンアップ
@@ -1291,6 +1597,25 @@ func createRepository(name string, group string) error {
 		return fmt.Errorf("リポジトリの初期化に失敗しました: %w", err)
 	}
 
+	// ベアリポジトリは既定で reflog を記録しないため、強制プッシュなどから復旧できるよう有効にする
+	if err := enableReflog(ctx, repoPath); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
+	// グループにプッシュのチャット通知がある場合は post-receive フックを設置する
+	if err := syncPostReceiveHook(ctx, repoPath); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
+	// 最初のコミットを作成できない場合は、中途半端なリポジトリを残さないよう削除する
+	if err := commitRepositoryTemplate(ctx, repoPath, groupName, baseName, template); err != nil {
+		os.RemoveAll(repoPath)
+		return err
+	}
+
+	// 同じ名前で削除したリポジトリの索引が残っていれば削除する
+	invalidateRepositoryIndex(repoPath)
+
 	return nil
 }
 
@@ -1329,6 +1654,18 @@ func deleteRepository(name string) error {
     if renameErr != nil {
         return fmt.Errorf("リポジトリの名前変更に失敗しました: %w", renameErr)
     }
+    invalidateRepositoryIndex(repoPath)
+
+    // 同じ名前で作り直したリポジトリが公開範囲やイシューを引き継がないよう、メタデータもゴミ箱用の名前に移す
+    if err := trashRepositoryMetadata(groupName, baseName); err != nil {
+        log.Printf("警告: リポジトリ '%s' のメタデータの移動に失敗しました: %v", name, err)
+    }
+
+    // 削除日時を記録する（ゴミ箱の保持期間はこの更新日時から計算される）
+    now := time.Now()
+    if err := os.Chtimes(newPath, now, now); err != nil {
+        log.Printf("警告: 削除日時の記録に失敗しました: %v", err)
+    }
 
     // 権限を変更（読み書き禁止: chmod 000）
     chmodErr := os.Chmod(newPath, 0000)
@@ -1337,9 +1674,74 @@ func deleteRepository(name string) error {
         log.Printf("警告: リポジトリのアクセス権限変更に失敗しました: %v", chmodErr)
     }
 
+    // Wiki もリポジトリと一緒にゴミ箱へ移動する
+    trashWikiRepository(name)
+
     return nil
 }
 
+// deleteRepositoryRequest はリポジトリをゴミ箱へ移動し、結果を書き込む
+func deleteRepositoryRequest(w http.ResponseWriter, r *http.Request, groupName, repoName string) {
+	if _, ok := findAccessibleRepository(r, groupName, repoName); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if err := deleteRepository(groupName + "/" + repoName); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	notifyRepositoryEvent(ChatEventRepositoryDeleted, groupName, repoName)
+	recordAudit(r, "repository.delete", groupName, repoName, nil)
+
+	// 成功レスポンス
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリが削除されました"})
+}
+
+// renameRepository はグループ内でリポジトリの名前を変更する
+// Wiki、メタデータ、マージリクエスト、イシュー、一覧の索引も新しい名前に移す
+func renameRepository(groupName, repoName, newName string) error {
+	if isWikiRepositoryName(repoName) {
+		return fmt.Errorf("Wiki のリポジトリの名前は変更できません")
+	}
+	if err := validateRepositoryName(newName, groupName); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(wikiRepositoryPath(groupName, newName)); err == nil {
+		return fmt.Errorf("リポジトリ '%s' の Wiki が既に存在します", newName)
+	}
+
+	repoPath := filepath.Join(GitRepositoryHome, groupName, repoName+".git")
+	newPath := filepath.Join(GitRepositoryHome, groupName, newName+".git")
+	if err := os.Rename(repoPath, newPath); err != nil {
+		return fmt.Errorf("リポジトリの名前変更に失敗しました: %w", err)
+	}
+
+	// メタデータを移せなかった場合は、名前を元に戻してメタデータと食い違わないようにする
+	if err := moveRepositoryMetadata(groupName, repoName, groupName, newName); err != nil {
+		if renameErr := os.Rename(newPath, repoPath); renameErr != nil {
+			log.Printf("警告: リポジトリ '%s/%s' の名前を元に戻せませんでした: %v", groupName, newName, renameErr)
+		}
+		return fmt.Errorf("メタデータの移動に失敗しました: %w", err)
+	}
+	invalidateRepositoryCache(repoPath)
+	invalidateRepositoryIndex(repoPath)
+	invalidateRepositoryIndex(newPath)
+
+	// Wiki もリポジトリと一緒に名前を変更する
+	if _, err := os.Stat(wikiRepositoryPath(groupName, repoName)); err == nil {
+		if err := os.Rename(wikiRepositoryPath(groupName, repoName), wikiRepositoryPath(groupName, newName)); err != nil {
+			log.Printf("警告: リポジトリ '%s/%s' の Wiki の名前変更に失敗しました: %v", groupName, repoName, err)
+		}
+	}
+
+	return nil
+}
+
 // changeHeadBranchHandler はリポジトリのHEADブランチを変更するAPIハンドラー
 func changeHeadBranchHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1359,18 +1761,13 @@ func changeHeadBranchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// リポジトリパスを取得（/api/head/以降の部分）
-	encodedPath := strings.TrimPrefix(r.URL.Path, "/api/head/")
-	// URLエンコードされたパスをデコード
-	decodedPath, err := url.PathUnescape(encodedPath)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリパス"})
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	if _, ok := findAccessibleRepository(r, groupName, repoName); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
 		return
 	}
 
-	groupName, repoName := splitRepositoryName(decodedPath)
-
 	// リクエストボディからブランチ名を取得
 	var requestBody map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -1387,43 +1784,25 @@ func changeHeadBranchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// HEADブランチを変更
-	err = changeRepositoryHead(groupName, repoName, branchName)
-	if err != nil {
+	if err := changeRepositoryHead(r.Context(), groupName, repoName, branchName); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
+	recordAudit(r, "repository.update", groupName, repoName, map[string]string{"defaultBranch": branchName})
+
 	// 成功レスポンス
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "HEADブランチが変更されました"})
 }
 
 // changeRepositoryHead はリポジトリのHEADブランチを変更する
-func changeRepositoryHead(groupName, repoName, branchName string) error {
+func changeRepositoryHead(ctx context.Context, groupName, repoName, branchName string) error {
 	repoPath := filepath.Join(GitRepositoryHome, groupName, repoName+".git")
 	
-	// リポジトリの存在確認
-	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
-		return fmt.Errorf("リポジトリが見つかりません: %s", repoPath)
-	}
-
-	// 指定されたブランチが存在するかチェック
-	branchRefPath := filepath.Join(repoPath, "refs", "heads", branchName)
-	if _, err := os.Stat(branchRefPath); os.IsNotExist(err) {
-		return fmt.Errorf("ブランチ '%s' が見つかりません", branchName)
-	}
-
-	// HEADファイルを更新
-	headFilePath := filepath.Join(repoPath, "HEAD")
-	headContent := fmt.Sprintf("ref: refs/heads/%s\n", branchName)
-	
-	err := os.WriteFile(headFilePath, []byte(headContent), 0644)
-	if err != nil {
-		return fmt.Errorf("HEADファイルの更新に失敗しました: %w", err)
-	}
-
-	return nil
+	// git symbolic-ref でHEADを更新（packed-refs のブランチにも対応）
+	return setDefaultBranch(ctx, repoPath, branchName)
 }
 
 // getCurrentHeadBranch はリポジトリの現在のHEADブランチを取得する
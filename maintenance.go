@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaintenanceScanInterval はメンテナンスが必要なリポジトリを探す間隔
+var MaintenanceScanInterval = 6 * time.Hour
+
+// MaintenanceMaxAge は最後のメンテナンスからこの期間を過ぎたリポジトリを自動メンテナンスの対象にする（0 の場合は自動メンテナンスを行わない）
+var MaintenanceMaxAge = 7 * 24 * time.Hour
+
+// maintenanceConfigKeys はメンテナンス結果を保存するgit configのキー
+// 結果はリポジトリ自身の config ファイルに保存される
+const (
+	maintenanceLastRunKey  = "guilty.maintenance.lastRun"
+	maintenanceTaskKey     = "guilty.maintenance.task"
+	maintenanceSuccessKey  = "guilty.maintenance.success"
+	maintenanceMessageKey  = "guilty.maintenance.message"
+	maintenanceDurationKey = "guilty.maintenance.duration"
+)
+
+// maintenanceTasks はメンテナンスの種類と実行するgitコマンドの引数
+var maintenanceTasks = map[string][]string{
+	"gc":     {"gc", "--quiet"},
+	"repack": {"repack", "-a", "-d", "--quiet"},
+}
+
+// MaintenanceStatus はリポジトリの最後のメンテナンス結果を表す
+type MaintenanceStatus struct {
+	LastRun  *time.Time `json:"lastRun"`  // 最後に実行した日時（未実行の場合は null）
+	Task     string     `json:"task"`     // "gc" または "repack"
+	Success  bool       `json:"success"`  // 成功したかどうか
+	Message  string     `json:"message"`  // 失敗した場合のエラー出力
+	Duration float64    `json:"duration"` // 実行時間（秒）
+	Running  bool       `json:"running"`  // 現在実行中かどうか
+}
+
+// MaintenanceRequest はメンテナンス実行リクエスト用の構造体
+type MaintenanceRequest struct {
+	Task string `json:"task"` // "gc"（省略時）または "repack"
+}
+
+// runningMaintenance は現在メンテナンス中のリポジトリのパス
+var runningMaintenance sync.Map
+
+// maintenanceHandler はメンテナンスの状態取得と実行を行う
+//
+//	GET  /api/repository/{group}/{repo}/maintenance  最後のメンテナンス結果
+//	POST /api/repository/{group}/{repo}/maintenance  {"task": "gc" | "repack"}
+func maintenanceHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(getMaintenanceStatus(r.Context(), repoPath))
+
+	case http.MethodPost:
+		var req MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if req.Task == "" {
+			req.Task = "gc"
+		}
+		if _, ok := maintenanceTasks[req.Task]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不明なメンテナンスです: " + req.Task})
+			return
+		}
+
+		// 時間がかかるためバックグラウンドで実行し、結果は GET で確認する
+		if _, running := runningMaintenance.LoadOrStore(repoPath, true); running {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "メンテナンスが既に実行中です"})
+			return
+		}
+		go func() {
+			defer runningMaintenance.Delete(repoPath)
+			// レスポンスを返した後も続けるため、リクエストではなくサーバーの context で実行する
+			runMaintenance(serverContext, repoPath, req.Task)
+		}()
+
+		recordRepositoryAudit(r, "maintenance.run", map[string]string{"task": req.Task})
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "メンテナンスを開始しました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// runMaintenance は git gc / git repack を実行し、結果をリポジトリの config に保存する
+func runMaintenance(ctx context.Context, repoPath, task string) error {
+	args := append([]string{"--git-dir=" + repoPath}, maintenanceTasks[task]...)
+
+	cmd, cancel := gitMaintenanceCommand(ctx, args...)
+	defer cancel()
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	duration := time.Since(start)
+
+	message := ""
+	if err != nil {
+		// git config --get-regexp の出力を行単位で読むため、改行は空白に置き換える
+		message = strings.ReplaceAll(strings.TrimSpace(string(output)), "\n", " ")
+		if message == "" {
+			message = err.Error()
+		}
+		log.Printf("警告: %s のメンテナンス（%s）に失敗しました: %s", repoPath, task, message)
+	}
+
+	values := map[string]string{
+		maintenanceLastRunKey:  strconv.FormatInt(start.Unix(), 10),
+		maintenanceTaskKey:     task,
+		maintenanceSuccessKey:  strconv.FormatBool(err == nil),
+		maintenanceMessageKey:  message,
+		maintenanceDurationKey: strconv.FormatFloat(duration.Seconds(), 'f', 3, 64),
+	}
+	for key, value := range values {
+		if output, err := gitCombinedOutput(ctx, "--git-dir="+repoPath, "config", key, value); err != nil {
+			return fmt.Errorf("メンテナンス結果の保存に失敗しました: %s", strings.TrimSpace(string(output)))
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("メンテナンス（%s）に失敗しました: %s", task, message)
+	}
+	return nil
+}
+
+// getMaintenanceStatus はリポジトリの config から最後のメンテナンス結果を取得する
+func getMaintenanceStatus(ctx context.Context, repoPath string) *MaintenanceStatus {
+	status := &MaintenanceStatus{}
+	_, status.Running = runningMaintenance.Load(repoPath)
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--get-regexp", `^guilty\.maintenance\.`)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		// まだ一度も実行していない
+		return status
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		// git config はキーの変数名部分を小文字で出力するため、大文字小文字を区別せずに比較する
+		key, value, _ := strings.Cut(line, " ")
+		switch strings.ToLower(key) {
+		case strings.ToLower(maintenanceLastRunKey):
+			if unixTime, err := strconv.ParseInt(value, 10, 64); err == nil {
+				lastRun := time.Unix(unixTime, 0)
+				status.LastRun = &lastRun
+			}
+		case strings.ToLower(maintenanceTaskKey):
+			status.Task = value
+		case strings.ToLower(maintenanceSuccessKey):
+			status.Success = value == "true"
+		case strings.ToLower(maintenanceMessageKey):
+			status.Message = value
+		case strings.ToLower(maintenanceDurationKey):
+			status.Duration, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	return status
+}
+
+// needsMaintenance は最後のメンテナンスから MaintenanceMaxAge を過ぎ、パックされていないオブジェクトがあるか確認する
+func needsMaintenance(ctx context.Context, repoPath string) bool {
+	status := getMaintenanceStatus(ctx, repoPath)
+	if status.LastRun != nil && time.Since(*status.LastRun) < MaintenanceMaxAge {
+		return false
+	}
+
+	size, err := getRepositorySize(ctx, repoPath)
+	if err != nil {
+		return false
+	}
+
+	return size.LooseObjects > 0 || size.Packs > 1
+}
+
+// startMaintenanceScheduler は最近メンテナンスされていないリポジトリに git gc を実行するバックグラウンド処理を開始する
+func startMaintenanceScheduler() {
+	if MaintenanceMaxAge <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			runScheduledMaintenance(serverContext)
+			time.Sleep(MaintenanceScanInterval)
+		}
+	}()
+}
+
+// runScheduledMaintenance はすべてのグループのリポジトリを確認し、必要なものを順に git gc する
+func runScheduledMaintenance(ctx context.Context) {
+	groups, err := getGroupList()
+	if err != nil {
+		log.Printf("警告: グループ一覧の取得に失敗しました: %v", err)
+		return
+	}
+
+	for _, groupName := range groups {
+		repos, err := getGitRepositories(ctx, groupName)
+		if err != nil {
+			continue
+		}
+
+		for _, repo := range repos {
+			repoPath, ok := findRepository(groupName, repo.Name)
+			if !ok || !needsMaintenance(ctx, repoPath) {
+				continue
+			}
+
+			if _, running := runningMaintenance.LoadOrStore(repoPath, true); running {
+				continue
+			}
+			if err := runMaintenance(ctx, repoPath, "gc"); err == nil {
+				log.Printf("%s/%s のメンテナンス（gc）を実行しました", groupName, repo.Name)
+			}
+			runningMaintenance.Delete(repoPath)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Markdown の簡易レンダラー
+// イシューの本文やWikiのページで使う記法（見出し、段落、強調、コード、リンク、画像、引用、リスト、表、水平線）に対応する
+// 生のHTMLはすべてエスケープし、リンクは安全なスキームだけを許可する
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)[ \t]*#*[ \t]*$`)
+	mdFencePattern     = regexp.MustCompile("^[ \t]{0,3}(```+|~~~+)[ \t]*([^` \t]*)")
+	mdRulePattern      = regexp.MustCompile(`^[ \t]{0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	mdListPattern      = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	mdTaskPattern      = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	mdTableRulePattern = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdURLPattern       = regexp.MustCompile(`^https?://[^\s<>"]+`)
+)
+
+// renderMarkdown は Markdown を HTML に変換する
+func renderMarkdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	return renderMarkdownBlocks(strings.Split(source, "\n"))
+}
+
+// renderMarkdownBlocks は行の並びをブロック要素に分けて HTML に変換する
+func renderMarkdownBlocks(lines []string) string {
+	var out strings.Builder
+	var paragraph []string
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderMarkdownLines(paragraph) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+
+		case mdFencePattern.MatchString(line):
+			flushParagraph()
+			m := mdFencePattern.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if m[2] != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(m[2]))
+			}
+			out.WriteString(fmt.Sprintf("<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n"))))
+
+		case mdHeadingPattern.MatchString(line):
+			flushParagraph()
+			m := mdHeadingPattern.FindStringSubmatch(line)
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", len(m[1]), renderMarkdownInline(m[2]), len(m[1])))
+
+		case mdRulePattern.MatchString(line):
+			flushParagraph()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(text, " "))
+			}
+			i--
+			out.WriteString("<blockquote>\n" + renderMarkdownBlocks(quoted) + "</blockquote>\n")
+
+		case mdListPattern.MatchString(line) && (len(paragraph) == 0 || strings.TrimSpace(mdListPattern.FindStringSubmatch(line)[3]) != ""):
+			flushParagraph()
+			i = renderMarkdownList(&out, lines, i) - 1
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdTableRulePattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flushParagraph()
+			i = renderMarkdownTable(&out, lines, i) - 1
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flushParagraph()
+
+	return out.String()
+}
+
+// renderMarkdownList は start 行から始まるリストを HTML に変換し、リストの次の行の位置を返す
+// 字下げした行はその項目の続き（入れ子のリストを含む）として扱う
+func renderMarkdownList(out *strings.Builder, lines []string, start int) int {
+	first := mdListPattern.FindStringSubmatch(lines[start])
+	indent := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	out.WriteString("<" + tag + ">\n")
+
+	i := start
+	for i < len(lines) {
+		m := mdListPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || (m[2][0] >= '0' && m[2][0] <= '9') != ordered {
+			break
+		}
+
+		// 項目の本文と、字下げされた続きの行を集める
+		item := []string{m[3]}
+		loose := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// 空行のあとも字下げが続く場合は項目の続き
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) > indent {
+					item = append(item, "")
+					loose = true
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) <= indent {
+				break
+			}
+			item = append(item, strings.TrimPrefix(line, strings.Repeat(" ", indent+2)))
+		}
+
+		content := renderMarkdownBlocks(item)
+		// 1段落だけの項目は <p> で囲まない
+		if !loose && strings.HasPrefix(content, "<p>") && strings.Count(content, "<p>") == 1 {
+			content = strings.Replace(strings.Replace(content, "<p>", "", 1), "</p>\n", "", 1)
+		}
+		if task := mdTaskPattern.FindStringSubmatch(item[0]); task != nil {
+			checked := ""
+			if task[1] != " " {
+				checked = " checked"
+			}
+			content = strings.Replace(content, html.EscapeString(task[0]), fmt.Sprintf(`<input type="checkbox" disabled%s> `, checked), 1)
+		}
+		out.WriteString("<li>" + strings.TrimSuffix(content, "\n") + "</li>\n")
+
+		// 空行をはさんで同じリストが続く場合
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) {
+			if next := mdListPattern.FindStringSubmatch(lines[i+1]); next != nil && len(next[1]) == indent {
+				i++
+			}
+		}
+	}
+
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderMarkdownTable は start 行から始まる表を HTML に変換し、表の次の行の位置を返す
+func renderMarkdownTable(out *strings.Builder, lines []string, start int) int {
+	header := splitMarkdownTableRow(lines[start])
+	rules := splitMarkdownTableRow(lines[start+1])
+
+	aligns := make([]string, len(header))
+	for j := range aligns {
+		if j >= len(rules) {
+			break
+		}
+		rule := strings.TrimSpace(rules[j])
+		switch {
+		case strings.HasPrefix(rule, ":") && strings.HasSuffix(rule, ":"):
+			aligns[j] = ` style="text-align: center"`
+		case strings.HasSuffix(rule, ":"):
+			aligns[j] = ` style="text-align: right"`
+		case strings.HasPrefix(rule, ":"):
+			aligns[j] = ` style="text-align: left"`
+		}
+	}
+
+	out.WriteString("<table>\n<thead>\n<tr>")
+	for j, cell := range header {
+		out.WriteString(fmt.Sprintf("<th%s>%s</th>", aligns[j], renderMarkdownInline(cell)))
+	}
+	out.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	i := start + 2
+	for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+		cells := splitMarkdownTableRow(lines[i])
+		out.WriteString("<tr>")
+		for j := range header {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			out.WriteString(fmt.Sprintf("<td%s>%s</td>", aligns[j], renderMarkdownInline(cell)))
+		}
+		out.WriteString("</tr>\n")
+	}
+
+	out.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// splitMarkdownTableRow は表の行をセルに分割する（先頭と末尾の | は省略できる）
+func splitMarkdownTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := strings.Split(line, "|")
+	for j := range cells {
+		cells[j] = strings.TrimSpace(cells[j])
+	}
+	return cells
+}
+
+// leadingSpaces は行頭の空白の数を返す（タブは4文字とする）
+func leadingSpaces(line string) int {
+	count := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			count++
+		case '\t':
+			count += 4
+		default:
+			return count
+		}
+	}
+	return count
+}
+
+// renderMarkdownLines は段落の各行をインライン要素として変換する
+// 行末の2つ以上の空白またはバックスラッシュは改行にする
+func renderMarkdownLines(lines []string) string {
+	rendered := make([]string, len(lines))
+	for j, line := range lines {
+		hardBreak := j < len(lines)-1 && (strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\"))
+		line = strings.TrimSpace(line)
+		if hardBreak {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		rendered[j] = renderMarkdownInline(line)
+		if hardBreak {
+			rendered[j] += "<br>"
+		}
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// renderMarkdownInline は強調、コード、リンク、画像などのインライン要素を変換する
+func renderMarkdownInline(text string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|~<>", rune(rest[1])):
+			out.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += ticks + end + ticks
+				continue
+			}
+			out.WriteString(rest[:ticks])
+			i += ticks
+			continue
+
+		case strings.HasPrefix(rest, "!["):
+			if label, target, n, ok := parseMarkdownLink(rest[1:]); ok {
+				if src, ok := safeMarkdownURL(target); ok {
+					out.WriteString(fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(src), html.EscapeString(label)))
+					i += 1 + n
+					continue
+				}
+			}
+
+		case rest[0] == '[':
+			if label, target, n, ok := parseMarkdownLink(rest); ok {
+				if href, ok := safeMarkdownURL(target); ok {
+					out.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), renderMarkdownInline(label)))
+					i += n
+					continue
+				}
+			}
+
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 {
+				if href := rest[1:end]; mdURLPattern.MatchString(href) && len(mdURLPattern.FindString(href)) == len(href) {
+					out.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(href)))
+					i += end + 1
+					continue
+				}
+			}
+
+		case (rest[0] == 'h') && (i == 0 || !isMarkdownWordChar(text[i-1])) && mdURLPattern.MatchString(rest):
+			// 文中のURLは自動的にリンクにする（末尾の句読点は含めない）
+			href := strings.TrimRight(mdURLPattern.FindString(rest), ".,:;!?)")
+			out.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(href)))
+			i += len(href)
+			continue
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if inner, n, ok := findMarkdownDelimited(text, i, rest[:2]); ok {
+				out.WriteString("<strong>" + renderMarkdownInline(inner) + "</strong>")
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if inner, n, ok := findMarkdownDelimited(text, i, "~~"); ok {
+				out.WriteString("<del>" + renderMarkdownInline(inner) + "</del>")
+				i += n
+				continue
+			}
+
+		case rest[0] == '*' || rest[0] == '_':
+			if inner, n, ok := findMarkdownDelimited(text, i, rest[:1]); ok {
+				out.WriteString("<em>" + renderMarkdownInline(inner) + "</em>")
+				i += n
+				continue
+			}
+		}
+
+		out.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+
+	return out.String()
+}
+
+// findMarkdownDelimited は text の i 文字目から始まる delimiter で囲まれた部分を探す
+// 囲まれた内容と、閉じる delimiter までの長さを返す
+// _ は snake_case のような単語の途中では強調とみなさない
+func findMarkdownDelimited(text string, i int, delimiter string) (string, int, bool) {
+	if delimiter[0] == '_' && i > 0 && isMarkdownWordChar(text[i-1]) {
+		return "", 0, false
+	}
+
+	body := text[i+len(delimiter):]
+	if body == "" || body[0] == ' ' {
+		return "", 0, false
+	}
+
+	for offset := 0; offset < len(body); {
+		end := strings.Index(body[offset:], delimiter)
+		if end < 0 {
+			return "", 0, false
+		}
+		end += offset
+
+		// 閉じる位置の直前が空白の場合や、*** のように続いている場合は次を探す
+		// 1文字の区切りは ** の途中（*em **strong** em* の strong の前後）でも閉じない
+		after := end + len(delimiter)
+		if end > 0 && body[end-1] != ' ' &&
+			(len(delimiter) == 2 || (body[end-1] != delimiter[0] && (after >= len(body) || body[after] != delimiter[0]))) &&
+			(delimiter[0] != '_' || after >= len(body) || !isMarkdownWordChar(body[after])) {
+			return body[:end], len(delimiter) + after, true
+		}
+		offset = end + 1
+	}
+
+	return "", 0, false
+}
+
+// parseMarkdownLink は [ラベル](URL "タイトル") の形式を解析する
+// ラベル、URL、読み進めた長さを返す
+func parseMarkdownLink(text string) (string, string, int, bool) {
+	depth := 0
+	labelEnd := -1
+	for j := 0; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			labelEnd = j
+			break
+		}
+	}
+	if labelEnd < 0 || labelEnd+1 >= len(text) || text[labelEnd+1] != '(' {
+		return "", "", 0, false
+	}
+
+	closing := strings.IndexByte(text[labelEnd+2:], ')')
+	if closing < 0 {
+		return "", "", 0, false
+	}
+
+	target := strings.TrimSpace(text[labelEnd+2 : labelEnd+2+closing])
+	// タイトルは使わない
+	if space := strings.IndexAny(target, " \t"); space >= 0 {
+		target = target[:space]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+
+	return text[1:labelEnd], target, labelEnd + 3 + closing, true
+}
+
+// safeMarkdownURL はリンクとして出力してよいURLかどうかを確認する
+// javascript: などのスキームは許可せず、相対URLとアンカーは許可する
+// &#106;avascript: のような文字参照や、スキームの途中のタブや制御文字ですり抜けられないよう、展開して取り除いてから確認する
+func safeMarkdownURL(target string) (string, bool) {
+	if target == "" {
+		return "", false
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(target))
+	scheme, _, hasScheme := strings.Cut(normalized, ":")
+	if hasScheme && !strings.ContainsAny(scheme, "/?#") {
+		switch strings.ToLower(scheme) {
+		case "http", "https", "mailto":
+			return target, true
+		default:
+			return "", false
+		}
+	}
+
+	return target, true
+}
+
+// isMarkdownWordChar は英数字かどうかを返す（_ の強調や自動リンクの判定に使う）
+func isMarkdownWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
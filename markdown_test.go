@@ -0,0 +1,215 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// markdownTagPattern は出力に含まれる HTML のタグ
+var markdownTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)([^>]*)>`)
+
+// markdownAttributePattern はタグの属性（値はすべて二重引用符で囲まれている必要がある）
+var markdownAttributePattern = regexp.MustCompile(`^\s+([a-z]+)="([^"<>]*)"`)
+
+// markdownAllowedTags はレンダラーが出力してよいタグと属性
+var markdownAllowedTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"strong": nil, "em": nil, "del": nil, "code": {"class"}, "pre": nil, "blockquote": nil,
+	"ul": nil, "ol": {"start"}, "li": nil, "input": {"type", "checked", "disabled"},
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"style"}, "td": {"style"},
+	"a": {"href"}, "img": {"src", "alt"},
+}
+
+// assertSafeMarkdownHTML は出力に許可していないタグ、属性、スキームが含まれていないか確認する
+func assertSafeMarkdownHTML(t *testing.T, source, output string) {
+	t.Helper()
+
+	for _, m := range markdownTagPattern.FindAllStringSubmatch(output, -1) {
+		allowed, ok := markdownAllowedTags[m[2]]
+		if !ok {
+			t.Errorf("renderMarkdown(%q) のタグ <%s> は許可されていません: %q", source, m[2], output)
+			continue
+		}
+		attributes := strings.TrimSuffix(m[3], " /")
+		for attributes != "" {
+			a := markdownAttributePattern.FindStringSubmatch(attributes)
+			if a == nil {
+				// checked や disabled のような値のない属性
+				name := strings.Fields(attributes)[0]
+				if !containsString(allowed, name) {
+					t.Errorf("renderMarkdown(%q) の属性 %q は許可されていません: %q", source, attributes, output)
+				}
+				attributes = strings.TrimPrefix(strings.TrimSpace(attributes), name)
+				continue
+			}
+			if !containsString(allowed, a[1]) {
+				t.Errorf("renderMarkdown(%q) の <%s> の属性 %s は許可されていません: %q", source, m[2], a[1], output)
+			}
+			if a[1] == "href" || a[1] == "src" {
+				if _, ok := safeMarkdownURL(a[2]); !ok {
+					t.Errorf("renderMarkdown(%q) の %s=%q は安全なURLではありません", source, a[1], a[2])
+				}
+			}
+			attributes = attributes[len(a[0]):]
+		}
+	}
+}
+
+func TestRenderMarkdownEscapesHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"script タグ", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"イベントハンドラーの属性", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"引用符付きのイベントハンドラー", `<a href="x" onclick="alert(1)">x</a>`, "<p>&lt;a href=&#34;x&#34; onclick=&#34;alert(1)&#34;&gt;x&lt;/a&gt;</p>\n"},
+		{"見出しの中", "# <script>", "<h1>&lt;script&gt;</h1>\n"},
+		{"引用の中", "> <script>", "<blockquote>\n<p>&lt;script&gt;</p>\n</blockquote>\n"},
+		{"リストの中", "- <script>", "<ul>\n<li>&lt;script&gt;</li>\n</ul>\n"},
+		{"表の中", "| a | <b> |\n|---|---|\n| <i> | x |",
+			"<table>\n<thead>\n<tr><th>a</th><th>&lt;b&gt;</th></tr>\n</thead>\n<tbody>\n<tr><td>&lt;i&gt;</td><td>x</td></tr>\n</tbody>\n</table>\n"},
+		{"コードブロック", "```html\n<script>\n```", "<pre><code class=\"language-html\">&lt;script&gt;</code></pre>\n"},
+		{"コードブロックの言語名", "```\"><script>\nx\n```", "<pre><code class=\"language-&#34;&gt;&lt;script&gt;\">x</code></pre>\n"},
+		{"リンクのラベルの引用符", `[x" onmouseover="alert(1)](http://a)`, "<p><a href=\"http://a\">x&#34; onmouseover=&#34;alert(1)</a></p>\n"},
+		{"画像の代替テキストの引用符", `![a" onerror="alert(1)](http://a/b.png)`, "<p><img src=\"http://a/b.png\" alt=\"a&#34; onerror=&#34;alert(1)\"></p>\n"},
+		{"URLの引用符", `[x](http://a/"onmouseover="alert(1))`, "<p><a href=\"http://a/&#34;onmouseover=&#34;alert(1\">x</a>)</p>\n"},
+		{"自動リンクの後のタグ", "http://a/<script>", "<p><a href=\"http://a/\">http://a/</a>&lt;script&gt;</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderMarkdown(tt.source)
+			if got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+			assertSafeMarkdownHTML(t, tt.source, got)
+		})
+	}
+}
+
+func TestRenderMarkdownRejectsUnsafeURLs(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"javascript", "[x](javascript:alert(1))"},
+		{"大文字小文字の混在", "[x](JaVaScRiPt:alert(1))"},
+		{"vbscript", "[x](vbscript:msgbox)"},
+		{"data", "[x](data:text/html;base64,PHNjcmlwdD4=)"},
+		{"画像の javascript", "![x](javascript:alert(1))"},
+		{"画像の data", "![x](data:image/svg+xml,<svg onload=alert(1)>)"},
+		{"山括弧で囲んだ URL", "[x](<javascript:alert(1)>)"},
+		{"10進数の文字参照", "[x](&#106;avascript:alert(1))"},
+		{"16進数の文字参照", "[x](&#x6A;avascript:alert(1))"},
+		{"コロンの文字参照", "[x](javascript&#58;alert(1))"},
+		{"コロンの名前付き文字参照", "[x](javascript&colon;alert(1))"},
+		{"スキームの中のタブの文字参照", "[x](java&Tab;script:alert(1))"},
+		{"スキームの中の改行の文字参照", "[x](java&#10;script:alert(1))"},
+		{"先頭の制御文字", "[x](\x01javascript:alert(1))"},
+		{"画像の文字参照", "![x](&#100;ata:image/png;base64,AAAA)"},
+		{"自動リンク", "<javascript:alert(1)>"},
+		{"表の中のリンク", "| a |\n|---|\n| [x](javascript:1) |"},
+		{"リンクのラベルの中のリンク", "[[x](javascript:1)](http://a)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderMarkdown(tt.source)
+			assertSafeMarkdownHTML(t, tt.source, got)
+			if strings.Contains(strings.ToLower(got), "href=\"java") || strings.Contains(got, "src=\"&amp;") || strings.Contains(got, "href=\"&amp;") {
+				t.Errorf("renderMarkdown(%q) = %q, 危険なURLがリンクになっています", tt.source, got)
+			}
+		})
+	}
+}
+
+func TestSafeMarkdownURL(t *testing.T) {
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{"http://example.com/", true},
+		{"HTTPS://example.com/", true},
+		{"mailto:a@example.com", true},
+		{"docs/page.md", true},
+		{"../README.md", true},
+		{"#section", true},
+		{"/path?a=1&b=2", true},
+		{"?q=a:b", true},
+
+		{"", false},
+		{"javascript:alert(1)", false},
+		{"JaVaScRiPt:alert(1)", false},
+		{"data:text/html,x", false},
+		{"vbscript:x", false},
+		{"file:///etc/passwd", false},
+		{"&#106;avascript:alert(1)", false},
+		{"javascript&#x3A;alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"\x00javascript:alert(1)", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := safeMarkdownURL(tt.target); ok != tt.ok {
+			t.Errorf("safeMarkdownURL(%q) ok = %v, want %v", tt.target, ok, tt.ok)
+		}
+	}
+}
+
+func TestRenderMarkdownInline(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"入れ子の強調", "**bold *em* bold**", "<strong>bold <em>em</em> bold</strong>"},
+		{"強調の中の強調", "*em **strong** em*", "<em>em <strong>strong</strong> em</em>"},
+		{"強調の中のコード", "**a `<b>` c**", "<strong>a <code>&lt;b&gt;</code> c</strong>"},
+		{"コードの中の <", "`<script>`", "<code>&lt;script&gt;</code>"},
+		{"コードの中の強調", "`**<i>**`", "<code>**&lt;i&gt;**</code>"},
+		{"複数のバッククォート", "``a ` <b>``", "<code>a ` &lt;b&gt;</code>"},
+		{"強調の中の <", "_a <b>_", "<em>a &lt;b&gt;</em>"},
+		{"取り消し線の中の <", "~~<s>~~", "<del>&lt;s&gt;</del>"},
+		{"リンクのラベルの強調", "[**x**](http://a)", `<a href="http://a"><strong>x</strong></a>`},
+		{"エスケープした <", `\<script>`, "&lt;script&gt;"},
+
+		{"閉じていない強調", "**unclosed <b>", "**unclosed &lt;b&gt;"},
+		{"閉じていないコード", "`unclosed <b>", "`unclosed &lt;b&gt;"},
+		{"閉じていないリンク", "[x](http://a", `[x](<a href="http://a">http://a</a>`},
+		{"閉じていないラベル", "[x <b>", "[x &lt;b&gt;"},
+		{"閉じていない自動リンク", "<http://a", `&lt;<a href="http://a">http://a</a>`},
+		{"閉じていない画像", "![x](", "![x]("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdownInline(tt.source); got != tt.want {
+				t.Errorf("renderMarkdownInline(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownUnclosedBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"閉じていないコードブロック", "```\n<script>\nalert(1)", "<pre><code>&lt;script&gt;\nalert(1)</code></pre>\n"},
+		{"閉じていない入れ子の強調", "***both <b>", "<p>***both &lt;b&gt;</p>\n"},
+		{"区切り行だけの表", "|---|", "<p>|---|</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderMarkdown(tt.source)
+			if got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+			assertSafeMarkdownHTML(t, tt.source, got)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ServerCommitterName はサーバー上で作成するコミット（マージ、Webからの編集）のコミッター名
+var ServerCommitterName = "Guilty"
+
+// ServerCommitterEmail はサーバー上で作成するコミットのコミッターのメールアドレス
+var ServerCommitterEmail = "guilty@localhost"
+
+// マージの結果
+const (
+	MergeStatusUpToDate    = "up-to-date"   // 取り込むコミットがない
+	MergeStatusFastForward = "fast-forward" // 早送りでブランチを更新した（できる）
+	MergeStatusMerged      = "merged"       // マージコミットを作成した（できる）
+	MergeStatusConflict    = "conflict"     // 競合があるためマージできない
+)
+
+// MergeBranchRequest はブランチのマージリクエスト用の構造体
+type MergeBranchRequest struct {
+	Base          string `json:"base"`          // マージ先のブランチ
+	Head          string `json:"head"`          // 取り込むブランチ、タグ、コミットSHA
+	Message       string `json:"message"`       // マージコミットのメッセージ（省略時は自動生成）
+	NoFastForward bool   `json:"noFastForward"` // 早送りできる場合もマージコミットを作成する
+	DryRun        bool   `json:"dryRun"`        // マージできるかどうかの確認だけを行う
+}
+
+// MergeConflict はマージの競合1件を表す
+type MergeConflict struct {
+	Paths   []string `json:"paths"`   // 競合に関係するファイル（名前変更の競合などでは複数）
+	Type    string   `json:"type"`    // "content"、"modify/delete"、"rename/delete" など
+	Message string   `json:"message"` // git merge-tree のメッセージ
+}
+
+// MergeResult はマージの結果を表す
+type MergeResult struct {
+	Status          string          `json:"status"`          // "up-to-date"、"fast-forward"、"merged"、"conflict"
+	Base            string          `json:"base"`            // マージ先のブランチ
+	BaseCommit      string          `json:"baseCommit"`      // マージ前のマージ先のコミット
+	HeadCommit      string          `json:"headCommit"`      // 取り込むコミット
+	Commit          string          `json:"commit"`          // マージ後のマージ先のコミット（dryRun や競合の場合は空文字）
+	DryRun          bool            `json:"dryRun"`          // 確認だけを行ったかどうか
+	ConflictedFiles []string        `json:"conflictedFiles"` // 競合したファイル
+	Conflicts       []MergeConflict `json:"conflicts"`       // 競合の詳細
+}
+
+// isAncestor は ancestor が commit から辿れるかどうかを返す
+func isAncestor(ctx context.Context, repoPath, ancestor, commit string) bool {
+	return gitRun(ctx, "--git-dir="+repoPath, "merge-base", "--is-ancestor", ancestor, commit) == nil
+}
+
+// mergeTree は git merge-tree --write-tree で作業ツリーを使わずにマージ結果のツリーを作成する
+// 競合がある場合はツリーの代わりに競合の情報を返す（リポジトリの ref は変更しない）
+func mergeTree(ctx context.Context, repoPath, baseCommit, headCommit string) (string, []string, []MergeConflict, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "merge-tree", "--write-tree", "-z", "--name-only",
+		baseCommit, headCommit)
+	defer cancel()
+	output, err := cmd.Output()
+
+	// 終了コード 0 は競合なし、1 は競合あり、それ以外はマージ自体の失敗
+	conflicted := false
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		conflicted = true
+	} else if err != nil {
+		return "", nil, nil, fmt.Errorf("マージに失敗しました: %w", err)
+	}
+
+	// -z の場合は「ツリー NUL」「競合したファイル NUL ...」「NUL」「メッセージ」の並びになる
+	fields := strings.Split(string(output), "\x00")
+	tree := fields[0]
+	if !conflicted {
+		return tree, nil, nil, nil
+	}
+
+	files := []string{}
+	i := 1
+	for ; i < len(fields) && fields[i] != ""; i++ {
+		files = append(files, fields[i])
+	}
+
+	// メッセージは「パスの数 NUL パス NUL ... 種類 NUL メッセージ NUL」の繰り返し
+	conflicts := []MergeConflict{}
+	for i++; i < len(fields); {
+		count, err := strconv.Atoi(fields[i])
+		if err != nil || i+count+2 >= len(fields) {
+			break
+		}
+
+		paths := fields[i+1 : i+1+count]
+		kind := fields[i+1+count]
+		message := strings.TrimSpace(fields[i+2+count])
+		i += count + 3
+
+		// "Auto-merging" などの情報メッセージは除く
+		if !strings.HasPrefix(kind, "CONFLICT") {
+			continue
+		}
+		kind = strings.Trim(strings.TrimPrefix(kind, "CONFLICT"), " ()")
+		if kind == "contents" {
+			kind = "content"
+		}
+		conflicts = append(conflicts, MergeConflict{Paths: append([]string{}, paths...), Type: kind, Message: message})
+	}
+
+	return "", files, conflicts, nil
+}
+
+// createMergeCommit は git commit-tree でマージ先と取り込むコミットを親とするコミットを作成する
+func createMergeCommit(ctx context.Context, repoPath, tree, baseCommit, headCommit, message string) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "commit-tree", tree,
+		"-p", baseCommit, "-p", headCommit, "-m", message)
+	defer cancel()
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+ServerCommitterName,
+		"GIT_AUTHOR_EMAIL="+ServerCommitterEmail,
+		"GIT_COMMITTER_NAME="+ServerCommitterName,
+		"GIT_COMMITTER_EMAIL="+ServerCommitterEmail,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("マージコミットの作成に失敗しました: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// updateBranch はブランチが oldCommit のままの場合だけ newCommit に更新する
+// 確認してから更新するまでの間にプッシュされた場合はエラーになる
+func updateBranch(ctx context.Context, repoPath, branchName, newCommit, oldCommit, reason string) error {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "update-ref", "-m", reason,
+		"refs/heads/"+branchName, newCommit, oldCommit)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ブランチ '%s' の更新に失敗しました: %s", branchName, strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+	return nil
+}
+
+// mergeBranch は head をブランチ base にマージする
+// 競合がある場合は ref を変更せずに競合の情報を返す
+func mergeBranch(ctx context.Context, repoPath string, req MergeBranchRequest) (*MergeResult, error) {
+	if !branchExists(ctx, repoPath, req.Base) {
+		return nil, fmt.Errorf("ブランチ '%s' が見つかりません", req.Base)
+	}
+
+	baseCommit, err := resolveCommit(ctx, repoPath, "refs/heads/"+req.Base)
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := resolveCommit(ctx, repoPath, req.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{
+		Base:            req.Base,
+		BaseCommit:      baseCommit,
+		HeadCommit:      headCommit,
+		DryRun:          req.DryRun,
+		ConflictedFiles: []string{},
+		Conflicts:       []MergeConflict{},
+	}
+
+	if isAncestor(ctx, repoPath, headCommit, baseCommit) {
+		result.Status = MergeStatusUpToDate
+		result.Commit = baseCommit
+		return result, nil
+	}
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Merge '%s' into %s", req.Head, req.Base)
+	}
+
+	var newCommit string
+	if isAncestor(ctx, repoPath, baseCommit, headCommit) && !req.NoFastForward {
+		result.Status = MergeStatusFastForward
+		newCommit = headCommit
+	} else {
+		tree, files, conflicts, err := mergeTree(ctx, repoPath, baseCommit, headCommit)
+		if err != nil {
+			return nil, err
+		}
+		if tree == "" {
+			result.Status = MergeStatusConflict
+			result.ConflictedFiles = files
+			result.Conflicts = conflicts
+			return result, nil
+		}
+
+		result.Status = MergeStatusMerged
+		if req.DryRun {
+			return result, nil
+		}
+
+		newCommit, err = createMergeCommit(ctx, repoPath, tree, baseCommit, headCommit, message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	if err := updateBranch(ctx, repoPath, req.Base, newCommit, baseCommit, "merge "+req.Head+": "+result.Status); err != nil {
+		return nil, err
+	}
+	result.Commit = newCommit
+
+	return result, nil
+}
+
+// mergeHandler はサーバー上でブランチをマージする
+// 作業ツリーを使わないため、ベアリポジトリのまま実行できる
+//
+//	POST /api/repository/{group}/{repo}/merge  {"base": "main", "head": "feature", "dryRun": false}
+func mergeHandler(w http.ResponseWriter, r *http.Request, groupName, repoPath string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	var req MergeBranchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+		return
+	}
+
+	if req.Base == "" || req.Head == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "base と head を指定してください"})
+		return
+	}
+
+	// プッシュと同様に、容量制限を超えたグループでは新しいコミットを作成しない
+	if !req.DryRun && isQuotaEnforcedOnPush(groupName) {
+		if err := checkGroupQuota(groupName); err != nil {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := mergeBranch(r.Context(), repoPath, req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case result.Status == MergeStatusConflict:
+		w.WriteHeader(http.StatusConflict)
+	case result.DryRun || result.Status == MergeStatusUpToDate:
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// mergeRequestsBucket はマージリクエストを保存するバケット名
+// リポジトリ（group/name）ごとの入れ子のバケットに、番号をキーとして保存する
+var mergeRequestsBucket = []byte("merge-requests")
+
+// マージリクエストの状態
+const (
+	MergeRequestOpen   = "open"
+	MergeRequestClosed = "closed"
+	MergeRequestMerged = "merged"
+)
+
+// MergeRequest はブランチ間のマージリクエストを表す
+type MergeRequest struct {
+	ID          int        `json:"id"` // リポジトリごとの通し番号（1から）
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Author      string     `json:"author"`
+	Source      string     `json:"source"` // 取り込むブランチ
+	Target      string     `json:"target"` // マージ先のブランチ
+	State       string     `json:"state"`  // "open"、"closed"、"merged"
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	ClosedAt    *time.Time `json:"closedAt"` // クローズまたはマージした日時
+	// マージした時点のコミット（マージ後にブランチが削除・更新されても差分を表示できるようにする）
+	BaseCommit  string `json:"baseCommit,omitempty"`
+	HeadCommit  string `json:"headCommit,omitempty"`
+	MergeCommit string `json:"mergeCommit,omitempty"`
+}
+
+// CreateMergeRequestRequest はマージリクエスト作成用の構造体
+type CreateMergeRequestRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Source      string `json:"source"`
+	Target      string `json:"target"` // 省略時はデフォルトブランチ
+}
+
+// UpdateMergeRequestRequest はマージリクエストの部分更新（PATCH）用の構造体
+type UpdateMergeRequestRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	State       *string `json:"state"` // "open" または "closed"
+}
+
+// AcceptMergeRequestRequest はマージリクエストのマージ用の構造体
+type AcceptMergeRequestRequest struct {
+	Message       string `json:"message"`
+	NoFastForward bool   `json:"noFastForward"`
+}
+
+// MergeRequestDiff はマージリクエストの差分（マージ先との共通祖先から取り込むブランチまで）を表す
+type MergeRequestDiff struct {
+	BaseCommit string        `json:"baseCommit"` // マージ先のコミット
+	HeadCommit string        `json:"headCommit"` // 取り込むコミット
+	MergeBase  string        `json:"mergeBase"`  // 共通祖先
+	Commits    []Commit      `json:"commits"`    // 取り込まれるコミット（新しい順）
+	Files      []ChangedFile `json:"files"`
+	Patch      string        `json:"patch"`     // unified diff 形式の差分
+	Truncated  bool          `json:"truncated"` // 差分が MaxFileContentSize を超えたため切り詰めたかどうか
+}
+
+// sequenceKey は通し番号で保存するバケット（マージリクエスト、イシュー）でのキーを返す
+// ビッグエンディアンにすることでキーの順序が番号の順序になる
+func sequenceKey(id int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+// getMergeRequests はリポジトリのマージリクエストを新しい順に取得する
+// state が空の場合はすべての状態のものを返す
+func getMergeRequests(groupName, repoName, state string) ([]MergeRequest, error) {
+	requests := []MergeRequest{}
+	if metadataStore == nil {
+		return requests, nil
+	}
+
+	err := metadataStore.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(mergeRequestsBucket).Bucket(metadataKey(groupName, repoName))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var request MergeRequest
+			if err := json.Unmarshal(v, &request); err != nil {
+				return err
+			}
+			if state == "" || request.State == state {
+				requests = append(requests, request)
+			}
+		}
+		return nil
+	})
+
+	return requests, err
+}
+
+// getMergeRequest は番号を指定してマージリクエストを取得する（見つからない場合は nil）
+func getMergeRequest(groupName, repoName string, id int) (*MergeRequest, error) {
+	if metadataStore == nil {
+		return nil, fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	var request *MergeRequest
+	err := metadataStore.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(mergeRequestsBucket).Bucket(metadataKey(groupName, repoName))
+		if bucket == nil {
+			return nil
+		}
+
+		data := bucket.Get(sequenceKey(id))
+		if data == nil {
+			return nil
+		}
+		request = &MergeRequest{}
+		return json.Unmarshal(data, request)
+	})
+
+	return request, err
+}
+
+// saveMergeRequest はマージリクエストを保存する
+// 番号が 0 の場合は新しい番号を割り当てる
+func saveMergeRequest(groupName, repoName string, request *MergeRequest) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(mergeRequestsBucket).CreateBucketIfNotExists(metadataKey(groupName, repoName))
+		if err != nil {
+			return err
+		}
+
+		if request.ID == 0 {
+			id, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			request.ID = int(id)
+		}
+
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(sequenceKey(request.ID), data)
+	})
+}
+
+// deleteMergeRequests はリポジトリのマージリクエストをすべて削除する
+func deleteMergeRequests(tx *bolt.Tx, groupName, repoName string) error {
+	err := tx.Bucket(mergeRequestsBucket).DeleteBucket(metadataKey(groupName, repoName))
+	if err == bolt.ErrBucketNotFound {
+		return nil
+	}
+	return err
+}
+
+// createMergeRequest はリクエストの内容を検証してマージリクエストを作成する
+func createMergeRequest(ctx context.Context, groupName, repoName, repoPath string, req CreateMergeRequestRequest) (*MergeRequest, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return nil, fmt.Errorf("タイトルを入力してください")
+	}
+
+	if req.Target == "" {
+		defaultBranch, err := getDefaultBranch(ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		req.Target = defaultBranch
+	}
+
+	for _, branch := range []string{req.Source, req.Target} {
+		if !branchExists(ctx, repoPath, branch) {
+			return nil, fmt.Errorf("ブランチ '%s' が見つかりません", branch)
+		}
+	}
+	if req.Source == req.Target {
+		return nil, fmt.Errorf("取り込むブランチとマージ先のブランチが同じです")
+	}
+
+	now := time.Now()
+	request := &MergeRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Author:      strings.TrimSpace(req.Author),
+		Source:      req.Source,
+		Target:      req.Target,
+		State:       MergeRequestOpen,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := saveMergeRequest(groupName, repoName, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// getMergeRequestDiff はマージリクエストで取り込まれるコミットと差分を取得する
+// マージ済みの場合はマージした時点のコミットを使う
+func getMergeRequestDiff(ctx context.Context, repoPath string, request *MergeRequest) (*MergeRequestDiff, error) {
+	baseCommit, headCommit := request.BaseCommit, request.HeadCommit
+	if request.State != MergeRequestMerged {
+		var err error
+		if baseCommit, err = resolveCommit(ctx, repoPath, "refs/heads/"+request.Target); err != nil {
+			return nil, err
+		}
+		if headCommit, err = resolveCommit(ctx, repoPath, "refs/heads/"+request.Source); err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := gitOutput(ctx, "--git-dir="+repoPath, "merge-base", baseCommit, headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("共通祖先が見つかりません")
+	}
+	mergeBase := strings.TrimSpace(string(output))
+
+	diff := &MergeRequestDiff{BaseCommit: baseCommit, HeadCommit: headCommit, MergeBase: mergeBase}
+
+	if diff.Commits, err = getCommits(ctx, repoPath, mergeBase+".."+headCommit, 0, MaxCommitsPerPage); err != nil {
+		return nil, err
+	}
+	if diff.Files, err = getChangedFiles(ctx, repoPath, mergeBase, headCommit); err != nil {
+		return nil, err
+	}
+
+	patch, truncated, err := gitLimitedOutput(ctx, MaxFileContentSize, "--git-dir="+repoPath, "diff", "--no-color", "-M", mergeBase, headCommit, "--")
+	if err != nil {
+		return nil, fmt.Errorf("差分の取得に失敗しました: %w", err)
+	}
+	diff.Patch, diff.Truncated = string(patch), truncated
+
+	return diff, nil
+}
+
+// acceptMergeRequest はサーバー側のマージでマージリクエストをマージする
+// 競合がある場合はマージリクエストを変更せずに結果を返す
+func acceptMergeRequest(ctx context.Context, groupName, repoName, repoPath string, request *MergeRequest, req AcceptMergeRequestRequest) (*MergeResult, error) {
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("Merge '%s' into %s (!%d)\n\n%s", request.Source, request.Target, request.ID, request.Title)
+	}
+
+	result, err := mergeBranch(ctx, repoPath, MergeBranchRequest{
+		Base:          request.Target,
+		Head:          "refs/heads/" + request.Source,
+		Message:       message,
+		NoFastForward: req.NoFastForward,
+	})
+	if err != nil || result.Status == MergeStatusConflict {
+		return result, err
+	}
+
+	now := time.Now()
+	request.State = MergeRequestMerged
+	request.UpdatedAt = now
+	request.ClosedAt = &now
+	request.BaseCommit = result.BaseCommit
+	request.HeadCommit = result.HeadCommit
+	request.MergeCommit = result.Commit
+	if err := saveMergeRequest(groupName, repoName, request); err != nil {
+		return nil, fmt.Errorf("ブランチはマージされましたが、マージリクエストの保存に失敗しました: %w", err)
+	}
+
+	return result, nil
+}
+
+// mergeRequestsHandler はマージリクエストの一覧・作成・更新・マージを行う
+//
+//	GET   /api/repository/{group}/{repo}/merge-requests?state=open  一覧（state は open、closed、merged、all）
+//	POST  /api/repository/{group}/{repo}/merge-requests             {"title": "...", "source": "feature", "target": "main"}
+//	GET   /api/repository/{group}/{repo}/merge-requests/{id}        詳細
+//	PATCH /api/repository/{group}/{repo}/merge-requests/{id}        {"title": "...", "state": "closed"}
+//	GET   /api/repository/{group}/{repo}/merge-requests/{id}/diff   差分
+//	POST  /api/repository/{group}/{repo}/merge-requests/{id}/merge  {"message": "...", "noFastForward": false}
+func mergeRequestsHandler(w http.ResponseWriter, r *http.Request, groupName, repoName, repoPath, subPath string) {
+	if metadataStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "メタデータストアが利用できません"})
+		return
+	}
+
+	if subPath == "" {
+		switch r.Method {
+		case http.MethodGet:
+			state := r.URL.Query().Get("state")
+			switch state {
+			case "":
+				state = MergeRequestOpen
+			case "all":
+				state = ""
+			case MergeRequestOpen, MergeRequestClosed, MergeRequestMerged:
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "state には open、closed、merged、all のいずれかを指定してください"})
+				return
+			}
+
+			requests, err := getMergeRequests(groupName, repoName, state)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "マージリクエストの取得に失敗しました: " + err.Error()})
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(requests)
+
+		case http.MethodPost:
+			var req CreateMergeRequestRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+				return
+			}
+
+			request, err := createMergeRequest(r.Context(), groupName, repoName, repoPath, req)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(request)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		}
+		return
+	}
+
+	// 番号とそれ以降の操作（diff、merge）に分割
+	idPart, action, _ := strings.Cut(subPath, "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "マージリクエストの番号が不正です"})
+		return
+	}
+
+	request, err := getMergeRequest(groupName, repoName, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "マージリクエストの取得に失敗しました: " + err.Error()})
+		return
+	}
+	if request == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "マージリクエストが見つかりません"})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "":
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(request)
+
+	case r.Method == http.MethodPatch && action == "":
+		var req UpdateMergeRequestRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if request.State == MergeRequestMerged {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "マージ済みのマージリクエストは変更できません"})
+			return
+		}
+
+		now := time.Now()
+		if req.Title != nil {
+			title := strings.TrimSpace(*req.Title)
+			if title == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "タイトルを入力してください"})
+				return
+			}
+			request.Title = title
+		}
+		if req.Description != nil {
+			request.Description = *req.Description
+		}
+		if req.State != nil && *req.State != request.State {
+			switch *req.State {
+			case MergeRequestOpen:
+				request.ClosedAt = nil
+			case MergeRequestClosed:
+				request.ClosedAt = &now
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "state には open または closed を指定してください"})
+				return
+			}
+			request.State = *req.State
+		}
+		request.UpdatedAt = now
+
+		if err := saveMergeRequest(groupName, repoName, request); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "マージリクエストの保存に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(request)
+
+	case r.Method == http.MethodGet && action == "diff":
+		diff, err := getMergeRequestDiff(r.Context(), repoPath, request)
+		if err != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(diff)
+
+	case r.Method == http.MethodPost && action == "merge":
+		var req AcceptMergeRequestRequest
+		// 本文は省略できる
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if request.State != MergeRequestOpen {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "オープンではないマージリクエストはマージできません"})
+			return
+		}
+
+		// サーバー側のマージAPIと同様に、容量制限を超えたグループでは新しいコミットを作成しない
+		if isQuotaEnforcedOnPush(groupName) {
+			if err := checkGroupQuota(groupName); err != nil {
+				w.WriteHeader(http.StatusInsufficientStorage)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		result, err := acceptMergeRequest(r.Context(), groupName, repoName, repoPath, request, req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if result.Status == MergeStatusConflict {
+			w.WriteHeader(http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		json.NewEncoder(w).Encode(result)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
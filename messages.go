@@ -0,0 +1,326 @@
+package main
+
+// messageCatalog はAPIが返すメッセージの訳とコード
+// ハンドラーやエラーのメッセージを追加・変更した場合は、ここにも追加する（ない場合は原文のまま返し、英語ではステータスコードの一般的なメッセージになる）
+// コードはクライアントが判定に使うため、一度決めたら変更しない
+var messageCatalog = []catalogMessage{
+	// 共通
+	{Code: "method_not_allowed", Ja: "サポートされていないメソッドです", En: "The method is not supported"},
+	{Code: "method_not_allowed", Ja: "POSTメソッドのみサポートしています", En: "Only the POST method is supported"},
+	{Code: "invalid_request_body", Ja: "不正なリクエスト形式", En: "The request body is invalid"},
+	{Code: "invalid_request_body", Ja: "無効なリクエスト形式です", En: "The request body is invalid"},
+	{Code: "invalid_operation", Ja: "不正な操作タイプ", En: "The operation is invalid"},
+	{Code: "unknown_api", Ja: "不明なAPIです: %s", En: "Unknown API: %s"},
+	{Code: "metadata_store_unavailable", Ja: "メタデータストアが利用できません", En: "The metadata store is not available"},
+	{Code: "internal_only", Ja: "このAPIはサーバー内部からのみ利用できます", En: "This API is only available from the server itself"},
+	{Code: "too_many_requests", Ja: "リクエストが多すぎます。しばらくしてから再試行してください", En: "Too many requests. Please retry later"},
+	{Code: "internal_error", Ja: "サーバー内部でエラーが発生しました（リクエストID: %s）", En: "An internal server error occurred (request ID: %s)"},
+	{Code: "temporary_file_failed", Ja: "一時ファイルの作成に失敗しました", En: "Failed to create a temporary file"},
+	{Code: "temporary_directory_failed", Ja: "一時ディレクトリの作成に失敗しました: %w", En: "Failed to create a temporary directory: %s"},
+	{Code: "directory_create_failed", Ja: "ディレクトリの作成に失敗しました: %w", En: "Failed to create the directory: %s"},
+	{Code: "invalid_number", Ja: "数値ではありません: %s", En: "Not a number: %s"},
+	{Code: "invalid_boolean", Ja: "true または false を指定してください: %s", En: "Specify true or false: %s"},
+	{Code: "invalid_duration", Ja: "期間の形式が不正です（例: 720h）: %s", En: "Invalid duration (e.g. 720h): %s"},
+
+	// リポジトリ
+	{Code: "repository_not_found", Ja: "リポジトリが見つかりません", En: "Repository not found"},
+	{Code: "repository_not_found", Ja: "リポジトリ '%s' は存在しません", En: "Repository '%s' does not exist"},
+	{Code: "not_a_repository", Ja: "Gitリポジトリではありません", En: "Not a Git repository"},
+	{Code: "invalid_repository_path", Ja: "無効なリポジトリパス", En: "Invalid repository path"},
+	{Code: "invalid_repository_path", Ja: "無効なリポジトリパス: %s", En: "Invalid repository path: %s"},
+	{Code: "invalid_group_name", Ja: "無効なグループ名です", En: "Invalid group name"},
+	{Code: "invalid_group_name", Ja: "無効なグループ名です: %s", En: "Invalid group name: %s"},
+	{Code: "invalid_group_name", Ja: "グループ名を空にすることはできません", En: "The group name must not be empty"},
+	{Code: "invalid_repository_name", Ja: "リポジトリ名が指定されていません", En: "No repository name was given"},
+	{Code: "invalid_repository_name", Ja: "リポジトリ名にはファイルシステムで禁止されている文字（/ \\ : * ? \" < > |）は使用できません", En: "Repository names must not contain characters forbidden by file systems (/ \\ : * ? \" < > |)"},
+	{Code: "invalid_repository_name", Ja: "リポジトリ名の先頭や末尾にスペースやドットは使用できません", En: "Repository names must not start or end with a space or a dot"},
+	{Code: "invalid_repository_name", Ja: "リポジトリ名の末尾に '%s' は使用できません", En: "Repository names must not end with '%s'"},
+	{Code: "repository_exists", Ja: "リポジトリ '%s' は既に存在します", En: "Repository '%s' already exists"},
+	{Code: "repository_exists", Ja: "リポジトリ '%s/%s' は既に存在します", En: "Repository '%s/%s' already exists"},
+	{Code: "repository_exists", Ja: "リポジトリは既に存在します（上書きする場合は force=true を指定してください）", En: "The repository already exists (specify force=true to overwrite it)"},
+	{Code: "repository_created", Ja: "リポジトリが作成されました", En: "The repository was created"},
+	{Code: "repository_deleted", Ja: "リポジトリが削除されました", En: "The repository was deleted"},
+	{Code: "repository_updated", Ja: "リポジトリの設定が更新されました", En: "The repository settings were updated"},
+	{Code: "description_updated", Ja: "リポジトリの説明が更新されました", En: "The repository description was updated"},
+	{Code: "description_missing", Ja: "descriptionが指定されていません", En: "No description was given"},
+	{Code: "description_update_failed", Ja: "descriptionファイルの更新に失敗しました: %w", En: "Failed to update the description file: %s"},
+	{Code: "repository_list_failed", Ja: "リポジトリ一覧の取得に失敗しました: %s", En: "Failed to list repositories: %s"},
+	{Code: "repository_list_failed", Ja: "グループ '%s' のリポジトリ一覧の取得に失敗しました: %w", En: "Failed to list repositories in group '%s': %s"},
+	{Code: "repository_list_failed", Ja: "GitRepositoryHomeのディレクトリ読み取りに失敗しました: %w", En: "Failed to read the repository home directory: %s"},
+	{Code: "group_list_failed", Ja: "グループ一覧の取得に失敗しました: %w", En: "Failed to list groups: %s"},
+	{Code: "group_create_failed", Ja: "グループディレクトリの作成に失敗しました: %w", En: "Failed to create the group directory: %s"},
+	{Code: "repository_init_failed", Ja: "リポジトリの初期化に失敗しました: %w", En: "Failed to initialize the repository: %s"},
+	{Code: "repository_init_failed", Ja: "最初のコミットの作成に失敗しました: %w", En: "Failed to create the initial commit: %s"},
+	{Code: "star_requires_user", Ja: "スターを付けるにはユーザーの認証が必要です", En: "Starring requires an authenticated user"},
+	{Code: "admin_requires_user", Ja: "このAPIを利用するにはユーザーの認証が必要です", En: "This API requires an authenticated user"},
+	{Code: "admin_required", Ja: "このAPIは管理者だけが利用できます", En: "This API is available only to administrators"},
+	{Code: "access_change_forbidden", Ja: "公開範囲とメンバーは管理者とリポジトリのメンバーだけが変更できます", En: "Only administrators and repository members can change visibility and members"},
+	{Code: "too_many_pins", Ja: "ピン留めできるリポジトリは%d個までです", En: "Up to %s repositories can be pinned"},
+	{Code: "unknown_gitignore_template", Ja: "不明な .gitignore のテンプレートです: %s", En: "Unknown .gitignore template: %s"},
+	{Code: "unknown_license", Ja: "不明なライセンスです: %s", En: "Unknown license: %s"},
+	{Code: "invalid_copyright_holder", Ja: "copyrightHolder は改行を含まない%d文字以内で指定してください", En: "copyrightHolder must be at most %s characters without line breaks"},
+	{Code: "repository_permission_failed", Ja: "リポジトリのアクセス権限変更に失敗しました: %w", En: "Failed to change the repository permissions: %s"},
+	{Code: "repository_rename_failed", Ja: "リポジトリの名前変更に失敗しました: %w", En: "Failed to rename the repository: %s"},
+	{Code: "wiki_rename_forbidden", Ja: "Wiki のリポジトリの名前は変更できません", En: "Wiki repositories cannot be renamed"},
+	{Code: "metadata_move_failed", Ja: "メタデータの移動に失敗しました: %w", En: "Failed to move the metadata: %s"},
+	{Code: "metadata_save_failed", Ja: "メタデータの保存に失敗しました: %w", En: "Failed to save the metadata: %s"},
+	{Code: "invalid_topic", Ja: "トピック '%s' は不正です（英小文字、数字、ハイフンのみ、35文字以内）", En: "Topic '%s' is invalid (lowercase letters, digits and hyphens only, up to 35 characters)"},
+	{Code: "invalid_website", Ja: "ウェブサイトには http または https のURLを指定してください", En: "The website must be an http or https URL"},
+	{Code: "invalid_visibility", Ja: "公開範囲には '%s'、'%s'、'%s' のいずれかを指定してください", En: "Visibility must be '%s', '%s' or '%s'"},
+	{Code: "invalid_member", Ja: "メンバー '%s' はユーザー名として使えません", En: "Member '%s' is not a valid user name"},
+	{Code: "quota_exceeded", Ja: "グループ '%s' のディスク使用量が上限（%d バイト）を超えているため、リポジトリを作成できません", En: "Cannot create the repository because group '%s' exceeds its disk quota (%s bytes)"},
+	{Code: "head_read_failed", Ja: "HEADファイルの読み込みに失敗しました: %w", En: "Failed to read the HEAD file: %s"},
+	{Code: "detached_head", Ja: "detached HEAD状態です", En: "HEAD is detached"},
+	{Code: "repository_summaries_invalid", Ja: "name は1から%d個指定してください", En: "Specify between 1 and %s names"},
+	{Code: "invalid_sort", Ja: "sort には %s のいずれかを指定してください", En: "sort must be one of %s"},
+	{Code: "invalid_last_commit", Ja: "lastCommit には true または false を指定してください", En: "lastCommit must be true or false"},
+	{Code: "invalid_sort", Ja: "lastCommit=false の場合は sort=last_commit を指定できません", En: "sort=last_commit cannot be used with lastCommit=false"},
+	{Code: "invalid_page", Ja: "page は1以上の整数で指定してください", En: "page must be an integer of 1 or more"},
+	{Code: "invalid_page", Ja: "per_page は1から%dの整数で指定してください", En: "per_page must be an integer between 1 and %s"},
+	{Code: "invalid_page", Ja: "limit は1から%dの整数で指定してください", En: "limit must be an integer between 1 and %s"},
+	{Code: "invalid_page", Ja: "offset は0以上の整数で指定してください", En: "offset must be an integer of 0 or more"},
+	{Code: "export_empty_repository", Ja: "コミットのないリポジトリはエクスポートできません", En: "Repositories without commits cannot be exported"},
+	{Code: "bundle_failed", Ja: "バンドルの作成に失敗しました", En: "Failed to create the bundle"},
+	{Code: "bundle_failed", Ja: "'%s/%s' のバンドルの作成に失敗しました: %s", En: "Failed to create the bundle of '%s/%s': %s"},
+
+	// ブランチ、タグ、参照
+	{Code: "branch_not_found", Ja: "ブランチ '%s' が見つかりません", En: "Branch '%s' not found"},
+	{Code: "branch_exists", Ja: "ブランチ '%s' は既に存在します", En: "Branch '%s' already exists"},
+	{Code: "invalid_branch_name", Ja: "ブランチ名 '%s' は不正です", En: "Branch name '%s' is invalid"},
+	{Code: "branch_name_missing", Ja: "ブランチ名が指定されていません", En: "No branch name was given"},
+	{Code: "from_missing", Ja: "fromが指定されていません", En: "No from ref was given"},
+	{Code: "branch_created", Ja: "ブランチが作成されました", En: "The branch was created"},
+	{Code: "branch_deleted", Ja: "ブランチが削除されました", En: "The branch was deleted"},
+	{Code: "branch_create_failed", Ja: "ブランチの作成に失敗しました: %s", En: "Failed to create the branch: %s"},
+	{Code: "branch_delete_failed", Ja: "ブランチの削除に失敗しました: %s", En: "Failed to delete the branch: %s"},
+	{Code: "branch_update_failed", Ja: "ブランチ '%s' の更新に失敗しました: %s", En: "Failed to update branch '%s': %s"},
+	{Code: "branch_list_failed", Ja: "ブランチ一覧の取得に失敗しました: %w", En: "Failed to list branches: %s"},
+	{Code: "default_branch_protected", Ja: "デフォルトブランチ '%s' は削除できません", En: "The default branch '%s' cannot be deleted"},
+	{Code: "protected_branch", Ja: "保護ブランチ '%s' は削除できません", En: "The protected branch '%s' cannot be deleted"},
+	{Code: "head_changed", Ja: "HEADブランチが変更されました", En: "The HEAD branch was changed"},
+	{Code: "default_branch_changed", Ja: "デフォルトブランチが変更されました", En: "The default branch was changed"},
+	{Code: "default_branch_failed", Ja: "デフォルトブランチの取得に失敗しました: %w", En: "Failed to get the default branch: %s"},
+	{Code: "default_branch_failed", Ja: "デフォルトブランチの変更に失敗しました: %s", En: "Failed to change the default branch: %s"},
+	{Code: "protected_branches_updated", Ja: "保護ブランチの設定が更新されました", En: "The protected branch settings were updated"},
+	{Code: "protected_branches_failed", Ja: "保護ブランチの保存に失敗しました: %s", En: "Failed to save the protected branches: %s"},
+	{Code: "invalid_pattern", Ja: "パターン '%s' は不正です", En: "Pattern '%s' is invalid"},
+	{Code: "invalid_pattern", Ja: "パターン '%s' には使用できない文字が含まれています", En: "Pattern '%s' contains characters that cannot be used"},
+	{Code: "tag_not_found", Ja: "タグが見つかりません", En: "Tag not found"},
+	{Code: "tag_not_found", Ja: "タグ '%s' が見つかりません", En: "Tag '%s' not found"},
+	{Code: "tag_exists", Ja: "タグ '%s' は既に存在します", En: "Tag '%s' already exists"},
+	{Code: "invalid_tag_name", Ja: "タグ名 '%s' は不正です", En: "Tag name '%s' is invalid"},
+	{Code: "tag_created", Ja: "タグが作成されました", En: "The tag was created"},
+	{Code: "tag_deleted", Ja: "タグが削除されました", En: "The tag was deleted"},
+	{Code: "tag_create_failed", Ja: "タグの作成に失敗しました: %s", En: "Failed to create the tag: %s"},
+	{Code: "tag_delete_failed", Ja: "タグの削除に失敗しました: %s", En: "Failed to delete the tag: %s"},
+	{Code: "tag_list_failed", Ja: "タグ一覧の取得に失敗しました: %s", En: "Failed to list tags: %s"},
+	{Code: "tag_list_failed", Ja: "タグ情報の取得に失敗しました: %s", En: "Failed to get the tag information: %s"},
+	{Code: "ref_not_found", Ja: "ref '%s' が見つかりません", En: "Ref '%s' not found"},
+	{Code: "invalid_ref", Ja: "ref '%s' は不正です", En: "Ref '%s' is invalid"},
+	{Code: "revision_not_found", Ja: "リビジョン '%s' が見つかりません: %w", En: "Revision '%s' not found: %s"},
+	{Code: "refs_failed", Ja: "参照の取得に失敗しました: %s", En: "Failed to get the refs: %s"},
+	{Code: "refs_failed", Ja: "'%s/%s' の参照の取得に失敗しました: %w", En: "Failed to get the refs of '%s/%s': %s"},
+
+	// コミット、ファイル
+	{Code: "commit_not_found", Ja: "コミットが見つかりません", En: "Commit not found"},
+	{Code: "commit_not_found", Ja: "コミット '%s' が見つかりません", En: "Commit '%s' not found"},
+	{Code: "object_not_found", Ja: "オブジェクト '%s' が見つかりません", En: "Object '%s' not found"},
+	{Code: "blob_not_found", Ja: "blob '%s' が見つかりません", En: "Blob '%s' not found"},
+	{Code: "commit_history_failed", Ja: "コミット履歴の取得に失敗しました: %w", En: "Failed to get the commit history: %s"},
+	{Code: "commit_count_failed", Ja: "コミット数の取得に失敗しました: %w", En: "Failed to count commits: %s"},
+	{Code: "diff_failed", Ja: "差分の取得に失敗しました: %w", En: "Failed to get the diff: %s"},
+	{Code: "changed_files_failed", Ja: "変更されたファイルの取得に失敗しました: %w", En: "Failed to get the changed files: %s"},
+	{Code: "file_not_found", Ja: "ファイルが見つかりません", En: "File not found"},
+	{Code: "not_found", Ja: "'%s' が見つかりません", En: "'%s' not found"},
+	{Code: "is_directory", Ja: "'%s' はディレクトリです", En: "'%s' is a directory"},
+	{Code: "not_a_directory", Ja: "'%s' はディレクトリではありません", En: "'%s' is not a directory"},
+	{Code: "not_a_file", Ja: "'%s' はファイルではありません", En: "'%s' is not a file"},
+	{Code: "invalid_directory_path", Ja: "無効なディレクトリパス", En: "Invalid directory path"},
+	{Code: "invalid_file_path", Ja: "無効なパス形式です（ファイルパスがありません）", En: "Invalid path (no file path was given)"},
+	{Code: "invalid_file_path", Ja: "ファイルパス '%s' は不正です", En: "File path '%s' is invalid"},
+	{Code: "directory_failed", Ja: "ディレクトリ内容の取得に失敗しました: %s", En: "Failed to get the directory contents: %s"},
+	{Code: "file_list_failed", Ja: "ファイル一覧の取得に失敗しました: %s", En: "Failed to list files: %s"},
+	{Code: "file_content_failed", Ja: "ファイル内容の取得に失敗しました: %s", En: "Failed to get the file contents: %s"},
+	{Code: "symlink_target_failed", Ja: "リンク先の取得に失敗しました: %s", En: "Failed to get the link target: %s"},
+	{Code: "tree_read_failed", Ja: "ツリーの読み込みに失敗しました: %w", En: "Failed to read the tree: %s"},
+	{Code: "file_history_failed", Ja: "ファイルの履歴の取得に失敗しました: %w", En: "Failed to get the file history: %s"},
+	{Code: "diff_from_required", Ja: "from に比較元のブランチ、タグまたはコミットを指定してください", En: "Specify the branch, tag or commit to compare from in from"},
+	{Code: "commit_graph_failed", Ja: "コミットグラフの取得に失敗しました: %w", En: "Failed to get the commit graph: %s"},
+	{Code: "file_not_in_history", Ja: "履歴にファイルが見つかりません", En: "The file was not found in the history"},
+	{Code: "symlink", Ja: "シンボリックリンクです: %s", En: "This is a symbolic link: %s"},
+	{Code: "binary_file", Ja: "バイナリファイルのため表示できません", En: "Binary files cannot be displayed"},
+	{Code: "preview_unsupported", Ja: "このファイル形式はプレビューできません", En: "This file type cannot be previewed"},
+	{Code: "lfs_object_missing", Ja: "LFSオブジェクトが保存先にありません", En: "The LFS object is not in the store"},
+	{Code: "lfs_object_missing", Ja: "LFSオブジェクト %s が見つかりません", En: "LFS object %s not found"},
+	{Code: "lfs_read_failed", Ja: "LFSオブジェクトの読み込みに失敗しました", En: "Failed to read the LFS object"},
+
+	// ファイルの編集
+	{Code: "invalid_action", Ja: "action には create、update、delete のいずれかを指定してください", En: "action must be create, update or delete"},
+	{Code: "invalid_encoding", Ja: "encoding には text または base64 を指定してください", En: "encoding must be text or base64"},
+	{Code: "invalid_base64", Ja: "base64 のデコードに失敗しました: %w", En: "Failed to decode base64: %s"},
+	{Code: "duplicate_path", Ja: "'%s' が複数回指定されています", En: "'%s' is specified more than once"},
+	{Code: "already_exists", Ja: "'%s' は既に存在します: %w", En: "'%s' already exists: %s"},
+	{Code: "sha_required", Ja: "'%s' を変更するには現在の sha を指定してください", En: "Specify the current sha to change '%s'"},
+	{Code: "sha_mismatch", Ja: "'%s' の sha が一致しません: %w", En: "The sha of '%s' does not match: %s"},
+	{Code: "stale_contents", Ja: "ファイルまたはブランチが更新されています", En: "The file or branch has been updated"},
+	{Code: "stale_contents", Ja: "ブランチ '%s' は parent のコミットから更新されています: %w", En: "Branch '%s' has been updated since the parent commit: %s"},
+	{Code: "commit_message_missing", Ja: "コミットメッセージを入力してください", En: "Enter a commit message"},
+	{Code: "no_changes", Ja: "変更するファイルを指定してください", En: "Specify the files to change"},
+	{Code: "author_missing", Ja: "作成者の名前とメールアドレスを指定してください", En: "Specify the author name and email address"},
+	{Code: "tree_create_failed", Ja: "ツリーの作成に失敗しました: %w", En: "Failed to create the tree: %s"},
+	{Code: "commit_create_failed", Ja: "コミットの作成に失敗しました: %w", En: "Failed to create the commit: %s"},
+	{Code: "file_write_failed", Ja: "ファイルの書き込みに失敗しました: %w", En: "Failed to write the file: %s"},
+
+	// マージ、マージリクエスト
+	{Code: "merge_refs_missing", Ja: "base と head を指定してください", En: "Specify base and head"},
+	{Code: "merge_same_branch", Ja: "取り込むブランチとマージ先のブランチが同じです", En: "The head and base branches are the same"},
+	{Code: "merge_base_not_found", Ja: "共通祖先が見つかりません", En: "No common ancestor was found"},
+	{Code: "merge_failed", Ja: "マージに失敗しました: %w", En: "The merge failed: %s"},
+	{Code: "merge_commit_failed", Ja: "マージコミットの作成に失敗しました: %w", En: "Failed to create the merge commit: %s"},
+	{Code: "merge_request_not_found", Ja: "マージリクエストが見つかりません", En: "Merge request not found"},
+	{Code: "invalid_merge_request_id", Ja: "マージリクエストの番号が不正です", En: "The merge request number is invalid"},
+	{Code: "merge_request_merged", Ja: "マージ済みのマージリクエストは変更できません", En: "Merged merge requests cannot be changed"},
+	{Code: "merge_request_not_open", Ja: "オープンではないマージリクエストはマージできません", En: "Only open merge requests can be merged"},
+	{Code: "merge_request_failed", Ja: "マージリクエストの取得に失敗しました: %s", En: "Failed to get the merge requests: %s"},
+	{Code: "merge_request_save_failed", Ja: "マージリクエストの保存に失敗しました: %s", En: "Failed to save the merge request: %s"},
+	{Code: "merge_request_save_failed", Ja: "ブランチはマージされましたが、マージリクエストの保存に失敗しました: %w", En: "The branch was merged, but saving the merge request failed: %s"},
+	{Code: "invalid_state", Ja: "state には open または closed を指定してください", En: "state must be open or closed"},
+	{Code: "invalid_state", Ja: "state には open、closed、all のいずれかを指定してください", En: "state must be open, closed or all"},
+	{Code: "invalid_state", Ja: "state には open、closed、merged、all のいずれかを指定してください", En: "state must be open, closed, merged or all"},
+	{Code: "title_missing", Ja: "タイトルを入力してください", En: "Enter a title"},
+
+	// イシュー
+	{Code: "issue_not_found", Ja: "イシューが見つかりません", En: "Issue not found"},
+	{Code: "invalid_issue_id", Ja: "イシューの番号が不正です", En: "The issue number is invalid"},
+	{Code: "issue_deleted", Ja: "イシューが削除されました", En: "The issue was deleted"},
+	{Code: "issue_failed", Ja: "イシューの取得に失敗しました: %s", En: "Failed to get the issues: %s"},
+	{Code: "issue_save_failed", Ja: "イシューの保存に失敗しました: %s", En: "Failed to save the issue: %s"},
+	{Code: "issue_delete_failed", Ja: "イシューの削除に失敗しました: %s", En: "Failed to delete the issue: %s"},
+	{Code: "invalid_label", Ja: "ラベル '%s' は不正です（カンマと改行は使えません、%d文字以内）", En: "Label '%s' is invalid (no commas or newlines, up to %s characters)"},
+
+	// Wiki
+	{Code: "wiki_page_not_found", Ja: "ページ '%s' が見つかりません", En: "Page '%s' not found"},
+	{Code: "wiki_page_list_failed", Ja: "ページ一覧の取得に失敗しました: %w", En: "Failed to list the pages: %s"},
+	{Code: "wiki_exists", Ja: "Wiki は既に作成されています", En: "The wiki already exists"},
+	{Code: "wiki_exists", Ja: "リポジトリ '%s' の Wiki が既に存在します", En: "The wiki of repository '%s' already exists"},
+	{Code: "wiki_of_wiki", Ja: "Wiki のリポジトリに Wiki は作成できません", En: "Wiki repositories cannot have a wiki"},
+	{Code: "wiki_created", Ja: "Wiki を作成しました", En: "The wiki was created"},
+
+	// 統計、履歴
+	{Code: "unknown_stat", Ja: "不明な統計情報です: %s", En: "Unknown statistic: %s"},
+	{Code: "invalid_months", Ja: "months は1から%dの整数で指定してください", En: "months must be an integer between 1 and %s"},
+	{Code: "invalid_interval", Ja: "interval は day または week で指定してください", En: "interval must be day or week"},
+	{Code: "activity_failed", Ja: "コミットアクティビティの取得に失敗しました: %s", En: "Failed to get the commit activity: %s"},
+	{Code: "languages_failed", Ja: "言語統計の取得に失敗しました: %s", En: "Failed to get the language statistics: %s"},
+	{Code: "contributors_failed", Ja: "コントリビューターの取得に失敗しました: %s", En: "Failed to get the contributors: %s"},
+
+	// 通知
+	{Code: "notification_recipients_updated", Ja: "通知メールの宛先が更新されました", En: "The notification recipients were updated"},
+	{Code: "notification_recipients_failed", Ja: "通知メールの宛先の保存に失敗しました: %s", En: "Failed to save the notification recipients: %s"},
+	{Code: "too_many_recipients", Ja: "宛先は %d 件までです", En: "Up to %s recipients are allowed"},
+	{Code: "invalid_email", Ja: "メールアドレス '%s' は不正です", En: "Email address '%s' is invalid"},
+	{Code: "too_many_notifiers", Ja: "通知先は %d 件までです", En: "Up to %s notifiers are allowed"},
+	{Code: "invalid_notifier_type", Ja: "通知先の種類 '%s' は不正です（%s）", En: "Notifier type '%s' is invalid (%s)"},
+	{Code: "invalid_notifier_event", Ja: "イベント '%s' は不正です（%s）", En: "Event '%s' is invalid (%s)"},
+	{Code: "invalid_webhook_url", Ja: "Webhook の URL '%s' は不正です", En: "Webhook URL '%s' is invalid"},
+	{Code: "webhook_failed", Ja: "Webhook が %s を返しました", En: "The webhook returned %s"},
+	{Code: "notifiers_save_failed", Ja: "チャット通知の設定の保存に失敗しました: %w", En: "Failed to save the chat notifiers: %s"},
+
+	// アバター
+	{Code: "avatar_proxy_disabled", Ja: "アバターのプロキシは無効です", En: "The avatar proxy is disabled"},
+	{Code: "invalid_avatar_hash", Ja: "無効なハッシュ", En: "Invalid hash"},
+	{Code: "invalid_avatar_size", Ja: "s は 1 から %d の数値で指定してください", En: "s must be a number between 1 and %s"},
+	{Code: "avatar_failed", Ja: "アバター画像の取得に失敗しました: %s", En: "Failed to fetch the avatar image: %s"},
+	{Code: "avatar_too_large", Ja: "アバター画像が大きすぎます", En: "The avatar image is too large"},
+	{Code: "avatar_invalid_type", Ja: "アバター画像の形式 '%s' は不正です", En: "Avatar image type '%s' is invalid"},
+
+	// 管理者用（メンテナンス、フック、reflog、バックアップ、復元、ゴミ箱）
+	{Code: "unknown_maintenance", Ja: "不明なメンテナンスです: %s", En: "Unknown maintenance task: %s"},
+	{Code: "maintenance_running", Ja: "メンテナンスが既に実行中です", En: "Maintenance is already running"},
+	{Code: "maintenance_started", Ja: "メンテナンスを開始しました", En: "Maintenance started"},
+	{Code: "maintenance_failed", Ja: "メンテナンス（%s）に失敗しました: %s", En: "Maintenance (%s) failed: %s"},
+	{Code: "maintenance_save_failed", Ja: "メンテナンス結果の保存に失敗しました: %s", En: "Failed to save the maintenance result: %s"},
+	{Code: "unknown_hook_template", Ja: "不明なテンプレートです: %s", En: "Unknown template: %s"},
+	{Code: "invalid_hook_name", Ja: "無効なフック名です", En: "Invalid hook name"},
+	{Code: "hook_not_found", Ja: "フックが見つかりません", En: "Hook not found"},
+	{Code: "hook_deleted", Ja: "フックを削除しました", En: "The hook was deleted"},
+	{Code: "hook_managed", Ja: "このフックは保護ブランチ・容量制限・通知の設定で管理されています", En: "This hook is managed by the protected branch, quota and notification settings"},
+	{Code: "hook_not_from_template", Ja: "テンプレート以外から設置されたフックは削除できません（無効にすることはできます）", En: "Hooks not installed from a template cannot be deleted (they can be disabled)"},
+	{Code: "hook_exists", Ja: "テンプレート以外から設置された %s フックが既に存在します", En: "A %s hook not installed from a template already exists"},
+	{Code: "hook_exists", Ja: "guilty以外が設置した post-receive フックが既に存在します", En: "A post-receive hook not installed by guilty already exists"},
+	{Code: "hook_exists", Ja: "guilty以外が設置した pre-receive フックが既に存在します", En: "A pre-receive hook not installed by guilty already exists"},
+	{Code: "hook_install_failed", Ja: "%s フックの設置に失敗しました: %w", En: "Failed to install the %s hook: %s"},
+	{Code: "hook_install_failed", Ja: "hooksディレクトリの作成に失敗しました: %w", En: "Failed to create the hooks directory: %s"},
+	{Code: "hook_delete_failed", Ja: "フックの削除に失敗しました: %s", En: "Failed to delete the hook: %s"},
+	{Code: "hook_delete_failed", Ja: "%s フックの削除に失敗しました: %w", En: "Failed to delete the %s hook: %s"},
+	{Code: "hook_permission_failed", Ja: "パーミッションの変更に失敗しました: %s", En: "Failed to change the permissions: %s"},
+	{Code: "reflog_enabled", Ja: "reflog の記録を有効にしました", En: "Reflog recording was enabled"},
+	{Code: "reflog_not_found", Ja: "ref '%s' の reflog がありません", En: "Ref '%s' has no reflog"},
+	{Code: "reflog_failed", Ja: "ref '%s' の reflog の取得に失敗しました: %w", En: "Failed to get the reflog of ref '%s': %s"},
+	{Code: "reflog_enable_failed", Ja: "reflog の有効化に失敗しました: %s", En: "Failed to enable the reflog: %s"},
+	{Code: "backup_running", Ja: "バックアップが既に実行中です", En: "A backup is already running"},
+	{Code: "backup_created", Ja: "バックアップを作成しました", En: "The backup was created"},
+	{Code: "backup_failed", Ja: "バックアップに失敗しました: %s", En: "The backup failed: %s"},
+	{Code: "backup_exists", Ja: "バックアップ先に既にバックアップがあります: %s", En: "A backup already exists at the destination: %s"},
+	{Code: "backup_failed", Ja: "バックアップ先の作成に失敗しました: %w", En: "Failed to create the backup destination: %s"},
+	{Code: "invalid_backup_name", Ja: "無効なバックアップ名です", En: "Invalid backup name"},
+	{Code: "manifest_failed", Ja: "マニフェストの保存に失敗しました: %w", En: "Failed to save the manifest: %s"},
+	{Code: "manifest_failed", Ja: "マニフェストの読み込みに失敗しました: %w", En: "Failed to read the manifest: %s"},
+	{Code: "manifest_failed", Ja: "マニフェストの形式が不正です: %w", En: "The manifest is invalid: %s"},
+	{Code: "bundle_upload_failed", Ja: "バンドルの受信に失敗しました", En: "Failed to receive the bundle"},
+	{Code: "invalid_bundle_path", Ja: "バンドルのパスが不正です: %s", En: "The bundle path is invalid: %s"},
+	{Code: "restore_failed", Ja: "バンドルの復元に失敗しました: %s", En: "Failed to restore the bundle: %s"},
+	{Code: "restore_failed", Ja: "リポジトリの配置に失敗しました: %w", En: "Failed to move the repository into place: %s"},
+	{Code: "repository_restored", Ja: "リポジトリを復元しました", En: "The repository was restored"},
+	{Code: "repository_restored", Ja: "リポジトリが復元されました", En: "The repository was restored"},
+	{Code: "repository_purged", Ja: "リポジトリが完全に削除されました", En: "The repository was permanently deleted"},
+	{Code: "trash_failed", Ja: "ゴミ箱の取得に失敗しました: %s", En: "Failed to list the trash: %s"},
+	{Code: "not_in_trash", Ja: "ゴミ箱にリポジトリ '%s' は存在しません", En: "Repository '%s' is not in the trash"},
+	{Code: "purge_failed", Ja: "削除済みリポジトリの削除に失敗しました: %w", En: "Failed to delete the trashed repository: %s"},
+	{Code: "purge_failed", Ja: "削除済みリポジトリの権限変更に失敗しました: %w", En: "Failed to change the permissions of the trashed repository: %s"},
+	{Code: "purge_failed", Ja: "既存の削除済みリポジトリの削除に失敗しました: %w", En: "Failed to delete the existing trashed repository: %s"},
+	{Code: "metadata_store_failed", Ja: "メタデータストアの初期化に失敗しました: %w", En: "Failed to initialize the metadata store: %s"},
+	{Code: "audit_log_disabled", Ja: "監査ログが有効になっていません", En: "The audit log is not enabled"},
+	{Code: "audit_log_failed", Ja: "監査ログを開けませんでした: %w", En: "Failed to open the audit log: %s"},
+	{Code: "audit_log_failed", Ja: "監査ログを読めませんでした: %w", En: "Failed to read the audit log: %s"},
+	{Code: "invalid_time", Ja: "%s は RFC 3339 形式の日時で指定してください: %s", En: "%s must be an RFC 3339 date and time: %s"},
+	{Code: "invalid_cursor", Ja: "before は1以上の数値で指定してください: %s", En: "before must be a number of 1 or more: %s"},
+
+	// 設定
+	{Code: "config_reload_failed", Ja: "設定の再読み込みに失敗しました: %s", En: "Failed to reload the configuration: %s"},
+	{Code: "invalid_config", Ja: "設定ファイル '%s' を開けません: %w", En: "Cannot open the configuration file '%s': %s"},
+	{Code: "invalid_config", Ja: "設定ファイル '%s' の読み込みに失敗しました: %w", En: "Failed to read the configuration file '%s': %s"},
+	{Code: "invalid_config", Ja: "環境変数 %s: %w", En: "Environment variable %s: %s"},
+	{Code: "invalid_config", Ja: "port は 1 から 65535 の数値で指定してください: %d", En: "port must be a number between 1 and 65535: %s"},
+	{Code: "invalid_config", Ja: "smtpPort は 1 から 65535 の数値で指定してください: %d", En: "smtpPort must be a number between 1 and 65535: %s"},
+	{Code: "invalid_config", Ja: "httpRedirectPort は port 以外の 1 から 65535 の数値で指定してください: %d", En: "httpRedirectPort must be a number between 1 and 65535 other than port: %s"},
+	{Code: "invalid_config", Ja: "httpRedirectPort は tlsCertFile または autocertDomains と一緒に指定してください", En: "httpRedirectPort requires tlsCertFile or autocertDomains"},
+	{Code: "invalid_config", Ja: "tlsCertFile と autocertDomains は同時に指定できません", En: "tlsCertFile and autocertDomains cannot be used together"},
+	{Code: "invalid_config", Ja: "tlsCertFile と tlsKeyFile は両方指定してください", En: "Specify both tlsCertFile and tlsKeyFile"},
+	{Code: "invalid_config", Ja: "repositoryHome を空にすることはできません", En: "repositoryHome must not be empty"},
+	{Code: "invalid_config", Ja: "hostName に使えない文字が含まれています: %s", En: "hostName contains invalid characters: %s"},
+	{Code: "invalid_config", Ja: "%s には %%s を3つ（ホスト名、グループ名、リポジトリ名）含めてください: %s", En: "%s must contain three %%s (host name, group name and repository name): %s"},
+	{Code: "invalid_config", Ja: "basePath '%s' は不正です", En: "basePath '%s' is invalid"},
+	{Code: "invalid_config", Ja: "assetDirectory '%s' はディレクトリではありません", En: "assetDirectory '%s' is not a directory"},
+	{Code: "invalid_config", Ja: "groupNameBlacklist の正規表現 '%s' が不正です: %w", En: "The groupNameBlacklist regular expression '%s' is invalid: %s"},
+	{Code: "invalid_config", Ja: "group=バイト の形式で指定してください: %s", En: "Use the group=bytes format: %s"},
+	{Code: "invalid_config", Ja: "グループ '%s' のディスク使用量の上限に負の値は指定できません", En: "The disk quota of group '%s' must not be negative"},
+	{Code: "invalid_config", Ja: "defaultGroupQuota と trashRetention に負の値は指定できません", En: "defaultGroupQuota and trashRetention must not be negative"},
+	{Code: "invalid_config", Ja: "rateLimit と expensiveRateLimit に負の値は指定できません", En: "rateLimit and expensiveRateLimit must not be negative"},
+	{Code: "invalid_config", Ja: "trustedProxies の '%s' は CIDR または IP アドレスではありません", En: "'%s' in trustedProxies is not a CIDR or an IP address"},
+	{Code: "invalid_config", Ja: "logFormat は text または json で指定してください: %s", En: "logFormat must be text or json: %s"},
+	{Code: "invalid_config", Ja: "readTimeout、writeTimeout、idleTimeout、shutdownTimeout に負の値は指定できません", En: "readTimeout, writeTimeout, idleTimeout and shutdownTimeout must not be negative"},
+	{Code: "invalid_config", Ja: "pprofAddress は 127.0.0.1:6060 のような host:port の形式で指定してください: %s", En: "pprofAddress must be in the host:port format such as 127.0.0.1:6060: %s"},
+	{Code: "invalid_config", Ja: "gitCommandTimeout と gitMaintenanceTimeout に負の値は指定できません", En: "gitCommandTimeout and gitMaintenanceTimeout must not be negative"},
+	{Code: "invalid_config", Ja: "scanConcurrency には 1 以上の値を指定してください", En: "scanConcurrency must be 1 or more"},
+	{Code: "invalid_config", Ja: "repositoryCacheTtl に負の値は指定できません", En: "repositoryCacheTtl must not be negative"},
+	{Code: "invalid_config", Ja: "gitBackend は git または go-git で指定してください: %s", En: "gitBackend must be git or go-git: %s"},
+	{Code: "invalid_config", Ja: "unixSocketMode は 0660 のような8進数で指定してください: %s", En: "unixSocketMode must be an octal number such as 0660: %s"},
+	{Code: "invalid_config", Ja: "language には %s のいずれかを指定してください: %s", En: "language must be one of %s: %s"},
+	{Code: "invalid_config", Ja: "accessTokens は ユーザー名=トークンの SHA-256（16進数64文字） の形式で指定してください: %s", En: "accessTokens must use the user=SHA-256 of the token (64 hex digits) format: %s"},
+	{Code: "invalid_config", Ja: "adminUsers の '%s' はユーザー名として使えません", En: "'%s' in adminUsers is not a valid user name"},
+	{Code: "invalid_config", Ja: "authProxyHeader はHTTPのヘッダー名として使えません: %s", En: "authProxyHeader is not a valid HTTP header name: %s"},
+	{Code: "invalid_config", Ja: "authProxyHeader を指定する場合は authProxySources に認証プロキシの接続元を指定してください", En: "authProxySources must list the authentication proxy addresses when authProxyHeader is set"},
+	{Code: "invalid_config", Ja: "authProxySources の '%s' は CIDR、IP アドレスまたは unix ではありません", En: "'%s' in authProxySources is not a CIDR, an IP address or unix"},
+	{Code: "invalid_config", Ja: "defaultBranch はブランチ名として使えません: %s", En: "defaultBranch is not a valid branch name: %s"},
+}
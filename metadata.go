@@ -0,0 +1,477 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MetadataStorePath はgitの外で管理するリポジトリのメタデータを保存するファイルを定義します
+var MetadataStorePath = "guilty.db"
+
+// metadataBucket はリポジトリごとのメタデータを保存するバケット名
+var metadataBucket = []byte("repositories")
+
+// metadataStore はメタデータの保存に使う組み込みデータベース（開けなかった場合は nil）
+var metadataStore *bolt.DB
+
+// リポジトリの公開範囲
+const (
+	VisibilityPublic   = "public"
+	VisibilityInternal = "internal" // 認証されたユーザーだけが見られる
+	VisibilityPrivate  = "private"  // 管理者とメンバーだけが見られる
+)
+
+// トピック名のパターン（英小文字、数字、ハイフンのみ）
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,34}$`)
+
+// RepositoryMetadata はgitリポジトリ自体には保存されないリポジトリの付加情報
+type RepositoryMetadata struct {
+	Topics     []string `json:"topics"`
+	Website    string   `json:"website"`
+	Visibility string   `json:"visibility"` // "public"、"internal"、"private"
+	Members    []string `json:"members"`    // private のリポジトリを見られるユーザー
+	Archived   bool     `json:"archived"`
+}
+
+// UpdateRepositoryRequest はリポジトリ設定の部分更新（PATCH）用の構造体
+// 指定されなかった項目は変更しない
+type UpdateRepositoryRequest struct {
+	Description *string   `json:"description"`
+	Topics      *[]string `json:"topics"`
+	Website     *string   `json:"website"`
+	Visibility  *string   `json:"visibility"`
+	Members     *[]string `json:"members"`
+	Archived    *bool     `json:"archived"`
+
+	DefaultBranch *string `json:"defaultBranch"` // HEAD が指すブランチ
+	Name          *string `json:"name"`          // 新しいリポジトリ名（同じグループ内での名前の変更）
+}
+
+// openMetadataStore はメタデータストアを開く
+func openMetadataStore(path string) error {
+	// サーバー起動中に backup コマンドを実行した場合などにロック待ちで止まらないようにする
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("メタデータストアを開けませんでした: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{metadataBucket, mergeRequestsBucket, issuesBucket, notifiersBucket, repositoryIndexBucket, starsBucket, pinsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("メタデータストアの初期化に失敗しました: %w", err)
+	}
+
+	metadataStore = db
+	return nil
+}
+
+// closeMetadataStore はメタデータストアを閉じる（サーバーの終了時に呼ぶ）
+func closeMetadataStore() {
+	if metadataStore == nil {
+		return
+	}
+	if err := metadataStore.Close(); err != nil {
+		log.Printf("警告: メタデータストアを閉じられませんでした: %v", err)
+	}
+}
+
+// metadataKey はメタデータストアのキー（group/name）を返す
+func metadataKey(groupName, repoName string) []byte {
+	return []byte(groupName + "/" + repoName)
+}
+
+// getRepositoryMetadata はリポジトリのメタデータを取得する
+// 保存されていない場合は既定値を返す
+func getRepositoryMetadata(groupName, repoName string) RepositoryMetadata {
+	meta := RepositoryMetadata{
+		Topics:     []string{},
+		Visibility: VisibilityPublic,
+		Members:    []string{},
+	}
+
+	if metadataStore == nil {
+		return meta
+	}
+
+	metadataStore.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metadataBucket).Get(metadataKey(groupName, repoName))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			log.Printf("警告: リポジトリ '%s/%s' のメタデータの読み込みに失敗しました: %v", groupName, repoName, err)
+		}
+		return nil
+	})
+
+	if meta.Topics == nil {
+		meta.Topics = []string{}
+	}
+	if meta.Visibility == "" {
+		meta.Visibility = VisibilityPublic
+	}
+	if meta.Members == nil {
+		meta.Members = []string{}
+	}
+
+	return meta
+}
+
+// setRepositoryMetadata はリポジトリのメタデータを保存する
+func setRepositoryMetadata(groupName, repoName string, meta RepositoryMetadata) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metadataBucket).Put(metadataKey(groupName, repoName), data)
+	})
+}
+
+// deleteRepositoryMetadata はリポジトリのメタデータ、マージリクエスト、イシュー、スター、ピン留めを削除する
+func deleteRepositoryMetadata(groupName, repoName string) error {
+	if metadataStore == nil {
+		return nil
+	}
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		if err := deleteMergeRequests(tx, groupName, repoName); err != nil {
+			return err
+		}
+		if err := deleteIssues(tx, groupName, repoName); err != nil {
+			return err
+		}
+		if err := deleteRepositoryStars(tx, groupName, repoName); err != nil {
+			return err
+		}
+		return tx.Bucket(metadataBucket).Delete(metadataKey(groupName, repoName))
+	})
+}
+
+// hasRepositoryMetadata はリポジトリのメタデータ、マージリクエスト、イシュー、スターのいずれかが保存されているか確認する
+func hasRepositoryMetadata(groupName, repoName string) bool {
+	if metadataStore == nil {
+		return false
+	}
+
+	found := false
+	key := metadataKey(groupName, repoName)
+	metadataStore.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(metadataBucket).Get(key) != nil ||
+			tx.Bucket(starsBucket).Get(key) != nil ||
+			tx.Bucket(mergeRequestsBucket).Bucket(key) != nil ||
+			tx.Bucket(issuesBucket).Bucket(key) != nil
+		return nil
+	})
+	return found
+}
+
+// moveRepositoryMetadata はリポジトリのメタデータ、マージリクエスト、イシュー、一覧の索引、スター、ピン留めを新しい名前に移す
+func moveRepositoryMetadata(groupName, repoName, newGroupName, newRepoName string) error {
+	if metadataStore == nil {
+		return nil
+	}
+
+	oldKey, newKey := metadataKey(groupName, repoName), metadataKey(newGroupName, newRepoName)
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{metadataBucket, repositoryIndexBucket, starsBucket} {
+			bucket := tx.Bucket(name)
+			data := bucket.Get(oldKey)
+			if data == nil {
+				continue
+			}
+			if err := bucket.Put(newKey, append([]byte(nil), data...)); err != nil {
+				return err
+			}
+			if err := bucket.Delete(oldKey); err != nil {
+				return err
+			}
+		}
+		for _, name := range [][]byte{mergeRequestsBucket, issuesBucket} {
+			if err := moveNestedBucket(tx.Bucket(name), oldKey, newKey); err != nil {
+				return err
+			}
+		}
+		return renamePinnedRepository(tx, groupName, repoName, newGroupName, newRepoName)
+	})
+}
+
+// moveNestedBucket は parent の中のバケットを、通し番号とともに新しいキーに移す（bbolt にはバケットの名前を変える操作がない）
+func moveNestedBucket(parent *bolt.Bucket, oldKey, newKey []byte) error {
+	old := parent.Bucket(oldKey)
+	if old == nil {
+		return nil
+	}
+
+	moved, err := parent.CreateBucket(newKey)
+	if err != nil {
+		return err
+	}
+	err = old.ForEach(func(key, value []byte) error {
+		return moved.Put(append([]byte(nil), key...), append([]byte(nil), value...))
+	})
+	if err != nil {
+		return err
+	}
+	if err := moved.SetSequence(old.Sequence()); err != nil {
+		return err
+	}
+	return parent.DeleteBucket(oldKey)
+}
+
+// normalizeTopics はトピックを小文字化・重複除去・検証してソートする
+func normalizeTopics(topics []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+
+	for _, topic := range topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" || seen[topic] {
+			continue
+		}
+		if !topicPattern.MatchString(topic) {
+			return nil, fmt.Errorf("トピック '%s' は不正です（英小文字、数字、ハイフンのみ、35文字以内）", topic)
+		}
+		seen[topic] = true
+		normalized = append(normalized, topic)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// applyRepositoryUpdate は部分更新リクエストの内容をメタデータに反映する
+func applyRepositoryUpdate(meta *RepositoryMetadata, req UpdateRepositoryRequest) error {
+	if req.Topics != nil {
+		topics, err := normalizeTopics(*req.Topics)
+		if err != nil {
+			return err
+		}
+		meta.Topics = topics
+	}
+
+	if req.Website != nil {
+		website := strings.TrimSpace(*req.Website)
+		if website != "" {
+			u, err := url.Parse(website)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("ウェブサイトには http または https のURLを指定してください")
+			}
+		}
+		meta.Website = website
+	}
+
+	if req.Visibility != nil {
+		if *req.Visibility != VisibilityPublic && *req.Visibility != VisibilityInternal && *req.Visibility != VisibilityPrivate {
+			return fmt.Errorf("公開範囲には '%s'、'%s'、'%s' のいずれかを指定してください", VisibilityPublic, VisibilityInternal, VisibilityPrivate)
+		}
+		meta.Visibility = *req.Visibility
+	}
+
+	if req.Members != nil {
+		members := []string{}
+		for _, member := range *req.Members {
+			if !userNamePattern.MatchString(member) {
+				return fmt.Errorf("メンバー '%s' はユーザー名として使えません", member)
+			}
+			if !containsString(members, member) {
+				members = append(members, member)
+			}
+		}
+		meta.Members = members
+	}
+
+	if req.Archived != nil {
+		meta.Archived = *req.Archived
+	}
+
+	return nil
+}
+
+// patchRepository はリクエストボディに含まれる項目だけリポジトリの設定を更新し、結果を書き込む
+// 名前の変更は、ほかの項目を更新した後に行う
+func patchRepository(w http.ResponseWriter, r *http.Request, groupName, repoName string) {
+	var req UpdateRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if (req.Visibility != nil || req.Members != nil) && !canManageRepositoryAccess(requestUser(r), getRepositoryMetadata(groupName, repoName)) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "公開範囲とメンバーは管理者とリポジトリのメンバーだけが変更できます"})
+		return
+	}
+
+	// 名前を変更する場合は、ほかの項目を更新する前に新しい名前を検証する
+	rename := req.Name != nil && *req.Name != repoName
+	if rename {
+		if err := validateRepositoryName(*req.Name, groupName); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// メタデータの項目が含まれている場合は検証してから保存
+	if req.Topics != nil || req.Website != nil || req.Visibility != nil || req.Members != nil || req.Archived != nil {
+		meta := getRepositoryMetadata(groupName, repoName)
+		if err := applyRepositoryUpdate(&meta, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if err := setRepositoryMetadata(groupName, repoName, meta); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "メタデータの保存に失敗しました: " + err.Error()})
+			return
+		}
+	}
+
+	if req.Description != nil {
+		if err := setRepositoryDescription(repoPath, *req.Description); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.DefaultBranch != nil {
+		if err := changeRepositoryHead(r.Context(), groupName, repoName, *req.DefaultBranch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if details := repositoryUpdateDetails(req); details != nil {
+		recordAudit(r, "repository.update", groupName, repoName, details)
+	}
+
+	if rename {
+		if err := renameRepository(groupName, repoName, *req.Name); err != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		recordAudit(r, "repository.rename", groupName, *req.Name, map[string]string{"from": groupName + "/" + repoName, "to": groupName + "/" + *req.Name})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリの設定が更新されました"})
+}
+
+// repositoryUpdateDetails は監査ログに記録する、更新した項目（fields）と変更後の公開範囲などの値を返す（名前の変更は含めない）
+// 更新した項目がない場合は nil を返す
+func repositoryUpdateDetails(req UpdateRepositoryRequest) map[string]string {
+	var fields []string
+	details := map[string]string{}
+	if req.Description != nil {
+		fields = append(fields, "description")
+	}
+	if req.Topics != nil {
+		fields = append(fields, "topics")
+		details["topics"] = strings.Join(*req.Topics, ",")
+	}
+	if req.Website != nil {
+		fields = append(fields, "website")
+		details["website"] = *req.Website
+	}
+	if req.Visibility != nil {
+		fields = append(fields, "visibility")
+		details["visibility"] = *req.Visibility
+	}
+	if req.Members != nil {
+		fields = append(fields, "members")
+		details["members"] = strings.Join(*req.Members, ",")
+	}
+	if req.Archived != nil {
+		fields = append(fields, "archived")
+		details["archived"] = strconv.FormatBool(*req.Archived)
+	}
+	if req.DefaultBranch != nil {
+		fields = append(fields, "defaultBranch")
+		details["defaultBranch"] = *req.DefaultBranch
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	details["fields"] = strings.Join(fields, ",")
+	return details
+}
+
+// filterRepositories はクエリパラメータ（topic, visibility, archived, pinned）でリポジトリ一覧を絞り込む
+func filterRepositories(repos []GitRepository, query url.Values) []GitRepository {
+	topic := strings.ToLower(query.Get("topic"))
+	visibility := query.Get("visibility")
+	archived := query.Get("archived")
+	pinned := query.Get("pinned")
+	q := query.Get("q")
+
+	if topic == "" && visibility == "" && archived == "" && pinned == "" && q == "" {
+		return repos
+	}
+
+	filtered := []GitRepository{}
+	for _, repo := range repos {
+		if topic != "" && !containsString(repo.Topics, topic) {
+			continue
+		}
+		if visibility != "" && repo.Visibility != visibility {
+			continue
+		}
+		if archived != "" && fmt.Sprintf("%t", repo.Archived) != archived {
+			continue
+		}
+		if pinned != "" && fmt.Sprintf("%t", repo.Pinned) != pinned {
+			continue
+		}
+		if q != "" && !matchesRepositoryQuery(repo, q) {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+
+	return filtered
+}
+
+// containsString はスライスに文字列が含まれているか確認する
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
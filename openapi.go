@@ -0,0 +1,730 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenAPIVersion は /api/openapi.json が準拠する OpenAPI のバージョン
+const OpenAPIVersion = "3.0.3"
+
+// APIVersion は OpenAPI ドキュメントの info.version に書く API のバージョン
+var APIVersion = "1.0.0"
+
+// openAPISchema は OpenAPI のスキーマ（JSON にそのまま変換する）
+type openAPISchema = map[string]interface{}
+
+// APIError はエラーレスポンスの形式を表す（ドキュメント用）
+type APIError struct {
+	Error  string `json:"error"`            // Accept-Language（または language の設定）の言語のメッセージ
+	Code   string `json:"code"`             // 機械的に判定するためのコード（repository_not_found など）
+	Detail string `json:"detail,omitempty"` // 翻訳できなかったメッセージの原文
+}
+
+// APIMessage は成功メッセージのレスポンスの形式を表す（ドキュメント用）
+type APIMessage struct {
+	Message string `json:"message"`
+}
+
+// FileContent はファイル内容取得APIのレスポンスの形式を表す（ドキュメント用）
+// ファイルの種類によって含まれる項目が異なる
+type FileContent struct {
+	Content   string      `json:"content"`
+	IsBinary  bool        `json:"isBinary"`
+	IsSymlink bool        `json:"isSymlink,omitempty"` // シンボリックリンクの場合
+	Target    string      `json:"target,omitempty"`    // シンボリックリンクのリンク先
+	Preview   string      `json:"preview,omitempty"`   // "image" または "pdf"（内容は raw=1 で取得する）
+	Truncated bool        `json:"truncated,omitempty"` // MaxFileContentSize を超えたため先頭部分だけを返した
+	Size      int64       `json:"size,omitempty"`      // truncated の場合のファイル全体のサイズ
+	IsLfs     bool        `json:"isLfs,omitempty"`     // Git LFS のポインタファイルの場合
+	LFS       *LFSPointer `json:"lfs,omitempty"`
+	Message   string      `json:"message,omitempty"` // 内容を表示できない理由など
+}
+
+// apiParameter はパスまたはクエリのパラメータを表す
+type apiParameter struct {
+	Name        string
+	In          string // "path" または "query"
+	Type        string // "string"、"integer"、"boolean"
+	Description string
+}
+
+// apiResponse はステータスコードごとのレスポンスを表す
+type apiResponse struct {
+	Status      int
+	Description string
+	Body        interface{} // レスポンスの型の値（スキーマを生成する）、または openAPISchema
+	ContentType string      // 省略時は application/json
+}
+
+// apiOperation は1つのエンドポイントとメソッドの組を表す
+type apiOperation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Parameters  []apiParameter
+	RequestBody interface{} // リクエストの型の値、または openAPISchema
+	RequestType string      // 省略時は application/json
+	Responses   []apiResponse
+	Deprecated  bool // 互換性のために残している操作
+}
+
+// よく使うパラメータ
+var (
+	groupParam = apiParameter{Name: "groupName", In: "path", Type: "string", Description: "グループ名"}
+	repoParam  = apiParameter{Name: "repoName", In: "path", Type: "string", Description: "リポジトリ名"}
+	refParam   = apiParameter{Name: "ref", In: "query", Type: "string", Description: "ブランチ、タグ、コミットSHA（省略時は HEAD）"}
+	pageParam  = apiParameter{Name: "page", In: "query", Type: "integer", Description: "ページ番号（1から）"}
+	limitParam = apiParameter{Name: "limit", In: "query", Type: "integer", Description: "1ページの件数"}
+	idParam    = apiParameter{Name: "id", In: "path", Type: "integer", Description: "リポジトリごとの通し番号"}
+	repoParams = []apiParameter{groupParam, repoParam}
+)
+
+// stringListSchema は文字列の配列を1つ持つオブジェクトのスキーマを作る（{"patterns": [...]} など）
+func stringListSchema(name string) openAPISchema {
+	return objectSchema(openAPISchema{name: openAPISchema{"type": "array", "items": openAPISchema{"type": "string"}}})
+}
+
+// objectSchema はプロパティを指定したオブジェクトのスキーマを作る
+func objectSchema(properties openAPISchema) openAPISchema {
+	return openAPISchema{"type": "object", "properties": properties}
+}
+
+// withParams はリポジトリのパラメータに追加のパラメータを加える
+func withParams(params ...apiParameter) []apiParameter {
+	return append(append([]apiParameter{}, repoParams...), params...)
+}
+
+// okResponse は 200 OK のレスポンスを作る
+func okResponse(description string, body interface{}) apiResponse {
+	return apiResponse{Status: http.StatusOK, Description: description, Body: body}
+}
+
+// errorResponse は任意のステータスコードのレスポンスを作る（本文はエラーの形式）
+func errorResponse(code int, description string) apiResponse {
+	return apiResponse{Status: code, Description: description, Body: APIError{}}
+}
+
+// messageResponse は成功メッセージを返すレスポンスを作る
+func messageResponse(code int, description string) apiResponse {
+	return apiResponse{Status: code, Description: description, Body: APIMessage{}}
+}
+
+// repositoryListParameters はリポジトリ一覧（/api/repositories と /api/repositories/all）の絞り込み・並び順・ページのパラメータ
+var repositoryListParameters = []apiParameter{
+	{Name: "topic", In: "query", Type: "string", Description: "トピックで絞り込む"},
+	{Name: "visibility", In: "query", Type: "string", Description: "public、internal、private のいずれか"},
+	{Name: "archived", In: "query", Type: "boolean", Description: "アーカイブ状態で絞り込む"},
+	{Name: "pinned", In: "query", Type: "boolean", Description: "グループでピン留めされているかどうかで絞り込む"},
+	{Name: "size", In: "query", Type: "boolean", Description: "true の場合は diskSize を含める"},
+	{Name: "q", In: "query", Type: "string", Description: "名前に含まれる文字列で絞り込む（大文字小文字を区別しない）"},
+	{Name: "sort", In: "query", Type: "string", Description: "name（名前順）、last_commit（最終コミットの新しい順、既定）、size（ディスク使用量の大きい順）、stars（スターの多い順）"},
+	pageParam,
+	{Name: "per_page", In: "query", Type: "integer", Description: "1ページの件数（page と per_page のどちらも省略した場合はすべて返す）"},
+	{Name: "lastCommit", In: "query", Type: "boolean", Description: "false の場合は lastCommit と license を含めずにすぐ返す（既定の並び順は name）"},
+}
+
+// apiOperations はguiltyのすべてのAPIの一覧
+// エンドポイントを追加・変更した場合はここも更新する
+var apiOperations = []apiOperation{
+	// リポジトリ
+	{Method: "GET", Path: "/api/repositories", Tag: "repositories", Summary: "リポジトリの一覧",
+		Parameters: append([]apiParameter{
+			{Name: "group", In: "query", Type: "string", Description: "グループ名"},
+		}, repositoryListParameters...),
+		Responses: []apiResponse{okResponse("リポジトリの一覧（絞り込んだ後の総数は X-Total-Count ヘッダー）", []GitRepository{}), errorResponse(http.StatusBadRequest, "page、per_page、sort、lastCommit が不正")}},
+	{Method: "GET", Path: "/api/repositories/all", Tag: "repositories", Summary: "すべてのグループのリポジトリの一覧",
+		Parameters: repositoryListParameters,
+		Responses:  []apiResponse{okResponse("すべてのグループのリポジトリの一覧（絞り込んだ後の総数は X-Total-Count ヘッダー）", []GitRepository{}), errorResponse(http.StatusBadRequest, "page、per_page、sort、lastCommit が不正")}},
+	{Method: "GET", Path: "/api/repositories/summaries", Tag: "repositories", Summary: "リポジトリの最新コミット情報をまとめて取得",
+		Parameters: []apiParameter{
+			{Name: "group", In: "query", Type: "string", Description: "グループ名"},
+			{Name: "name", In: "query", Type: "string", Description: "リポジトリ名（1〜100個、複数指定する）"},
+		},
+		Responses: []apiResponse{okResponse("指定したリポジトリの最新コミットとライセンス（存在しないリポジトリは含めない）", []RepositorySummary{}), errorResponse(http.StatusBadRequest, "name がない、または多すぎる")}},
+	{Method: "POST", Path: "/api/repositories", Tag: "repositories", Summary: "リポジトリの作成",
+		RequestBody: CreateRepositoryRequest{},
+		Responses:   []apiResponse{messageResponse(http.StatusOK, "作成しました"), errorResponse(http.StatusBadRequest, "名前またはテンプレートが不正、または既に存在する")}},
+	{Method: "GET", Path: "/api/repository-templates", Tag: "repositories", Summary: "リポジトリの作成時に選べる .gitignore とライセンスのテンプレート",
+		Responses: []apiResponse{okResponse("テンプレートの一覧", RepositoryTemplateList{})}},
+	{Method: "GET", Path: "/api/groups/{groupName}/pins", Tag: "repositories", Summary: "グループでピン留めしたリポジトリ",
+		Parameters: []apiParameter{groupParam}, Responses: []apiResponse{okResponse("ピン留めしたリポジトリ名（表示する順）", PinnedRepositories{})}},
+	{Method: "PUT", Path: "/api/groups/{groupName}/pins", Tag: "repositories", Summary: "グループでピン留めするリポジトリの置き換え",
+		Parameters: []apiParameter{groupParam}, RequestBody: PinnedRepositories{},
+		Responses: []apiResponse{okResponse("更新後のピン留めしたリポジトリ名", PinnedRepositories{}), errorResponse(http.StatusBadRequest, "リポジトリが存在しない、または多すぎる")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/star", Tag: "repositories", Summary: "スターの数と、リクエストを送ったユーザーがスターを付けているか",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("スターの状態", StarStatus{})}},
+	{Method: "PUT", Path: "/api/repository/{groupName}/{repoName}/star", Tag: "repositories", Summary: "スターを付ける",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("スターの状態", StarStatus{}), errorResponse(http.StatusUnauthorized, "ユーザーが認証されていない")}},
+	{Method: "DELETE", Path: "/api/repository/{groupName}/{repoName}/star", Tag: "repositories", Summary: "スターを外す",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("スターの状態", StarStatus{}), errorResponse(http.StatusUnauthorized, "ユーザーが認証されていない")}},
+	{Method: "GET", Path: "/api/groups", Tag: "repositories", Summary: "グループの一覧",
+		Parameters: []apiParameter{{Name: "quota", In: "query", Type: "boolean", Description: "true の場合は GroupQuotaStatus の配列を返す"}},
+		Responses: []apiResponse{okResponse("グループ名の配列（quota=true の場合は GroupQuotaStatus の配列）", openAPISchema{"oneOf": []interface{}{
+			openAPISchema{"type": "array", "items": openAPISchema{"type": "string"}},
+			openAPISchema{"type": "array", "items": openAPISchema{"$ref": "#/components/schemas/GroupQuotaStatus"}},
+		}})}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}", Tag: "repositories", Summary: "リポジトリの詳細",
+		Parameters: repoParams,
+		Responses:  []apiResponse{okResponse("リポジトリの詳細", RepositoryDetails{}), errorResponse(http.StatusNotFound, "リポジトリが見つからない")}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}", Tag: "repositories", Summary: "リポジトリの操作（削除、説明の変更、デフォルトブランチの変更）。DELETE と PATCH を使うこと",
+		Parameters: repoParams, Deprecated: true,
+		RequestBody: objectSchema(openAPISchema{
+			"operation":   openAPISchema{"type": "string", "enum": []string{"delete", "description", "default-branch"}},
+			"description": openAPISchema{"type": "string"},
+			"branch":      openAPISchema{"type": "string"},
+		}),
+		Responses: []apiResponse{messageResponse(http.StatusOK, "操作しました"), errorResponse(http.StatusBadRequest, "不正な操作")}},
+	{Method: "PATCH", Path: "/api/repository/{groupName}/{repoName}", Tag: "repositories", Summary: "説明、メタデータ、デフォルトブランチの更新と名前の変更",
+		Parameters: repoParams, RequestBody: UpdateRepositoryRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusOK, "更新しました"), errorResponse(http.StatusBadRequest, "不正な値"),
+			errorResponse(http.StatusForbidden, "管理者とメンバー以外が visibility または members を変更しようとした"), errorResponse(http.StatusConflict, "変更後の名前のリポジトリが既にある")}},
+	{Method: "DELETE", Path: "/api/repository/{groupName}/{repoName}", Tag: "repositories", Summary: "リポジトリの削除（ゴミ箱へ移動）",
+		Parameters: repoParams,
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "削除しました"), errorResponse(http.StatusNotFound, "リポジトリが見つからない")}},
+	{Method: "POST", Path: "/api/head/{groupName}/{repoName}", Tag: "repositories", Summary: "HEAD ブランチの変更。PATCH /api/repository/{groupName}/{repoName} の defaultBranch を使うこと",
+		Parameters: repoParams, Deprecated: true, RequestBody: objectSchema(openAPISchema{"branch": openAPISchema{"type": "string"}}),
+		Responses: []apiResponse{messageResponse(http.StatusOK, "変更しました"), errorResponse(http.StatusBadRequest, "ブランチが不正")}},
+
+	// ブランチ・タグ
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/branches", Tag: "refs", Summary: "ブランチの一覧",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("ブランチの一覧", []BranchInfo{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/branches", Tag: "refs", Summary: "ブランチの作成",
+		Parameters: repoParams, RequestBody: CreateBranchRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusCreated, "作成しました"), errorResponse(http.StatusConflict, "既に存在する")}},
+	{Method: "DELETE", Path: "/api/repository/{groupName}/{repoName}/branches/{branchName}", Tag: "refs", Summary: "ブランチの削除",
+		Parameters: withParams(apiParameter{Name: "branchName", In: "path", Type: "string", Description: "ブランチ名"}),
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "削除しました"), errorResponse(http.StatusBadRequest, "デフォルトブランチは削除できない")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/tags", Tag: "refs", Summary: "タグの一覧",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("タグの一覧（作成日時の新しい順）", []TagInfo{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/tags", Tag: "refs", Summary: "タグの作成",
+		Parameters: repoParams, RequestBody: CreateTagRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusCreated, "作成しました"), errorResponse(http.StatusConflict, "既に存在する")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/tags/{tagName}", Tag: "refs", Summary: "タグの詳細",
+		Parameters: withParams(apiParameter{Name: "tagName", In: "path", Type: "string", Description: "タグ名"}),
+		Responses:  []apiResponse{okResponse("タグの詳細", TagInfo{}), errorResponse(http.StatusNotFound, "タグが見つからない")}},
+	{Method: "DELETE", Path: "/api/repository/{groupName}/{repoName}/tags/{tagName}", Tag: "refs", Summary: "タグの削除",
+		Parameters: withParams(apiParameter{Name: "tagName", In: "path", Type: "string", Description: "タグ名"}),
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "削除しました"), errorResponse(http.StatusNotFound, "タグが見つからない")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/protected-branches", Tag: "refs", Summary: "保護ブランチのパターン",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("パターンの一覧", stringListSchema("patterns"))}},
+	{Method: "PUT", Path: "/api/repository/{groupName}/{repoName}/protected-branches", Tag: "refs", Summary: "保護ブランチのパターンの置き換え",
+		Parameters: repoParams, RequestBody: ProtectedBranchesRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusOK, "更新しました"), errorResponse(http.StatusBadRequest, "パターンが不正"),
+			errorResponse(http.StatusForbidden, "ユーザーが管理者（adminUsers）ではない")}},
+	{Method: "GET", Path: "/api/refs/{groupName}/{repoName}", Tag: "refs", Summary: "ブランチとタグの一覧",
+		Parameters: withParams(apiParameter{Name: "type", In: "query", Type: "string", Description: "branch または tag"}),
+		Responses:  []apiResponse{okResponse("参照の一覧（コミット日時の新しい順）", []RefInfo{})}},
+
+	// コミット
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/commits", Tag: "commits", Summary: "コミット履歴",
+		Parameters: withParams(refParam, pageParam, limitParam),
+		Responses:  []apiResponse{okResponse("コミットの一覧（新しい順）", []Commit{})}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/commits/{sha}", Tag: "commits", Summary: "コミットの詳細",
+		Parameters: withParams(apiParameter{Name: "sha", In: "path", Type: "string", Description: "コミットのSHA"}),
+		Responses:  []apiResponse{okResponse("コミットの詳細", CommitDetail{}), errorResponse(http.StatusNotFound, "コミットが見つからない")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/changelog", Tag: "commits", Summary: "2つの ref 間の変更履歴",
+		Parameters: withParams(
+			apiParameter{Name: "from", In: "query", Type: "string", Description: "開始タグ（または ref）"},
+			apiParameter{Name: "to", In: "query", Type: "string", Description: "終了タグ（省略時は HEAD）"},
+			apiParameter{Name: "group", In: "query", Type: "string", Description: "type の場合は Conventional Commits の種別ごとにまとめる"}),
+		Responses: []apiResponse{okResponse("変更履歴", Changelog{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/merge", Tag: "commits", Summary: "サーバー上でのマージ",
+		Parameters: repoParams, RequestBody: MergeBranchRequest{},
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "ブランチを更新した", Body: MergeResult{}},
+			okResponse("dryRun、または取り込むコミットがない", MergeResult{}),
+			{Status: http.StatusConflict, Description: "競合がある", Body: MergeResult{}},
+		}},
+	{Method: "GET", Path: "/api/history/{groupName}/{repoName}/{filePath}", Tag: "commits", Summary: "ファイルの変更履歴",
+		Parameters: withParams(apiParameter{Name: "filePath", In: "path", Type: "string", Description: "ファイルのパス"}, refParam, pageParam, limitParam),
+		Responses:  []apiResponse{okResponse("ファイルを変更したコミット（新しい順）", []FileHistoryEntry{})}},
+	{Method: "GET", Path: "/api/diff/{groupName}/{repoName}/{filePath}", Tag: "commits", Summary: "2つのコミットの間での1つのファイルの差分",
+		Parameters: withParams(apiParameter{Name: "filePath", In: "path", Type: "string", Description: "ファイルのパス（比較先での名前。名前を変更したファイルは比較元での名前でもよい）"},
+			apiParameter{Name: "from", In: "query", Type: "string", Description: "比較元のブランチ、タグまたはコミット"},
+			apiParameter{Name: "to", In: "query", Type: "string", Description: "比較先のブランチ、タグまたはコミット（省略時は HEAD）"}),
+		Responses: []apiResponse{okResponse("ファイルの差分", FileDiff{}), errorResponse(http.StatusBadRequest, "from がない"), errorResponse(http.StatusNotFound, "from、to が解決できない、またはファイルがない")}},
+	{Method: "GET", Path: "/api/graph/{groupName}/{repoName}", Tag: "commits", Summary: "ブランチとマージのグラフ",
+		Parameters: withParams(apiParameter{Name: "limit", In: "query", Type: "integer", Description: "コミット数（1〜1000、既定100）"}),
+		Responses:  []apiResponse{okResponse("すべてのブランチとタグから辿れるコミット（子が親より前になる順）と列", CommitGraph{}), errorResponse(http.StatusBadRequest, "limit が不正")}},
+
+	// ファイル
+	{Method: "GET", Path: "/api/directory/{groupName}/{repoName}/{dirPath}", Tag: "files", Summary: "ディレクトリの内容",
+		Parameters: withParams(apiParameter{Name: "dirPath", In: "path", Type: "string", Description: "ディレクトリのパス"}, refParam,
+			apiParameter{Name: "offset", In: "query", Type: "integer", Description: "先頭から読み飛ばすエントリ数"},
+			apiParameter{Name: "limit", In: "query", Type: "integer", Description: "返すエントリ数（1〜1000、省略時は100）"}),
+		Responses: []apiResponse{okResponse("ファイルとディレクトリの一覧（エントリの総数は X-Total-Count ヘッダー、/api/v1/ では pagination.total）", []GitFile{}), errorResponse(http.StatusBadRequest, "offset、limit が不正"), errorResponse(http.StatusNotFound, "ref が解決できない")}},
+	{Method: "GET", Path: "/api/file/{groupName}/{repoName}/{filePath}", Tag: "files", Summary: "ファイルの内容",
+		Parameters: withParams(apiParameter{Name: "filePath", In: "path", Type: "string", Description: "ファイルのパス"}, refParam,
+			apiParameter{Name: "raw", In: "query", Type: "string", Description: "指定した場合はファイルの内容をそのまま返す"}),
+		Responses: []apiResponse{
+			okResponse("ファイルの内容（raw を指定した場合はファイルそのもの）", FileContent{}),
+			errorResponse(http.StatusNotFound, "ファイルが見つからない"),
+		}},
+	{Method: "PUT", Path: "/api/repository/{groupName}/{repoName}/contents/{filePath}", Tag: "files", Summary: "ファイルの作成・更新",
+		Parameters:  withParams(apiParameter{Name: "filePath", In: "path", Type: "string", Description: "ファイルのパス"}),
+		RequestBody: PutContentsRequest{},
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "作成した", Body: ContentsCommitResult{}},
+			okResponse("更新した", ContentsCommitResult{}),
+			errorResponse(http.StatusConflict, "ファイルまたはブランチが更新されている"),
+		}},
+	{Method: "DELETE", Path: "/api/repository/{groupName}/{repoName}/contents/{filePath}", Tag: "files", Summary: "ファイルの削除",
+		Parameters:  withParams(apiParameter{Name: "filePath", In: "path", Type: "string", Description: "ファイルのパス"}),
+		RequestBody: DeleteContentsRequest{},
+		Responses:   []apiResponse{okResponse("削除した", ContentsCommitResult{}), errorResponse(http.StatusConflict, "ファイルまたはブランチが更新されている")}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/contents", Tag: "files", Summary: "複数のファイルの変更を1つのコミットにする",
+		Parameters: repoParams, RequestBody: CommitContentsRequest{},
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "コミットした", Body: ContentsCommitResult{}},
+			errorResponse(http.StatusConflict, "ファイルまたはブランチが更新されている"),
+		}},
+	{Method: "GET", Path: "/api/export/{groupName}/{repoName}", Tag: "files", Summary: "バンドルのダウンロード",
+		Parameters: repoParams,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "git bundle --all の内容", Body: openAPISchema{"type": "string", "format": "binary"}, ContentType: "application/octet-stream"},
+			errorResponse(http.StatusConflict, "コミットがない"),
+		}},
+
+	// マージリクエスト・イシュー・Wiki
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/merge-requests", Tag: "collaboration", Summary: "マージリクエストの一覧",
+		Parameters: withParams(apiParameter{Name: "state", In: "query", Type: "string", Description: "open、closed、merged、all"}),
+		Responses:  []apiResponse{okResponse("マージリクエストの一覧（新しい順）", []MergeRequest{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/merge-requests", Tag: "collaboration", Summary: "マージリクエストの作成",
+		Parameters: repoParams, RequestBody: CreateMergeRequestRequest{},
+		Responses: []apiResponse{{Status: http.StatusCreated, Description: "作成した", Body: MergeRequest{}}}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/merge-requests/{id}", Tag: "collaboration", Summary: "マージリクエスト",
+		Parameters: withParams(idParam), Responses: []apiResponse{okResponse("マージリクエスト", MergeRequest{}), errorResponse(http.StatusNotFound, "見つからない")}},
+	{Method: "PATCH", Path: "/api/repository/{groupName}/{repoName}/merge-requests/{id}", Tag: "collaboration", Summary: "マージリクエストの更新",
+		Parameters: withParams(idParam), RequestBody: UpdateMergeRequestRequest{},
+		Responses: []apiResponse{okResponse("更新した", MergeRequest{}), errorResponse(http.StatusConflict, "マージ済み")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/merge-requests/{id}/diff", Tag: "collaboration", Summary: "マージリクエストの差分",
+		Parameters: withParams(idParam), Responses: []apiResponse{okResponse("差分", MergeRequestDiff{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/merge-requests/{id}/merge", Tag: "collaboration", Summary: "マージリクエストのマージ",
+		Parameters: withParams(idParam), RequestBody: AcceptMergeRequestRequest{},
+		Responses: []apiResponse{okResponse("マージした", MergeResult{}), {Status: http.StatusConflict, Description: "競合がある、またはオープンではない", Body: MergeResult{}}}},
+	{Method: "GET", Path: "/api/issues/{groupName}/{repoName}", Tag: "collaboration", Summary: "イシューの一覧",
+		Parameters: withParams(
+			apiParameter{Name: "state", In: "query", Type: "string", Description: "open、closed、all"},
+			apiParameter{Name: "label", In: "query", Type: "string", Description: "ラベルで絞り込む"}),
+		Responses: []apiResponse{okResponse("イシューの一覧（新しい順）", []Issue{})}},
+	{Method: "POST", Path: "/api/issues/{groupName}/{repoName}", Tag: "collaboration", Summary: "イシューの作成",
+		Parameters: repoParams, RequestBody: CreateIssueRequest{},
+		Responses: []apiResponse{{Status: http.StatusCreated, Description: "作成した", Body: Issue{}}}},
+	{Method: "GET", Path: "/api/issues/{groupName}/{repoName}/{id}", Tag: "collaboration", Summary: "イシュー",
+		Parameters: withParams(idParam), Responses: []apiResponse{okResponse("イシュー", Issue{}), errorResponse(http.StatusNotFound, "見つからない")}},
+	{Method: "PATCH", Path: "/api/issues/{groupName}/{repoName}/{id}", Tag: "collaboration", Summary: "イシューの更新",
+		Parameters: withParams(idParam), RequestBody: UpdateIssueRequest{}, Responses: []apiResponse{okResponse("更新した", Issue{})}},
+	{Method: "DELETE", Path: "/api/issues/{groupName}/{repoName}/{id}", Tag: "collaboration", Summary: "イシューの削除",
+		Parameters: withParams(idParam), Responses: []apiResponse{messageResponse(http.StatusOK, "削除した")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/wiki", Tag: "collaboration", Summary: "Wiki のページ一覧",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("Wiki の状態とページ一覧", WikiIndex{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/wiki", Tag: "collaboration", Summary: "Wiki リポジトリの作成",
+		Parameters: repoParams, Responses: []apiResponse{messageResponse(http.StatusCreated, "作成した"), messageResponse(http.StatusOK, "既に作成されている")}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/wiki/{pageName}", Tag: "collaboration", Summary: "Wiki のページ",
+		Parameters: withParams(apiParameter{Name: "pageName", In: "path", Type: "string", Description: "ページ名"}),
+		Responses:  []apiResponse{okResponse("ページ", WikiPage{}), errorResponse(http.StatusNotFound, "ページが見つからない")}},
+
+	// 統計
+	{Method: "GET", Path: "/api/contributors/{groupName}/{repoName}", Tag: "stats", Summary: "作者ごとのコミット数",
+		Parameters: withParams(refParam,
+			apiParameter{Name: "since", In: "query", Type: "string", Description: "集計の開始（git log --since と同じ形式）"},
+			apiParameter{Name: "until", In: "query", Type: "string", Description: "集計の終了"}),
+		Responses: []apiResponse{okResponse("作者の一覧（コミット数の多い順）", []Contributor{})}},
+	{Method: "GET", Path: "/api/stats/{groupName}/{repoName}/activity", Tag: "stats", Summary: "コミット数の推移",
+		Parameters: withParams(
+			apiParameter{Name: "months", In: "query", Type: "integer", Description: "集計期間の月数（1〜60）"},
+			apiParameter{Name: "interval", In: "query", Type: "string", Description: "day または week"}),
+		Responses: []apiResponse{okResponse("日ごと・週ごとのコミット数", CommitActivity{})}},
+	{Method: "GET", Path: "/api/stats/{groupName}/{repoName}/languages", Tag: "stats", Summary: "言語ごとのサイズ",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("言語ごとのサイズ", LanguageStats{})}},
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/maintenance", Tag: "stats", Summary: "最後のメンテナンス結果",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("メンテナンス結果", MaintenanceStatus{})}},
+	{Method: "POST", Path: "/api/repository/{groupName}/{repoName}/maintenance", Tag: "stats", Summary: "git gc / git repack の実行",
+		Parameters: repoParams, RequestBody: MaintenanceRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusAccepted, "バックグラウンドで開始した"), errorResponse(http.StatusConflict, "実行中")}},
+
+	// 通知
+	{Method: "GET", Path: "/api/repository/{groupName}/{repoName}/subscribers", Tag: "notifications", Summary: "プッシュ通知メールの宛先",
+		Parameters: repoParams,
+		Responses: []apiResponse{okResponse("宛先", objectSchema(openAPISchema{
+			"subscribers":    openAPISchema{"type": "array", "items": openAPISchema{"type": "string"}},
+			"smtpConfigured": openAPISchema{"type": "boolean"},
+		}))}},
+	{Method: "PUT", Path: "/api/repository/{groupName}/{repoName}/subscribers", Tag: "notifications", Summary: "プッシュ通知メールの宛先の置き換え",
+		Parameters: repoParams, RequestBody: SubscribersRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusOK, "更新した"), errorResponse(http.StatusBadRequest, "メールアドレスが不正")}},
+	{Method: "GET", Path: "/api/admin/notifiers/{groupName}", Tag: "notifications", Summary: "グループのチャット通知の設定",
+		Parameters: []apiParameter{groupParam}, Responses: []apiResponse{okResponse("通知先の一覧", ChatNotifiersRequest{})}},
+	{Method: "PUT", Path: "/api/admin/notifiers/{groupName}", Tag: "notifications", Summary: "グループのチャット通知の設定の置き換え",
+		Parameters: []apiParameter{groupParam}, RequestBody: ChatNotifiersRequest{},
+		Responses: []apiResponse{okResponse("更新後の通知先の一覧", ChatNotifiersRequest{}), errorResponse(http.StatusBadRequest, "設定が不正")}},
+	{Method: "POST", Path: "/api/internal/post-receive", Tag: "notifications", Summary: "post-receive フックからの通知（サーバー内部用）",
+		RequestBody: openAPISchema{"type": "string"}, RequestType: "text/plain",
+		Responses: []apiResponse{
+			{Status: http.StatusAccepted, Description: "通知をバックグラウンドで送る", Body: objectSchema(openAPISchema{"updates": openAPISchema{"type": "integer"}})},
+			errorResponse(http.StatusForbidden, "X-Guilty-Hook-Secret が一致しない"),
+		}},
+
+	// ゴミ箱
+	{Method: "GET", Path: "/api/trash", Tag: "trash", Summary: "論理削除されたリポジトリの一覧",
+		Responses: []apiResponse{okResponse("削除日時の新しい順", []TrashedRepository{})}},
+	{Method: "POST", Path: "/api/trash/{groupName}/{repoName}", Tag: "trash", Summary: "復元または完全削除。POST .../restore と DELETE を使うこと",
+		Parameters: repoParams, Deprecated: true,
+		RequestBody: objectSchema(openAPISchema{
+			"operation": openAPISchema{"type": "string", "enum": []string{"restore", "purge"}},
+		}),
+		Responses: []apiResponse{messageResponse(http.StatusOK, "操作した"), errorResponse(http.StatusNotFound, "ゴミ箱にない")}},
+	{Method: "DELETE", Path: "/api/trash/{groupName}/{repoName}", Tag: "trash", Summary: "完全削除",
+		Parameters: repoParams,
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "完全に削除した"), errorResponse(http.StatusNotFound, "ゴミ箱にない")}},
+	{Method: "POST", Path: "/api/trash/{groupName}/{repoName}/restore", Tag: "trash", Summary: "復元",
+		Parameters: repoParams,
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "復元した"), errorResponse(http.StatusNotFound, "ゴミ箱にない")}},
+
+	// 管理者用
+	{Method: "POST", Path: "/api/admin/fsck", Tag: "admin", Summary: "すべてのリポジトリの整合性チェック",
+		Responses: []apiResponse{okResponse("リポジトリごとの結果", []FsckResult{})}},
+	{Method: "POST", Path: "/api/admin/fsck/{groupName}/{repoName}", Tag: "admin", Summary: "リポジトリの整合性チェック",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("結果", FsckResult{})}},
+	{Method: "POST", Path: "/api/admin/backup", Tag: "admin", Summary: "すべてのリポジトリのバックアップ",
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "バックアップを作成した", Body: objectSchema(openAPISchema{
+				"message":   openAPISchema{"type": "string"},
+				"directory": openAPISchema{"type": "string"},
+				"manifest":  openAPISchema{"$ref": "#/components/schemas/BackupManifest"},
+			})},
+			errorResponse(http.StatusConflict, "実行中"),
+		}},
+	{Method: "POST", Path: "/api/admin/restore", Tag: "admin", Summary: "バックアップからの一括復元",
+		RequestBody: RestoreRequest{}, Responses: []apiResponse{okResponse("リポジトリごとの結果", []RestoreResult{})}},
+	{Method: "POST", Path: "/api/admin/restore/{groupName}/{repoName}", Tag: "admin", Summary: "バンドルからの復元",
+		Parameters:  withParams(apiParameter{Name: "force", In: "query", Type: "boolean", Description: "既存のリポジトリを上書きする"}),
+		RequestBody: openAPISchema{"type": "string", "format": "binary"}, RequestType: "application/octet-stream",
+		Responses: []apiResponse{
+			messageResponse(http.StatusCreated, "復元した"),
+			errorResponse(http.StatusConflict, "既に存在する"),
+			errorResponse(http.StatusInsufficientStorage, "グループの容量の上限を超えている"),
+		}},
+	{Method: "GET", Path: "/api/admin/config", Tag: "admin", Summary: "現在の設定",
+		Responses: []apiResponse{okResponse("設定", objectSchema(openAPISchema{
+			"configFile": openAPISchema{"type": "string"},
+			"config":     openAPISchema{"type": "object", "additionalProperties": true},
+			"reloadable": openAPISchema{"type": "array", "items": openAPISchema{"type": "string"}},
+		}))}},
+	{Method: "POST", Path: "/api/admin/config/reload", Tag: "admin", Summary: "設定の再読み込み（SIGHUP と同じ）",
+		Responses: []apiResponse{okResponse("再読み込みの結果", ConfigReloadResult{}), errorResponse(http.StatusInternalServerError, "設定が不正")}},
+	{Method: "GET", Path: "/api/admin/audit", Tag: "admin", Summary: "監査ログの検索（新しい順）",
+		Parameters: []apiParameter{
+			{Name: "group", In: "query", Type: "string", Description: "対象のグループ"},
+			{Name: "repository", In: "query", Type: "string", Description: "対象のリポジトリ（グループ名/リポジトリ名）"},
+			{Name: "action", In: "query", Type: "string", Description: "操作の前方一致（repository. など）"},
+			{Name: "actor", In: "query", Type: "string", Description: "操作した主体のIPアドレスまたはユーザー名"},
+			{Name: "since", In: "query", Type: "string", Description: "この日時以降（RFC 3339）"},
+			{Name: "until", In: "query", Type: "string", Description: "この日時より前（RFC 3339）"},
+			{Name: "before", In: "query", Type: "integer", Description: "この通し番号より前（前の結果の next を指定する）"},
+			{Name: "limit", In: "query", Type: "integer", Description: "件数（既定 100、最大 1000）"},
+		},
+		Responses: []apiResponse{
+			okResponse("監査ログ", AuditLogPage{}),
+			errorResponse(http.StatusBadRequest, "検索条件が不正"),
+			errorResponse(http.StatusServiceUnavailable, "監査ログが無効"),
+		}},
+	{Method: "GET", Path: "/api/admin/hooks", Tag: "admin", Summary: "フックのテンプレートの一覧",
+		Responses: []apiResponse{okResponse("テンプレートの一覧", []HookTemplate{})}},
+	{Method: "GET", Path: "/api/admin/hooks/{groupName}/{repoName}", Tag: "admin", Summary: "リポジトリのフックの一覧",
+		Parameters: repoParams, Responses: []apiResponse{okResponse("フックの一覧", []HookInfo{})}},
+	{Method: "POST", Path: "/api/admin/hooks/{groupName}/{repoName}", Tag: "admin", Summary: "テンプレートからフックを設置",
+		Parameters: repoParams, RequestBody: InstallHookRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusCreated, "設置した"), errorResponse(http.StatusConflict, "テンプレート以外のフックがある")}},
+	{Method: "PATCH", Path: "/api/admin/hooks/{groupName}/{repoName}/{hookName}", Tag: "admin", Summary: "フックの有効・無効の切り替え",
+		Parameters: withParams(apiParameter{Name: "hookName", In: "path", Type: "string", Description: "フック名"}), RequestBody: UpdateHookRequest{},
+		Responses: []apiResponse{messageResponse(http.StatusOK, "変更した"), errorResponse(http.StatusConflict, "guiltyが管理するフック")}},
+	{Method: "DELETE", Path: "/api/admin/hooks/{groupName}/{repoName}/{hookName}", Tag: "admin", Summary: "フックの削除",
+		Parameters: withParams(apiParameter{Name: "hookName", In: "path", Type: "string", Description: "フック名"}),
+		Responses:  []apiResponse{messageResponse(http.StatusOK, "削除した"), errorResponse(http.StatusConflict, "guiltyが管理するフック")}},
+	{Method: "GET", Path: "/api/admin/reflog/{groupName}/{repoName}", Tag: "admin", Summary: "reflog",
+		Parameters: withParams(
+			apiParameter{Name: "ref", In: "query", Type: "string", Description: "HEAD、ブランチ名または refs/heads/..."},
+			apiParameter{Name: "limit", In: "query", Type: "integer", Description: "1つの ref について返す件数"}),
+		Responses: []apiResponse{okResponse("reflog", ReflogResult{}), errorResponse(http.StatusNotFound, "reflog がない")}},
+	{Method: "POST", Path: "/api/admin/reflog/{groupName}/{repoName}", Tag: "admin", Summary: "reflog の記録を始める",
+		Parameters: repoParams, Responses: []apiResponse{messageResponse(http.StatusOK, "有効にした")}},
+
+	// 画像
+	{Method: "GET", Path: "/api/avatar/{hash}", Tag: "images", Summary: "アバター画像のプロキシ（AvatarProxyEnabled が有効な場合のみ）",
+		Parameters: []apiParameter{
+			{Name: "hash", In: "path", Type: "string", Description: "メールアドレスの SHA-256 または MD5"},
+			{Name: "s", In: "query", Type: "integer", Description: "画像の大きさ（1〜512）"},
+		},
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "画像", Body: openAPISchema{"type": "string", "format": "binary"}, ContentType: "image/*"},
+			errorResponse(http.StatusNotFound, "プロキシが無効"),
+			errorResponse(http.StatusBadGateway, "取得に失敗した"),
+		}},
+	{Method: "GET", Path: "/badge/{groupName}/{repoName}/{badge}.svg", Tag: "images", Summary: "リポジトリのバッジ",
+		Parameters: withParams(
+			apiParameter{Name: "badge", In: "path", Type: "string", Description: "last-commit、branches、tags"},
+			apiParameter{Name: "label", In: "query", Type: "string", Description: "左側の文字列"}),
+		Responses: []apiResponse{{Status: http.StatusOK, Description: "SVG のバッジ", Body: openAPISchema{"type": "string"}, ContentType: "image/svg+xml"}}},
+	{Method: "GET", Path: "/badge/{groupName}/repositories.svg", Tag: "images", Summary: "グループのリポジトリ数のバッジ",
+		Parameters: []apiParameter{groupParam, {Name: "label", In: "query", Type: "string", Description: "左側の文字列"}},
+		Responses:  []apiResponse{{Status: http.StatusOK, Description: "SVG のバッジ", Body: openAPISchema{"type": "string"}, ContentType: "image/svg+xml"}}},
+
+	{Method: "GET", Path: "/api/openapi.json", Tag: "meta", Summary: "この OpenAPI ドキュメント",
+		Responses: []apiResponse{okResponse("OpenAPI 3 のドキュメント", openAPISchema{"type": "object"})}},
+}
+
+// openAPIGenerator は Go の型からスキーマを生成し、components.schemas に登録する
+type openAPIGenerator struct {
+	schemas openAPISchema
+}
+
+// timeType は日時として扱う型
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor は値または openAPISchema からスキーマを作る
+func (g *openAPIGenerator) schemaFor(v interface{}) openAPISchema {
+	if schema, ok := v.(openAPISchema); ok {
+		return schema
+	}
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf は型のスキーマを作る。名前のある構造体は components.schemas への参照にする
+func (g *openAPIGenerator) schemaOf(t reflect.Type) openAPISchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.schemaOf(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			// OpenAPI 3.0 では $ref と同じ階層に nullable を書けない
+			return openAPISchema{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return openAPISchema{"type": "string"}
+	case reflect.Bool:
+		return openAPISchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return openAPISchema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return openAPISchema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return openAPISchema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return openAPISchema{"type": "string", "format": "byte"}
+		}
+		return openAPISchema{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return openAPISchema{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return openAPISchema{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, exists := g.schemas[t.Name()]; !exists {
+			// 再帰的な型に備えて先に登録してから中身を作る
+			g.schemas[t.Name()] = openAPISchema{}
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return openAPISchema{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return openAPISchema{}
+	}
+}
+
+// structSchema は構造体のフィールドを encoding/json と同じ規則でプロパティにする
+// 埋め込まれた構造体のフィールドは親のプロパティとして展開する
+func (g *openAPIGenerator) structSchema(t reflect.Type) openAPISchema {
+	properties := openAPISchema{}
+	g.addProperties(t, properties)
+	return objectSchema(properties)
+}
+
+func (g *openAPIGenerator) addProperties(t reflect.Type, properties openAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addProperties(field.Type, properties)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaOf(field.Type)
+	}
+}
+
+// buildOpenAPIDocument は apiOperations から OpenAPI 3 のドキュメントを作る
+func buildOpenAPIDocument() openAPISchema {
+	g := &openAPIGenerator{schemas: openAPISchema{}}
+	// エラーの形式と、手書きのスキーマから $ref で参照する型を登録する
+	for _, v := range []interface{}{APIError{}, GroupQuotaStatus{}, BackupManifest{}} {
+		g.schemaOf(reflect.TypeOf(v))
+	}
+
+	paths := map[string]openAPISchema{}
+	tags := []string{}
+	for _, op := range apiOperations {
+		operation := openAPISchema{
+			"summary":     op.Summary,
+			"operationId": strings.ToLower(op.Method) + operationName(op.Path),
+			"tags":        []string{op.Tag},
+		}
+		if op.Deprecated {
+			operation["deprecated"] = true
+		}
+		if !containsString(tags, op.Tag) {
+			tags = append(tags, op.Tag)
+		}
+
+		params := []interface{}{}
+		for _, p := range op.Parameters {
+			params = append(params, openAPISchema{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.In == "path",
+				"description": p.Description,
+				"schema":      openAPISchema{"type": p.Type},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if op.RequestBody != nil {
+			contentType := op.RequestType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			operation["requestBody"] = openAPISchema{
+				"required": true,
+				"content":  openAPISchema{contentType: openAPISchema{"schema": g.schemaFor(op.RequestBody)}},
+			}
+		}
+
+		opResponses := op.Responses
+		if strings.HasPrefix(op.Path, "/api/admin/") || strings.HasPrefix(op.Path, "/api/trash") {
+			// 管理者用APIとゴミ箱のAPIは requireAdmin で登録している
+			opResponses = append(append([]apiResponse{}, op.Responses...),
+				errorResponse(http.StatusUnauthorized, "ユーザーが認証されていない"),
+				errorResponse(http.StatusForbidden, "ユーザーが管理者（adminUsers）ではない"))
+		}
+
+		responses := openAPISchema{}
+		for _, resp := range opResponses {
+			contentType := resp.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			responses[strconv.Itoa(resp.Status)] = openAPISchema{
+				"description": resp.Description,
+				"content":     openAPISchema{contentType: openAPISchema{"schema": g.schemaFor(resp.Body)}},
+			}
+		}
+		// どのAPIもエラーは同じ形式で返す
+		responses["default"] = openAPISchema{
+			"description": "エラー",
+			"content":     openAPISchema{"application/json": openAPISchema{"schema": openAPISchema{"$ref": "#/components/schemas/APIError"}}},
+		}
+		operation["responses"] = responses
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = openAPISchema{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	sort.Strings(tags)
+	tagList := []interface{}{}
+	for _, tag := range tags {
+		tagList = append(tagList, openAPISchema{"name": tag})
+	}
+
+	return openAPISchema{
+		"openapi": OpenAPIVersion,
+		"info": openAPISchema{
+			"title": "guilty API",
+			"description": "ベアリポジトリを管理するguiltyのAPI。パスのグループ名・リポジトリ名・ファイルのパスは URL エンコードして指定する。" +
+				"/api/... のすべてのAPIは /api/v1/... でも利用でき、その場合 JSON のレスポンスは {\"data\": ...} または {\"error\": {\"code\", \"message\", \"detail\"}} に包まれる",
+			"version": APIVersion,
+		},
+		"servers":    []interface{}{openAPISchema{"url": BasePath + "/"}},
+		"tags":       tagList,
+		"paths":      paths,
+		"components": openAPISchema{"schemas": g.schemas},
+	}
+}
+
+// operationName はパスから operationId に使う名前を作る
+// （/api/repository/{groupName}/{repoName}/tags/{tagName} → RepositoryTagsByTagName）
+func operationName(path string) string {
+	var b strings.Builder
+	parts := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "{") {
+			// 末尾のパラメータだけを名前に含め、一覧と個別のAPIを区別する
+			if i == len(parts)-1 {
+				name := strings.Trim(part, "{}")
+				b.WriteString("By" + strings.ToUpper(name[:1]) + name[1:])
+			}
+			continue
+		}
+		part = strings.TrimSuffix(strings.TrimSuffix(part, ".svg"), ".json")
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '.' || r == '_' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// openAPIDocument は生成済みの OpenAPI ドキュメント（型は実行中に変わらないため1回だけ生成する）
+var (
+	openAPIDocumentOnce sync.Once
+	openAPIDocument     []byte
+)
+
+// openAPIHandler はすべてのAPIを記述した OpenAPI 3 のドキュメントを返す
+//
+//	GET /api/openapi.json
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	openAPIDocumentOnce.Do(func() {
+		openAPIDocument, _ = json.MarshalIndent(buildOpenAPIDocument(), "", "  ")
+	})
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPIDocument)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// PprofEnabled は net/http/pprof のプロファイルを PprofAddress で提供するかどうか
+var PprofEnabled = false
+
+// PprofAddress はプロファイルを提供する管理者用のアドレス（既定は同じホストからだけ接続できる）
+// メインのサーバーとは別のポートで待ち受け、ファイアウォールや SSH のポート転送で管理者だけが接続できるようにする
+var PprofAddress = "127.0.0.1:6060"
+
+// pprofPathPrefix はプロファイルのパスの接頭辞
+const pprofPathPrefix = "/debug/pprof/"
+
+// validatePprofAddress はプロファイルを提供するアドレスが host:port の形式かどうかを確認する
+func validatePprofAddress(address string) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("pprofAddress は 127.0.0.1:6060 のような host:port の形式で指定してください: %s", address)
+	}
+	return nil
+}
+
+// hidePprofHandler はメインのサーバーでプロファイルを提供しないようにする
+// net/http/pprof は読み込まれると http.DefaultServeMux に /debug/pprof/ を登録するため、メインのサーバーでは 404 を返す
+func hidePprofHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, strings.TrimSuffix(pprofPathPrefix, "/")) {
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// startPprofServer は PprofEnabled の場合に PprofAddress でプロファイルを提供する
+// 終了時に停止できるよう、起動したサーバーを返す（提供しない場合は nil）
+//
+//	go tool pprof http://127.0.0.1:6060/debug/pprof/profile?seconds=30  CPU
+//	go tool pprof http://127.0.0.1:6060/debug/pprof/heap                メモリ
+//	curl http://127.0.0.1:6060/debug/pprof/goroutine?debug=2            goroutine の一覧
+func startPprofServer() (*http.Server, error) {
+	if !PprofEnabled {
+		return nil, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(pprofPathPrefix, pprof.Index)
+	mux.HandleFunc(pprofPathPrefix+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(pprofPathPrefix+"profile", pprof.Profile)
+	mux.HandleFunc(pprofPathPrefix+"symbol", pprof.Symbol)
+	mux.HandleFunc(pprofPathPrefix+"trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", PprofAddress)
+	if err != nil {
+		return nil, fmt.Errorf("プロファイル用のアドレス %s で待ち受けできません: %w", PprofAddress, err)
+	}
+
+	server := newHTTPServer(mux)
+	go func() {
+		log.Printf("プロファイルを http://%s%s で提供します", listener.Addr(), pprofPathPrefix)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("警告: プロファイル用のサーバーが停止しました: %v", err)
+		}
+	}()
+	return server, nil
+}
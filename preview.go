@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// previewContentTypes はインライン表示できるファイルの拡張子と Content-Type の対応
+var previewContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".pdf":  "application/pdf",
+}
+
+// getPreviewType はファイルのプレビュー種別（"image" または "pdf"）と Content-Type を返す
+// インライン表示できないファイルの場合は空文字列を返す
+func getPreviewType(filePath string) (previewType string, contentType string) {
+	contentType, ok := previewContentTypes[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return "", ""
+	}
+
+	if contentType == "application/pdf" {
+		return "pdf", contentType
+	}
+	return "image", contentType
+}
+
+// serveRawBlob は rev 時点のファイルの内容を Content-Type 付きでそのままストリーミングする
+// LFS ポインタの場合は保存先にある実体を返す
+func serveRawBlob(ctx context.Context, w http.ResponseWriter, repoPath, rev, filePath string) {
+	_, contentType := getPreviewType(filePath)
+	if contentType == "" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(map[string]string{"error": "このファイル形式はプレビューできません"})
+		return
+	}
+
+	entry, err := getTreeEntry(ctx, repoPath, rev, filePath)
+	if err != nil || entry.Type != "blob" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ファイルが見つかりません"})
+		return
+	}
+
+	size := getGitObjectSize(ctx, repoPath, entry.SHA, true)
+
+	// LFS ポインタであれば実体を返す
+	if size <= LFSPointerMaxSize {
+		if pointer, ok := readLFSPointerBlob(ctx, repoPath, entry.SHA); ok {
+			objectPath, found := findLFSObject(repoPath, pointer.OID)
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"error": "LFSオブジェクトが保存先にありません"})
+				return
+			}
+
+			file, err := os.Open(objectPath)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "LFSオブジェクトの読み込みに失敗しました"})
+				return
+			}
+			defer file.Close()
+
+			writeRawHeaders(w, contentType, pointer.Size)
+			io.Copy(w, file)
+			return
+		}
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "cat-file", "blob", entry.SHA)
+	defer cancel()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ファイル内容の取得に失敗しました: " + err.Error()})
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ファイル内容の取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	writeRawHeaders(w, contentType, size)
+	io.Copy(w, stdout)
+	cmd.Wait()
+}
+
+// writeRawHeaders はファイルをそのまま返すときのレスポンスヘッダーを書き込む
+func writeRawHeaders(w http.ResponseWriter, contentType string, size int64) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if contentType == "image/svg+xml" {
+		// SVG に埋め込まれたスクリプトが実行されないようにする
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// readLFSPointerBlob は blob を読み込み、LFS ポインタであれば解析して返す
+func readLFSPointerBlob(ctx context.Context, repoPath, objectHash string) (*LFSPointer, bool) {
+	content, err := readBlob(ctx, repoPath, objectHash)
+	if err != nil {
+		return nil, false
+	}
+	return parseLFSPointer(content)
+}
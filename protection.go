@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// protectedBranchConfigKey は保護ブランチのパターンを保存するgit configのキー
+// 設定はリポジトリ自身の config ファイルに保存される
+const protectedBranchConfigKey = "guilty.protectedBranch"
+
+// managedHookMarker はguiltyが管理するフックであることを示す目印
+const managedHookMarker = "# guilty-managed pre-receive hook"
+
+// preReceiveHookScript は保護ブランチへの強制プッシュと削除、上限を超えたグループへのプッシュを拒否する pre-receive フック
+const preReceiveHookScript = `#!/bin/sh
+` + managedHookMarker + `
+# このファイルはguiltyが自動生成しています。手動で編集しないでください。
+# 保護対象のパターンは git config guilty.protectedBranch で管理されます。
+
+# グループのディスク使用量が上限を超えている場合はプッシュを拒否する（目印はguiltyが定期的に更新します）
+if [ -e ../` + quotaExceededMarker + ` ]; then
+	echo "guilty: グループのディスク使用量が上限を超えているため、プッシュは拒否されました" >&2
+	exit 1
+fi
+
+# SHA-1/SHA-256 どちらでも、すべて 0 のオブジェクト名は「存在しない」を表す
+is_zero() { [ -z "$(echo "$1" | tr -d 0)" ]; }
+
+# パターンがファイル名として展開されないようにする
+set -f
+
+patterns=$(git config --get-all guilty.protectedBranch)
+status=0
+
+while read oldrev newrev refname; do
+	case "$refname" in
+	refs/heads/*) branch=${refname#refs/heads/} ;;
+	*) continue ;;
+	esac
+
+	for pattern in $patterns; do
+		case "$branch" in
+		$pattern)
+			if is_zero "$newrev"; then
+				echo "guilty: 保護ブランチ '$branch' は削除できません" >&2
+				status=1
+			elif ! is_zero "$oldrev" && ! git merge-base --is-ancestor "$oldrev" "$newrev"; then
+				echo "guilty: 保護ブランチ '$branch' への強制プッシュは拒否されました" >&2
+				status=1
+			fi
+			break
+			;;
+		esac
+	done
+done
+
+exit $status
+`
+
+// ProtectedBranchesRequest は保護ブランチ設定の更新リクエスト用の構造体
+type ProtectedBranchesRequest struct {
+	Patterns []string `json:"patterns"`
+}
+
+// protectedBranchesHandler は保護ブランチの設定を取得・更新する
+//
+//	GET /api/repository/{group}/{repo}/protected-branches
+//	PUT /api/repository/{group}/{repo}/protected-branches  {"patterns": ["main", "release/*"]}
+func protectedBranchesHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	// 保護を外すと強制プッシュや削除ができるようになるため、変更は管理者（adminUsers）に限る
+	if r.Method != http.MethodGet && !isAdminUser(requestUser(r)) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "このAPIは管理者だけが利用できます"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string][]string{"patterns": getProtectedBranches(r.Context(), repoPath)})
+
+	case http.MethodPut:
+		var req ProtectedBranchesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := setProtectedBranches(r.Context(), repoPath, req.Patterns); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "protection.update", map[string]string{"patterns": strings.Join(req.Patterns, ",")})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "保護ブランチの設定が更新されました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// getProtectedBranches はリポジトリの config から保護ブランチのパターンを取得する
+func getProtectedBranches(ctx context.Context, repoPath string) []string {
+	patterns := []string{}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--get-all", protectedBranchConfigKey)
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		// キーが存在しない場合もエラーになる
+		return patterns
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return patterns
+}
+
+// isProtectedBranch はブランチが保護パターンのいずれかに一致するか確認する
+func isProtectedBranch(ctx context.Context, repoPath, branchName string) bool {
+	for _, pattern := range getProtectedBranches(ctx, repoPath) {
+		if re, err := compileBranchPattern(pattern); err == nil && re.MatchString(branchName) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileBranchPattern は保護ブランチのパターンを正規表現に変換する
+// pre-receive フックのシェルの case 文と同じ規則で一致させるため、path.Match と違って * と ? は / にも一致する
+// [...] は文字クラスで、先頭の ! は否定を表す
+// 先頭の ^ は bash では否定、dash では文字として扱われ、フックを実行するシェルによって意味が変わるため使用できない
+func compileBranchPattern(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			// 先頭（否定の記号の直後を含む）の ] は文字として扱う
+			j := i + 1
+			if j < len(pattern) && pattern[j] == '^' {
+				return nil, fmt.Errorf("文字クラスの否定には ^ ではなく ! を使用してください")
+			}
+			if j < len(pattern) && pattern[j] == '!' {
+				j++
+			}
+			if j < len(pattern) && pattern[j] == ']' {
+				j++
+			}
+			end := strings.IndexByte(pattern[j:], ']')
+			if end < 0 {
+				// 閉じていない [ はシェルと同じく文字として扱う
+				expr.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : j+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i = j + end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// setProtectedBranches は保護ブランチのパターンを保存し、pre-receive フックを設置または削除する
+func setProtectedBranches(ctx context.Context, repoPath string, patterns []string) error {
+	var normalized []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		// フック内でシェルのパターンとして展開されるため、空白やクォートは許可しない
+		if strings.ContainsAny(pattern, " \t\n'\"`$\\;") {
+			return fmt.Errorf("パターン '%s' には使用できない文字が含まれています", pattern)
+		}
+		if _, err := compileBranchPattern(pattern); err != nil {
+			return fmt.Errorf("パターン '%s' は不正です", pattern)
+		}
+		normalized = append(normalized, pattern)
+	}
+
+	// 既存の設定を削除してから追加し直す（キーがない場合のエラーは無視）
+	gitRun(ctx, "--git-dir="+repoPath, "config", "--unset-all", protectedBranchConfigKey)
+	for _, pattern := range normalized {
+		cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--add", protectedBranchConfigKey, pattern)
+		defer cancel()
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("保護ブランチの保存に失敗しました: %s", strings.TrimSpace(string(output)))
+		}
+	}
+
+	// フックはグループの容量制限にも使われるため、その場合は残しておく
+	if len(normalized) == 0 && !isQuotaEnforcedOnPush(filepath.Base(filepath.Dir(repoPath))) {
+		return removePreReceiveHook(repoPath)
+	}
+	return installPreReceiveHook(repoPath)
+}
+
+// installPreReceiveHook はguilty管理の pre-receive フックを設置する
+// guilty以外が設置したフックがある場合は上書きしない
+func installPreReceiveHook(repoPath string) error {
+	hookPath := filepath.Join(repoPath, "hooks", "pre-receive")
+
+	if content, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(content), managedHookMarker) {
+		return fmt.Errorf("guilty以外が設置した pre-receive フックが既に存在します")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("hooksディレクトリの作成に失敗しました: %w", err)
+	}
+
+	if err := os.WriteFile(hookPath, []byte(preReceiveHookScript), 0755); err != nil {
+		return fmt.Errorf("pre-receive フックの設置に失敗しました: %w", err)
+	}
+
+	// 既存ファイルを上書きした場合はパーミッションが変わらないため明示的に設定
+	return os.Chmod(hookPath, 0755)
+}
+
+// removePreReceiveHook はguilty管理の pre-receive フックを削除する
+func removePreReceiveHook(repoPath string) error {
+	hookPath := filepath.Join(repoPath, "hooks", "pre-receive")
+
+	content, err := os.ReadFile(hookPath)
+	if err != nil || !strings.Contains(string(content), managedHookMarker) {
+		// フックがない、またはguilty管理でない場合は何もしない
+		return nil
+	}
+
+	if err := os.Remove(hookPath); err != nil {
+		return fmt.Errorf("pre-receive フックの削除に失敗しました: %w", err)
+	}
+
+	return nil
+}
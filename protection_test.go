@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// shellCaseMatch は pre-receive フックと同じ case 文で、ブランチ名がパターンに一致するかを /bin/sh で確認する
+func shellCaseMatch(t *testing.T, pattern, branch string) bool {
+	t.Helper()
+	cmd := exec.Command("/bin/sh", "-c", `set -f; case "$2" in $1) echo yes ;; *) echo no ;; esac`, "sh", pattern, branch)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("/bin/sh の実行に失敗しました: %v", err)
+	}
+	return strings.TrimSpace(string(output)) == "yes"
+}
+
+func TestCompileBranchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		branch  string
+		want    bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"main", "feature/main", false},
+
+		{"release/*", "release/1.0", true},
+		{"release/*", "release/1.0/hotfix", true},
+		{"release/*", "release/", true},
+		{"release/*", "release", false},
+		{"release/*", "pre-release/1.0", false},
+
+		{"*", "main", true},
+		{"*", "feature/a/b", true},
+
+		{"feature/**", "feature/x", true},
+		{"feature/**", "feature/x/y", true},
+		{"feature/**", "feature", false},
+		{"feature/**", "features/x", false},
+
+		{"*/hotfix", "release/1.0/hotfix", true},
+		{"*/hotfix", "hotfix", false},
+
+		{"v?", "v1", true},
+		{"v?", "v10", false},
+		{"a?b", "a/b", true},
+		{"release/?.?", "release/1.0", true},
+		{"release/?.?", "release/1x0", false},
+		{"release/?.?", "release/10.0", false},
+
+		{"v[0-9]*", "v1.2", true},
+		{"v[0-9]*", "vx", false},
+		{"release/[0-9].[0-9]", "release/1.2", true},
+		{"[!f]*", "main", true},
+		{"[!f]*", "feature/x", false},
+		{"a[/]b", "a/b", true},
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"a[]b]c", "a]c", true},
+		{"a[]b]c", "abc", true},
+		{"a[!]]c", "a]c", false},
+		{"a[!]]c", "abc", true},
+
+		{"[", "[", true},
+		{"[a", "[a", true},
+		{"[a", "a", false},
+		{"a]", "a]", true},
+
+		{"v1.0", "v1x0", false},
+		{"a+b", "a+b", true},
+		{"a+b", "aab", false},
+		{"(x)", "(x)", true},
+		{"a{2}", "a{2}", true},
+		{"a{2}", "aa", false},
+	}
+
+	_, err := exec.LookPath("/bin/sh")
+	compareShell := err == nil
+
+	for _, tt := range tests {
+		re, err := compileBranchPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("compileBranchPattern(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.branch); got != tt.want {
+			t.Errorf("compileBranchPattern(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.branch, got, tt.want)
+		}
+		if compareShell {
+			if got := shellCaseMatch(t, tt.pattern, tt.branch); got != tt.want {
+				t.Errorf("case %q in %s) = %v, want %v（フックと一致しません）", tt.branch, tt.pattern, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCompileBranchPatternRejectsCaret(t *testing.T) {
+	// [^...] は bash では否定、dash では ^ という文字になるため、どちらの意味にも決められない
+	for _, pattern := range []string{"[^a]*", "release/[^0-9]"} {
+		if _, err := compileBranchPattern(pattern); err == nil {
+			t.Errorf("compileBranchPattern(%q) error = nil, want error", pattern)
+		}
+	}
+
+	if _, err := compileBranchPattern("[a^]"); err != nil {
+		t.Errorf("compileBranchPattern(%q) error = %v（先頭以外の ^ は文字として使える）", "[a^]", err)
+	}
+}
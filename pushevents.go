@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPushEventCommits はプッシュ通知に含める1つの参照あたりのコミット数の上限
+var MaxPushEventCommits = 50
+
+// maxPostReceiveBodySize は post-receive フックから受け取る参照の一覧の最大サイズ
+const maxPostReceiveBodySize = 1024 * 1024
+
+// managedPostReceiveHookMarker はguiltyが通知のために設置した post-receive フックであることを示す目印
+const managedPostReceiveHookMarker = "# guilty-managed post-receive hook"
+
+// postReceiveRepositoryHeader は post-receive フックがリポジトリのパスを送るヘッダー
+const postReceiveRepositoryHeader = "X-Guilty-Repository"
+
+// postReceiveSecretHeader は post-receive フックが共有の秘密の値を送るヘッダー
+const postReceiveSecretHeader = "X-Guilty-Hook-Secret"
+
+// hookSecretFile は post-receive フックとguiltyで共有する秘密の値を保存するファイル（GitRepositoryHome の直下）
+// 中身は「X-Guilty-Hook-Secret: 値」の1行で、フックは curl -H @ファイル でそのまま送る（ps などに値が出ない）
+const hookSecretFile = ".guilty-hook-secret"
+
+// postReceiveSecret は起動時に読み込んだ共有の秘密の値（空の場合は post-receive の通知をすべて拒否する）
+var postReceiveSecret string
+
+// objectNamePattern は SHA-1（40文字）または SHA-256（64文字）のオブジェクト名
+var objectNamePattern = regexp.MustCompile(`^(?:[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// 更新された参照の種類
+const (
+	RefTypeBranch = "branch"
+	RefTypeTag    = "tag"
+	RefTypeOther  = "other"
+)
+
+// RefUpdate はプッシュで更新された参照1つを表す
+type RefUpdate struct {
+	Ref          string   `json:"ref"`          // refs/heads/main など
+	Name         string   `json:"name"`         // ブランチ名・タグ名（その他の参照の場合は ref と同じ）
+	Type         string   `json:"type"`         // "branch"、"tag"、"other"
+	OldCommit    string   `json:"oldCommit"`    // 更新前のオブジェクト（作成の場合は空文字）
+	NewCommit    string   `json:"newCommit"`    // 更新後のオブジェクト（削除の場合は空文字）
+	Created      bool     `json:"created"`      // 参照が作成された
+	Deleted      bool     `json:"deleted"`      // 参照が削除された
+	Forced       bool     `json:"forced"`       // 更新前のコミットが更新後から辿れない（強制プッシュ）
+	Commits      []Commit `json:"commits"`      // 新しく追加されたコミット（新しい順、最大 MaxPushEventCommits 件）
+	TotalCommits int      `json:"totalCommits"` // 新しく追加されたコミットの総数
+}
+
+// PushEvent はリポジトリへの1回のプッシュを表す
+type PushEvent struct {
+	Group      string      `json:"group"`
+	Repository string      `json:"repository"`
+	PushedAt   time.Time   `json:"pushedAt"`
+	Updates    []RefUpdate `json:"updates"`
+}
+
+// postReceiveHookScript は push された参照をguiltyに送る post-receive フックを返す
+// 通知の送信はサーバー側で行うため、フックはプッシュを待たせないよう短いタイムアウトで送るだけにする
+func postReceiveHookScript() string {
+	return `#!/bin/sh
+` + managedPostReceiveHookMarker + `
+# このファイルはguiltyが自動生成しています。手動で編集しないでください。
+# プッシュされた参照をguiltyに送り、通知はguiltyが行います。
+
+curl -fsS -m 10 -X POST -H 'Content-Type: text/plain' \
+	-H "` + postReceiveRepositoryHeader + `: $(pwd)" -H @` + shellQuote(hookSecretPath()) + ` \
+	--data-binary @- ` + internalAPICurlTarget("/api/internal/post-receive") + ` >/dev/null ||
+	echo "guilty: プッシュの通知に失敗しました" >&2
+exit 0
+`
+}
+
+// hookSecretPath はフックから読む共有の秘密の値のファイルの絶対パスを返す（フックはリポジトリのディレクトリで実行される）
+func hookSecretPath() string {
+	path := filepath.Join(GitRepositoryHome, hookSecretFile)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// initPostReceiveSecret は共有の秘密の値を読み込む（ファイルがない場合は作成する）
+// ループバックアドレスからの接続は同じホストのリバースプロキシを経由したものかもしれないため、接続元ではなくこの値で確認する
+func initPostReceiveSecret() error {
+	path := hookSecretPath()
+	if content, err := os.ReadFile(path); err == nil {
+		_, value, ok := strings.Cut(strings.TrimSpace(string(content)), ":")
+		if value = strings.TrimSpace(value); ok && value != "" {
+			postReceiveSecret = value
+			return nil
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("フックの秘密の値の生成に失敗しました: %w", err)
+	}
+	value := hex.EncodeToString(secret)
+	if err := os.WriteFile(path, []byte(postReceiveSecretHeader+": "+value+"\n"), 0600); err != nil {
+		return fmt.Errorf("フックの秘密の値の保存に失敗しました: %w", err)
+	}
+	postReceiveSecret = value
+	return nil
+}
+
+// refreshPostReceiveHooks はすべてのリポジトリのguilty管理の post-receive フックを現在の設定で書き直す
+// 通知先（ポート、unix ドメインソケット、HTTPS）や秘密の値のファイルが変わった後も通知が届くよう、起動時に実行する
+func refreshPostReceiveHooks() {
+	groups, err := getGroupList()
+	if err != nil {
+		log.Printf("警告: %v", err)
+		return
+	}
+	script := postReceiveHookScript()
+	for _, groupName := range groups {
+		repos, err := listGitRepositories(groupName)
+		if err != nil {
+			continue
+		}
+		for _, repo := range repos {
+			hookPath := filepath.Join(repo.Path, "hooks", "post-receive")
+			content, err := os.ReadFile(hookPath)
+			if err != nil || !strings.Contains(string(content), managedPostReceiveHookMarker) || string(content) == script {
+				continue
+			}
+			if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+				log.Printf("警告: %s/%s の post-receive フックを更新できませんでした: %v", groupName, repo.Name, err)
+			}
+		}
+	}
+}
+
+// hasPushNotifications はリポジトリへのプッシュを通知する設定（メールの宛先、グループのチャット通知）があるかどうかを返す
+func hasPushNotifications(ctx context.Context, repoPath string) bool {
+	return len(getSubscribers(ctx, repoPath)) > 0 || hasChatPushNotifiers(filepath.Base(filepath.Dir(repoPath)))
+}
+
+// syncPostReceiveHook は通知の設定に合わせて post-receive フックを設置または削除する
+func syncPostReceiveHook(ctx context.Context, repoPath string) error {
+	hookPath := filepath.Join(repoPath, "hooks", "post-receive")
+	content, err := os.ReadFile(hookPath)
+	managed := err == nil && strings.Contains(string(content), managedPostReceiveHookMarker)
+
+	if !hasPushNotifications(ctx, repoPath) {
+		if managed {
+			if err := os.Remove(hookPath); err != nil {
+				return fmt.Errorf("post-receive フックの削除に失敗しました: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if err == nil && !managed {
+		return fmt.Errorf("guilty以外が設置した post-receive フックが既に存在します")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("hooksディレクトリの作成に失敗しました: %w", err)
+	}
+
+	if err := os.WriteFile(hookPath, []byte(postReceiveHookScript()), 0755); err != nil {
+		return fmt.Errorf("post-receive フックの設置に失敗しました: %w", err)
+	}
+
+	// 既存ファイルを上書きした場合はパーミッションが変わらないため明示的に設定
+	return os.Chmod(hookPath, 0755)
+}
+
+// isZeroObjectName はすべて 0 のオブジェクト名（存在しないことを表す）かどうかを返す
+func isZeroObjectName(name string) bool {
+	return strings.Trim(name, "0") == ""
+}
+
+// parseRefUpdate は post-receive フックの標準入力の1行（"<old> <new> <ref>"）を解析する
+// 値は git の引数になるため、オブジェクト名は16進数の40文字か64文字、参照は refs/ で始まるものだけを受け付ける
+func parseRefUpdate(line string) (RefUpdate, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !objectNamePattern.MatchString(fields[0]) || !objectNamePattern.MatchString(fields[1]) ||
+		!strings.HasPrefix(fields[2], "refs/") {
+		return RefUpdate{}, false
+	}
+
+	update := RefUpdate{Ref: fields[2], Name: fields[2], Type: RefTypeOther, Commits: []Commit{}}
+	if name, ok := strings.CutPrefix(update.Ref, "refs/heads/"); ok {
+		update.Name, update.Type = name, RefTypeBranch
+	} else if name, ok := strings.CutPrefix(update.Ref, "refs/tags/"); ok {
+		update.Name, update.Type = name, RefTypeTag
+	}
+
+	if isZeroObjectName(fields[0]) {
+		update.Created = true
+	} else {
+		update.OldCommit = fields[0]
+	}
+	if isZeroObjectName(fields[1]) {
+		update.Deleted = true
+	} else {
+		update.NewCommit = fields[1]
+	}
+
+	return update, true
+}
+
+// getPushedCommits はブランチの更新で新しく追加されたコミットを取得する
+// 作成されたブランチの場合は、ほかのブランチから辿れないコミットを新しいコミットとする
+func getPushedCommits(ctx context.Context, repoPath string, update *RefUpdate) error {
+	if update.Type != RefTypeBranch || update.Deleted {
+		return nil
+	}
+
+	var revs []string
+	if update.Created {
+		// --branches と組み合わせる --exclude には refs/heads/ を除いた名前を指定する
+		revs = []string{update.NewCommit, "--not", "--exclude=" + update.Name, "--branches"}
+	} else {
+		revs = []string{update.OldCommit + ".." + update.NewCommit}
+		update.Forced = !isAncestor(ctx, repoPath, update.OldCommit, update.NewCommit)
+	}
+
+	countArgs := append([]string{"--git-dir=" + repoPath, "rev-list", "--count"}, revs...)
+	output, err := gitOutput(ctx, countArgs...)
+	if err != nil {
+		return fmt.Errorf("コミット数の取得に失敗しました: %w", err)
+	}
+	update.TotalCommits, _ = strconv.Atoi(strings.TrimSpace(string(output)))
+
+	logArgs := append([]string{"--git-dir=" + repoPath, "log", "--format=" + commitFormat,
+		"--max-count=" + strconv.Itoa(MaxPushEventCommits)}, revs...)
+	output, err = gitOutput(ctx, append(logArgs, "--")...)
+	if err != nil {
+		return fmt.Errorf("コミット履歴の取得に失敗しました: %w", err)
+	}
+	update.Commits = parseCommits(string(output))
+
+	return nil
+}
+
+// buildPushEvent は post-receive フックから受け取った参照の一覧からプッシュイベントを作る
+func buildPushEvent(ctx context.Context, groupName, repoName, repoPath, input string) *PushEvent {
+	event := &PushEvent{Group: groupName, Repository: repoName, PushedAt: time.Now(), Updates: []RefUpdate{}}
+
+	for _, line := range strings.Split(input, "\n") {
+		update, ok := parseRefUpdate(line)
+		if !ok {
+			continue
+		}
+		if err := getPushedCommits(ctx, repoPath, &update); err != nil {
+			log.Printf("警告: %s/%s: %v", groupName, repoName, err)
+		}
+		event.Updates = append(event.Updates, update)
+	}
+
+	return event
+}
+
+// dispatchPushEvent はプッシュイベントを設定されたすべての通知先に送る
+func dispatchPushEvent(ctx context.Context, repoPath string, event *PushEvent) {
+	if err := sendPushEmails(ctx, repoPath, event); err != nil {
+		log.Printf("警告: %s/%s のプッシュ通知メールの送信に失敗しました: %v", event.Group, event.Repository, err)
+	}
+	notifyChat(ChatEvent{Event: ChatEventPush, Group: event.Group, Repository: event.Repository, Date: event.PushedAt, Push: event})
+}
+
+// isPostReceiveRequest はリクエストがguilty管理の post-receive フックから送られたものかどうかを、共有の秘密の値で確認する
+func isPostReceiveRequest(r *http.Request) bool {
+	secret := r.Header.Get(postReceiveSecretHeader)
+	return postReceiveSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(postReceiveSecret)) == 1
+}
+
+// postReceiveHandler はguilty管理の post-receive フックからプッシュされた参照を受け取り、通知を送る
+// フックが送る共有の秘密の値（X-Guilty-Hook-Secret）が一致するリクエストだけを受け付ける
+//
+//	POST /api/internal/post-receive  （本文は post-receive フックの標準入力そのまま）
+func postReceiveHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !isPostReceiveRequest(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "このAPIはサーバー内部からのみ利用できます"})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	// フックの作業ディレクトリ（ベアリポジトリのパス）からグループ名とリポジトリ名を求める
+	rel, err := filepath.Rel(GitRepositoryHome, filepath.Clean(r.Header.Get(postReceiveRepositoryHeader)))
+	if err != nil || !strings.HasSuffix(rel, ".git") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なリポジトリパス"})
+		return
+	}
+	groupName, repoName := splitRepositoryName(strings.TrimSuffix(filepath.ToSlash(rel), ".git"))
+	repoPath, ok := findRepository(groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	input, err := io.ReadAll(io.LimitReader(r.Body, maxPostReceiveBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+		return
+	}
+
+	// リポジトリ一覧と詳細のキャッシュを読み直させ、一覧の索引は次の更新を待たずに更新する
+	invalidateRepositoryCache(repoPath)
+	invalidateRepositoryIndex(repoPath)
+	go indexRepository(serverContext, groupName, repoName, repoPath, true)
+
+	event := buildPushEvent(r.Context(), groupName, repoName, repoPath, string(input))
+
+	// プッシュしたクライアントを待たせないよう、通知はバックグラウンドで送る
+	go dispatchPushEvent(serverContext, repoPath, event)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int{"updates": len(event.Updates)})
+}
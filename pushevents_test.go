@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRefUpdate(t *testing.T) {
+	sha1 := strings.Repeat("a", 40)
+	sha256 := strings.Repeat("b", 64)
+	zero1 := strings.Repeat("0", 40)
+	zero256 := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name string
+		line string
+		ok   bool
+		want RefUpdate
+	}{
+		{"ブランチの更新", sha1 + " " + strings.Repeat("c", 40) + " refs/heads/main", true,
+			RefUpdate{Ref: "refs/heads/main", Name: "main", Type: RefTypeBranch, OldCommit: sha1, NewCommit: strings.Repeat("c", 40)}},
+		{"ブランチの作成", zero1 + " " + sha1 + " refs/heads/feature/x", true,
+			RefUpdate{Ref: "refs/heads/feature/x", Name: "feature/x", Type: RefTypeBranch, NewCommit: sha1, Created: true}},
+		{"タグの削除（SHA-256）", sha256 + " " + zero256 + " refs/tags/v1.0", true,
+			RefUpdate{Ref: "refs/tags/v1.0", Name: "v1.0", Type: RefTypeTag, OldCommit: sha256, Deleted: true}},
+		{"その他の参照", sha1 + " " + sha1 + " refs/notes/commits", true,
+			RefUpdate{Ref: "refs/notes/commits", Name: "refs/notes/commits", Type: RefTypeOther, OldCommit: sha1, NewCommit: sha1}},
+		{"前後の空白", "  " + zero1 + "\t" + sha1 + " refs/heads/main \r", true,
+			RefUpdate{Ref: "refs/heads/main", Name: "main", Type: RefTypeBranch, NewCommit: sha1, Created: true}},
+
+		{"空行", "", false, RefUpdate{}},
+		{"フィールドが足りない", sha1 + " refs/heads/main", false, RefUpdate{}},
+		{"フィールドが多い", sha1 + " " + sha1 + " refs/heads/main extra", false, RefUpdate{}},
+		{"オプションを新しいオブジェクトに指定", zero1 + " --output=/tmp/x refs/heads/x", false, RefUpdate{}},
+		{"オプションを古いオブジェクトに指定", "--output=/tmp/x " + sha1 + " refs/heads/x", false, RefUpdate{}},
+		{"オプションを参照に指定", zero1 + " " + sha1 + " --output=/tmp/x", false, RefUpdate{}},
+		{"refs/ で始まらない参照", zero1 + " " + sha1 + " main", false, RefUpdate{}},
+		{"短いオブジェクト名", "abc1234 " + sha1 + " refs/heads/main", false, RefUpdate{}},
+		{"41文字のオブジェクト名", sha1 + "a " + sha1 + " refs/heads/main", false, RefUpdate{}},
+		{"大文字の16進数", strings.Repeat("A", 40) + " " + sha1 + " refs/heads/main", false, RefUpdate{}},
+		{"リビジョンの範囲", sha1 + ".." + sha1 + " " + sha1 + " refs/heads/main", false, RefUpdate{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRefUpdate(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseRefUpdate(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if got.Ref != tt.want.Ref || got.Name != tt.want.Name || got.Type != tt.want.Type ||
+				got.OldCommit != tt.want.OldCommit || got.NewCommit != tt.want.NewCommit ||
+				got.Created != tt.want.Created || got.Deleted != tt.want.Deleted {
+				t.Errorf("parseRefUpdate(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+			if got.Commits == nil {
+				t.Errorf("parseRefUpdate(%q) Commits = nil, want empty slice", tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultGroupQuota はグループごとのディスク使用量の上限（バイト単位、0 は無制限）
+var DefaultGroupQuota int64 = 0
+
+// GroupQuotas はグループごとに個別に設定する上限（DefaultGroupQuota より優先、0 は無制限）
+var GroupQuotas = map[string]int64{}
+
+// EnforceQuotaOnPush が true の場合、上限を超えたグループへのプッシュも pre-receive フックで拒否する
+var EnforceQuotaOnPush = false
+
+// QuotaScanInterval はグループのディスク使用量を集計し直す間隔
+var QuotaScanInterval = 10 * time.Minute
+
+// quotaExceededMarker は上限を超えたグループのディレクトリに置く目印のファイル名
+// pre-receive フックはこのファイルの有無でプッシュを拒否するか判断する
+const quotaExceededMarker = ".guilty-quota-exceeded"
+
+// GroupQuotaStatus はグループのディスク使用量と上限を表す
+type GroupQuotaStatus struct {
+	Group     string    `json:"group"`
+	Quota     int64     `json:"quota"`     // 上限（バイト単位、0 は無制限）
+	Usage     int64     `json:"usage"`     // 使用量（バイト単位、最後の集計時点）
+	Exceeded  bool      `json:"exceeded"`  // 上限を超えているかどうか
+	UpdatedAt time.Time `json:"updatedAt"` // 最後に集計した日時（未集計の場合はゼロ値）
+}
+
+// groupUsage は最後に集計したグループごとのディスク使用量
+var (
+	groupUsageMutex     sync.RWMutex
+	groupUsage          = map[string]int64{}
+	groupUsageUpdatedAt = map[string]time.Time{}
+)
+
+// getGroupQuota はグループのディスク使用量の上限を返す（0 は無制限）
+func getGroupQuota(groupName string) int64 {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if quota, ok := GroupQuotas[groupName]; ok {
+		return quota
+	}
+	return DefaultGroupQuota
+}
+
+// hasGroupQuota はいずれかのグループに上限が設定されているかどうかを返す
+func hasGroupQuota() bool {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if DefaultGroupQuota > 0 {
+		return true
+	}
+	for _, quota := range GroupQuotas {
+		if quota > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getGroupQuotaStatus はグループの最後の集計結果と上限を返す
+func getGroupQuotaStatus(groupName string) GroupQuotaStatus {
+	groupUsageMutex.RLock()
+	defer groupUsageMutex.RUnlock()
+
+	status := GroupQuotaStatus{
+		Group:     groupName,
+		Quota:     getGroupQuota(groupName),
+		Usage:     groupUsage[groupName],
+		UpdatedAt: groupUsageUpdatedAt[groupName],
+	}
+	status.Exceeded = status.Quota > 0 && status.Usage >= status.Quota
+
+	return status
+}
+
+// checkGroupQuota はグループが上限を超えている場合にエラーを返す
+func checkGroupQuota(groupName string) error {
+	status := getGroupQuotaStatus(groupName)
+	if status.Exceeded {
+		return fmt.Errorf("グループ '%s' のディスク使用量が上限（%d バイト）を超えているため、リポジトリを作成できません", groupName, status.Quota)
+	}
+	return nil
+}
+
+// isQuotaEnforcedOnPush はグループへのプッシュを上限で制限するかどうかを返す
+func isQuotaEnforcedOnPush(groupName string) bool {
+	configMutex.RLock()
+	enforce := EnforceQuotaOnPush
+	configMutex.RUnlock()
+
+	return enforce && getGroupQuota(groupName) > 0
+}
+
+// calculateGroupUsage はグループ内のリポジトリのディスク使用量を合計する
+// 論理削除済みのリポジトリは読み取りできないため含めない
+func calculateGroupUsage(ctx context.Context, groupName string) (int64, error) {
+	// サイズだけを読むため、最新のコミット情報とライセンスは読まない
+	repos, err := listGitRepositories(groupName)
+	if err != nil {
+		return 0, err
+	}
+
+	var usage int64
+	for _, repo := range repos {
+		repoPath, ok := findRepository(groupName, repo.Name)
+		if !ok {
+			continue
+		}
+		if size, err := getRepositorySize(ctx, repoPath); err == nil {
+			usage += size.TotalSize
+		}
+	}
+
+	return usage, nil
+}
+
+// updateGroupUsage はグループの使用量を集計し直し、上限超過の目印とフックを更新する
+func updateGroupUsage(ctx context.Context, groupName string) {
+	usage, err := calculateGroupUsage(ctx, groupName)
+	if err != nil {
+		log.Printf("警告: グループ '%s' の使用量の集計に失敗しました: %v", groupName, err)
+		return
+	}
+
+	groupUsageMutex.Lock()
+	groupUsage[groupName] = usage
+	groupUsageUpdatedAt[groupName] = time.Now()
+	groupUsageMutex.Unlock()
+
+	markerPath := filepath.Join(GitRepositoryHome, groupName, quotaExceededMarker)
+	if !isQuotaEnforcedOnPush(groupName) || !getGroupQuotaStatus(groupName).Exceeded {
+		os.Remove(markerPath)
+		return
+	}
+
+	if err := os.WriteFile(markerPath, []byte(fmt.Sprintf("%d\n", usage)), 0644); err != nil {
+		log.Printf("警告: グループ '%s' の上限超過の目印を作成できませんでした: %v", groupName, err)
+	}
+
+	// 上限超過の確認はguilty管理の pre-receive フックで行うため、グループ内のリポジトリに設置する
+	repos, _ := listGitRepositories(groupName)
+	for _, repo := range repos {
+		if repoPath, ok := findRepository(groupName, repo.Name); ok {
+			if err := installPreReceiveHook(repoPath); err != nil {
+				log.Printf("警告: %s/%s: %v", groupName, repo.Name, err)
+			}
+		}
+	}
+}
+
+// startQuotaMonitor はグループのディスク使用量を定期的に集計するバックグラウンド処理を開始する
+func startQuotaMonitor() {
+	go func() {
+		for {
+			updateAllGroupUsage(serverContext)
+			time.Sleep(QuotaScanInterval)
+		}
+	}()
+}
+
+// updateAllGroupUsage はすべてのグループの使用量を集計し直す
+func updateAllGroupUsage(ctx context.Context) {
+	groups, err := getGroupList()
+	if err != nil {
+		log.Printf("警告: グループ一覧の取得に失敗しました: %v", err)
+	}
+
+	// 上限がどのグループにも設定されていない場合は集計しない（上限を外した場合に残った目印だけ削除する）
+	if !hasGroupQuota() {
+		for _, groupName := range groups {
+			os.Remove(filepath.Join(GitRepositoryHome, groupName, quotaExceededMarker))
+		}
+		return
+	}
+
+	for _, groupName := range groups {
+		updateGroupUsage(ctx, groupName)
+	}
+}
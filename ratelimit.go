@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit はクライアントごとに1分間に受け付けるAPIのリクエスト数（0 の場合は制限しない）
+var RateLimit = 600
+
+// ExpensiveRateLimit は負荷の大きいAPI（expensiveAPIPaths）にクライアントごとに1分間に受け付けるリクエスト数（0 の場合は制限しない）
+var ExpensiveRateLimit = 60
+
+// TrustedProxies は X-Forwarded-For を信用するリバースプロキシのアドレス（CIDR または IP アドレス）
+// unix ドメインソケットからの接続は常にリバースプロキシからの接続として扱う
+var TrustedProxies = []string{"127.0.0.1/8", "::1/128"}
+
+// trustedProxyNetworks は TrustedProxies を解析したもの
+var trustedProxyNetworks = mustParseTrustedProxies(TrustedProxies)
+
+// expensiveAPIPaths は負荷の大きいAPIのパス（/ で終わるものは前方一致）
+// リポジトリ一覧（すべてのグループの一覧と最新コミット情報の取得を含む）はリポジトリごとに最新コミットを読み、エクスポートはバンドルを作るため、通常のAPIより厳しく制限する
+var expensiveAPIPaths = []string{"/api/repositories", "/api/repositories/all", "/api/repositories/summaries", "/api/export/"}
+
+// parseTrustedProxies は CIDR または IP アドレスのリストを解析する
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("trustedProxies の '%s' は CIDR または IP アドレスではありません", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trustedProxies の '%s' は CIDR または IP アドレスではありません", proxy)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// mustParseTrustedProxies は検証済みの TrustedProxies を解析する
+func mustParseTrustedProxies(proxies []string) []*net.IPNet {
+	networks, err := parseTrustedProxies(proxies)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
+
+// getRateLimits は通常のAPIと負荷の大きいAPIの1分間のリクエスト数の上限を返す
+func getRateLimits() (int, int) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return RateLimit, ExpensiveRateLimit
+}
+
+// isTrustedProxy は ip が X-Forwarded-For を信用するリバースプロキシかどうかを返す
+func isTrustedProxy(ip net.IP) bool {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	for _, network := range trustedProxyNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddress はリクエストを送ったクライアントのIPアドレスを返す
+// 信用するリバースプロキシからの接続の場合は、X-Forwarded-For を右から見て最初の信用しないアドレスを使う
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	// unix ドメインソケットの場合は RemoteAddr が空（または @）になる
+	fromProxy := ip == nil || isTrustedProxy(ip)
+	if !fromProxy {
+		return ip.String()
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			break
+		}
+		if !isTrustedProxy(forwardedIP) || i == 0 {
+			return forwardedIP.String()
+		}
+	}
+	if ip == nil {
+		return "unix"
+	}
+	return ip.String()
+}
+
+// rateBucket はクライアントごとのトークンバケット
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter はクライアントごとのリクエスト数を1分間あたりの上限で制限する
+// 上限までのリクエストはまとめて受け付け、その後は上限/60 件ずつ毎秒受け付けられるようになる
+type rateLimiter struct {
+	mutex     sync.Mutex
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: map[string]*rateBucket{}}
+}
+
+// allow は key のバケットからトークンを1つ使う
+// 使えない場合は false と、次のトークンが使えるようになるまでの時間を返す
+func (l *rateLimiter) allow(key string, limit int, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// 1分以上使われていないバケットは満タンになっているため削除する
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, bucket := range l.buckets {
+			if now.Sub(bucket.updated) > time.Minute {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	perSecond := float64(limit) / 60
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &rateBucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(float64(limit), bucket.tokens+now.Sub(bucket.updated).Seconds()*perSecond)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// apiRateLimiter はサーバー全体で共有するAPIのリクエスト数の制限
+var apiRateLimiter = newRateLimiter()
+
+// isExpensiveAPIPath は負荷の大きいAPIかどうかを返す
+func isExpensiveAPIPath(path string) bool {
+	for _, expensive := range expensiveAPIPaths {
+		if path == expensive || strings.HasSuffix(expensive, "/") && strings.HasPrefix(path, expensive) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitHandler はAPIへのリクエストをクライアントのIPアドレスごとに制限し、上限を超えた場合は 429 と Retry-After を返す
+// 負荷の大きいAPIは別の、より厳しい上限で制限する。フックからの内部APIとページ、静的ファイルは制限しない
+// 認証を導入した場合は、トークンごとに制限するよう clientAddress の代わりにトークンをキーにする
+func rateLimitHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasPrefix(path, APIV1Prefix) {
+			path = "/api/" + strings.TrimPrefix(path, APIV1Prefix)
+		}
+		if !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/api/internal/") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		limit, expensiveLimit := getRateLimits()
+		class := "api"
+		if isExpensiveAPIPath(path) && r.Method == http.MethodGet {
+			class, limit = "expensive", expensiveLimit
+		}
+		if limit <= 0 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, retryAfter := apiRateLimiter.allow(class+" "+clientAddress(r), limit, time.Now())
+		if allowed {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+		message := "リクエストが多すぎます。しばらくしてから再試行してください"
+		if strings.HasPrefix(r.URL.Path, APIV1Prefix) {
+			detail, _ := translateMessage(message, requestLanguage(r))
+			writeAPIV1Response(w, http.StatusTooManyRequests, APIV1Response{Error: newAPIV1Error(http.StatusTooManyRequests, detail)})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(localizedError(r, http.StatusTooManyRequests, message))
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// recoverHandler はハンドラーのパニックを回復し、スタックトレースをログに記録して 500 のエラーを返す
+// 1つの不正なリクエストでサーバー全体が停止したり、接続が途中で切られたりしないようにする
+// レスポンスを書き始めた後のパニックはエラーを返せないため、接続を切る（http.ErrAbortHandler）
+func recoverHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// クライアントの切断などでハンドラーが意図的に中断した場合
+				panic(recovered)
+			}
+
+			id := requestIDFromContext(r.Context())
+			slog.Error("パニックが発生しました",
+				"request_id", id,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+
+			if recorder.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+
+			message := "サーバー内部でエラーが発生しました（リクエストID: " + id + "）"
+			w.Header().Del("Content-Length")
+			w.Header().Del("Content-Disposition")
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if strings.HasPrefix(r.URL.Path, APIV1Prefix) {
+				detail, _ := translateMessage(message, requestLanguage(r))
+				writeAPIV1Response(w, http.StatusInternalServerError, APIV1Response{Error: newAPIV1Error(http.StatusInternalServerError, detail)})
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(localizedError(r, http.StatusInternalServerError, message))
+		}()
+
+		handler.ServeHTTP(recorder, r)
+	})
+}
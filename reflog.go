@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReflogEntries は1つの ref について返す reflog の件数の既定値
+var DefaultReflogEntries = 50
+
+// MaxReflogEntries は1つの ref について返す reflog の件数の上限
+var MaxReflogEntries = 1000
+
+// ReflogEntry は reflog の1件（ref の更新1回分）を表す
+type ReflogEntry struct {
+	Selector string    `json:"selector"` // main@{0} などの reflog のセレクタ
+	SHA      string    `json:"sha"`      // 更新後のコミット
+	Name     string    `json:"name"`     // 更新した人
+	Email    string    `json:"email"`
+	Date     time.Time `json:"date"`    // 更新日時
+	Message  string    `json:"message"` // 更新の理由（"push"、"branch: Created from ..." など）
+	Subject  string    `json:"subject"` // 更新後のコミットのメッセージの1行目
+}
+
+// Reflog は1つの ref の reflog を表す
+type Reflog struct {
+	Ref     string        `json:"ref"` // "HEAD" または "refs/heads/{branch}"
+	Entries []ReflogEntry `json:"entries"`
+}
+
+// ReflogResult はリポジトリの reflog の一覧を表す
+type ReflogResult struct {
+	Repository string   `json:"repository"` // group/name
+	Enabled    bool     `json:"enabled"`    // core.logAllRefUpdates が有効かどうか
+	Reflogs    []Reflog `json:"reflogs"`
+}
+
+// reflogFormat は git reflog show で reflog を取得するためのフォーマット
+// --date=unix を指定すると %gD が main@{1700000000} のように更新日時を含む形式になる
+const reflogFormat = "%H%x00%gD%x00%gn%x00%ge%x00%gs%x00%s%x1e"
+
+// isReflogEnabled はリポジトリで reflog が記録される設定になっているかを返す
+// ベアリポジトリは既定では reflog を記録しない
+func isReflogEnabled(ctx context.Context, repoPath string) bool {
+	output, err := gitOutput(ctx, "--git-dir="+repoPath, "config", "--bool", "core.logAllRefUpdates")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "true"
+}
+
+// enableReflog は core.logAllRefUpdates を有効にして、以降の ref の更新を reflog に記録させる
+func enableReflog(ctx context.Context, repoPath string) error {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "core.logAllRefUpdates", "true")
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("reflog の有効化に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// getReflogRefs は reflog がある ref（HEAD とブランチ）を返す
+// 削除されたブランチの reflog は git によって削除されるため含まれない
+func getReflogRefs(ctx context.Context, repoPath string) ([]string, error) {
+	output, err := gitOutput(ctx, "--git-dir="+repoPath, "for-each-ref", "--format=%(refname)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("ブランチ一覧の取得に失敗しました: %w", err)
+	}
+
+	refs := []string{}
+	for _, ref := range append([]string{"HEAD"}, strings.Fields(string(output))...) {
+		if gitRun(ctx, "--git-dir="+repoPath, "reflog", "exists", ref) == nil {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// getReflog は git reflog show で ref の reflog を新しい順に取得する
+func getReflog(ctx context.Context, repoPath, ref string, limit int) (Reflog, error) {
+	reflog := Reflog{Ref: ref, Entries: []ReflogEntry{}}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "reflog", "show", "--date=unix",
+		"--format="+reflogFormat, "--max-count="+strconv.Itoa(limit), ref, "--")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return reflog, fmt.Errorf("ref '%s' の reflog の取得に失敗しました: %w", ref, err)
+	}
+
+	// セレクタは日時の代わりに何件前の更新かを表す番号にする
+	shortRef := strings.TrimPrefix(ref, "refs/heads/")
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) != 6 {
+			continue
+		}
+
+		entry := ReflogEntry{
+			Selector: fmt.Sprintf("%s@{%d}", shortRef, len(reflog.Entries)),
+			SHA:      fields[0],
+			Name:     fields[2],
+			Email:    fields[3],
+			Message:  fields[4],
+			Subject:  fields[5],
+		}
+		if _, selector, ok := strings.Cut(fields[1], "@{"); ok {
+			if unixTime, err := strconv.ParseInt(strings.TrimSuffix(selector, "}"), 10, 64); err == nil {
+				entry.Date = time.Unix(unixTime, 0)
+			}
+		}
+
+		reflog.Entries = append(reflog.Entries, entry)
+	}
+
+	return reflog, nil
+}
+
+// reflogHandler はリポジトリの reflog を返す管理者用ハンドラー
+// 強制プッシュで上書きされたコミットを探し、ブランチ作成APIで復旧するために使う
+//
+//	GET  /api/admin/reflog/{group}/{repo}?ref=main&limit=50  reflog（ref 省略時は HEAD とすべてのブランチ）
+//	POST /api/admin/reflog/{group}/{repo}                    reflog の記録を有効にする
+func reflogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if err := enableReflog(r.Context(), repoPath); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordAudit(r, "reflog.enable", groupName, repoName, nil)
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "reflog の記録を有効にしました"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	var err error
+	limit := DefaultReflogEntries
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxReflogEntries {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("limit は1から%dの整数で指定してください", MaxReflogEntries)})
+			return
+		}
+	}
+
+	result := ReflogResult{
+		Repository: groupName + "/" + repoName,
+		Enabled:    isReflogEnabled(r.Context(), repoPath),
+		Reflogs:    []Reflog{},
+	}
+
+	var refs []string
+	if ref := query.Get("ref"); ref != "" {
+		// ブランチ名だけの指定も受け付ける
+		if ref != "HEAD" && !strings.HasPrefix(ref, "refs/") {
+			ref = "refs/heads/" + ref
+		}
+		if strings.HasPrefix(ref, "-") || gitRun(r.Context(), "--git-dir="+repoPath, "reflog", "exists", ref) != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("ref '%s' の reflog がありません", ref)})
+			return
+		}
+		refs = []string{ref}
+	} else {
+		refs, err = getReflogRefs(r.Context(), repoPath)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	for _, ref := range refs {
+		reflog, err := getReflog(r.Context(), repoPath, ref, limit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		result.Reflogs = append(result.Reflogs, reflog)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefInfo はブランチまたはタグの参照を表す
+type RefInfo struct {
+	Name   string    `json:"name"`   // 短い名前（main, v1.0 など）
+	Ref    string    `json:"ref"`    // 完全な参照名（refs/heads/main など）
+	Type   string    `json:"type"`   // "branch" または "tag"
+	Target string    `json:"target"` // 参照先のコミットSHA（注釈付きタグは展開後のSHA）
+	Object string    `json:"object"` // 参照が直接指すオブジェクトのSHA
+	Date   time.Time `json:"date"`   // 参照先コミットのコミット日時
+}
+
+// refsFormat は git for-each-ref で参照を取得するためのフォーマット
+// 注釈付きタグの場合は *objectname / *committerdate に展開後の値が入る
+const refsFormat = "%(refname)%00%(objectname)%00%(*objectname)%00%(committerdate:unix)%00%(*committerdate:unix)"
+
+// getRefs はブランチとタグを取得する
+// 結果はコミット日時の新しい順に並ぶ
+func getRefs(ctx context.Context, repoPath string) ([]RefInfo, error) {
+	return getGitBackend().Refs(ctx, repoPath)
+}
+
+// Refs は git for-each-ref でブランチとタグを1回のコマンドで取得する
+func (commandGitBackend) Refs(ctx context.Context, repoPath string) ([]RefInfo, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "for-each-ref",
+		"--sort=-committerdate", "--format="+refsFormat, "refs/heads", "refs/tags")
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []RefInfo{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+
+		ref := RefInfo{
+			Ref:    fields[0],
+			Object: fields[1],
+			Target: fields[1],
+		}
+
+		if strings.HasPrefix(ref.Ref, "refs/heads/") {
+			ref.Type = "branch"
+			ref.Name = strings.TrimPrefix(ref.Ref, "refs/heads/")
+		} else {
+			ref.Type = "tag"
+			ref.Name = strings.TrimPrefix(ref.Ref, "refs/tags/")
+		}
+
+		// 注釈付きタグは展開後のコミットの情報を使う
+		timestamp := fields[3]
+		if fields[2] != "" {
+			ref.Target = fields[2]
+			timestamp = fields[4]
+		}
+		if unixTime, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			ref.Date = time.Unix(unixTime, 0)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	// 注釈付きタグは committerdate が空になり git 側の並びでは末尾に来るため、展開後の日時で並べ直す
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Date.After(refs[j].Date)
+	})
+
+	return refs, nil
+}
+
+// getRefNames は指定した種類（"branch" または "tag"）の参照名を名前順で返す
+func getRefNames(ctx context.Context, repoPath, refType string) ([]string, error) {
+	refs, err := getRefs(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, ref := range refs {
+		if ref.Type == refType {
+			names = append(names, ref.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// refsHandler はリポジトリのブランチとタグをまとめて返すハンドラー
+//
+//	GET /api/refs/{group}/{repo}?type=branch|tag
+func refsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, r.PathValue("groupName"), r.PathValue("repoName"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	refs, err := getRefs(r.Context(), repoPath)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "参照の取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	// 種類で絞り込む
+	if refType := r.URL.Query().Get("type"); refType != "" {
+		filtered := []RefInfo{}
+		for _, ref := range refs {
+			if ref.Type == refType {
+				filtered = append(filtered, ref)
+			}
+		}
+		refs = filtered
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(refs)
+}
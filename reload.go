@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConfigReloadResult は設定の再読み込みの結果を表す
+type ConfigReloadResult struct {
+	Message         string   `json:"message"`
+	Changed         []string `json:"changed"`         // 反映した設定項目
+	RestartRequired []string `json:"restartRequired"` // 変更されたが、再起動するまで反映されない設定項目
+}
+
+// configReloadMutex は SIGHUP と管理者用APIの再読み込みが同時に実行されないようにする
+var configReloadMutex sync.Mutex
+
+// configFieldValue は Config のフィールドのポインタから表示用の値を取り出す（期間は 720h0m0s のような文字列）
+func configFieldValue(field interface{}) interface{} {
+	value := reflect.ValueOf(field).Elem().Interface()
+	if d, ok := value.(time.Duration); ok {
+		return d.String()
+	}
+	return value
+}
+
+// reloadConfig は起動時と同じ設定ファイル、環境変数、フラグから設定を読み込み直し、再起動せずに変更できる項目を反映する
+// 処理中のリクエストは読み込み前の値か読み込み後の値のどちらかで処理される
+// 設定が不正な場合は何も変更せずにエラーを返す
+func reloadConfig() (*ConfigReloadResult, error) {
+	configReloadMutex.Lock()
+	defer configReloadMutex.Unlock()
+
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	blacklist, err := validateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	current := configFromVariables()
+	result := &ConfigReloadResult{Changed: []string{}, RestartRequired: []string{}}
+	for _, option := range configOptions {
+		// nil と空のリストを区別しないよう、表示用の文字列で比較する
+		if fmt.Sprint(configFieldValue(option.Field(config))) == fmt.Sprint(configFieldValue(option.Field(&current))) {
+			continue
+		}
+		if option.Reloadable {
+			result.Changed = append(result.Changed, option.Name)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, option.Name)
+		}
+	}
+
+	applyReloadableConfig(config, blacklist)
+
+	// 上限の変更をプッシュの制限（上限超過の目印）に反映する
+	for _, name := range result.Changed {
+		if strings.Contains(strings.ToLower(name), "quota") {
+			go updateAllGroupUsage(serverContext)
+			break
+		}
+	}
+
+	result.Message = fmt.Sprintf("設定を再読み込みしました（%d 個の項目を反映）", len(result.Changed))
+	log.Printf("%s: %s", result.Message, strings.Join(result.Changed, ", "))
+	if len(result.RestartRequired) > 0 {
+		log.Printf("警告: 次の設定は再起動するまで反映されません: %s", strings.Join(result.RestartRequired, ", "))
+	}
+
+	return result, nil
+}
+
+// startConfigReloader は SIGHUP を受け取ったときに設定を再読み込みする
+func startConfigReloader() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			result, err := reloadConfig()
+			if err != nil {
+				log.Printf("警告: 設定の再読み込みに失敗しました（以前の設定のまま動作します）: %v", err)
+				continue
+			}
+			recordSystemAudit("config.reload", "", "", configReloadDetails(result, "SIGHUP"))
+		}
+	}()
+}
+
+// configHandler は現在の設定を返す管理者用ハンドラー
+//
+//	GET /api/admin/config
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	config := configFromVariables()
+	values := map[string]interface{}{}
+	reloadable := []string{}
+	for _, option := range configOptions {
+		values[option.Name] = configFieldValue(option.Field(&config))
+		if option.Reloadable {
+			reloadable = append(reloadable, option.Name)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"configFile": configFilePath,
+		"config":     values,
+		"reloadable": reloadable,
+	})
+}
+
+// configReloadHandler は設定ファイル、環境変数、フラグから設定を再読み込みする管理者用ハンドラー（SIGHUP と同じ）
+//
+//	POST /api/admin/config/reload
+func configReloadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	result, err := reloadConfig()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "設定の再読み込みに失敗しました: " + err.Error()})
+		return
+	}
+
+	recordAudit(r, "config.reload", "", "", configReloadDetails(result, "api"))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// configReloadDetails は監査ログに記録する、再読み込みのきっかけと変更された設定項目を返す
+func configReloadDetails(result *ConfigReloadResult, trigger string) map[string]string {
+	return map[string]string{
+		"trigger":         trigger,
+		"changed":         strings.Join(result.Changed, ","),
+		"restartRequired": strings.Join(result.RestartRequired, ","),
+	}
+}
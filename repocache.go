@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RepositoryCacheTTL はリポジトリの最新コミット、ライセンス、ブランチ、タグをメモリにキャッシュする期間（0 はキャッシュしない）
+// 参照（HEAD、refs/、packed-refs）が変わった場合とプッシュの通知を受け取った場合は、期間内でも読み直す
+var RepositoryCacheTTL = time.Minute
+
+// getRepositoryCacheTTL はキャッシュする期間を返す
+func getRepositoryCacheTTL() time.Duration {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return RepositoryCacheTTL
+}
+
+// repositoryCacheEntry はリポジトリごとのキャッシュ
+// 返したポインタとスライスは他のリクエストと共有するため、呼び出し側で変更しない
+type repositoryCacheEntry struct {
+	Fingerprint string
+	CachedAt    time.Time
+
+	HasSummary bool
+	LastCommit *CommitInfo
+	License    *LicenseInfo
+
+	HasRefs  bool
+	Branches []BranchInfo
+	Tags     []string
+}
+
+// repositoryCache はリポジトリのパスごとのキャッシュ
+var (
+	repositoryCacheMutex sync.Mutex
+	repositoryCache      = map[string]*repositoryCacheEntry{}
+)
+
+// refsFingerprint はリポジトリの参照の状態を表す文字列を git を実行せずに求める
+// HEAD とルーズな参照の内容、packed-refs の更新日時と大きさが同じなら、先端のコミットは変わっていない
+func refsFingerprint(repoPath string) (string, error) {
+	hash := fnv.New64a()
+
+	head, err := os.ReadFile(filepath.Join(repoPath, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	hash.Write(head)
+
+	if info, err := os.Stat(filepath.Join(repoPath, "packed-refs")); err == nil {
+		fmt.Fprintf(hash, "\x00packed-refs %d %d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	refsDir := filepath.Join(repoPath, "refs")
+	err = filepath.WalkDir(refsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(refsDir, path)
+		fmt.Fprintf(hash, "\x00%s %s", rel, content)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum64()), nil
+}
+
+// lookupRepositoryCache は参照の状態が変わっておらず期限内のキャッシュを返す
+// 使えるキャッシュがない場合は、現在の参照の状態で新しいエントリを作って返す（キャッシュしない場合は nil）
+func lookupRepositoryCache(repoPath string) *repositoryCacheEntry {
+	ttl := getRepositoryCacheTTL()
+	if ttl <= 0 {
+		return nil
+	}
+	fingerprint, err := refsFingerprint(repoPath)
+	if err != nil {
+		return nil
+	}
+
+	repositoryCacheMutex.Lock()
+	defer repositoryCacheMutex.Unlock()
+
+	key := filepath.Clean(repoPath)
+	entry, ok := repositoryCache[key]
+	if ok && entry.Fingerprint == fingerprint && time.Since(entry.CachedAt) <= ttl {
+		copied := *entry
+		return &copied
+	}
+	entry = &repositoryCacheEntry{Fingerprint: fingerprint, CachedAt: time.Now()}
+	repositoryCache[key] = entry
+	copied := *entry
+	return &copied
+}
+
+// storeRepositoryCache は読み直した値をキャッシュに反映する
+// 読んでいる間に参照が変わってエントリが作り直された場合は、古い値を保存しない
+func storeRepositoryCache(repoPath string, fingerprint string, update func(entry *repositoryCacheEntry)) {
+	repositoryCacheMutex.Lock()
+	defer repositoryCacheMutex.Unlock()
+
+	entry, ok := repositoryCache[filepath.Clean(repoPath)]
+	if ok && entry.Fingerprint == fingerprint {
+		update(entry)
+	}
+}
+
+// invalidateRepositoryCache はリポジトリのキャッシュを削除する（プッシュの通知を受け取ったときなど）
+func invalidateRepositoryCache(repoPath string) {
+	repositoryCacheMutex.Lock()
+	defer repositoryCacheMutex.Unlock()
+	delete(repositoryCache, filepath.Clean(repoPath))
+}
+
+// getCachedRepositorySummary はリポジトリ一覧と詳細で使う最新コミットとライセンスを、キャッシュがあればキャッシュから返す
+func getCachedRepositorySummary(ctx context.Context, repoPath string) (*CommitInfo, *LicenseInfo) {
+	entry := lookupRepositoryCache(repoPath)
+	if entry != nil && entry.HasSummary {
+		return entry.LastCommit, entry.License
+	}
+
+	lastCommit := getLastCommit(ctx, repoPath)
+	license := detectLicense(ctx, repoPath)
+
+	// キャンセルされた場合は読めなかった値をキャッシュしない
+	if entry != nil && ctx.Err() == nil {
+		storeRepositoryCache(repoPath, entry.Fingerprint, func(entry *repositoryCacheEntry) {
+			entry.HasSummary, entry.LastCommit, entry.License = true, lastCommit, license
+		})
+	}
+	return lastCommit, license
+}
+
+// getCachedRepositoryRefs はリポジトリ詳細で使うブランチとタグを、キャッシュがあればキャッシュから返す
+// 取得に失敗した場合は空の一覧を返す
+func getCachedRepositoryRefs(ctx context.Context, repoPath string) ([]BranchInfo, []string) {
+	entry := lookupRepositoryCache(repoPath)
+	if entry != nil && entry.HasRefs {
+		return entry.Branches, entry.Tags
+	}
+
+	cacheable := entry != nil
+	branches, err := getBranchInfos(ctx, repoPath)
+	if err != nil {
+		branches = []BranchInfo{}
+		cacheable = false
+	}
+	tags, err := getRefNames(ctx, repoPath, "tag")
+	if err != nil {
+		tags = []string{}
+		cacheable = false
+	}
+
+	if cacheable && ctx.Err() == nil {
+		storeRepositoryCache(repoPath, entry.Fingerprint, func(entry *repositoryCacheEntry) {
+			entry.HasRefs, entry.Branches, entry.Tags = true, branches, tags
+		})
+	}
+	return branches, tags
+}
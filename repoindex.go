@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// RepositoryIndexEnabled はリポジトリ一覧に使う最新コミット、ライセンス、サイズをメタデータストアに保存しておくかどうか
+// 保存しておくと、再起動した直後も一覧を表示するときに git を実行しない
+var RepositoryIndexEnabled = true
+
+// RepositoryIndexInterval はすべてのリポジトリを確認して索引を更新する間隔
+var RepositoryIndexInterval = 5 * time.Minute
+
+// repositoryIndexBucket はリポジトリの索引を保存するバケット名（キーは metadataKey と同じ group/name）
+var repositoryIndexBucket = []byte("repository-index")
+
+// repositoryIndexEntry は索引に保存するリポジトリの情報
+//
+// 索引は SQLite ではなく、ほかのメタデータと同じ bbolt のファイル（metadataStore）に保存する
+// SQLite のドライバーは cgo が必要（または純Goの実装は依存が大きい）で、静的な単一バイナリで配布できなくなるため
+// 一覧で使うのは group/name をキーにした取得と全件の走査だけで、並べ替えと絞り込みはメモリ上で行うため、SQL は使わない
+type repositoryIndexEntry struct {
+	Fingerprint string       `json:"fingerprint"` // 読んだときの参照の状態（refsFingerprint）
+	LastCommit  *CommitInfo  `json:"lastCommit"`
+	License     *LicenseInfo `json:"license"`
+	DiskSize    *int64       `json:"diskSize"` // まだ読んでいない場合は nil
+	IndexedAt   time.Time    `json:"indexedAt"`
+}
+
+// repositoryIndexAvailable は索引を使えるかどうかを返す（メタデータストアを開けなかった場合は使わない）
+func repositoryIndexAvailable() bool {
+	return RepositoryIndexEnabled && metadataStore != nil
+}
+
+// loadRepositoryIndexEntry は索引からリポジトリの情報を読む
+func loadRepositoryIndexEntry(groupName, repoName string) (*repositoryIndexEntry, bool) {
+	if !repositoryIndexAvailable() {
+		return nil, false
+	}
+
+	var entry *repositoryIndexEntry
+	metadataStore.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(repositoryIndexBucket).Get(metadataKey(groupName, repoName))
+		if data == nil {
+			return nil
+		}
+		var loaded repositoryIndexEntry
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			log.Printf("警告: リポジトリ '%s/%s' の索引の読み込みに失敗しました: %v", groupName, repoName, err)
+			return nil
+		}
+		entry = &loaded
+		return nil
+	})
+	if entry == nil {
+		return nil, false
+	}
+
+	// アバターのURLは設定で変わるため、保存したものを使わずに作り直す
+	if entry.LastCommit != nil {
+		entry.LastCommit.AuthorAvatar = newAuthorAvatar(entry.LastCommit.AuthorEmail)
+	}
+	return entry, true
+}
+
+// saveRepositoryIndexEntry は索引にリポジトリの情報を保存する
+func saveRepositoryIndexEntry(groupName, repoName string, entry *repositoryIndexEntry) {
+	if !repositoryIndexAvailable() {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// 並列に読んだリポジトリの保存を1回の書き込みにまとめる
+	err = metadataStore.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(repositoryIndexBucket).Put(metadataKey(groupName, repoName), data)
+	})
+	if err != nil {
+		log.Printf("警告: リポジトリ '%s/%s' の索引の保存に失敗しました: %v", groupName, repoName, err)
+	}
+}
+
+// invalidateRepositoryIndex はリポジトリの索引を削除し、次に一覧を表示するときに読み直させる
+// 一覧では参照を確かめずに索引を使うため、プッシュ、APIでのブランチ・タグ・デフォルトブランチの変更、
+// リポジトリの作成・削除・名前の変更・復元のときに呼ぶ
+func invalidateRepositoryIndex(repoPath string) {
+	if !repositoryIndexAvailable() {
+		return
+	}
+	rel, err := filepath.Rel(GitRepositoryHome, filepath.Clean(repoPath))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	groupName, repoName := splitRepositoryName(strings.TrimSuffix(filepath.ToSlash(rel), ".git"))
+
+	err = metadataStore.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(repositoryIndexBucket).Delete(metadataKey(groupName, repoName))
+	})
+	if err != nil {
+		log.Printf("警告: リポジトリ '%s/%s' の索引の削除に失敗しました: %v", groupName, repoName, err)
+	}
+}
+
+// indexRepository はリポジトリの最新コミット、ライセンス（withSize が true の場合はサイズも）を読んで索引を更新する
+// 参照が変わっていない場合は最新コミットとライセンスを読み直さない（サイズは git gc などで変わるため毎回読む）
+func indexRepository(ctx context.Context, groupName, repoName, repoPath string, withSize bool) *repositoryIndexEntry {
+	fingerprint, err := refsFingerprint(repoPath)
+	if err != nil {
+		return nil
+	}
+
+	entry, ok := loadRepositoryIndexEntry(groupName, repoName)
+	if !ok {
+		entry = &repositoryIndexEntry{}
+	}
+	if !ok || entry.Fingerprint != fingerprint {
+		entry.Fingerprint = fingerprint
+		entry.LastCommit, entry.License = getCachedRepositorySummary(ctx, repoPath)
+	}
+	if withSize {
+		if size, err := getRepositorySize(ctx, repoPath); err == nil {
+			entry.DiskSize = &size.TotalSize
+		}
+	}
+	entry.IndexedAt = time.Now()
+
+	// キャンセルされた場合は読めなかった値を保存しない
+	if ctx.Err() == nil {
+		saveRepositoryIndexEntry(groupName, repoName, entry)
+	}
+	return entry
+}
+
+// getIndexedRepositorySummary はリポジトリ一覧で使う最新コミットとライセンスを索引から返す
+// 索引にあれば参照を確かめずにそのまま返す（変更したときは invalidateRepositoryIndex で索引を削除する）
+// 索引にない場合は読み直して索引を更新する
+func getIndexedRepositorySummary(ctx context.Context, groupName, repoName, repoPath string) (*CommitInfo, *LicenseInfo) {
+	if !repositoryIndexAvailable() {
+		return getCachedRepositorySummary(ctx, repoPath)
+	}
+
+	if entry, ok := loadRepositoryIndexEntry(groupName, repoName); ok {
+		return entry.LastCommit, entry.License
+	}
+
+	// 一覧を待たせないよう、サイズはバックグラウンドの更新で読む
+	if entry := indexRepository(ctx, groupName, repoName, repoPath, false); entry != nil {
+		return entry.LastCommit, entry.License
+	}
+	return getCachedRepositorySummary(ctx, repoPath)
+}
+
+// getIndexedRepositoryDiskSize はリポジトリのディスク上の合計サイズを索引から返す（索引にない場合は読む）
+func getIndexedRepositoryDiskSize(ctx context.Context, groupName, repoName, repoPath string) (int64, bool) {
+	if entry, ok := loadRepositoryIndexEntry(groupName, repoName); ok && entry.DiskSize != nil {
+		return *entry.DiskSize, true
+	}
+	size, err := getRepositorySize(ctx, repoPath)
+	if err != nil {
+		return 0, false
+	}
+	return size.TotalSize, true
+}
+
+// updateRepositoryIndex はすべてのグループのリポジトリの索引を更新し、なくなったリポジトリを索引から削除する
+func updateRepositoryIndex(ctx context.Context) {
+	groups, err := getGroupList()
+	if err != nil {
+		log.Printf("警告: グループ一覧の取得に失敗しました: %v", err)
+		return
+	}
+
+	indexed := map[string]bool{}
+	for _, groupName := range groups {
+		repos, err := listGitRepositories(groupName)
+		if err != nil {
+			continue
+		}
+
+		scanInParallel(ctx, len(repos), func(i int) {
+			indexRepository(ctx, groupName, repos[i].Name, repos[i].Path, true)
+		})
+		for _, repo := range repos {
+			indexed[string(metadataKey(groupName, repo.Name))] = true
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// 削除、名前の変更、ゴミ箱への移動などでなくなったリポジトリの索引を削除する
+	err = metadataStore.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(repositoryIndexBucket)
+		var stale [][]byte
+		bucket.ForEach(func(key, _ []byte) error {
+			if !indexed[string(key)] {
+				stale = append(stale, append([]byte(nil), key...))
+			}
+			return nil
+		})
+		for _, key := range stale {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("警告: リポジトリの索引の整理に失敗しました: %v", err)
+	}
+}
+
+// startRepositoryIndexer はリポジトリの索引を定期的に更新するバックグラウンド処理を開始する
+// プッシュの通知を受け取ったリポジトリは、次の更新を待たずにすぐ索引を更新する
+func startRepositoryIndexer() {
+	if !repositoryIndexAvailable() {
+		return
+	}
+
+	go func() {
+		for {
+			updateRepositoryIndex(serverContext)
+			time.Sleep(RepositoryIndexInterval)
+		}
+	}()
+}
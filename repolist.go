@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRepositoriesPerPage はリポジトリ一覧で page だけを指定した場合に1ページに返す件数
+var DefaultRepositoriesPerPage = 30
+
+// MaxRepositoriesPerPage はリポジトリ一覧で1ページに返す件数の上限
+var MaxRepositoriesPerPage = 100
+
+// TotalCountHeader は絞り込んだ後のリポジトリの総数を返すヘッダー
+const TotalCountHeader = "X-Total-Count"
+
+// repositorySortKeys はリポジトリ一覧の sort に指定できる値
+var repositorySortKeys = []string{"name", "last_commit", "size", "stars"}
+
+// repositoryListOptions はリポジトリ一覧のページと並び順の指定
+type repositoryListOptions struct {
+	Page       int    // 1から（0 の場合はページに分けずにすべて返す）
+	PerPage    int    // 1ページの件数
+	Sort       string // "name"、"last_commit"、"size"、"stars"（空の場合は last_commit、LastCommit が false の場合は name）
+	LastCommit bool   // 最新のコミット情報とライセンスを含める（false の場合は git を実行せずにすぐ返す）
+}
+
+// parseRepositoryListOptions は page、per_page、sort、lastCommit のパラメータを解析する
+// page と per_page のどちらも指定しない場合は、従来どおりすべてのリポジトリを返す
+func parseRepositoryListOptions(query url.Values) (repositoryListOptions, error) {
+	options := repositoryListOptions{Sort: query.Get("sort"), LastCommit: true}
+	if options.Sort != "" && !containsString(repositorySortKeys, options.Sort) {
+		return options, fmt.Errorf("sort には %s のいずれかを指定してください", strings.Join(repositorySortKeys, "、"))
+	}
+
+	switch query.Get("lastCommit") {
+	case "", "true":
+	case "false":
+		options.LastCommit = false
+		if options.Sort == "last_commit" {
+			return options, fmt.Errorf("lastCommit=false の場合は sort=last_commit を指定できません")
+		}
+		if options.Sort == "" {
+			options.Sort = "name"
+		}
+	default:
+		return options, fmt.Errorf("lastCommit には true または false を指定してください")
+	}
+
+	pageValue, perPageValue := query.Get("page"), query.Get("per_page")
+	if pageValue == "" && perPageValue == "" {
+		return options, nil
+	}
+
+	options.Page, options.PerPage = 1, DefaultRepositoriesPerPage
+	if pageValue != "" {
+		page, err := strconv.Atoi(pageValue)
+		if err != nil || page < 1 {
+			return options, fmt.Errorf("page は1以上の整数で指定してください")
+		}
+		options.Page = page
+	}
+	if perPageValue != "" {
+		perPage, err := strconv.Atoi(perPageValue)
+		if err != nil || perPage < 1 || perPage > MaxRepositoriesPerPage {
+			return options, fmt.Errorf("per_page は1から%dの整数で指定してください", MaxRepositoriesPerPage)
+		}
+		options.PerPage = perPage
+	}
+	return options, nil
+}
+
+// sortRepositories はリポジトリを sort の順に並べ替える
+// name は名前の昇順（大文字小文字を区別しない）、last_commit は最終コミットの新しい順、size はディスク使用量の大きい順、stars はスターの多い順
+// 同じ値の場合は名前の昇順（同じ名前の場合はグループ名の昇順）にする
+func sortRepositories(repos []GitRepository, sortKey string) {
+	byName := func(i, j int) bool {
+		left, right := strings.ToLower(repos[i].Name), strings.ToLower(repos[j].Name)
+		if left != right {
+			return left < right
+		}
+		return repos[i].Group < repos[j].Group
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		switch sortKey {
+		case "name":
+			return byName(i, j)
+		case "size":
+			if repos[i].DiskSize != repos[j].DiskSize {
+				return repos[i].DiskSize > repos[j].DiskSize
+			}
+			return byName(i, j)
+		case "stars":
+			if repos[i].Stars != repos[j].Stars {
+				return repos[i].Stars > repos[j].Stars
+			}
+			return byName(i, j)
+		default:
+			// コミット情報がない場合は最後に表示
+			left, right := repos[i].LastCommit, repos[j].LastCommit
+			if left == nil || right == nil {
+				if left != right {
+					return right == nil
+				}
+				return byName(i, j)
+			}
+			if !left.Date.Equal(right.Date) {
+				return left.Date.After(right.Date)
+			}
+			return byName(i, j)
+		}
+	})
+}
+
+// paginateRepositories は options のページのリポジトリを返す（範囲外のページは空）
+func paginateRepositories(repos []GitRepository, options repositoryListOptions) []GitRepository {
+	if options.Page == 0 {
+		return repos
+	}
+	// 非常に大きい page を指定された場合に掛け算があふれないよう、先にページ数と比べる
+	if options.Page-1 >= (len(repos)+options.PerPage-1)/options.PerPage {
+		return []GitRepository{}
+	}
+	start := (options.Page - 1) * options.PerPage
+	end := start + options.PerPage
+	if end > len(repos) {
+		end = len(repos)
+	}
+	return repos[start:end]
+}
+
+// writeRepositoryList はリポジトリ一覧を絞り込み、並べ替えてページに分けたものを返す（総数はヘッダーで返す）
+func writeRepositoryList(w http.ResponseWriter, r *http.Request, repos []GitRepository, options repositoryListOptions) {
+	// Wiki 用のリポジトリと、リクエストを送った利用者が見られないリポジトリは一覧に表示しない
+	repos = filterAccessibleRepositories(r, excludeWikiRepositories(repos))
+
+	// スターの数とピン留めを設定する（pinned での絞り込みと sort=stars に使う）
+	setRepositoryStars(r, repos)
+
+	// トピック・公開範囲・アーカイブ状態・ピン留め・名前で絞り込む
+	repos = filterRepositories(repos, r.URL.Query())
+
+	// size=true または sort=size の場合はディスク上の合計サイズも返す
+	// sort=size はすべてのリポジトリのサイズで並べるため、ページに分ける前に求める
+	withSize := r.URL.Query().Get("size") == "true" || options.Sort == "size"
+	if options.Sort == "size" {
+		setRepositoryDiskSizes(r.Context(), repos)
+	}
+
+	// 並べ替えてページに分ける（総数はヘッダーで返す）
+	sortRepositories(repos, options.Sort)
+	w.Header().Set(TotalCountHeader, strconv.Itoa(len(repos)))
+	w.Header().Set("Access-Control-Expose-Headers", TotalCountHeader)
+	repos = paginateRepositories(repos, options)
+
+	// クローンURLはアクセスされたホスト名で作り直す
+	hostName := cloneHostName(r)
+	for i := range repos {
+		repos[i].CloneURL = repositoryCloneURL(hostName, repos[i].Group, repos[i].Name)
+		repos[i].CloneURLs = repositoryCloneURLs(hostName, repos[i].Group, repos[i].Name)
+	}
+
+	// ページのリポジトリだけサイズを求める
+	if withSize && options.Sort != "size" {
+		setRepositoryDiskSizes(r.Context(), repos)
+	}
+
+	// 結果をJSONとして返す
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(repos)
+}
+
+// matchesRepositoryQuery はリポジトリ名に q が含まれるかどうかを返す（大文字小文字を区別しない）
+func matchesRepositoryQuery(repo GitRepository, q string) bool {
+	return strings.Contains(strings.ToLower(repo.Name), strings.ToLower(q))
+}
+
+// setRepositoryDiskSizes は各リポジトリのディスク上の合計サイズを DiskSize に設定する（索引にある場合は索引の値を使う）
+func setRepositoryDiskSizes(ctx context.Context, repos []GitRepository) {
+	for i := range repos {
+		if repoPath, ok := findRepository(repos[i].Group, repos[i].Name); ok {
+			if size, ok := getIndexedRepositoryDiskSize(ctx, repos[i].Group, repos[i].Name, repoPath); ok {
+				repos[i].DiskSize = size
+			}
+		}
+	}
+}
+
+// MaxRepositorySummaries は1回のリクエストで最新のコミット情報を取得できるリポジトリ数の上限
+var MaxRepositorySummaries = 100
+
+// RepositorySummary はリポジトリの最新のコミット情報とライセンス
+type RepositorySummary struct {
+	Group      string       `json:"group"`
+	Name       string       `json:"name"`
+	LastCommit *CommitInfo  `json:"lastCommit"`
+	License    *LicenseInfo `json:"license"`
+}
+
+// repositorySummariesHandler は指定したリポジトリの最新のコミット情報とライセンスをまとめて返すハンドラー
+// 一覧を lastCommit=false ですぐに表示した後、フロントエンドが表示中のリポジトリの分を少しずつ取得して埋める
+// 存在しないリポジトリは結果に含めない
+//
+//	GET /api/repositories/summaries?group=git&name=repo1&name=repo2
+func repositorySummariesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName := r.URL.Query().Get("group")
+	if groupName == "" {
+		groupName = "git"
+	}
+	names := r.URL.Query()["name"]
+	if len(names) == 0 || len(names) > MaxRepositorySummaries {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("name は1から%d個指定してください", MaxRepositorySummaries)})
+		return
+	}
+
+	summaries := []RepositorySummary{}
+	paths := []string{}
+	for _, name := range names {
+		if repoPath, ok := findAccessibleRepository(r, groupName, name); ok {
+			summaries = append(summaries, RepositorySummary{Group: groupName, Name: name})
+			paths = append(paths, repoPath)
+		}
+	}
+
+	scanInParallel(r.Context(), len(summaries), func(i int) {
+		summaries[i].LastCommit, summaries[i].License = getIndexedRepositorySummary(r.Context(), groupName, summaries[i].Name, paths[i])
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// listAllGitRepositories はすべてのグループのリポジトリを返す（lastCommit が false の場合は git を実行せずにディレクトリだけ読む）
+// ディレクトリがまだないグループ（リポジトリのない git グループ）は飛ばす
+func listAllGitRepositories(ctx context.Context, lastCommit bool) ([]GitRepository, error) {
+	groups, err := getGroupList()
+	if err != nil {
+		return nil, err
+	}
+
+	all := []GitRepository{}
+	for _, groupName := range groups {
+		var repos []GitRepository
+		if lastCommit {
+			repos, err = getGitRepositories(ctx, groupName)
+		} else {
+			repos, err = listGitRepositories(groupName)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+	}
+	return all, nil
+}
+
+// allRepositoriesHandler はすべてのグループのリポジトリをまとめて返すハンドラー
+// 各リポジトリの group にグループ名が入る以外は /api/repositories の GET と同じ（page、per_page、sort、lastCommit と絞り込みを指定できる）
+// ホームページの「すべてのグループ」で、最近コミットされたリポジトリをグループごとに取得せずに表示するために使う
+//
+//	GET /api/repositories/all?sort=last_commit&per_page=30
+func allRepositoriesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	options, err := parseRepositoryListOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	repos, err := listAllGitRepositories(r.Context(), options.LastCommit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeRepositoryList(w, r, repos, options)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findRepository はグループ名とリポジトリ名からベアリポジトリのパスを求め、存在するか確認する
+func findRepository(groupName, repoName string) (string, bool) {
+	if !isValidGroupName(groupName) || repoName == "" || repoName == "." || repoName == ".." {
+		return "", false
+	}
+
+	repoPath := filepath.Join(GitRepositoryHome, groupName, repoName+".git")
+	if _, err := os.Stat(repoPath); err != nil {
+		return "", false
+	}
+
+	return repoPath, true
+}
+
+// repositorySubresourceHandler は /api/repository/{groupName}/{repoName}/{resource}/{rest...} 形式のリクエストを振り分ける
+// rest はサブリソース以降のパス（ブランチ名、ファイルパスなど）
+func repositorySubresourceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	// OPTIONSリクエスト（プリフライト）に対する応答
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	resource, rest := r.PathValue("resource"), r.PathValue("rest")
+
+	// Wiki リポジトリはページを初めて作成するときに作る（元のリポジトリを見られない場合は作らない）
+	if resource == "contents" && r.Method != http.MethodGet && isWikiRepositoryName(repoName) && repositoryAccessible(r, groupName, repoName) {
+		if _, err := ensureWikiRepository(r.Context(), groupName, strings.TrimSuffix(repoName, WikiRepositorySuffix)); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	repoPath, ok := findAccessibleRepository(r, groupName, repoName)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	switch resource {
+	case "branches":
+		branchesHandler(w, r, repoPath, rest)
+	case "tags":
+		tagsHandler(w, r, repoPath, rest)
+	case "protected-branches":
+		protectedBranchesHandler(w, r, repoPath)
+	case "subscribers":
+		subscribersHandler(w, r, repoPath)
+	case "commits":
+		commitsHandler(w, r, repoPath, rest)
+	case "merge":
+		mergeHandler(w, r, groupName, repoPath)
+	case "merge-requests":
+		mergeRequestsHandler(w, r, groupName, repoName, repoPath, rest)
+	case "contents":
+		contentsHandler(w, r, groupName, repoPath, rest)
+	case "wiki":
+		wikiHandler(w, r, groupName, repoName, rest)
+	case "changelog":
+		changelogHandler(w, r, repoPath)
+	case "maintenance":
+		maintenanceHandler(w, r, repoPath)
+	case "star":
+		starHandler(w, r, groupName, repoName)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不明なAPIです: " + resource})
+	}
+}
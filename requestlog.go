@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// LogFormat はサーバーのログの形式（text または json）
+var LogFormat = "text"
+
+// RequestIDHeader はリクエストIDを受け取り、レスポンスで返すヘッダー
+const RequestIDHeader = "X-Request-Id"
+
+// validRequestID はリバースプロキシなどから受け取ったリクエストIDとして使える値
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestIDKey はリクエストの context にリクエストIDを保存するキー
+type requestIDKey struct{}
+
+// validateLogFormat はログの形式が text または json かどうかを確認する
+func validateLogFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("logFormat は text または json で指定してください: %s", format)
+	}
+	return nil
+}
+
+// setupLogger は LogFormat の形式で標準エラー出力に書き出す slog のロガーを既定にする
+// log.Printf のログも同じ形式で出力される
+func setupLogger() {
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, nil)
+	if LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// newRequestID はランダムなリクエストID（16文字の16進数）を作る
+func newRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// requestIDFromContext はリクエストの context からリクエストIDを取り出す（ない場合は空）
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// statusRecorder はレスポンスのステータスコードと大きさを記録する
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap は http.ResponseController が元の ResponseWriter の機能（Flush など）を使えるようにする
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestLogHandler はリクエストごとにメソッド、パス、ステータスコード、処理時間、リクエストIDをログに記録する
+// リクエストIDは X-Request-Id ヘッダーで返す。リバースプロキシが X-Request-Id を付けている場合はその値を使う
+func requestLogHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		// レスポンスの途中で中断された（http.ErrAbortHandler）リクエストも記録する
+		defer func() {
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			slog.Log(r.Context(), level, "request",
+				"request_id", id,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"bytes", recorder.bytes,
+				"duration", time.Since(start),
+				"remote", clientAddress(r),
+			)
+		}()
+
+		handler.ServeHTTP(recorder, r)
+	})
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RestoreRequest はバックアップからの一括復元リクエスト用の構造体
+type RestoreRequest struct {
+	Backup string `json:"backup"` // BackupDirectory の下のバックアップ名（例: 20240101-120000）
+	Force  bool   `json:"force"`  // 既存のリポジトリを上書きするかどうか
+}
+
+// RestoreResult はリポジトリごとの復元結果を表す
+type RestoreResult struct {
+	Repository string `json:"repository"` // group/name
+	Restored   bool   `json:"restored"`
+	Error      string `json:"error,omitempty"`
+}
+
+// validateRestoreTarget は復元先のグループ名とリポジトリ名を確認し、既に存在するかどうかを返す
+func validateRestoreTarget(groupName, repoName string) (bool, error) {
+	if !isValidGroupName(groupName) {
+		return false, fmt.Errorf("無効なグループ名です: %s", groupName)
+	}
+	if err := validateRepositoryNameFormat(repoName); err != nil {
+		return false, err
+	}
+
+	_, exists := findRepository(groupName, repoName)
+	return exists, nil
+}
+
+// restoreRepositoryFromBundle はバンドルから git clone --mirror でベアリポジトリを作成する
+// 既存のリポジトリがある場合（force 指定時）は、復元に成功してから論理削除して置き換える
+func restoreRepositoryFromBundle(ctx context.Context, bundlePath, groupName, repoName string) error {
+	bundlePath, err := filepath.Abs(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	groupPath := filepath.Join(GitRepositoryHome, groupName)
+	if err := os.MkdirAll(groupPath, 0755); err != nil {
+		return fmt.Errorf("グループディレクトリの作成に失敗しました: %w", err)
+	}
+
+	// 失敗したときに既存のリポジトリを壊さないよう、一時ディレクトリに復元してから置き換える
+	tempPath, err := os.MkdirTemp(groupPath, ".restore-")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗しました: %w", err)
+	}
+	defer os.RemoveAll(tempPath)
+
+	clonePath := filepath.Join(tempPath, repoName+".git")
+	cmd, cancel := gitMaintenanceCommand(ctx, "clone", "--mirror", "--quiet", bundlePath, clonePath)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("バンドルの復元に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+
+	// 復元元のバンドルを指すリモートは不要なので削除する
+	gitRun(ctx, "--git-dir="+clonePath, "remote", "remove", "origin")
+
+	if err := enableReflog(ctx, clonePath); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
+	repoPath := filepath.Join(groupPath, repoName+".git")
+	if _, err := os.Stat(repoPath); err == nil {
+		if err := deleteRepository(groupName + "/" + repoName); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(clonePath, repoPath); err != nil {
+		return fmt.Errorf("リポジトリの配置に失敗しました: %w", err)
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	// 容量制限をプッシュ時にも適用するグループでは、新しいリポジトリにもフックが必要
+	if isQuotaEnforcedOnPush(groupName) {
+		if err := installPreReceiveHook(repoPath); err != nil {
+			log.Printf("警告: %s に pre-receive フックを設置できませんでした: %v", repoPath, err)
+		}
+	}
+
+	// グループにプッシュのチャット通知がある場合は post-receive フックを設置する
+	if err := syncPostReceiveHook(ctx, repoPath); err != nil {
+		log.Printf("警告: %s: %v", repoPath, err)
+	}
+
+	return nil
+}
+
+// restoreFromBackup は backup で作成したディレクトリのマニフェストに従い、すべてのリポジトリを復元する
+// 説明、メタデータ、保護ブランチ、HEAD もマニフェストの内容に戻す
+func restoreFromBackup(ctx context.Context, backupDir string, force bool) ([]RestoreResult, error) {
+	data, err := os.ReadFile(filepath.Join(backupDir, backupManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストの読み込みに失敗しました: %w", err)
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("マニフェストの形式が不正です: %w", err)
+	}
+
+	results := []RestoreResult{}
+	for _, entry := range manifest.Repositories {
+		result := RestoreResult{Repository: entry.Group + "/" + entry.Name}
+		if err := restoreBackupEntry(ctx, backupDir, entry, force); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Restored = true
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// restoreBackupEntry はマニフェストの1つのリポジトリを復元する
+func restoreBackupEntry(ctx context.Context, backupDir string, entry BackupRepository, force bool) error {
+	exists, err := validateRestoreTarget(entry.Group, entry.Name)
+	if err != nil {
+		return err
+	}
+	if exists && !force {
+		return fmt.Errorf("リポジトリ '%s/%s' は既に存在します", entry.Group, entry.Name)
+	}
+
+	if entry.Empty {
+		// 空のリポジトリはバンドルがないため、新しく作成する
+		if exists {
+			if err := deleteRepository(entry.Group + "/" + entry.Name); err != nil {
+				return err
+			}
+		}
+		if err := createRepository(ctx, entry.Name, entry.Group, entry.HeadBranch, RepositoryTemplate{}); err != nil {
+			return err
+		}
+	} else {
+		// マニフェストのパスがバックアップ先の外を指していないか確認する
+		bundlePath := filepath.Join(backupDir, filepath.FromSlash(entry.Bundle))
+		if !strings.HasPrefix(bundlePath, filepath.Clean(backupDir)+string(filepath.Separator)) {
+			return fmt.Errorf("バンドルのパスが不正です: %s", entry.Bundle)
+		}
+		if err := restoreRepositoryFromBundle(ctx, bundlePath, entry.Group, entry.Name); err != nil {
+			return err
+		}
+	}
+
+	repoPath := filepath.Join(GitRepositoryHome, entry.Group, entry.Name+".git")
+	if err := setRepositoryDescription(repoPath, entry.Description); err != nil {
+		return err
+	}
+	if entry.HeadBranch != "" {
+		if err := setDefaultBranch(ctx, repoPath, entry.HeadBranch); err != nil {
+			log.Printf("警告: %s/%s の HEAD を %s に設定できませんでした: %v", entry.Group, entry.Name, entry.HeadBranch, err)
+		}
+	}
+	if len(entry.ProtectedBranches) > 0 {
+		if err := setProtectedBranches(ctx, repoPath, entry.ProtectedBranches); err != nil {
+			return err
+		}
+	}
+	if metadataStore != nil {
+		if err := setRepositoryMetadata(entry.Group, entry.Name, entry.Metadata); err != nil {
+			return fmt.Errorf("メタデータの保存に失敗しました: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runRestoreCommand は restore サブコマンドを実行し、終了コードを返す
+//
+//	guilty restore [-force] {バックアップのディレクトリ}
+//	guilty restore [-force] {バンドルファイル} {group}/{name}
+func runRestoreCommand(args []string) int {
+	force := false
+	if len(args) > 0 && (args[0] == "-force" || args[0] == "--force") {
+		force = true
+		args = args[1:]
+	}
+
+	if !requireMetadataStore() {
+		return 1
+	}
+
+	switch len(args) {
+	case 1:
+		results, err := restoreFromBackup(serverContext, args[0], force)
+		if err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+		recordRestoreResults(recordCLIAudit, args[0], results)
+
+		failed := 0
+		for _, result := range results {
+			if !result.Restored {
+				log.Printf("エラー: %s: %s", result.Repository, result.Error)
+				failed++
+			}
+		}
+		log.Printf("%d 個のリポジトリを復元しました", len(results)-failed)
+		if failed > 0 {
+			return 1
+		}
+		return 0
+
+	case 2:
+		groupName, repoName := splitRepositoryName(args[1])
+		exists, err := validateRestoreTarget(groupName, repoName)
+		if err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+		if exists && !force {
+			log.Printf("エラー: リポジトリ '%s/%s' は既に存在します（上書きする場合は -force を指定してください）", groupName, repoName)
+			return 1
+		}
+
+		if err := restoreRepositoryFromBundle(serverContext, args[0], groupName, repoName); err != nil {
+			log.Printf("エラー: %v", err)
+			return 1
+		}
+		recordCLIAudit("repository.restore", groupName, repoName, map[string]string{"source": "bundle"})
+		log.Printf("%s/%s を復元しました", groupName, repoName)
+		return 0
+
+	default:
+		log.Printf("使い方: guilty restore [-force] {バックアップのディレクトリ} または guilty restore [-force] {バンドルファイル} {group}/{name}")
+		return 2
+	}
+}
+
+// restoreHandler はバンドルからリポジトリを復元する管理者用ハンドラー
+//
+//	POST /api/admin/restore                             {"backup": "...", "force": false} BackupDirectory のバックアップから一括復元
+//	POST /api/admin/restore/{group}/{repo}?force=true   リクエストボディのバンドルから復元
+func restoreHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	groupName, repoName := r.PathValue("groupName"), r.PathValue("repoName")
+	if groupName == "" {
+		restoreBackupHandler(w, r)
+		return
+	}
+
+	exists, err := validateRestoreTarget(groupName, repoName)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if exists && r.URL.Query().Get("force") != "true" {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリは既に存在します（上書きする場合は force=true を指定してください）"})
+		return
+	}
+
+	// グループのディスク使用量の上限を確認
+	if err := checkGroupQuota(groupName); err != nil {
+		w.WriteHeader(http.StatusInsufficientStorage)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// アップロードされたバンドルを一時ファイルに保存する
+	bundleFile, err := os.CreateTemp("", "guilty-restore-*.bundle")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "一時ファイルの作成に失敗しました"})
+		return
+	}
+	defer os.Remove(bundleFile.Name())
+
+	_, err = io.Copy(bundleFile, r.Body)
+	bundleFile.Close()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "バンドルの受信に失敗しました"})
+		return
+	}
+
+	if err := restoreRepositoryFromBundle(r.Context(), bundleFile.Name(), groupName, repoName); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	recordAudit(r, "repository.restore", groupName, repoName, map[string]string{"source": "bundle"})
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリを復元しました"})
+}
+
+// restoreBackupHandler は BackupDirectory の下のバックアップからすべてのリポジトリを復元する
+func restoreBackupHandler(w http.ResponseWriter, r *http.Request) {
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+		return
+	}
+
+	// 任意のディレクトリを読ませないよう、バックアップ名のみ受け付ける
+	if req.Backup == "" || req.Backup != filepath.Base(req.Backup) || strings.HasPrefix(req.Backup, ".") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なバックアップ名です"})
+		return
+	}
+
+	results, err := restoreFromBackup(r.Context(), filepath.Join(BackupDirectory, req.Backup), req.Force)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	recordRestoreResults(requestAuditRecorder(r), req.Backup, results)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
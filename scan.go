@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ScanConcurrency はリポジトリ一覧で最新コミットなどを並列に読むリポジトリ数の上限（1 の場合は順に読む）
+// 大きくすると一覧は速くなるが、同時に実行する git のプロセスが増える
+var ScanConcurrency = 8
+
+// getScanConcurrency はリポジトリを並列に読む数の上限を返す
+func getScanConcurrency() int {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return ScanConcurrency
+}
+
+// scanInParallel は 0 から count-1 までの i について fn を最大 ScanConcurrency 個の goroutine で並列に実行し、すべて終わるまで待つ
+// fn は i ごとに別の要素だけを書き換えるようにする（結果の順番は呼び出し側の配列の順番のまま）
+// ctx がキャンセルされた場合、まだ始まっていない i の fn は実行しない
+func scanInParallel(ctx context.Context, count int, fn func(i int)) {
+	workers := getScanConcurrency()
+	if workers > count {
+		workers = count
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
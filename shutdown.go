@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// サーバーのタイムアウト
+// ReadTimeout はリクエスト全体（リストアのバンドルなどのアップロードを含む）、WriteTimeout はレスポンス全体（エクスポートのバンドルなど）を読み書きする時間の上限
+var (
+	ReadTimeout  = 5 * time.Minute
+	WriteTimeout = 10 * time.Minute
+	IdleTimeout  = 2 * time.Minute
+)
+
+// ShutdownTimeout は SIGINT または SIGTERM を受け取ってから、処理中のリクエストが終わるのを待つ時間の上限
+var ShutdownTimeout = 30 * time.Second
+
+// readHeaderTimeout はリクエストのヘッダーを読む時間の上限
+const readHeaderTimeout = 10 * time.Second
+
+// cancelGracePeriod は git のプロセスを停止した後、ハンドラーが終わるのを待つ時間の上限
+const cancelGracePeriod = 5 * time.Second
+
+// activeRequests は処理中のリクエスト（接続を切った後も実行中のハンドラーを含む）
+var activeRequests sync.WaitGroup
+
+// serverContext はサーバーの終了時にキャンセルされる context
+// リクエストの context はこの context から作られ、CLI とバックグラウンドの処理はこの context で git を実行する
+// 終了時にキャンセルして、実行中の git のプロセスを停止する
+var serverContext, cancelServerContext = context.WithCancel(context.Background())
+
+// trackActiveRequests は処理中のリクエストを activeRequests で数える
+func trackActiveRequests(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		activeRequests.Add(1)
+		defer activeRequests.Done()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// newHTTPServer はタイムアウトを設定した http.Server を作る
+func newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler: trackActiveRequests(handler),
+		// リクエストの context（git のコマンドの停止に使う）もサーバーの終了時にキャンセルする
+		BaseContext:       func(net.Listener) context.Context { return serverContext },
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       ReadTimeout,
+		WriteTimeout:      WriteTimeout,
+		IdleTimeout:       IdleTimeout,
+	}
+}
+
+// shutdownOnSignal は SIGINT または SIGTERM を受け取ったら servers の受け付けを止め、処理中のリクエストが終わるのを待つ
+// ShutdownTimeout までに終わらなかった場合は実行中の git のプロセスを停止して接続を切る
+// 停止が終わると返したチャネルが閉じられる。2回目のシグナルではすぐに終了する
+func shutdownOnSignal(servers ...*http.Server) <-chan struct{} {
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		defer close(done)
+		received := <-signals
+		signal.Stop(signals)
+		log.Printf("%v を受け取りました。処理中のリクエストが終わるのを待って終了します（最大 %v）", received, ShutdownTimeout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		for _, server := range servers {
+			wg.Add(1)
+			go func(server *http.Server) {
+				defer wg.Done()
+				if err := server.Shutdown(ctx); err != nil {
+					log.Printf("警告: 処理中のリクエストが終わらないため、実行中の git を停止して接続を切ります: %v", err)
+					cancelServerContext()
+					server.Close()
+				}
+			}(server)
+		}
+		wg.Wait()
+
+		// バックグラウンドの処理（定期 git gc など）が実行中の git も停止する
+		cancelServerContext()
+
+		// 接続を切ったハンドラーが git のプロセスの停止を待って終わるまで待つ（プロセスを残して終了しないようにする）
+		finished := make(chan struct{})
+		go func() {
+			activeRequests.Wait()
+			close(finished)
+		}()
+		select {
+		case <-finished:
+		case <-time.After(cancelGracePeriod):
+			log.Printf("警告: 終わらないリクエストを残して終了します")
+		}
+	}()
+
+	return done
+}
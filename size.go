@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// RepositorySize は git count-objects -v の結果を表す（サイズはすべてバイト単位）
+type RepositorySize struct {
+	LooseObjects  int64 `json:"looseObjects"`  // ルーズオブジェクトの数（count）
+	LooseSize     int64 `json:"looseSize"`     // ルーズオブジェクトのサイズ（size）
+	PackedObjects int64 `json:"packedObjects"` // パック済みオブジェクトの数（in-pack）
+	Packs         int64 `json:"packs"`         // パックファイルの数（packs）
+	PackSize      int64 `json:"packSize"`      // パックファイルのサイズ（size-pack）
+	PrunePackable int64 `json:"prunePackable"` // パックにも含まれるルーズオブジェクトの数（prune-packable）
+	Garbage       int64 `json:"garbage"`       // 不要なファイルの数（garbage）
+	GarbageSize   int64 `json:"garbageSize"`   // 不要なファイルのサイズ（size-garbage）
+	TotalSize     int64 `json:"totalSize"`     // ディスク上の合計サイズ（looseSize + packSize + garbageSize）
+}
+
+// getRepositorySize は git count-objects -v でリポジトリのオブジェクト数とサイズを取得する
+func getRepositorySize(ctx context.Context, repoPath string) (*RepositorySize, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "count-objects", "-v")
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// 各行の形式: <key>: <value>（サイズは KiB 単位）
+	size := &RepositorySize{}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch key {
+		case "count":
+			size.LooseObjects = number
+		case "size":
+			size.LooseSize = number * 1024
+		case "in-pack":
+			size.PackedObjects = number
+		case "packs":
+			size.Packs = number
+		case "size-pack":
+			size.PackSize = number * 1024
+		case "prune-packable":
+			size.PrunePackable = number
+		case "garbage":
+			size.Garbage = number
+		case "size-garbage":
+			size.GarbageSize = number * 1024
+		}
+	}
+
+	size.TotalSize = size.LooseSize + size.PackSize + size.GarbageSize
+
+	return size, nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MaxPinnedRepositories は1つのグループでピン留めできるリポジトリ数の上限
+var MaxPinnedRepositories = 6
+
+// starsBucket はリポジトリごとにスターを付けたユーザーを保存するバケット名（キーは group/name）
+var starsBucket = []byte("stars")
+
+// pinsBucket はグループごとにピン留めしたリポジトリ名を保存するバケット名（キーはグループ名）
+var pinsBucket = []byte("pins")
+
+// StarStatus はリポジトリのスターの数と、リクエストを送ったユーザーがスターを付けているかどうか
+type StarStatus struct {
+	Starred bool `json:"starred"`
+	Stars   int  `json:"stars"`
+}
+
+// PinnedRepositories はグループでピン留めしたリポジトリ（表示する順）
+type PinnedRepositories struct {
+	Repositories []string `json:"repositories"`
+}
+
+// getStargazers はリポジトリにスターを付けたユーザーを返す
+func getStargazers(tx *bolt.Tx, groupName, repoName string) []string {
+	users := []string{}
+	data := tx.Bucket(starsBucket).Get(metadataKey(groupName, repoName))
+	if data == nil {
+		return users
+	}
+	if err := json.Unmarshal(data, &users); err != nil {
+		log.Printf("警告: リポジトリ '%s/%s' のスターの読み込みに失敗しました: %v", groupName, repoName, err)
+	}
+	return users
+}
+
+// getStarStatus はリポジトリのスターの数と、user がスターを付けているかどうかを返す
+func getStarStatus(groupName, repoName, user string) StarStatus {
+	status := StarStatus{}
+	if metadataStore == nil {
+		return status
+	}
+	metadataStore.View(func(tx *bolt.Tx) error {
+		users := getStargazers(tx, groupName, repoName)
+		status.Stars = len(users)
+		status.Starred = user != "" && containsString(users, user)
+		return nil
+	})
+	return status
+}
+
+// setStar は user のスターを付ける（starred が false の場合は外す）
+func setStar(groupName, repoName, user string, starred bool) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		users := getStargazers(tx, groupName, repoName)
+		updated := []string{}
+		for _, u := range users {
+			if u != user {
+				updated = append(updated, u)
+			}
+		}
+		if starred {
+			updated = append(updated, user)
+		}
+
+		key := metadataKey(groupName, repoName)
+		if len(updated) == 0 {
+			return tx.Bucket(starsBucket).Delete(key)
+		}
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(starsBucket).Put(key, data)
+	})
+}
+
+// getPinnedRepositoryNames はグループでピン留めしたリポジトリ名を返す
+func getPinnedRepositoryNames(tx *bolt.Tx, groupName string) []string {
+	names := []string{}
+	data := tx.Bucket(pinsBucket).Get([]byte(groupName))
+	if data == nil {
+		return names
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		log.Printf("警告: グループ '%s' のピン留めの読み込みに失敗しました: %v", groupName, err)
+	}
+	return names
+}
+
+// putPinnedRepositoryNames はグループでピン留めしたリポジトリ名を保存する（空の場合は削除する）
+func putPinnedRepositoryNames(tx *bolt.Tx, groupName string, names []string) error {
+	if len(names) == 0 {
+		return tx.Bucket(pinsBucket).Delete([]byte(groupName))
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(pinsBucket).Put([]byte(groupName), data)
+}
+
+// getPinnedRepositories はグループでピン留めしたリポジトリのうち、リクエストを送った利用者が見られるものを返す
+// ゴミ箱に移動したリポジトリは除く（復元すると再び表示される）
+func getPinnedRepositories(r *http.Request, groupName string) []string {
+	visible := []string{}
+	if metadataStore == nil {
+		return visible
+	}
+	var names []string
+	metadataStore.View(func(tx *bolt.Tx) error {
+		names = getPinnedRepositoryNames(tx, groupName)
+		return nil
+	})
+	for _, name := range names {
+		if _, ok := findAccessibleRepository(r, groupName, name); ok {
+			visible = append(visible, name)
+		}
+	}
+	return visible
+}
+
+// setPinnedRepositories はグループでピン留めするリポジトリを置き換える
+// リクエストを送った利用者が見られないため指定できなかったリポジトリのピン留めは残す
+func setPinnedRepositories(r *http.Request, groupName string, names []string) error {
+	if metadataStore == nil {
+		return fmt.Errorf("メタデータストアが利用できません")
+	}
+
+	pinned := []string{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if containsString(pinned, name) {
+			continue
+		}
+		if _, ok := findAccessibleRepository(r, groupName, name); !ok || isWikiRepositoryName(name) {
+			return fmt.Errorf("リポジトリ '%s' は存在しません", name)
+		}
+		pinned = append(pinned, name)
+	}
+
+	return metadataStore.Update(func(tx *bolt.Tx) error {
+		for _, name := range getPinnedRepositoryNames(tx, groupName) {
+			if !containsString(pinned, name) && !repositoryAccessible(r, groupName, name) {
+				pinned = append(pinned, name)
+			}
+		}
+		if len(pinned) > MaxPinnedRepositories {
+			return fmt.Errorf("ピン留めできるリポジトリは%d個までです", MaxPinnedRepositories)
+		}
+		return putPinnedRepositoryNames(tx, groupName, pinned)
+	})
+}
+
+// deleteRepositoryStars はリポジトリのスターとピン留めを削除する（完全に削除したときに呼ぶ）
+func deleteRepositoryStars(tx *bolt.Tx, groupName, repoName string) error {
+	if err := tx.Bucket(starsBucket).Delete(metadataKey(groupName, repoName)); err != nil {
+		return err
+	}
+	return unpinRepository(tx, groupName, repoName)
+}
+
+// unpinRepository はリポジトリのピン留めを外す
+func unpinRepository(tx *bolt.Tx, groupName, repoName string) error {
+	names := getPinnedRepositoryNames(tx, groupName)
+	remaining := []string{}
+	for _, name := range names {
+		if name != repoName {
+			remaining = append(remaining, name)
+		}
+	}
+	if len(remaining) == len(names) {
+		return nil
+	}
+	return putPinnedRepositoryNames(tx, groupName, remaining)
+}
+
+// renamePinnedRepository はピン留めしたリポジトリの名前を変える（同じ位置のまま）
+// 別のグループに移した場合は、移す前のグループのピン留めを外す
+func renamePinnedRepository(tx *bolt.Tx, groupName, repoName, newGroupName, newRepoName string) error {
+	if groupName != newGroupName {
+		return unpinRepository(tx, groupName, repoName)
+	}
+	names := getPinnedRepositoryNames(tx, groupName)
+	for i, name := range names {
+		if name == repoName {
+			names[i] = newRepoName
+			return putPinnedRepositoryNames(tx, groupName, names)
+		}
+	}
+	return nil
+}
+
+// setRepositoryStars はリポジトリ一覧の各リポジトリにスターの数、リクエストを送ったユーザーのスター、ピン留めを設定する
+func setRepositoryStars(r *http.Request, repos []GitRepository) {
+	if metadataStore == nil || len(repos) == 0 {
+		return
+	}
+	user := requestUser(r)
+	metadataStore.View(func(tx *bolt.Tx) error {
+		pins := map[string][]string{}
+		for i := range repos {
+			users := getStargazers(tx, repos[i].Group, repos[i].Name)
+			repos[i].Stars = len(users)
+			repos[i].Starred = user != "" && containsString(users, user)
+
+			names, ok := pins[repos[i].Group]
+			if !ok {
+				names = getPinnedRepositoryNames(tx, repos[i].Group)
+				pins[repos[i].Group] = names
+			}
+			repos[i].Pinned = containsString(names, repos[i].Name)
+		}
+		return nil
+	})
+}
+
+// starHandler はリポジトリのスターを扱う
+//
+//	GET    /api/repository/{group}/{repo}/star  スターの数と、リクエストを送ったユーザーがスターを付けているか
+//	PUT    /api/repository/{group}/{repo}/star  スターを付ける
+//	DELETE /api/repository/{group}/{repo}/star  スターを外す
+func starHandler(w http.ResponseWriter, r *http.Request, groupName, repoName string) {
+	if metadataStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "メタデータストアが利用できません"})
+		return
+	}
+
+	user := requestUser(r)
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodDelete:
+		// スターはユーザーごとに付けるため、認証されていない利用者は付けられない
+		if user == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "スターを付けるにはユーザーの認証が必要です"})
+			return
+		}
+		if err := setStar(groupName, repoName, user, r.Method == http.MethodPut); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(getStarStatus(groupName, repoName, user))
+}
+
+// pinsHandler はグループでピン留めするリポジトリを扱う
+//
+//	GET /api/groups/{groupName}/pins  ピン留めしたリポジトリ名（表示する順）
+//	PUT /api/groups/{groupName}/pins  {"repositories": ["..."]} で置き換える（空の配列ですべて外す）
+func pinsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	groupName := r.PathValue("groupName")
+	if !isValidGroupName(groupName) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "無効なグループ名です"})
+		return
+	}
+
+	if metadataStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "メタデータストアが利用できません"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(PinnedRepositories{Repositories: getPinnedRepositories(r, groupName)})
+
+	case http.MethodPut:
+		var req PinnedRepositories
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := setPinnedRepositories(r, groupName, req.Repositories); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		pinned := getPinnedRepositories(r, groupName)
+		recordAudit(r, "pins.update", groupName, "", map[string]string{"repositories": strings.Join(pinned, ",")})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(PinnedRepositories{Repositories: pinned})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
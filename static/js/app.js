@@ -1,13 +1,29 @@
+// 最新のコミット情報を1回に取得するリポジトリ数
+const SUMMARY_BATCH_SIZE = 50;
+
+// グループの選択で「すべてのグループ」を表す値
+const ALL_GROUPS = '*';
+
+// 「すべてのグループ」で表示する最近コミットされたリポジトリの数
+const ALL_GROUPS_LIMIT = 50;
+
 // グローバルコンポーネントの定義をcreateAppの前に行う
 const RepositoryRow = {
-  props: ['repository'],
+  props: ['repository', 'showGroup'],
   template: `
     <tr class="repo-row" @click="openRepository" style="cursor: pointer;">
-      <td class="repo-name">{{ repository.name }}</td>
+      <td class="repo-name">
+        <span v-if="repository.pinned" class="mr-1" title="ピン留め">📌</span><span v-if="showGroup" class="text-muted">{{ repository.group }} / </span>{{ repository.name }}
+        <span v-if="repository.stars" class="badge badge-warning ml-1" title="スター">★ {{ repository.stars }}</span>
+        <span v-if="repository.license" class="badge badge-light ml-1" :title="repository.license.name">{{ repository.license.spdxId }}</span>
+      </td>
       <td class="repo-commit" v-if="repository.lastCommit">
         {{ formatDate(repository.lastCommit.date) }} by {{ repository.lastCommit.author }}<br>
         <small>{{ repository.lastCommit.message }}</small>
       </td>
+      <td class="repo-commit" v-else-if="repository.summaryLoading">
+        <small class="text-muted">読み込み中...</small>
+      </td>
       <td class="repo-commit" v-else>
         <small>コミット情報なし</small>
       </td>
@@ -35,6 +51,7 @@ const app = Vue.createApp({
       searchQuery: '',
       groups: [],
       selectedGroup: 'git',
+      ALL_GROUPS: ALL_GROUPS,
       loadingGroups: true,
       pageTitle: document.querySelector('h1'),
       pageMessage: document.querySelector('p')
@@ -63,6 +80,7 @@ const app = Vue.createApp({
             @change="onGroupChange"
             :disabled="loadingGroups"
           >
+            <option :value="ALL_GROUPS">すべてのグループ</option>
             <option v-for="group in groups" :key="group" :value="group">
               {{ group }}
             </option>
@@ -76,7 +94,7 @@ const app = Vue.createApp({
             placeholder="リポジトリを検索..."
           />
         </div>
-        <div>
+        <div v-if="selectedGroup !== ALL_GROUPS">
           <a :href="getCreateRepositoryUrl(selectedGroup)" class="btn btn-primary">
             <i class="fa fa-plus-circle"></i> 新規リポジトリ
           </a>
@@ -111,6 +129,7 @@ const app = Vue.createApp({
                 v-for="(repo, index) in filteredRepositories" 
                 :key="index" 
                 :repository="repo"
+                :show-group="selectedGroup === ALL_GROUPS"
               ></repository-row>
             </tbody>
           </table>
@@ -132,7 +151,7 @@ const app = Vue.createApp({
     fetchGroups() {
       // グループ一覧を取得
       this.loadingGroups = true;
-      axios.get('/api/groups')
+      axios.get(GuiltyUtils.url('/api/groups'))
         .then(response => {
           this.groups = response.data;
           this.loadingGroups = false;
@@ -147,20 +166,82 @@ const app = Vue.createApp({
     },
     fetchRepositories() {
       // APIエンドポイントからリポジトリを取得
+      // git を実行しない lastCommit=false で一覧をすぐに表示し、最新のコミット情報は後から埋める
       this.loading = true;
-      axios.get(GuiltyUtils.getRepositoriesApiUrl(this.selectedGroup))
+      const group = this.selectedGroup;
+      if (group === ALL_GROUPS) {
+        this.fetchAllRepositories();
+        return;
+      }
+      axios.get(GuiltyUtils.getRepositoriesApiUrl(group) + '&lastCommit=false')
         .then(response => {
-          this.repositories = response.data;
+          this.repositories = (response.data || []).map(repo => Object.assign(repo, { summaryLoading: true }));
           this.loading = false;
           
           // タイトルとメッセージを更新
           this.updatePageTitle();
+
+          this.fetchSummaries(group);
+        })
+        .catch(error => {
+          this.error = `リポジトリ一覧の取得に失敗しました: ${error.message}`;
+          this.loading = false;
+        });
+    },
+    fetchAllRepositories() {
+      // すべてのグループのリポジトリを最終コミットの新しい順に ALL_GROUPS_LIMIT 件取得する
+      const params = new URLSearchParams({ sort: 'last_commit', per_page: ALL_GROUPS_LIMIT });
+      axios.get(GuiltyUtils.url('/api/repositories/all?' + params.toString()))
+        .then(response => {
+          if (this.selectedGroup !== ALL_GROUPS) return;
+          this.repositories = response.data || [];
+          this.loading = false;
+          this.updatePageTitle();
         })
         .catch(error => {
           this.error = `リポジトリ一覧の取得に失敗しました: ${error.message}`;
           this.loading = false;
         });
     },
+    fetchSummaries(group) {
+      // SUMMARY_BATCH_SIZE 件ずつ最新のコミット情報を取得し、すべて取得したら最終コミットの新しい順に並べ直す
+      const repositories = this.repositories;
+      const batches = [];
+      for (let i = 0; i < repositories.length; i += SUMMARY_BATCH_SIZE) {
+        batches.push(repositories.slice(i, i + SUMMARY_BATCH_SIZE));
+      }
+
+      return batches.reduce((previous, batch) => previous.then(() => {
+        if (this.selectedGroup !== group) return;
+        const params = new URLSearchParams({ group: group });
+        batch.forEach(repo => params.append('name', repo.name));
+        return axios.get(GuiltyUtils.url('/api/repositories/summaries?' + params.toString()))
+          .then(response => {
+            const summaries = {};
+            response.data.forEach(summary => { summaries[summary.name] = summary; });
+            batch.forEach(repo => {
+              const summary = summaries[repo.name];
+              repo.lastCommit = summary ? summary.lastCommit : null;
+              repo.license = summary ? summary.license : null;
+              repo.summaryLoading = false;
+            });
+          })
+          .catch(() => {
+            batch.forEach(repo => { repo.summaryLoading = false; });
+          });
+      }), Promise.resolve())
+        .then(() => {
+          if (this.selectedGroup !== group || this.repositories !== repositories) return;
+          this.repositories = repositories.slice().sort((a, b) => {
+            // ピン留めしたリポジトリは先頭に表示
+            if (a.pinned !== b.pinned) return a.pinned ? -1 : 1;
+            // コミット情報がない場合は最後に表示
+            if (!a.lastCommit) return b.lastCommit ? 1 : 0;
+            if (!b.lastCommit) return -1;
+            return new Date(b.lastCommit.date) - new Date(a.lastCommit.date);
+          });
+        });
+    },
     onGroupChange() {
       // URLを更新（ブラウザの履歴に追加）
       const url = new URL(window.location);
@@ -173,6 +254,11 @@ const app = Vue.createApp({
     updatePageTitle() {
       // ページのタイトルとメッセージを更新
       if (this.pageTitle && this.pageMessage) {
+        if (this.selectedGroup === ALL_GROUPS) {
+          this.pageMessage.textContent = 'すべてのグループで最近コミットされたGitリポジトリ';
+          document.title = 'Guilty - すべてのグループのリポジトリ一覧';
+          return;
+        }
         this.pageMessage.textContent = `${this.selectedGroup} グループにあるGitリポジトリ一覧`;
         
         // ブラウザのタイトルも更新
@@ -10,7 +10,13 @@ const createRepoApp = Vue.createApp({
       validationError: null,
       groups: [],
       selectedGroup: 'git',
-      loadingGroups: true
+      loadingGroups: true,
+      templates: { gitignore: [], licenses: [] },
+      defaultBranch: '',
+      readme: false,
+      gitignore: '',
+      license: '',
+      copyrightHolder: ''
     };
   },
   computed: {
@@ -77,6 +83,49 @@ const createRepoApp = Vue.createApp({
                   リポジトリ名は日本語や英数字、各種記号を使用できます。ただし、ファイルシステムで禁止されている文字（/ \ : * ? " < > |）は使用できません。
                 </small>
               </div>
+
+              <div class="form-group mb-3">
+                <label for="defaultBranch">デフォルトブランチ</label>
+                <input 
+                  type="text" 
+                  class="form-control" 
+                  id="defaultBranch" 
+                  v-model="defaultBranch"
+                  placeholder="省略した場合はサーバーの設定（通常は main）"
+                  :disabled="isSubmitting"
+                >
+              </div>
+
+              <div class="form-group mb-3">
+                <label>最初のコミット</label>
+                <div class="form-check">
+                  <input type="checkbox" class="form-check-input" id="readmeCheck" v-model="readme" :disabled="isSubmitting">
+                  <label class="form-check-label" for="readmeCheck">README.md を作成する</label>
+                </div>
+                <div class="row mt-2">
+                  <div class="col-md-6 mb-2">
+                    <label for="gitignoreSelect">.gitignore</label>
+                    <select id="gitignoreSelect" class="form-control" v-model="gitignore" :disabled="isSubmitting">
+                      <option value="">なし</option>
+                      <option v-for="name in templates.gitignore" :key="name" :value="name">{{ name }}</option>
+                    </select>
+                  </div>
+                  <div class="col-md-6 mb-2">
+                    <label for="licenseSelect">ライセンス</label>
+                    <select id="licenseSelect" class="form-control" v-model="license" :disabled="isSubmitting">
+                      <option value="">なし</option>
+                      <option v-for="item in templates.licenses" :key="item.spdxId" :value="item.spdxId">{{ item.name }}</option>
+                    </select>
+                  </div>
+                </div>
+                <div v-if="license" class="mb-2">
+                  <label for="copyrightHolder">著作権者</label>
+                  <input type="text" class="form-control" id="copyrightHolder" v-model="copyrightHolder" :placeholder="selectedGroup" :disabled="isSubmitting">
+                </div>
+                <small class="form-text text-muted">
+                  いずれかを選ぶと、選んだファイルを含むコミットを作成します。何も選ばない場合は空のリポジトリになります。
+                </small>
+              </div>
               <button type="submit" class="btn btn-primary" :disabled="!isNameValid || isSubmitting">
                 <span v-if="isSubmitting">
                   <span class="spinner-border spinner-border-sm" role="status" aria-hidden="true"></span>
@@ -99,12 +148,13 @@ const createRepoApp = Vue.createApp({
     }
     
     this.fetchGroups();
+    this.fetchTemplates();
   },
   methods: {
     fetchGroups() {
       // グループ一覧を取得
       this.loadingGroups = true;
-      axios.get('/api/groups')
+      axios.get(GuiltyUtils.url('/api/groups'))
         .then(response => {
           this.groups = response.data;
           this.loadingGroups = false;
@@ -114,6 +164,16 @@ const createRepoApp = Vue.createApp({
           this.loadingGroups = false;
         });
     },
+    fetchTemplates() {
+      // 作成時に選べるテンプレートを取得（取得できない場合は空のリポジトリだけを作成できる）
+      axios.get(GuiltyUtils.url('/api/repository-templates'))
+        .then(response => {
+          this.templates = response.data;
+        })
+        .catch(error => {
+          console.error('テンプレート一覧の取得に失敗しました:', error);
+        });
+    },
     createRepository() {
       // 入力値の検証
       if (!this.repositoryName.trim()) {
@@ -140,9 +200,16 @@ const createRepoApp = Vue.createApp({
       this.error = null;
       
       // APIリクエストを送信
-      axios.post('/api/repositories', {
+      axios.post(GuiltyUtils.url('/api/repositories'), {
         name: this.repositoryName,
-        group: this.selectedGroup
+        group: this.selectedGroup,
+        defaultBranch: this.defaultBranch.trim(),
+        template: {
+          readme: this.readme,
+          gitignore: this.gitignore,
+          license: this.license,
+          copyrightHolder: this.copyrightHolder
+        }
       })
         .then(response => {
           this.isSubmitting = false;
@@ -4,6 +4,21 @@
 
 // グローバル名前空間汚染を避けるためにオブジェクトにまとめる
 const GuiltyUtils = {
+  /**
+   * guiltyを公開しているパスの接頭辞（/git など、ルートの場合は空文字）
+   * サーバーがテンプレートの base-path メタタグに設定する
+   */
+  basePath: (document.querySelector('meta[name="base-path"]') || { content: '' }).content.replace(/\/+$/, ''),
+
+  /**
+   * ルートからのパスに basePath を付けたURLを生成
+   * @param {string} path - / で始まるパス（/api/groups など）
+   * @returns {string} basePath を付けたURL
+   */
+  url(path) {
+    return `${this.basePath}${path}`;
+  },
+
   /**
    * グループ名とリポジトリ名をエンコードしたパスを生成する内部ヘルパー関数
    * @param {string} groupName - グループ名
@@ -22,7 +37,30 @@ const GuiltyUtils = {
    * @returns {string} リポジトリ詳細ページのURL
    */
   getRepositoryUrl(groupName, repoName) {
-    return `/repository/${this._getEncodedPath(groupName, repoName)}`;
+    return this.url(`/repository/${this._getEncodedPath(groupName, repoName)}`);
+  },
+
+  /**
+   * コミットを固定したリポジトリ詳細ページ（パーマリンク）のURLを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} sha - コミットのSHA
+   * @returns {string} そのコミットの時点の内容を表示するページのURL
+   */
+  getCommitPageUrl(groupName, repoName, sha) {
+    return `${this.getRepositoryUrl(groupName, repoName)}/commit/${encodeURIComponent(sha)}`;
+  },
+
+  /**
+   * Wiki のページのURLを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} pageName - ページ名（空文字の場合はホームページ）
+   * @returns {string} Wiki のページのURL
+   */
+  getWikiPageUrl(groupName, repoName, pageName) {
+    const urlPath = pageName.split('/').map(part => encodeURIComponent(part)).join('/');
+    return `${this.getRepositoryUrl(groupName, repoName)}/wiki/${urlPath}`;
   },
 
   /**
@@ -32,7 +70,50 @@ const GuiltyUtils = {
    * @returns {string} APIで使用するリポジトリパス
    */
   getApiRepositoryPath(groupName, repoName) {
-    return `/api/repository/${this._getEncodedPath(groupName, repoName)}`;
+    return this.url(`/api/repository/${this._getEncodedPath(groupName, repoName)}`);
+  },
+
+  /**
+   * グループ名、リポジトリ名からバンドルのエクスポート用パスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @returns {string} バンドルをダウンロードするAPIのパス
+   */
+  getApiExportPath(groupName, repoName) {
+    return this.url(`/api/export/${this._getEncodedPath(groupName, repoName)}`);
+  },
+
+  /**
+   * Wiki APIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} pageName - ページ名（空文字の場合はページ一覧）
+   * @returns {string} Wiki APIのパス
+   */
+  getApiWikiPath(groupName, repoName, pageName) {
+    const path = `${this.getApiRepositoryPath(groupName, repoName)}/wiki`;
+    if (!pageName) return path;
+    return `${path}/${pageName.split('/').map(part => encodeURIComponent(part)).join('/')}`;
+  },
+
+  /**
+   * グループ名、リポジトリ名からイシューAPIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @returns {string} イシューAPIのパス
+   */
+  getApiIssuesPath(groupName, repoName) {
+    return this.url(`/api/issues/${this._getEncodedPath(groupName, repoName)}`);
+  },
+
+  /**
+   * グループ名、リポジトリ名からコミットグラフAPIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @returns {string} コミットグラフAPIのパス
+   */
+  getApiGraphPath(groupName, repoName) {
+    return this.url(`/api/graph/${this._getEncodedPath(groupName, repoName)}`);
   },
 
   /**
@@ -43,7 +124,7 @@ const GuiltyUtils = {
    * @returns {string} APIで使用するファイルパス
    */
   getApiFilePath(groupName, repoName, filePath) {
-    const basePath = `/api/file/${this._getEncodedPath(groupName, repoName)}`;
+    const basePath = this.url(`/api/file/${this._getEncodedPath(groupName, repoName)}`);
     if (!filePath) return basePath;
     
     // パスの各部分を保持したままURLを構築
@@ -52,6 +133,42 @@ const GuiltyUtils = {
     return `${basePath}/${urlPath}`;
   },
 
+  /**
+   * グループ名、リポジトリ名、ファイルパスからファイル変更履歴APIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} filePath - ファイルパス
+   * @returns {string} ファイル変更履歴APIのパス
+   */
+  getApiHistoryPath(groupName, repoName, filePath) {
+    const urlPath = filePath.split('/').map(part => encodeURIComponent(part)).join('/');
+    return this.url(`/api/history/${this._getEncodedPath(groupName, repoName)}/${urlPath}`);
+  },
+
+  /**
+   * グループ名、リポジトリ名、ファイルパスから2つのコミットの間のファイルの差分APIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} filePath - ファイルパス
+   * @returns {string} ファイルの差分APIのパス
+   */
+  getApiDiffPath(groupName, repoName, filePath) {
+    const urlPath = filePath.split('/').map(part => encodeURIComponent(part)).join('/');
+    return this.url(`/api/diff/${this._getEncodedPath(groupName, repoName)}/${urlPath}`);
+  },
+
+  /**
+   * グループ名、リポジトリ名、ファイルパスからファイル作成・更新APIのパスを生成
+   * @param {string} groupName - グループ名
+   * @param {string} repoName - リポジトリ名
+   * @param {string} filePath - ファイルパス
+   * @returns {string} ファイル作成・更新APIのパス
+   */
+  getApiContentsPath(groupName, repoName, filePath) {
+    const urlPath = filePath.split('/').map(part => encodeURIComponent(part)).join('/');
+    return `${this.getApiRepositoryPath(groupName, repoName)}/contents/${urlPath}`;
+  },
+
   /**
    * グループ名、リポジトリ名、ディレクトリパスからAPI用のディレクトリパスを生成
    * @param {string} groupName - グループ名
@@ -60,7 +177,7 @@ const GuiltyUtils = {
    * @returns {string} APIで使用するディレクトリパス
    */
   getApiDirectoryPath(groupName, repoName, dirPath) {
-    const basePath = `/api/directory/${this._getEncodedPath(groupName, repoName)}`;
+    const basePath = this.url(`/api/directory/${this._getEncodedPath(groupName, repoName)}`);
     if (!dirPath) return basePath;
     
     // パスの各部分を保持したままURLを構築
@@ -75,7 +192,7 @@ const GuiltyUtils = {
    * @returns {string} リポジトリ一覧APIのURL
    */
   getRepositoriesApiUrl(groupName) {
-    return `/api/repositories?group=${encodeURIComponent(groupName)}`;
+    return this.url(`/api/repositories?group=${encodeURIComponent(groupName)}`);
   },
 
   /**
@@ -84,7 +201,7 @@ const GuiltyUtils = {
    * @returns {string} リポジトリ一覧ページのURL
    */
   getRepositoriesPageUrl(groupName) {
-    return `/?group=${encodeURIComponent(groupName)}`;
+    return this.url(`/?group=${encodeURIComponent(groupName)}`);
   },
 
   /**
@@ -93,9 +210,14 @@ const GuiltyUtils = {
    * @returns {string} 新規リポジトリ作成ページのURL
    */
   getCreateRepositoryUrl(groupName) {
-    return `/create-repository?group=${encodeURIComponent(groupName)}`;
+    return this.url(`/create-repository?group=${encodeURIComponent(groupName)}`);
   }
 };
 
 // グローバルスコープに公開（他のスクリプトから利用可能に）
-window.GuiltyUtils = GuiltyUtils;
\ No newline at end of file
+window.GuiltyUtils = GuiltyUtils;
+
+// 画面は日本語のため、APIのエラーメッセージもブラウザの言語ではなく画面の言語で受け取る
+if (window.axios) {
+  axios.defaults.headers.common['Accept-Language'] = document.documentElement.lang || 'ja';
+}
\ No newline at end of file
@@ -1,3 +1,14 @@
+// ディレクトリの内容を1回に取得する件数（大きなディレクトリでブラウザが止まらないようにする）
+const DIRECTORY_PAGE_SIZE = 500;
+
+// コミットグラフで表示するコミット数と、1行の高さ・1列の幅（ピクセル）
+const GRAPH_COMMITS = 100;
+const GRAPH_ROW_HEIGHT = 28;
+const GRAPH_COLUMN_WIDTH = 14;
+
+// コミットグラフの列ごとの線の色
+const GRAPH_COLORS = ['#007bff', '#28a745', '#dc3545', '#fd7e14', '#6f42c1', '#20c997', '#e83e8c', '#6c757d'];
+
 // コンポーネントの定義
 const FileRow = {
   props: ['file', 'repoName'],
@@ -6,6 +17,9 @@ const FileRow = {
       <td>
         <span v-if="file.type === 'dir'" class="text-primary" @click="openDirectory" style="cursor: pointer;">📁 {{ file.name }}/</span>
         <span v-if="file.type === 'file'" @click="openFile" style="cursor: pointer;">📄 {{ file.name }}</span>
+        <span v-if="file.type === 'file' && file.mode === '100755'" class="badge badge-secondary ml-1" title="実行可能ファイル">exec</span>
+        <span v-if="file.type === 'symlink'" :title="'リンク先: ' + file.target">🔗 {{ file.name }} → {{ file.target }}</span>
+        <span v-if="file.type === 'submodule'" :title="file.url">📦 {{ file.name }} @ <code>{{ (file.commit || '').substring(0, 7) }}</code></span>
       </td>
       <td>{{ formatFileType(file.type) }}</td>
       <td v-if="file.type === 'file'">{{ formatFileSize(file.size) }}</td>
@@ -15,7 +29,10 @@ const FileRow = {
   `,
   methods: {
     formatFileType(type) {
-      return type === 'dir' ? 'ディレクトリ' : 'ファイル';
+      if (type === 'dir') return 'ディレクトリ';
+      if (type === 'submodule') return 'サブモジュール';
+      if (type === 'symlink') return 'シンボリックリンク';
+      return 'ファイル';
     },
     formatFileSize(size) {
       if (size < 1024) return size + ' B';
@@ -51,7 +68,10 @@ const repositoryApp = Vue.createApp({
   data() {
     return {
       repository: null,
+      starError: null, // スター・ピン留めの変更に失敗した場合のメッセージ
       files: [],
+      filesTotal: 0,
+      filesLoadingMore: false,
       loading: true,
       error: null,
       searchQuery: '',
@@ -63,26 +83,131 @@ const repositoryApp = Vue.createApp({
       fileLoading: false,
       fileError: null,
       isBinaryFile: false,
+      lfsInfo: null, // Git LFS のポインタ情報
+      fileMessage: '',
+      previewType: '', // インライン表示の種別（'image' または 'pdf'）
+      previewUrl: '',
+      fileTruncated: false, // サイズ上限により先頭部分のみ取得したかどうか
+      fileEditing: false, // ファイルを編集中かどうか
+      editContent: '', // 編集中のファイルの内容
+      editMessage: '', // 編集のコミットメッセージ
+      editSaving: false,
+      editError: null,
+      fileSize: 0,
+      fileTab: 'content', // ファイルモーダルのタブ（'content' または 'history'）
+      fileHistory: [], // ファイルの変更履歴
+      fileHistoryLoading: false,
+      fileHistoryError: null,
+      fileDiffFrom: '', // 差分の比較元（ブランチまたはタグ）
+      fileDiff: null, // 比較元から表示中の内容までのファイルの差分（FileDiff）
+      fileDiffLoading: false,
+      fileDiffError: null,
       showFileModal: false,
       modalJustOpened: false,
       showDeleteModal: false, // 削除確認モーダル表示フラグ
       deleteInProgress: false, // 削除処理中フラグ
       deleteError: null, // 削除エラーメッセージ
       hostName: document.querySelector('meta[name="git-host"]')?.content || 'localhost',
+      cloneProtocol: localStorage.getItem('guilty.cloneProtocol') || 'ssh', // クローンURLのプロトコル（ssh、https、git）
       showDropdown: false, // ハンバーガーメニューの表示状態
       branches: [], // ブランチ一覧
       tags: [], // タグ一覧
       currentHead: '', // 現在のHEADブランチ
+      unbornBranch: '', // コミットがまだないデフォルトブランチ（最初のプッシュで作成される）
       showHeadModal: false, // HEADブランチ変更モーダル表示フラグ
       selectedBranch: '', // 選択されたブランチ
       headChangeInProgress: false, // HEADブランチ変更処理中フラグ
-      headChangeError: null // HEADブランチ変更エラーメッセージ
+      headChangeError: null, // HEADブランチ変更エラーメッセージ
+      showSubscribersDialog: false, // 通知メールの設定モーダル表示フラグ
+      subscribersText: '', // 通知メールの宛先（1行に1件）
+      smtpConfigured: true, // サーバーにメールの送信設定があるかどうか
+      subscribersSaving: false,
+      subscribersError: null,
+      commits: [], // 最近のコミット
+      commitsError: null, // コミット履歴の取得エラーメッセージ
+      commitView: 'list', // 'list'（最近のコミット）または 'graph'（ブランチとマージのグラフ）
+      graph: null, // コミットグラフ（CommitGraph）
+      graphLoading: false,
+      graphError: null,
+      mergeRequests: [], // オープンなマージリクエスト
+      mainTab: 'code', // 'code' または 'issues'
+      issues: [],
+      issuesState: 'open', // 表示しているイシューの状態
+      issuesLoaded: false,
+      issuesLoading: false,
+      issuesError: null,
+      selectedIssueId: null, // 本文を表示しているイシューの番号
+      showIssueForm: false,
+      newIssue: { title: '', body: '', labels: '' },
+      issueSaving: false,
+      issueFormError: null,
+      wiki: null, // Wiki の状態とページ一覧
+      wikiPage: null, // 表示しているページ（まだない場合は null）
+      wikiLoading: false,
+      wikiError: null,
+      wikiEditing: false,
+      wikiEditContent: '',
+      wikiEditMessage: '',
+      wikiSaving: false,
+      wikiSaveError: null,
+      newWikiPageName: ''
     };
   },
   computed: {
+    graphRows() {
+      // コミットグラフの各行の点の位置と色
+      if (!this.graph) return [];
+      return this.graph.commits.map((commit, index) => ({
+        commit: commit,
+        x: this.graphX(commit.column),
+        y: this.graphY(index),
+        color: GRAPH_COLORS[commit.column % GRAPH_COLORS.length]
+      }));
+    },
+    graphLines() {
+      // 子から親への線（最初の親へは同じ列を下りて親の行で曲がり、他の親へは次の行で曲がってから下りる）
+      if (!this.graph) return [];
+      const rows = {};
+      this.graph.commits.forEach((commit, index) => { rows[commit.sha] = index; });
+      const bottom = this.graph.commits.length * GRAPH_ROW_HEIGHT;
+      const lines = [];
+      this.graph.commits.forEach((commit, index) => {
+        const x = this.graphX(commit.column);
+        const y = this.graphY(index);
+        commit.parents.forEach((sha, parentIndex) => {
+          const parentRow = rows[sha];
+          if (parentRow === undefined) {
+            // 親が表示範囲より古い場合は下端まで伸ばす
+            lines.push({ key: commit.sha + sha, d: `M${x},${y} L${x},${bottom}`, color: GRAPH_COLORS[commit.column % GRAPH_COLORS.length] });
+            return;
+          }
+          const parent = this.graph.commits[parentRow];
+          const px = this.graphX(parent.column);
+          const py = this.graphY(parentRow);
+          const d = parentIndex === 0
+            ? `M${x},${y} L${x},${py - GRAPH_ROW_HEIGHT} L${px},${py}`
+            : `M${x},${y} L${px},${y + GRAPH_ROW_HEIGHT} L${px},${py}`;
+          const column = parentIndex === 0 ? commit.column : parent.column;
+          lines.push({ key: commit.sha + sha, d: d, color: GRAPH_COLORS[column % GRAPH_COLORS.length] });
+        });
+      });
+      return lines;
+    },
+    graphWidth() {
+      return this.graph ? Math.max(this.graph.columns, 1) * GRAPH_COLUMN_WIDTH : 0;
+    },
+    graphHeight() {
+      return this.graph ? this.graph.commits.length * GRAPH_ROW_HEIGHT : 0;
+    },
+    cloneUrl() {
+      return this.findCloneUrl(this.repository);
+    },
+    wikiCloneUrl() {
+      return this.findCloneUrl(this.wiki);
+    },
     repoPath() {
       const path = window.location.pathname;
-      return path.substring('/repository/'.length);
+      return path.substring(GuiltyUtils.url('/repository/').length);
     },
     groupName() {
       const parts = this.repoPath.split('/');
@@ -98,6 +223,28 @@ const repositoryApp = Vue.createApp({
       }
       return parts[0]; // グループが指定されていない場合
     },
+    pinnedCommit() {
+      // /repository/{group}/{repo}/commit/{sha} の場合はそのコミットの内容を表示する
+      const parts = this.repoPath.split('/');
+      if (parts.length >= 4 && parts[2] === 'commit' && parts[3]) {
+        return decodeURIComponent(parts[3]);
+      }
+      return '';
+    },
+    isWikiRoute() {
+      // /repository/{group}/{repo}/wiki/{page} の場合は Wiki を表示する
+      return this.repoPath.split('/')[2] === 'wiki';
+    },
+    wikiPageName() {
+      const parts = this.repoPath.split('/').slice(3).filter(part => part !== '');
+      return parts.length > 0 ? parts.map(part => decodeURIComponent(part)).join('/') : 'Home';
+    },
+    canEditFile() {
+      // 最新の内容を表示している、全体を取得できたテキストファイルだけ編集できる
+      return this.fileTab === 'content' && !this.pinnedCommit && !this.fileLoading && !this.fileError &&
+        !this.isBinaryFile && !this.lfsInfo && !this.previewType && !this.fileTruncated &&
+        this.selectedFile && this.selectedFile.sha && this.selectedFile.mode !== '120000';
+    },
     currentViewPath() {
       return this.currentPath ? this.currentPath : 'ルートディレクトリ';
     },
@@ -126,6 +273,7 @@ const repositoryApp = Vue.createApp({
             &#9776;
           </button>
           <div v-if="showDropdown" class="dropdown-menu dropdown-menu-end show position-absolute" style="right: 0; top: 100%;">
+            <a class="dropdown-item" href="#" @click.prevent="showSubscribersModal">通知メールの設定</a>
             <a class="dropdown-item text-danger" href="#" @click.prevent="confirmDeleteAndCloseDropdown">リポジトリの削除</a>
           </div>
         </div>
@@ -151,6 +299,22 @@ const repositoryApp = Vue.createApp({
             <dl class="row">
               <dt class="col-sm-2 text-left">名前</dt>
               <dd class="col-sm-10 text-left">{{ repository.name }}</dd>
+
+              <dt class="col-sm-2 text-left">スター</dt>
+              <dd class="col-sm-10 text-left">
+                <span class="mr-2">★ {{ repository.stars || 0 }}</span>
+                <button class="btn btn-sm ml-2"
+                        :class="repository.starred ? 'btn-warning' : 'btn-outline-warning'"
+                        @click="toggleStar">
+                  {{ repository.starred ? 'スターを外す' : 'スターを付ける' }}
+                </button>
+                <button class="btn btn-sm ml-2"
+                        :class="repository.pinned ? 'btn-secondary' : 'btn-outline-secondary'"
+                        @click="togglePin">
+                  {{ repository.pinned ? 'ピン留めを外す' : 'グループにピン留め' }}
+                </button>
+                <small v-if="starError" class="text-danger ml-2">{{ starError }}</small>
+              </dd>
               
               <dt class="col-sm-2 text-left">HEADブランチ</dt>
               <dd class="col-sm-10 text-left">
@@ -167,26 +331,146 @@ const repositoryApp = Vue.createApp({
               
               <dt class="col-sm-2 text-left">最終コミット</dt>
               <dd v-if="repository.lastCommit" class="col-sm-10 text-left">
-                {{ formatDate(repository.lastCommit.date) }} by {{ repository.lastCommit.author }}
+                {{ formatDate(repository.lastCommit.date) }} by
+                <img v-if="repository.lastCommit.avatarUrl" :src="repository.lastCommit.avatarUrl" class="avatar" alt="">{{ repository.lastCommit.author }}
               </dd>
               <dd v-else class="col-sm-10 text-left">コミット情報なし</dd>
               
               <dt class="col-sm-2 text-left">クローンURL</dt>
               <dd class="col-sm-10">
                 <div class="input-group">
-                  <input type="text" class="form-control" readonly :value="repository.cloneUrl || ''" id="cloneUrlInput">
+                  <div v-if="repository.cloneUrls && repository.cloneUrls.length > 1" class="input-group-prepend">
+                    <button v-for="clone in repository.cloneUrls" :key="clone.protocol" type="button"
+                            class="btn" :class="clone.protocol === cloneProtocol ? 'btn-secondary' : 'btn-outline-secondary'"
+                            @click="selectCloneProtocol(clone.protocol)">{{ clone.protocol.toUpperCase() }}</button>
+                  </div>
+                  <input type="text" class="form-control" readonly :value="cloneUrl" id="cloneUrlInput">
                   <div class="input-group-append">
                     <button class="btn btn-outline-secondary" type="button" @click="copyCloneUrl" title="URLをコピー">
                       <span>コピー</span>
                     </button>
                   </div>
                 </div>
-                <small class="text-muted mt-1 d-block">{{ repository.cloneUrl ? '' : 'クローンURLが取得できませんでした' }}</small>
+                <small class="text-muted mt-1 d-block">{{ cloneUrl ? '' : 'クローンURLが取得できませんでした' }}</small>
+                <a v-if="repository.lastCommit" :href="getExportUrl()" class="small d-inline-block mt-1" download>バンドルをダウンロード（git bundle）</a>
               </dd>
             </dl>
           </div>
         </div>
         
+        <ul class="nav nav-tabs mb-3">
+          <li class="nav-item">
+            <a class="nav-link" :class="{ active: mainTab === 'code' }" href="#" @click.prevent="mainTab = 'code'">コード</a>
+          </li>
+          <li class="nav-item">
+            <a class="nav-link" :class="{ active: mainTab === 'issues' }" href="#" @click.prevent="showIssues">イシュー</a>
+          </li>
+          <li class="nav-item">
+            <a class="nav-link" :class="{ active: mainTab === 'wiki' }" :href="getWikiPageUrl('')">Wiki</a>
+          </li>
+        </ul>
+
+        <!-- イシュー -->
+        <div v-if="mainTab === 'issues'" class="card">
+          <div class="card-header bg-light d-flex justify-content-between align-items-center">
+            <div class="btn-group btn-group-sm">
+              <button type="button" class="btn" :class="issuesState === 'open' ? 'btn-secondary' : 'btn-outline-secondary'" @click="fetchIssues('open')">オープン</button>
+              <button type="button" class="btn" :class="issuesState === 'closed' ? 'btn-secondary' : 'btn-outline-secondary'" @click="fetchIssues('closed')">クローズ</button>
+            </div>
+            <button type="button" class="btn btn-sm btn-primary" @click="openIssueForm">新しいイシュー</button>
+          </div>
+          <div class="card-body">
+            <form v-if="showIssueForm" class="mb-4 text-left" @submit.prevent="createIssue">
+              <input type="text" class="form-control mb-2" v-model="newIssue.title" placeholder="タイトル">
+              <textarea class="form-control mb-2" rows="6" v-model="newIssue.body" placeholder="本文（Markdown）"></textarea>
+              <input type="text" class="form-control mb-2" v-model="newIssue.labels" placeholder="ラベル（カンマ区切り）">
+              <div v-if="issueFormError" class="alert alert-danger">{{ issueFormError }}</div>
+              <button type="button" class="btn btn-secondary mr-2" @click="showIssueForm = false">キャンセル</button>
+              <button type="submit" class="btn btn-primary" :disabled="issueSaving || !newIssue.title.trim()">作成</button>
+            </form>
+            <div v-if="issuesLoading" class="text-center p-3">
+              <div class="spinner-border text-primary" role="status">
+                <span class="sr-only">イシュー読み込み中...</span>
+              </div>
+            </div>
+            <div v-else-if="issuesError" class="alert alert-danger mb-0">{{ issuesError }}</div>
+            <p v-else-if="issues.length === 0" class="text-muted mb-0">イシューはありません</p>
+            <div v-else class="list-group text-left">
+              <div v-for="issue in issues" :key="issue.id" class="list-group-item">
+                <div class="d-flex justify-content-between align-items-center">
+                  <a href="#" @click.prevent="toggleIssue(issue)"><strong>#{{ issue.id }} {{ issue.title }}</strong></a>
+                  <span>
+                    <span v-for="label in issue.labels" :key="label" class="badge badge-info ml-1">{{ label }}</span>
+                  </span>
+                </div>
+                <small class="text-muted">{{ issue.author || '匿名' }} が {{ formatDate(issue.createdAt) }} に作成</small>
+                <div v-if="selectedIssueId === issue.id" class="mt-3">
+                  <div class="markdown-body mb-2" v-html="issue.bodyHtml"></div>
+                  <button type="button" class="btn btn-sm btn-outline-secondary" @click="setIssueState(issue, issue.state === 'open' ? 'closed' : 'open')">
+                    {{ issue.state === 'open' ? 'クローズ' : '再オープン' }}
+                  </button>
+                </div>
+              </div>
+            </div>
+          </div>
+        </div>
+
+        <div v-else-if="mainTab === 'wiki'" class="row">
+          <!-- Wiki -->
+          <div class="col-md-9 mb-3">
+            <div class="card">
+              <div class="card-header bg-light d-flex justify-content-between align-items-center">
+                <h5 class="mb-0">{{ wikiPageName }}</h5>
+                <template v-if="wiki && !wikiLoading && !wikiEditing">
+                  <button v-if="wikiPage" type="button" class="btn btn-sm btn-outline-primary" @click="startEditWikiPage">編集</button>
+                  <button v-else type="button" class="btn btn-sm btn-primary" @click="startEditWikiPage">ページを作成</button>
+                </template>
+              </div>
+              <div class="card-body text-left">
+                <div v-if="wikiLoading" class="text-center p-3">
+                  <div class="spinner-border text-primary" role="status">
+                    <span class="sr-only">Wiki読み込み中...</span>
+                  </div>
+                </div>
+                <div v-else-if="wikiError" class="alert alert-danger mb-0">{{ wikiError }}</div>
+                <form v-else-if="wikiEditing" @submit.prevent="saveWikiPage">
+                  <textarea class="form-control text-monospace mb-2" rows="16" v-model="wikiEditContent" placeholder="本文（Markdown）"></textarea>
+                  <input type="text" class="form-control mb-2" v-model="wikiEditMessage" placeholder="コミットメッセージ">
+                  <div v-if="wikiSaveError" class="alert alert-danger">{{ wikiSaveError }}</div>
+                  <button type="button" class="btn btn-secondary mr-2" @click="wikiEditing = false">キャンセル</button>
+                  <button type="submit" class="btn btn-primary" :disabled="wikiSaving || !wikiEditMessage.trim()">
+                    <span v-if="wikiSaving" class="spinner-border spinner-border-sm mr-2" role="status"></span>
+                    保存
+                  </button>
+                </form>
+                <template v-else-if="wikiPage">
+                  <div class="markdown-body" v-html="wikiPage.html"></div>
+                  <small class="text-muted">最終更新: {{ formatDate(wikiPage.lastModified) }}</small>
+                </template>
+                <p v-else-if="wiki && wiki.exists" class="text-muted mb-0">ページ「{{ wikiPageName }}」はまだありません</p>
+                <p v-else class="text-muted mb-0">Wikiはまだありません。最初のページを作成するとWikiリポジトリが作成されます。</p>
+              </div>
+            </div>
+          </div>
+          <div class="col-md-3">
+            <div class="card">
+              <div class="card-header bg-light">ページ</div>
+              <div v-if="wiki && wiki.pages.length > 0" class="list-group list-group-flush text-left">
+                <a v-for="page in wiki.pages" :key="page.path" :href="getWikiPageUrl(page.name)"
+                   class="list-group-item list-group-item-action" :class="{ active: page.name === wikiPageName }">{{ page.name }}</a>
+              </div>
+              <div class="card-body">
+                <form @submit.prevent="openNewWikiPage">
+                  <input type="text" class="form-control form-control-sm mb-2" v-model="newWikiPageName" placeholder="新しいページ名">
+                  <button type="submit" class="btn btn-sm btn-outline-primary btn-block" :disabled="!newWikiPageName.trim()">作成</button>
+                </form>
+                <small v-if="wiki && wiki.exists" class="text-muted d-block mt-2 text-left">クローン: <code>{{ wikiCloneUrl }}</code></small>
+              </div>
+            </div>
+          </div>
+        </div>
+
+        <template v-else>
         <!-- ファイル一覧 -->
         <div class="card">
           <div class="card-header bg-light">
@@ -203,6 +487,11 @@ const repositoryApp = Vue.createApp({
             </div>
           </div>
           <div class="card-body">
+            <!-- コミットを固定して表示している場合 -->
+            <div v-if="pinnedCommit" class="alert alert-info">
+              コミット <code>{{ pinnedCommit.substring(0, 12) }}</code> の時点の内容を表示しています。
+              <a :href="getRepositoryUrl(groupName, repoName)">最新の内容を表示</a>
+            </div>
             <!-- パンくずリスト -->
             <div v-if="directoryStack.length > 0" class="mb-3">
               <nav aria-label="breadcrumb">
@@ -230,12 +519,13 @@ const repositoryApp = Vue.createApp({
                 <i class="fa fa-info-circle mr-1"></i> このリポジトリにはまだコミットがありません。<br>
                 最初のコミットをプッシュするには、以下のコマンドを実行してください：
                 <pre class="mt-2 mb-0 bg-light p-2 rounded text-left">
-git clone {{ repository.cloneUrl }}
+git clone {{ cloneUrl }}
 cd {{ repository.name }}
 touch README.md
 git add README.md
 git commit -m "Initial commit"
-git push origin master</pre>
+git branch -M {{ unbornBranch || 'main' }}
+git push -u origin {{ unbornBranch || 'main' }}</pre>
               </div>
             </div>
             
@@ -260,9 +550,89 @@ git push origin master</pre>
                   ></file-row>
                 </tbody>
               </table>
+              <div v-if="files.length < filesTotal" class="text-center mb-3">
+                <button class="btn btn-outline-secondary btn-sm" :disabled="filesLoadingMore" @click="loadMoreFiles">
+                  さらに表示（{{ files.length }} / {{ filesTotal }} 件）
+                </button>
+              </div>
+            </div>
+          </div>
+        </div>
+        
+        <!-- 最近のコミット -->
+        <div v-if="commits.length > 0 || commitsError" class="card mt-4">
+          <div class="card-header bg-light d-flex justify-content-between align-items-center">
+            <h3 class="mb-0">{{ commitView === 'graph' ? 'コミットグラフ' : '最近のコミット' }}</h3>
+            <button v-if="!commitsError" class="btn btn-sm btn-outline-secondary" @click="toggleCommitView">
+              {{ commitView === 'graph' ? '一覧' : 'グラフ' }}
+            </button>
+          </div>
+          <div class="card-body">
+            <div v-if="commitsError" class="alert alert-warning mb-0">{{ commitsError }}</div>
+            <div v-else-if="commitView === 'graph'">
+              <div v-if="graphLoading" class="text-muted">読み込み中...</div>
+              <div v-else-if="graphError" class="alert alert-warning mb-0">{{ graphError }}</div>
+              <div v-else-if="graph" class="d-flex text-left" style="overflow-x: auto;">
+                <svg :width="graphWidth" :height="graphHeight" class="flex-shrink-0">
+                  <path v-for="line in graphLines" :key="line.key" :d="line.d" :stroke="line.color" stroke-width="2" fill="none"></path>
+                  <circle v-for="row in graphRows" :key="row.commit.sha" :cx="row.x" :cy="row.y" r="4" :fill="row.color"></circle>
+                </svg>
+                <div class="flex-grow-1 ml-2" style="min-width: 0;">
+                  <div v-for="row in graphRows" :key="row.commit.sha" class="text-nowrap" :style="{ height: '${GRAPH_ROW_HEIGHT}px', lineHeight: '${GRAPH_ROW_HEIGHT}px', overflow: 'hidden' }">
+                    <a :href="getCommitPageUrl(row.commit.sha)"><code>{{ row.commit.sha.substring(0, 7) }}</code></a>
+                    <span v-for="ref in row.commit.refs" :key="ref.type + ref.name"
+                          class="badge ml-1"
+                          :class="ref.type === 'tag' ? 'badge-warning' : (ref.head ? 'badge-primary' : 'badge-info')">{{ ref.name }}</span>
+                    <span class="ml-1">{{ row.commit.subject }}</span>
+                    <small class="text-muted ml-2">{{ row.commit.author }}・{{ formatDate(row.commit.date) }}</small>
+                  </div>
+                  <div v-if="graph.hasMore" class="text-muted small">（新しい ${GRAPH_COMMITS} 件を表示しています）</div>
+                </div>
+              </div>
+            </div>
+            <div v-else class="table-responsive">
+              <table class="table table-sm">
+                <tbody>
+                  <tr v-for="commit in commits" :key="commit.sha">
+                    <td><a :href="getCommitPageUrl(commit.sha)"><code>{{ commit.sha.substring(0, 7) }}</code></a></td>
+                    <td class="text-left">
+                      {{ commit.subject }}
+                      <span v-if="commit.signature"
+                            class="badge ml-1"
+                            :class="signatureBadgeClass(commit.signature)"
+                            :title="formatSignatureTitle(commit.signature)">{{ formatSignatureStatus(commit.signature) }}</span>
+                    </td>
+                    <td class="text-nowrap"><img v-if="commit.avatarUrl" :src="commit.avatarUrl" class="avatar" alt="">{{ commit.author }}</td>
+                    <td class="datetime-cell">{{ formatDate(commit.date) }}</td>
+                  </tr>
+                </tbody>
+              </table>
             </div>
           </div>
         </div>
+
+        <!-- オープンなマージリクエスト -->
+        <div v-if="mergeRequests.length > 0" class="card mt-4">
+          <div class="card-header bg-light">
+            <h3 class="mb-0">マージリクエスト</h3>
+          </div>
+          <div class="card-body">
+            <div class="table-responsive">
+              <table class="table table-sm">
+                <tbody>
+                  <tr v-for="request in mergeRequests" :key="request.id">
+                    <td>!{{ request.id }}</td>
+                    <td class="text-left">{{ request.title }}</td>
+                    <td><code>{{ request.source }}</code> → <code>{{ request.target }}</code></td>
+                    <td>{{ request.author || '-' }}</td>
+                    <td class="datetime-cell">{{ formatDate(request.createdAt) }}</td>
+                  </tr>
+                </tbody>
+              </table>
+            </div>
+          </div>
+        </div>
+        </template>
       </div>
       
       <!-- ファイル内容を表示するモーダル -->
@@ -278,6 +648,85 @@ git push origin master</pre>
                 </button>
               </div>
               <div class="modal-body">
+                <ul class="nav nav-tabs mb-3">
+                  <li class="nav-item">
+                    <a class="nav-link" :class="{ active: fileTab === 'content' }" href="#" @click.prevent="fileTab = 'content'">内容</a>
+                  </li>
+                  <li class="nav-item">
+                    <a class="nav-link" :class="{ active: fileTab === 'history' }" href="#" @click.prevent="showFileHistory">履歴</a>
+                  </li>
+                  <li class="nav-item">
+                    <a class="nav-link" :class="{ active: fileTab === 'diff' }" href="#" @click.prevent="fileTab = 'diff'">差分</a>
+                  </li>
+                </ul>
+                <div v-if="fileTab === 'diff'">
+                  <div class="form-inline mb-3">
+                    <label class="mr-2">比較元</label>
+                    <select class="form-control form-control-sm" v-model="fileDiffFrom" @change="fetchFileDiff">
+                      <option value="" disabled>ブランチまたはタグを選択</option>
+                      <optgroup v-if="tags.length > 0" label="タグ">
+                        <option v-for="tag in tags" :key="'tag:' + tag" :value="tag">{{ tag }}</option>
+                      </optgroup>
+                      <optgroup label="ブランチ">
+                        <option v-for="branch in branches" :key="'branch:' + branch.name" :value="branch.name">{{ branch.name }}</option>
+                      </optgroup>
+                    </select>
+                    <span class="ml-2 text-muted">からの変更</span>
+                  </div>
+                  <div v-if="fileDiffLoading" class="text-center p-3">
+                    <div class="spinner-border text-primary" role="status">
+                      <span class="sr-only">差分読み込み中...</span>
+                    </div>
+                  </div>
+                  <div v-else-if="fileDiffError" class="alert alert-danger">
+                    {{ fileDiffError }}
+                  </div>
+                  <template v-else-if="fileDiff">
+                    <div v-if="fileDiff.oldPath" class="text-muted mb-2">{{ fileDiff.oldPath }} → {{ fileDiff.path }}</div>
+                    <div v-if="fileDiff.status === 'unchanged'" class="alert alert-info">{{ fileDiffFrom }} から変更されていません。</div>
+                    <div v-else-if="fileDiff.binary" class="alert alert-warning">バイナリファイルのため差分は表示できません。</div>
+                    <template v-else>
+                      <div v-if="fileDiff.truncated" class="alert alert-warning">差分が大きいため先頭部分のみ表示しています。</div>
+                      <table class="table table-sm text-monospace text-left mb-0" style="font-size: 0.85em;">
+                        <tbody v-for="(hunk, index) in fileDiff.hunks" :key="index">
+                          <tr class="table-info">
+                            <td colspan="3">@@ -{{ hunk.oldStart }},{{ hunk.oldLines }} +{{ hunk.newStart }},{{ hunk.newLines }} @@ {{ hunk.header }}</td>
+                          </tr>
+                          <tr v-for="(line, lineIndex) in hunk.lines" :key="lineIndex"
+                              :class="{ 'table-success': line.type === 'add', 'table-danger': line.type === 'delete' }">
+                            <td class="text-muted text-right" style="width: 1%;">{{ line.oldLine || '' }}</td>
+                            <td class="text-muted text-right" style="width: 1%;">{{ line.newLine || '' }}</td>
+                            <td style="white-space: pre-wrap;">{{ line.type === 'add' ? '+' : (line.type === 'delete' ? '-' : ' ') }}{{ line.content }}<small v-if="line.noNewline" class="text-muted ml-2">（末尾に改行なし）</small></td>
+                          </tr>
+                        </tbody>
+                      </table>
+                    </template>
+                  </template>
+                </div>
+                <div v-else-if="fileTab === 'history'">
+                  <div v-if="fileHistoryLoading" class="text-center p-3">
+                    <div class="spinner-border text-primary" role="status">
+                      <span class="sr-only">履歴読み込み中...</span>
+                    </div>
+                  </div>
+                  <div v-else-if="fileHistoryError" class="alert alert-danger">
+                    {{ fileHistoryError }}
+                  </div>
+                  <table v-else class="table table-sm">
+                    <tbody>
+                      <tr v-for="entry in fileHistory" :key="entry.sha">
+                        <td><a :href="getCommitPageUrl(entry.sha)"><code>{{ entry.sha.substring(0, 7) }}</code></a></td>
+                        <td class="text-left">
+                          {{ entry.subject }}
+                          <small v-if="entry.oldPath" class="text-muted d-block">{{ entry.oldPath }} → {{ entry.path }}</small>
+                        </td>
+                        <td class="text-nowrap"><img v-if="entry.avatarUrl" :src="entry.avatarUrl" class="avatar" alt="">{{ entry.author }}</td>
+                        <td class="datetime-cell">{{ formatDate(entry.date) }}</td>
+                      </tr>
+                    </tbody>
+                  </table>
+                </div>
+                <template v-else>
                 <div v-if="fileLoading" class="text-center p-3">
                   <div class="spinner-border text-primary" role="status">
                     <span class="sr-only">ファイル読み込み中...</span>
@@ -286,13 +735,41 @@ git push origin master</pre>
                 <div v-else-if="fileError" class="alert alert-danger">
                   {{ fileError }}
                 </div>
+                <div v-else-if="previewType === 'image'" class="text-center">
+                  <img :src="previewUrl" :alt="selectedFile && selectedFile.name" style="max-width: 100%;">
+                </div>
+                <iframe v-else-if="previewType === 'pdf'" :src="previewUrl" style="width: 100%; height: 70vh; border: none;"></iframe>
                 <div v-else-if="isBinaryFile" class="alert alert-warning">
                   このファイルはバイナリファイルのため表示できません。
                 </div>
-                <pre v-else class="file-content">{{ fileContent }}</pre>
+                <div v-else-if="lfsInfo && !fileContent" class="alert alert-info">
+                  Git LFS で管理されているファイルです（{{ lfsInfo.size }} バイト）。{{ fileMessage }}
+                </div>
+                <div v-else>
+                  <div v-if="fileTruncated" class="alert alert-warning">
+                    ファイルが大きいため先頭部分のみ表示しています（全体: {{ fileSize }} バイト）。
+                  </div>
+                  <div v-if="fileEditing">
+                    <textarea class="form-control text-monospace mb-2" rows="20" v-model="editContent"></textarea>
+                    <input type="text" class="form-control" v-model="editMessage" placeholder="コミットメッセージ">
+                    <div v-if="editError" class="alert alert-danger mt-2 mb-0">{{ editError }}</div>
+                  </div>
+                  <pre v-else class="file-content">{{ fileContent }}</pre>
+                </div>
+                </template>
               </div>
               <div class="modal-footer">
-                <button type="button" class="btn btn-secondary" @click="closeFileModal">閉じる</button>
+                <template v-if="fileEditing">
+                  <button type="button" class="btn btn-secondary" @click="fileEditing = false" :disabled="editSaving">キャンセル</button>
+                  <button type="button" class="btn btn-primary" @click="saveFile" :disabled="editSaving || !editMessage.trim()">
+                    <span v-if="editSaving" class="spinner-border spinner-border-sm mr-2" role="status"></span>
+                    コミット
+                  </button>
+                </template>
+                <template v-else>
+                  <button v-if="canEditFile" type="button" class="btn btn-outline-primary" @click="startEditFile">編集</button>
+                  <button type="button" class="btn btn-secondary" @click="closeFileModal">閉じる</button>
+                </template>
               </div>
             </div>
           </div>
@@ -344,8 +821,8 @@ git push origin master</pre>
             <label>新しいHEADブランチを選択:</label><br>
             <select v-model="selectedBranch" style="width: 100%; padding: 5px; margin-top: 5px;">
               <option value="">-- ブランチを選択してください --</option>
-              <option v-for="branch in branches" :key="branch" :value="branch">
-                {{ branch }}{{ branch === currentHead ? ' (現在)' : '' }}
+              <option v-for="branch in branches" :key="branch.name" :value="branch.name">
+                {{ branch.name }}{{ branch.name === currentHead ? ' (現在)' : '' }}
               </option>
             </select>
           </div>
@@ -363,10 +840,42 @@ git push origin master</pre>
           </div>
         </div>
       </div>
+
+      <!-- 通知メールの設定モーダル -->
+      <div v-show="showSubscribersDialog" style="position: fixed; top: 0; left: 0; width: 100%; height: 100%; background: rgba(0,0,0,0.5); z-index: 9999; display: flex; align-items: center; justify-content: center;">
+        <div style="background: white; padding: 20px; border-radius: 5px; max-width: 500px; width: 90%;">
+          <h3>通知メールの設定</h3>
+          <p>プッシュされたときに、ブランチ・作成者・コミットの一覧をメールで通知します。</p>
+          <div v-if="!smtpConfigured" class="alert alert-warning">サーバーにメールの送信設定（SMTP）がないため、現在はメールは送信されません。</div>
+
+          <div style="margin: 15px 0;">
+            <label>宛先（1行に1件）:</label>
+            <textarea v-model="subscribersText" class="form-control" rows="6" placeholder="dev@example.com"></textarea>
+          </div>
+
+          <div v-if="subscribersError" style="color: red; margin: 10px 0;">
+            {{ subscribersError }}
+          </div>
+
+          <div style="text-align: right; margin-top: 20px;">
+            <button @click="closeSubscribersModal" style="margin-right: 10px; padding: 8px 16px;">キャンセル</button>
+            <button @click="saveSubscribers" :disabled="subscribersSaving" style="background: #007bff; color: white; border: none; padding: 8px 16px; border-radius: 3px;">
+              <span v-if="subscribersSaving">処理中...</span>
+              <span v-else>保存する</span>
+            </button>
+          </div>
+        </div>
+      </div>
     </div>
   `,
   created() {
     this.fetchRepositoryDetails();
+    this.fetchCommits();
+    this.fetchMergeRequests();
+    if (this.isWikiRoute) {
+      this.mainTab = 'wiki';
+      this.fetchWiki();
+    }
     // キーボードイベントリスナーを登録
     document.addEventListener('keydown', this.handleKeyDown);
     // モーダル外クリック検出のためのイベントリスナー登録
@@ -398,10 +907,19 @@ git push origin master</pre>
           const details = response.data;
           this.repository = details.repository;
           this.files = details.files;
+          this.filesTotal = details.files.length;
           this.branches = details.branches || [];
           this.tags = details.tags || [];
           this.currentHead = details.currentHead || '';
+          this.unbornBranch = details.unbornBranch || '';
           
+          if (this.pinnedCommit) {
+            // ファイル一覧は固定したコミットの時点のものに差し替える
+            return this.fetchDirectoryFiles('')
+              .then(() => {
+                this.loading = false;
+              });
+          }
           this.loading = false;
         })
         .catch(error => {
@@ -410,6 +928,220 @@ git push origin master</pre>
           this.loading = false;
         });
     },
+    toggleCommitView() {
+      // 最近のコミットとコミットグラフを切り替える（グラフは最初に表示するときに取得する）
+      this.commitView = this.commitView === 'graph' ? 'list' : 'graph';
+      if (this.commitView === 'graph' && !this.graph && !this.graphLoading) {
+        this.fetchGraph();
+      }
+    },
+    fetchGraph() {
+      this.graphLoading = true;
+      this.graphError = null;
+      axios.get(GuiltyUtils.getApiGraphPath(this.groupName, this.repoName) + '?limit=' + GRAPH_COMMITS)
+        .then(response => {
+          this.graph = response.data;
+          this.graphLoading = false;
+        })
+        .catch(error => {
+          console.error('コミットグラフ取得エラー:', error);
+          this.graphError = `コミットグラフの取得に失敗しました: ${error.message}`;
+          this.graphLoading = false;
+        });
+    },
+    graphX(column) {
+      return column * GRAPH_COLUMN_WIDTH + GRAPH_COLUMN_WIDTH / 2;
+    },
+    graphY(row) {
+      return row * GRAPH_ROW_HEIGHT + GRAPH_ROW_HEIGHT / 2;
+    },
+    fetchCommits() {
+      axios.get(this.withRef(GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName) + '/commits?limit=10'))
+        .then(response => {
+          this.commits = response.data || [];
+        })
+        .catch(error => {
+          console.error('コミット履歴取得エラー:', error);
+          this.commitsError = `コミット履歴の取得に失敗しました: ${error.message}`;
+        });
+    },
+    fetchMergeRequests() {
+      axios.get(GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName) + '/merge-requests')
+        .then(response => {
+          this.mergeRequests = response.data || [];
+        })
+        .catch(error => {
+          // メタデータストアが使えない場合などは表示しない
+          console.error('マージリクエスト取得エラー:', error);
+        });
+    },
+    showIssues() {
+      this.mainTab = 'issues';
+      if (!this.issuesLoaded) {
+        this.fetchIssues(this.issuesState);
+      }
+    },
+    fetchIssues(state) {
+      this.issuesState = state;
+      this.issuesLoading = true;
+      this.issuesError = null;
+      axios.get(`${GuiltyUtils.getApiIssuesPath(this.groupName, this.repoName)}?state=${state}`)
+        .then(response => {
+          this.issues = response.data || [];
+          this.issuesLoaded = true;
+          this.issuesLoading = false;
+        })
+        .catch(error => {
+          console.error('イシュー取得エラー:', error);
+          this.issuesError = `イシューの取得に失敗しました: ${error.message}`;
+          this.issuesLoading = false;
+        });
+    },
+    openIssueForm() {
+      this.newIssue = { title: '', body: '', labels: '' };
+      this.issueFormError = null;
+      this.showIssueForm = true;
+    },
+    createIssue() {
+      this.issueSaving = true;
+      this.issueFormError = null;
+      axios.post(GuiltyUtils.getApiIssuesPath(this.groupName, this.repoName), {
+        title: this.newIssue.title,
+        body: this.newIssue.body,
+        labels: this.newIssue.labels.split(',')
+      })
+        .then(response => {
+          this.issueSaving = false;
+          this.showIssueForm = false;
+          this.selectedIssueId = response.data.id;
+          this.fetchIssues('open');
+        })
+        .catch(error => {
+          this.issueFormError = error.response && error.response.data && error.response.data.error
+            ? error.response.data.error
+            : `イシューの作成に失敗しました: ${error.message}`;
+          this.issueSaving = false;
+        });
+    },
+    toggleIssue(issue) {
+      this.selectedIssueId = this.selectedIssueId === issue.id ? null : issue.id;
+    },
+    setIssueState(issue, state) {
+      axios.patch(`${GuiltyUtils.getApiIssuesPath(this.groupName, this.repoName)}/${issue.id}`, { state })
+        .then(() => {
+          this.fetchIssues(this.issuesState);
+        })
+        .catch(error => {
+          this.issuesError = `イシューの更新に失敗しました: ${error.message}`;
+        });
+    },
+    getWikiPageUrl(pageName) {
+      return GuiltyUtils.getWikiPageUrl(this.groupName, this.repoName, pageName);
+    },
+    fetchWiki() {
+      this.wikiLoading = true;
+      this.wikiError = null;
+      const apiPath = GuiltyUtils.getApiWikiPath(this.groupName, this.repoName, '');
+      const pagePath = GuiltyUtils.getApiWikiPath(this.groupName, this.repoName, this.wikiPageName);
+
+      // ページがまだない場合（404）は作成を促す
+      Promise.all([
+        axios.get(apiPath),
+        axios.get(pagePath).catch(error => {
+          if (error.response && error.response.status === 404) return { data: null };
+          throw error;
+        })
+      ])
+        .then(([indexResponse, pageResponse]) => {
+          this.wiki = indexResponse.data;
+          this.wikiPage = pageResponse.data;
+          this.wikiLoading = false;
+        })
+        .catch(error => {
+          console.error('Wiki取得エラー:', error);
+          this.wikiError = `Wikiの取得に失敗しました: ${error.message}`;
+          this.wikiLoading = false;
+        });
+    },
+    startEditWikiPage() {
+      this.wikiEditContent = this.wikiPage ? this.wikiPage.content : `# ${this.wikiPageName}\n`;
+      this.wikiEditMessage = this.wikiPage ? `Update ${this.wikiPageName}` : `Create ${this.wikiPageName}`;
+      this.wikiSaveError = null;
+      this.wikiEditing = true;
+    },
+    saveWikiPage() {
+      // ページは Wiki リポジトリに対する contents API で保存する（Wiki がなければ作成される）
+      const filePath = this.wikiPage ? this.wikiPage.path : `${this.wikiPageName}.md`;
+      const body = { content: this.wikiEditContent, message: this.wikiEditMessage };
+      if (this.wikiPage) {
+        body.sha = this.wikiPage.sha;
+      }
+
+      this.wikiSaving = true;
+      this.wikiSaveError = null;
+      axios.put(GuiltyUtils.getApiContentsPath(this.groupName, this.wiki.repository, filePath), body)
+        .then(() => {
+          this.wikiSaving = false;
+          this.wikiEditing = false;
+          this.fetchWiki();
+        })
+        .catch(error => {
+          if (error.response && error.response.status === 409) {
+            this.wikiSaveError = 'ページは他の人によって更新されています。再読み込みしてから編集してください。';
+          } else if (error.response && error.response.data && error.response.data.error) {
+            this.wikiSaveError = error.response.data.error;
+          } else {
+            this.wikiSaveError = `ページの保存に失敗しました: ${error.message}`;
+          }
+          this.wikiSaving = false;
+        });
+    },
+    openNewWikiPage() {
+      window.location.href = this.getWikiPageUrl(this.newWikiPageName.trim());
+    },
+    signatureBadgeClass(signature) {
+      if (signature.status === 'good') return 'badge-success';
+      if (signature.status === 'bad') return 'badge-danger';
+      return 'badge-warning';
+    },
+    formatSignatureStatus(signature) {
+      if (signature.status === 'good') return '署名検証済み';
+      if (signature.status === 'bad') return '署名不正';
+      if (signature.status === 'expired') return '署名期限切れ';
+      if (signature.status === 'revoked') return '失効した鍵';
+      return '署名未検証';
+    },
+    formatSignatureTitle(signature) {
+      const signer = signature.signer || '不明な署名者';
+      return signature.key ? `${signer}（鍵: ${signature.key}）` : signer;
+    },
+    withRef(url) {
+      // コミットを固定している場合は API に ref を付ける
+      if (!this.pinnedCommit) return url;
+      const separator = url.includes('?') ? '&' : '?';
+      return `${url}${separator}ref=${encodeURIComponent(this.pinnedCommit)}`;
+    },
+    getRepositoryUrl(group, repo) {
+      return GuiltyUtils.getRepositoryUrl(group, repo);
+    },
+    getCommitPageUrl(sha) {
+      return GuiltyUtils.getCommitPageUrl(this.groupName, this.repoName, sha);
+    },
+    getExportUrl() {
+      return GuiltyUtils.getApiExportPath(this.groupName, this.repoName);
+    },
+    // findCloneUrl は選択したプロトコルのクローンURLを返す（ない場合は SSH のURL）
+    findCloneUrl(target) {
+      if (!target) {
+        return '';
+      }
+      const clone = (target.cloneUrls || []).find(c => c.protocol === this.cloneProtocol);
+      return clone ? clone.url : (target.cloneUrl || '');
+    },
+    selectCloneProtocol(protocol) {
+      this.cloneProtocol = protocol;
+      localStorage.setItem('guilty.cloneProtocol', protocol);
+    },
     copyCloneUrl() {
       const cloneUrlInput = document.getElementById('cloneUrlInput');
       if (cloneUrlInput) {
@@ -434,9 +1166,8 @@ git push origin master</pre>
       });
       this.currentPath = directory.path;
       
-      axios.get(GuiltyUtils.getApiDirectoryPath(this.groupName, this.repoName, directory.path))
-        .then(response => {
-          this.files = response.data;
+      this.fetchDirectoryFiles(directory.path)
+        .then(() => {
           this.loading = false;
         })
         .catch(error => {
@@ -444,14 +1175,53 @@ git push origin master</pre>
           this.loading = false;
         });
     },
+    directoryPageUrl(path, offset) {
+      const url = GuiltyUtils.getApiDirectoryPath(this.groupName, this.repoName, path);
+      return this.withRef(`${url}?offset=${offset}&limit=${DIRECTORY_PAGE_SIZE}`);
+    },
+    fetchDirectoryFiles(path) {
+      // 最初の DIRECTORY_PAGE_SIZE 件を取得し、総数は X-Total-Count ヘッダーから読む
+      return axios.get(this.directoryPageUrl(path, 0))
+        .then(response => {
+          this.files = response.data;
+          this.filesTotal = parseInt(response.headers['x-total-count'], 10) || response.data.length;
+        });
+    },
+    loadMoreFiles() {
+      // 続きのエントリを取得して一覧に追加する
+      this.filesLoadingMore = true;
+      const path = this.currentPath;
+      axios.get(this.directoryPageUrl(path, this.files.length))
+        .then(response => {
+          if (path !== this.currentPath) return;
+          this.files = this.files.concat(response.data);
+          this.filesTotal = parseInt(response.headers['x-total-count'], 10) || this.files.length;
+        })
+        .catch(error => {
+          this.error = `ディレクトリの内容を取得できませんでした: ${error.message}`;
+        })
+        .finally(() => {
+          this.filesLoadingMore = false;
+        });
+    },
     openFile(file) {
       // モーダルを開くときにフラグをリセット
       this.modalJustOpened = true;
       
       this.selectedFile = file;
+      this.fileTab = 'content';
+      this.fileHistory = [];
+      this.fileHistoryError = null;
+      this.fileDiffFrom = '';
+      this.fileDiff = null;
+      this.fileDiffError = null;
       this.fileLoading = true;
       this.fileError = null;
       this.fileContent = '';
+      this.lfsInfo = null;
+      this.previewType = '';
+      this.fileTruncated = false;
+      this.fileEditing = false;
       this.showFileModal = true;
       document.body.classList.add('modal-open');
       
@@ -460,10 +1230,18 @@ git push origin master</pre>
         this.modalJustOpened = false;
       }, 10);
       
-      axios.get(GuiltyUtils.getApiFilePath(this.groupName, this.repoName, file.path))
+      axios.get(this.withRef(GuiltyUtils.getApiFilePath(this.groupName, this.repoName, file.path)))
         .then(response => {
           this.fileContent = response.data.content;
           this.isBinaryFile = response.data.isBinary;
+          this.lfsInfo = response.data.isLfs ? response.data.lfs : null;
+          this.fileMessage = response.data.message || '';
+          this.fileTruncated = !!response.data.truncated;
+          this.fileSize = response.data.size || 0;
+          this.previewType = response.data.preview || '';
+          this.previewUrl = this.previewType
+            ? this.withRef(GuiltyUtils.getApiFilePath(this.groupName, this.repoName, file.path) + '?raw=1')
+            : '';
           this.fileLoading = false;
         })
         .catch(error => {
@@ -471,6 +1249,83 @@ git push origin master</pre>
           this.fileLoading = false;
         });
     },
+    showFileHistory() {
+      this.fileTab = 'history';
+      if (this.fileHistory.length > 0 || this.fileHistoryLoading || !this.selectedFile) {
+        return;
+      }
+
+      this.fileHistoryLoading = true;
+      this.fileHistoryError = null;
+      axios.get(this.withRef(GuiltyUtils.getApiHistoryPath(this.groupName, this.repoName, this.selectedFile.path)))
+        .then(response => {
+          this.fileHistory = response.data || [];
+          this.fileHistoryLoading = false;
+        })
+        .catch(error => {
+          console.error('ファイル履歴取得エラー:', error);
+          this.fileHistoryError = `ファイルの履歴の取得に失敗しました: ${error.message}`;
+          this.fileHistoryLoading = false;
+        });
+    },
+    fetchFileDiff() {
+      // 比較元から表示中の内容（コミットを固定している場合はそのコミット）までの差分を取得する
+      if (!this.fileDiffFrom || !this.selectedFile) {
+        return;
+      }
+
+      const from = this.fileDiffFrom;
+      const params = new URLSearchParams({ from: from });
+      if (this.pinnedCommit) {
+        params.set('to', this.pinnedCommit);
+      }
+      this.fileDiffLoading = true;
+      this.fileDiffError = null;
+      axios.get(GuiltyUtils.getApiDiffPath(this.groupName, this.repoName, this.selectedFile.path) + '?' + params.toString())
+        .then(response => {
+          if (this.fileDiffFrom !== from) return;
+          this.fileDiff = response.data;
+          this.fileDiffLoading = false;
+        })
+        .catch(error => {
+          if (this.fileDiffFrom !== from) return;
+          console.error('ファイル差分取得エラー:', error);
+          this.fileDiffError = `ファイルの差分の取得に失敗しました: ${error.message}`;
+          this.fileDiffLoading = false;
+        });
+    },
+    startEditFile() {
+      this.editContent = this.fileContent;
+      this.editMessage = `Update ${this.selectedFile.path}`;
+      this.editError = null;
+      this.fileEditing = true;
+    },
+    saveFile() {
+      this.editSaving = true;
+      this.editError = null;
+      axios.put(GuiltyUtils.getApiContentsPath(this.groupName, this.repoName, this.selectedFile.path), {
+        content: this.editContent,
+        message: this.editMessage,
+        sha: this.selectedFile.sha
+      })
+        .then(response => {
+          // 次の編集に備えて新しい blob の SHA に更新する
+          this.selectedFile.sha = response.data.files[this.selectedFile.path];
+          this.fileContent = this.editContent;
+          this.fileEditing = false;
+          this.editSaving = false;
+          this.fileHistory = [];
+          this.fileDiff = null;
+          this.fileDiffFrom = '';
+          this.fetchCommits();
+        })
+        .catch(error => {
+          this.editError = error.response && error.response.data && error.response.data.error
+            ? error.response.data.error
+            : `ファイルの保存に失敗しました: ${error.message}`;
+          this.editSaving = false;
+        });
+    },
     closeFileModal() {
       this.showFileModal = false;
       document.body.classList.remove('modal-open');
@@ -542,16 +1397,7 @@ git push origin master</pre>
       }
       
       // リポジトリ削除APIを呼び出し
-      axios({
-        method: 'post',
-        url: GuiltyUtils.getApiRepositoryPath(this.groupName, repoNameToDelete),
-        data: {
-          operation: "delete" // 操作タイプを指定
-        },
-        headers: {
-          'Content-Type': 'application/json'
-        }
-      })
+      axios.delete(GuiltyUtils.getApiRepositoryPath(this.groupName, repoNameToDelete))
         .then(response => {
           // 削除成功時の処理
           this.deleteInProgress = false;
@@ -627,9 +1473,8 @@ git push origin master</pre>
       this.directoryStack = this.directoryStack.slice(0, index + 1);
       this.currentPath = targetDir.path;
       
-      axios.get(GuiltyUtils.getApiDirectoryPath(this.groupName, this.repoName, targetDir.path))
-        .then(response => {
-          this.files = response.data;
+      this.fetchDirectoryFiles(targetDir.path)
+        .then(() => {
           this.loading = false;
         })
         .catch(error => {
@@ -647,6 +1492,43 @@ git push origin master</pre>
       this.showDropdown = false;
       this.confirmDelete();
     },
+    showSubscribersModal() {
+      this.showDropdown = false;
+      this.subscribersError = null;
+      axios.get(`${GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName)}/subscribers`)
+        .then(response => {
+          this.subscribersText = response.data.subscribers.join('\n');
+          this.smtpConfigured = response.data.smtpConfigured;
+          this.showSubscribersDialog = true;
+          document.body.classList.add('modal-open');
+        })
+        .catch(error => {
+          this.subscribersError = `通知メールの設定の取得に失敗しました: ${error.message}`;
+          this.showSubscribersDialog = true;
+          document.body.classList.add('modal-open');
+        });
+    },
+    closeSubscribersModal() {
+      this.showSubscribersDialog = false;
+      document.body.classList.remove('modal-open');
+    },
+    saveSubscribers() {
+      this.subscribersSaving = true;
+      this.subscribersError = null;
+      axios.put(`${GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName)}/subscribers`, {
+        subscribers: this.subscribersText.split('\n')
+      })
+        .then(() => {
+          this.subscribersSaving = false;
+          this.closeSubscribersModal();
+        })
+        .catch(error => {
+          this.subscribersError = error.response && error.response.data && error.response.data.error
+            ? error.response.data.error
+            : `通知メールの設定の保存に失敗しました: ${error.message}`;
+          this.subscribersSaving = false;
+        });
+    },
     showChangeHeadModal() {
       this.selectedBranch = this.currentHead;
       this.showHeadModal = true;
@@ -659,6 +1541,39 @@ git push origin master</pre>
       this.headChangeError = null;
       document.body.classList.remove('modal-open');
     },
+    toggleStar() {
+      // スターはユーザーごとのため、認証されていない場合はエラーを表示する
+      const url = GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName) + '/star';
+      const request = this.repository.starred ? axios.delete(url) : axios.put(url);
+      this.starError = null;
+      request
+        .then(response => {
+          this.repository.starred = response.data.starred;
+          this.repository.stars = response.data.stars;
+        })
+        .catch(error => {
+          this.starError = error.response?.data?.error || 'スターの変更に失敗しました';
+        });
+    },
+    togglePin() {
+      // グループのピン留めの一覧を取得し、このリポジトリを追加または削除して置き換える
+      const url = GuiltyUtils.url(`/api/groups/${encodeURIComponent(this.groupName)}/pins`);
+      this.starError = null;
+      axios.get(url)
+        .then(response => {
+          const names = response.data.repositories.filter(name => name !== this.repoName);
+          if (!this.repository.pinned) {
+            names.push(this.repoName);
+          }
+          return axios.put(url, { repositories: names });
+        })
+        .then(response => {
+          this.repository.pinned = response.data.repositories.includes(this.repoName);
+        })
+        .catch(error => {
+          this.starError = error.response?.data?.error || 'ピン留めの変更に失敗しました';
+        });
+    },
     changeHeadBranch() {
       if (!this.selectedBranch) {
         this.headChangeError = 'ブランチを選択してください';
@@ -673,10 +1588,8 @@ git push origin master</pre>
       this.headChangeInProgress = true;
       this.headChangeError = null;
 
-      const apiUrl = `/api/head/${encodeURIComponent(this.groupName)}/${encodeURIComponent(this.repoName)}`;
-
-      axios.post(apiUrl, {
-        branch: this.selectedBranch
+      axios.patch(GuiltyUtils.getApiRepositoryPath(this.groupName, this.repoName), {
+        defaultBranch: this.selectedBranch
       })
       .then(response => {
         // 成功時の処理
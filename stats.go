@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultActivityMonths はコミットアクティビティの既定の集計期間（月数）
+const DefaultActivityMonths = 12
+
+// MaxActivityMonths はコミットアクティビティで指定できる最大の集計期間（月数）
+const MaxActivityMonths = 60
+
+// ActivityCount は1日（または1週間）あたりのコミット数を表す
+type ActivityCount struct {
+	Date    string `json:"date"` // YYYY-MM-DD（週単位の場合はその週の月曜日）
+	Commits int    `json:"commits"`
+}
+
+// CommitActivity はコミットアクティビティの集計結果を表す
+type CommitActivity struct {
+	Interval string          `json:"interval"` // "day" または "week"
+	Since    string          `json:"since"`
+	Until    string          `json:"until"`
+	Total    int             `json:"total"`
+	Counts   []ActivityCount `json:"counts"` // 古い順。コミットのない日（週）も0として含む
+}
+
+// getCommitActivity は git log --date=short --format=%ad で直近 months か月のコミット数を日（週）ごとに集計する
+func getCommitActivity(ctx context.Context, repoPath string, months int, interval string) (*CommitActivity, error) {
+	until := time.Now()
+	since := until.AddDate(0, -months, 0)
+
+	activity := &CommitActivity{
+		Interval: interval,
+		Since:    since.Format("2006-01-02"),
+		Until:    until.Format("2006-01-02"),
+		Counts:   []ActivityCount{},
+	}
+
+	counts := map[string]int{}
+	if hasCommits(ctx, repoPath) {
+		cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "log", "--date=short", "--format=%ad",
+			"--since="+activity.Since, "HEAD")
+		defer cancel()
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(output), "\n") {
+			date, err := time.Parse("2006-01-02", strings.TrimSpace(line))
+			if err != nil {
+				continue
+			}
+			counts[activityBucket(date, interval).Format("2006-01-02")]++
+			activity.Total++
+		}
+	}
+
+	// コミットのない日（週）も含めて古い順に並べる
+	step := 1
+	if interval == "week" {
+		step = 7
+	}
+	last := activityBucket(until, interval)
+	for day := activityBucket(since, interval); !day.After(last); day = day.AddDate(0, 0, step) {
+		key := day.Format("2006-01-02")
+		activity.Counts = append(activity.Counts, ActivityCount{Date: key, Commits: counts[key]})
+	}
+
+	return activity, nil
+}
+
+// activityBucket は日付を集計単位の先頭（日単位はその日、週単位はその週の月曜日）に丸める
+func activityBucket(date time.Time, interval string) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if interval != "week" {
+		return day
+	}
+
+	// time.Weekday は日曜日が0なので、月曜日からの日数に変換する
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// statsHandler はリポジトリの統計情報を返すハンドラー
+//
+//	GET /api/stats/{group}/{repo}/activity?months=12&interval=day|week
+//	GET /api/stats/{group}/{repo}/languages
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	repoPath, ok := findAccessibleRepository(r, r.PathValue("groupName"), r.PathValue("repoName"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "リポジトリが見つかりません"})
+		return
+	}
+
+	switch stat := r.PathValue("stat"); stat {
+	case "activity":
+		activityStatsHandler(w, r, repoPath)
+	case "languages":
+		languageStatsHandler(w, r, repoPath)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "不明な統計情報です: " + stat})
+	}
+}
+
+// activityStatsHandler はコミットアクティビティを返す
+func activityStatsHandler(w http.ResponseWriter, r *http.Request, repoPath string) {
+	query := r.URL.Query()
+
+	months := DefaultActivityMonths
+	if value := query.Get("months"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxActivityMonths {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("months は1から%dの整数で指定してください", MaxActivityMonths)})
+			return
+		}
+		months = parsed
+	}
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "week" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "interval は day または week で指定してください"})
+		return
+	}
+
+	activity, err := getCommitActivity(r.Context(), repoPath, months, interval)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "コミットアクティビティの取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(activity)
+}
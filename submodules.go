@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// getSubmoduleURLs は指定したリビジョンの .gitmodules を解析し、サブモジュールのパスとURLの対応を返す
+// .gitmodules がない場合は空のマップを返す
+func getSubmoduleURLs(ctx context.Context, repoPath, rev string) map[string]string {
+	urls := map[string]string{}
+
+	// git config --blob で .gitmodules をワークツリーなしで読む
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "config", "--blob", rev+":.gitmodules",
+		"--get-regexp", `^submodule\..*\.(path|url)$`)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return urls
+	}
+
+	// 各行の形式: submodule.<name>.<path|url> <value>
+	// <name> にはドットが含まれることがあるため、最後のドットで属性名を分ける
+	paths := map[string]string{}
+	urlsByName := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimPrefix(key, "submodule.")
+		dot := strings.LastIndex(key, ".")
+		if dot < 0 {
+			continue
+		}
+
+		name, attr := key[:dot], key[dot+1:]
+		switch attr {
+		case "path":
+			paths[name] = value
+		case "url":
+			urlsByName[name] = value
+		}
+	}
+
+	for name, path := range paths {
+		urls[path] = urlsByName[name]
+	}
+
+	return urls
+}
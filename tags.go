@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTaggerName はタグ作成時にタガー名が指定されなかった場合の名前を定義します
+var DefaultTaggerName = "Guilty"
+
+// DefaultTaggerEmail はタグ作成時にタガーのメールアドレスが指定されなかった場合の値を定義します
+var DefaultTaggerEmail = "guilty@localhost"
+
+// CreateTagRequest はタグ作成リクエスト用の構造体
+// message が指定された場合は注釈付きタグ、省略された場合は軽量タグを作成する
+type CreateTagRequest struct {
+	Name        string `json:"name"`
+	Target      string `json:"target"` // タグを付けるref（省略時はデフォルトブランチ）
+	Message     string `json:"message"`
+	TaggerName  string `json:"taggerName"`
+	TaggerEmail string `json:"taggerEmail"`
+}
+
+// tagsHandler はタグの一覧・作成・削除を行う
+//
+//	GET    /api/repository/{group}/{repo}/tags         タグ一覧（TagInfoの配列）
+//	GET    /api/repository/{group}/{repo}/tags/{name}  タグの詳細（TagInfo）
+//	POST   /api/repository/{group}/{repo}/tags         CreateTagRequest
+//	DELETE /api/repository/{group}/{repo}/tags/{name}  タグ削除
+func tagsHandler(w http.ResponseWriter, r *http.Request, repoPath, tagName string) {
+	switch {
+	case r.Method == http.MethodGet && tagName == "":
+		tags, err := getTagInfos(r.Context(), repoPath, "")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "タグ一覧の取得に失敗しました: " + err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(tags)
+
+	case r.Method == http.MethodGet:
+		// for-each-ref のパターンとして解釈されないよう、タグの存在を先に確認する
+		if !tagExists(r.Context(), repoPath, tagName) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "タグが見つかりません"})
+			return
+		}
+
+		tags, err := getTagInfos(r.Context(), repoPath, tagName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "タグ情報の取得に失敗しました: " + err.Error()})
+			return
+		}
+
+		// パターンは階層の前方一致になるため、名前が完全に一致するものを返す
+		for _, tag := range tags {
+			if tag.Name == tagName {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(tag)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "タグが見つかりません"})
+
+	case r.Method == http.MethodPost && tagName == "":
+		var req CreateTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		if err := createTag(r.Context(), repoPath, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "tag.create", map[string]string{"tag": req.Name, "target": req.Target})
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": "タグが作成されました"})
+
+	case r.Method == http.MethodDelete && tagName != "":
+		if err := deleteTag(r.Context(), repoPath, tagName); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		recordRepositoryAudit(r, "tag.delete", map[string]string{"tag": tagName})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "タグが削除されました"})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// tagExists はタグが存在するか確認する
+func tagExists(ctx context.Context, repoPath, tagName string) bool {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "show-ref", "--verify", "--quiet", "refs/tags/"+tagName)
+	defer cancel()
+	return cmd.Run() == nil
+}
+
+// isValidTagName は git check-ref-format でタグ名として有効か確認する
+func isValidTagName(ctx context.Context, tagName string) bool {
+	if tagName == "" || strings.HasPrefix(tagName, "-") {
+		return false
+	}
+
+	cmd, cancel := gitCommand(ctx, "check-ref-format", "refs/tags/"+tagName)
+	defer cancel()
+	return cmd.Run() == nil
+}
+
+// createTag は指定したコミットに軽量タグまたは注釈付きタグを作成する
+func createTag(ctx context.Context, repoPath string, req CreateTagRequest) error {
+	if !isValidTagName(ctx, req.Name) {
+		return fmt.Errorf("タグ名 '%s' は不正です", req.Name)
+	}
+
+	if tagExists(ctx, repoPath, req.Name) {
+		return fmt.Errorf("タグ '%s' は既に存在します", req.Name)
+	}
+
+	// タグを付ける対象が指定されていない場合はデフォルトブランチに付ける
+	target := req.Target
+	if target == "" {
+		defaultBranch, err := getDefaultBranch(ctx, repoPath)
+		if err != nil {
+			return err
+		}
+		target = defaultBranch
+	}
+
+	commit, err := resolveCommit(ctx, repoPath, target)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	var cancel context.CancelFunc
+	if req.Message == "" {
+		// 軽量タグ
+		cmd, cancel = gitCommand(ctx, "--git-dir="+repoPath, "tag", req.Name, commit)
+	} else {
+		// 注釈付きタグ（タガーはコミッター用の環境変数で指定する）
+		cmd, cancel = gitCommand(ctx, "--git-dir="+repoPath, "tag", "-a", "-F", "-", req.Name, commit)
+		cmd.Stdin = strings.NewReader(req.Message)
+
+		taggerName := req.TaggerName
+		if taggerName == "" {
+			taggerName = DefaultTaggerName
+		}
+		taggerEmail := req.TaggerEmail
+		if taggerEmail == "" {
+			taggerEmail = DefaultTaggerEmail
+		}
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME="+taggerName, "GIT_COMMITTER_EMAIL="+taggerEmail)
+	}
+	defer cancel()
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("タグの作成に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	return nil
+}
+
+// deleteTag はタグを削除する
+func deleteTag(ctx context.Context, repoPath, tagName string) error {
+	if !tagExists(ctx, repoPath, tagName) {
+		return fmt.Errorf("タグ '%s' が見つかりません", tagName)
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "tag", "-d", tagName)
+	defer cancel()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("タグの削除に失敗しました: %s", strings.TrimSpace(string(output)))
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	return nil
+}
+
+// TagInfo はタグの詳細情報（リリース情報）を表す
+type TagInfo struct {
+	Name        string         `json:"name"`
+	Annotated   bool           `json:"annotated"`   // 注釈付きタグかどうか
+	Commit      string         `json:"commit"`      // タグが指すコミットのSHA
+	Object      string         `json:"object"`      // タグオブジェクトのSHA（軽量タグの場合はコミットのSHA）
+	Tagger      string         `json:"tagger"`      // タガー名（注釈付きタグのみ）
+	TaggerEmail string         `json:"taggerEmail"` // タガーのメールアドレス（注釈付きタグのみ）
+	Date        time.Time      `json:"date"`        // タグ作成日時（軽量タグの場合はコミット日時）
+	Subject     string         `json:"subject"`     // タグメッセージの1行目（注釈付きタグのみ）
+	Message     string         `json:"message"`     // タグメッセージ全体（リリースノート）
+	Signature   *SignatureInfo `json:"signature"`   // 署名の検証結果（署名のないタグは null）
+}
+
+// tagInfoFormat は git for-each-ref でタグ情報を取得するためのフォーマット
+// フィールドはNUL区切り、レコードは0x1e区切り
+const tagInfoFormat = "%(refname:short)%00%(objecttype)%00%(objectname)%00%(*objectname)%00" +
+	"%(taggername)%00%(taggeremail:trim)%00%(creatordate:unix)%00%(contents:subject)%00%(contents:body)%00%(contents:signature)%1e"
+
+// getTagInfos はタグの詳細情報を作成日時の新しい順に取得する
+// pattern を指定した場合は一致するタグのみを返す
+func getTagInfos(ctx context.Context, repoPath string, pattern string) ([]TagInfo, error) {
+	args := []string{"--git-dir=" + repoPath, "for-each-ref", "--sort=-creatordate", "--format=" + tagInfoFormat}
+	if pattern != "" {
+		args = append(args, "refs/tags/"+pattern)
+	} else {
+		args = append(args, "refs/tags")
+	}
+
+	output, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []TagInfo{}
+	for _, record := range strings.Split(string(output), "\x1e\n") {
+		fields := strings.Split(record, "\x00")
+		if len(fields) != 10 {
+			continue
+		}
+
+		tag := TagInfo{
+			Name:   fields[0],
+			Object: fields[2],
+			Commit: fields[2],
+		}
+
+		if unixTime, err := strconv.ParseInt(fields[6], 10, 64); err == nil {
+			tag.Date = time.Unix(unixTime, 0)
+		}
+
+		// 注釈付きタグの場合はタグオブジェクトの情報を使う
+		// 軽量タグの contents はコミットメッセージなので使わない
+		if fields[1] == "tag" {
+			tag.Annotated = true
+			tag.Commit = fields[3]
+			tag.Tagger = fields[4]
+			tag.TaggerEmail = fields[5]
+			tag.Subject = fields[7]
+			tag.Message = strings.TrimSpace(fields[7] + "\n\n" + fields[8])
+
+			// 署名付きタグの場合のみ検証する
+			if fields[9] != "" {
+				tag.Signature = verifyTagSignature(ctx, repoPath, tag.Name)
+			}
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
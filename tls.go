@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSCertFile と TLSKeyFile は HTTPS で使う証明書と秘密鍵のファイル（空の場合は HTTP で待ち受ける）
+// ファイルが更新された場合は次の接続から新しい証明書を使うため、証明書の更新に再起動は不要
+var (
+	TLSCertFile = ""
+	TLSKeyFile  = ""
+)
+
+// AutocertDomains は ACME（Let's Encrypt）で証明書を自動で取得するドメイン（空の場合は使わない）
+var AutocertDomains = []string{}
+
+// AutocertCacheDir は ACME で取得した証明書とアカウントの鍵を保存するディレクトリ
+var AutocertCacheDir = "autocert-cache"
+
+// AutocertEmail は ACME のアカウントに登録する連絡先（証明書の期限切れの通知などに使われる）
+var AutocertEmail = ""
+
+// HTTPRedirectPort は HTTPS を有効にした場合に HTTP で待ち受け、HTTPS にリダイレクトするポート（0 の場合は待ち受けない）
+// ACME の http-01 チャレンジもこのポートで応答するため、80 を指定する
+var HTTPRedirectPort = 0
+
+// isTLSEnabled は HTTPS で待ち受けるかどうかを返す
+func isTLSEnabled() bool {
+	return TLSCertFile != "" || len(AutocertDomains) > 0
+}
+
+// serverURL は起動時に表示するサーバーのURLを返す
+func serverURL(listener net.Listener) string {
+	scheme := "http"
+	if isTLSEnabled() {
+		scheme = "https"
+	}
+
+	if listener.Addr().Network() == "unix" {
+		return fmt.Sprintf("%s over unix:%s", scheme, listener.Addr().String())
+	}
+	host := "localhost"
+	if len(AutocertDomains) > 0 {
+		host = AutocertDomains[0]
+	}
+	if _, port, err := net.SplitHostPort(listener.Addr().String()); err == nil {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host + BasePath + "/"
+}
+
+// internalAPICurlTarget はフックからguiltyのAPIを呼ぶための curl のオプションとURLを返す
+// systemd のソケットアクティベーションの場合も、ソケットと同じ unixSocket または port の設定を使う
+// ACME の証明書はドメイン名でしか取得できないため、ドメイン名を 127.0.0.1 に解決させて接続する
+// 証明書ファイルの場合は証明書の名前が 127.0.0.1 と一致しないため、検証を省略する（接続はサーバー内で完結する）
+// ソケットのパスと basePath には空白やシェルの特殊文字が含まれることがあるため、値はすべて shellQuote で囲む
+func internalAPICurlTarget(path string) string {
+	path = BasePath + path
+	if UnixSocketPath != "" {
+		if isTLSEnabled() {
+			return "--unix-socket " + shellQuote(UnixSocketPath) + " -k " + shellQuote("https://localhost"+path)
+		}
+		return "--unix-socket " + shellQuote(UnixSocketPath) + " " + shellQuote("http://localhost"+path)
+	}
+
+	switch {
+	case len(AutocertDomains) > 0:
+		domain := AutocertDomains[0]
+		return fmt.Sprintf("--resolve %s %s", shellQuote(fmt.Sprintf("%s:%d:127.0.0.1", domain, ServerPort)),
+			shellQuote(fmt.Sprintf("https://%s:%d%s", domain, ServerPort, path)))
+	case TLSCertFile != "":
+		return "-k " + shellQuote(fmt.Sprintf("https://127.0.0.1:%d%s", ServerPort, path))
+	default:
+		return shellQuote(fmt.Sprintf("http://127.0.0.1:%d%s", ServerPort, path))
+	}
+}
+
+// certificateLoader は証明書ファイルを読み込み、ファイルが更新されたら読み込み直す
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+
+	mutex       sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+// load は証明書と秘密鍵のどちらかが更新されていれば読み込み直す
+func (l *certificateLoader) load() (*tls.Certificate, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var modTime time.Time
+	for _, file := range []string{l.certFile, l.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			if l.certificate != nil {
+				// 更新中などで一時的に読めない場合は読み込み済みの証明書を使う
+				return l.certificate, nil
+			}
+			return nil, fmt.Errorf("証明書のファイルを開けません: %w", err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if l.certificate != nil && !modTime.After(l.modTime) {
+		return l.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		if l.certificate != nil {
+			log.Printf("警告: 証明書の読み込みに失敗しました（以前の証明書を使います）: %v", err)
+			return l.certificate, nil
+		}
+		return nil, fmt.Errorf("証明書の読み込みに失敗しました: %w", err)
+	}
+	if l.certificate != nil {
+		log.Printf("証明書 %s を読み込み直しました", l.certFile)
+	}
+	l.certificate = &certificate
+	l.modTime = modTime
+	return l.certificate, nil
+}
+
+func (l *certificateLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return l.load()
+}
+
+// redirectToHTTPS は HTTP のリクエストを同じパスの HTTPS にリダイレクトする
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = h
+	}
+	if ServerPort != 443 {
+		host = net.JoinHostPort(host, fmt.Sprint(ServerPort))
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+// startHTTPRedirect は HTTPRedirectPort で HTTP のリクエストを受け付ける
+// 終了時に停止できるよう、起動したサーバーを返す（待ち受けない場合は nil）
+func startHTTPRedirect(handler http.Handler) *http.Server {
+	if HTTPRedirectPort == 0 {
+		return nil
+	}
+
+	server := newHTTPServer(handler)
+	server.Addr = fmt.Sprintf(":%d", HTTPRedirectPort)
+	go func() {
+		log.Printf("HTTP（ポート %d）へのリクエストを HTTPS にリダイレクトします", HTTPRedirectPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("警告: HTTP のリダイレクト用のポートで待ち受けできません: %v", err)
+		}
+	}()
+	return server
+}
+
+// listenAndServe は設定に合わせて HTTP または HTTPS でサーバーを起動する
+// SIGINT または SIGTERM で処理中のリクエストを待って停止した場合は nil を返す
+func listenAndServe() error {
+	listener, err := newListener()
+	if err != nil {
+		return err
+	}
+	server := newHTTPServer(requestLogHandler(recoverHandler(compressHandler(basePathHandler(rateLimitHandler(etagHandler(localizeHandler(hidePprofHandler(http.DefaultServeMux)))))))))
+	var redirectServer *http.Server
+
+	switch {
+	case len(AutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(AutocertDomains...),
+			Cache:      autocert.DirCache(AutocertCacheDir),
+			Email:      AutocertEmail,
+		}
+		// http-01 チャレンジ以外のリクエストは HTTPS にリダイレクトする
+		redirectServer = startHTTPRedirect(manager.HTTPHandler(http.HandlerFunc(redirectToHTTPS)))
+		server.TLSConfig = manager.TLSConfig()
+
+	case TLSCertFile != "":
+		loader := &certificateLoader{certFile: TLSCertFile, keyFile: TLSKeyFile}
+		if _, err := loader.load(); err != nil {
+			listener.Close()
+			return err
+		}
+		redirectServer = startHTTPRedirect(http.HandlerFunc(redirectToHTTPS))
+		server.TLSConfig = &tls.Config{GetCertificate: loader.GetCertificate, MinVersion: tls.VersionTLS12}
+	}
+
+	servers := []*http.Server{server}
+	if redirectServer != nil {
+		servers = append(servers, redirectServer)
+	}
+	pprofServer, err := startPprofServer()
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	if pprofServer != nil {
+		servers = append(servers, pprofServer)
+	}
+	stopped := shutdownOnSignal(servers...)
+
+	fmt.Printf("サーバーを起動しています。%s にアクセスしてください\n", serverURL(listener))
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
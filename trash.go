@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeletedRepositorySuffix は論理削除されたリポジトリのディレクトリ名に付く接尾辞
+const DeletedRepositorySuffix = ".git.deleted"
+
+// DeletedRepositoryRetention は論理削除されたリポジトリを保持する期間を定義します
+// この期間を過ぎたリポジトリはバックグラウンドで完全に削除されます（0以下で無効）
+var DeletedRepositoryRetention = 30 * 24 * time.Hour
+
+// TrashPurgeInterval はゴミ箱の自動削除ジョブを実行する間隔を定義します
+var TrashPurgeInterval = time.Hour
+
+// TrashedRepository はゴミ箱（論理削除済み）にあるリポジトリを表す
+type TrashedRepository struct {
+	Path      string    `json:"path"`
+	Group     string    `json:"group"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// trashHandler は論理削除されたリポジトリの一覧を返すハンドラー
+//
+//	GET /api/trash
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	// OPTIONSリクエスト（プリフライト）に対する応答
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	trashed, err := getTrashedRepositories()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ゴミ箱の取得に失敗しました: " + err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trashed)
+}
+
+// trashedRepositoryHandler はゴミ箱のリポジトリを完全に削除するハンドラー
+//
+//	DELETE /api/trash/{groupName}/{repoName}
+//	POST   /api/trash/{groupName}/{repoName}  {"operation": "restore" | "purge"}  （非推奨: 以前のクライアントのために残している）
+func trashedRepositoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	// OPTIONSリクエスト（プリフライト）に対する応答
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	name := r.PathValue("groupName") + "/" + r.PathValue("repoName")
+
+	switch r.Method {
+	case http.MethodDelete:
+		purgeTrashedRepository(w, r, name)
+
+	case http.MethodPost:
+		var requestBody map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正なリクエスト形式"})
+			return
+		}
+
+		switch requestBody["operation"] {
+		case "restore":
+			restoreTrashedRepository(w, r, name)
+		case "purge":
+			purgeTrashedRepository(w, r, name)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "不正な操作タイプ"})
+		}
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+	}
+}
+
+// restoreTrashedRepositoryHandler はゴミ箱のリポジトリを元の名前に戻すハンドラー
+//
+//	POST /api/trash/{groupName}/{repoName}/restore
+func restoreTrashedRepositoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	// OPTIONSリクエスト（プリフライト）に対する応答
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	restoreTrashedRepository(w, r, r.PathValue("groupName")+"/"+r.PathValue("repoName"))
+}
+
+// restoreTrashedRepository はゴミ箱のリポジトリを復元し、結果を書き込む
+func restoreTrashedRepository(w http.ResponseWriter, r *http.Request, name string) {
+	if err := restoreRepository(name); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	groupName, repoName := splitRepositoryName(name)
+	recordAudit(r, "repository.restore", groupName, repoName, map[string]string{"source": "trash"})
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリが復元されました"})
+}
+
+// purgeTrashedRepository はゴミ箱のリポジトリを完全に削除し、結果を書き込む
+func purgeTrashedRepository(w http.ResponseWriter, r *http.Request, name string) {
+	if err := purgeRepository(name); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	groupName, repoName := splitRepositoryName(name)
+	recordAudit(r, "repository.purge", groupName, repoName, nil)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "リポジトリが完全に削除されました"})
+}
+
+// getTrashedRepositories は全グループから論理削除されたリポジトリを収集する
+func getTrashedRepositories() ([]TrashedRepository, error) {
+	groups, err := getGroupList()
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := []TrashedRepository{}
+	for _, groupName := range groups {
+		entries, err := os.ReadDir(filepath.Join(GitRepositoryHome, groupName))
+		if err != nil {
+			// グループが読めない場合はスキップ
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || !strings.HasSuffix(entry.Name(), DeletedRepositorySuffix) {
+				continue
+			}
+
+			// 削除済みディレクトリは chmod 000 されているが、親ディレクトリからの Lstat は可能
+			// 更新日時は deleteRepository が削除時に記録したもの
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			// Wiki はリポジトリと一緒に復元・完全削除するため一覧には含めない
+			repoName := strings.TrimSuffix(entry.Name(), DeletedRepositorySuffix)
+			if isWikiRepositoryName(repoName) {
+				continue
+			}
+			trashed = append(trashed, TrashedRepository{
+				Path:      filepath.Join(groupName, repoName),
+				Group:     groupName,
+				Name:      repoName,
+				DeletedAt: info.ModTime(),
+			})
+		}
+	}
+
+	// 削除日時の降順でソート（新しい順）
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+
+	return trashed, nil
+}
+
+// trashedMetadataSuffix はゴミ箱に移動したリポジトリのメタデータを保存するときに名前に付ける接尾辞
+// リポジトリ名に使えない ':' を含むため、同じ名前で作り直したリポジトリのメタデータとは重ならない
+const trashedMetadataSuffix = ":deleted"
+
+// trashRepositoryMetadata はリポジトリのメタデータ、マージリクエスト、イシュー、スター、ピン留めをゴミ箱用の名前に移す
+// 以前にゴミ箱へ移動した同じ名前のリポジトリのメタデータは、ディレクトリと一緒に上書きされるため削除する
+func trashRepositoryMetadata(groupName, repoName string) error {
+	if err := deleteRepositoryMetadata(groupName, repoName+trashedMetadataSuffix); err != nil {
+		return err
+	}
+	return moveRepositoryMetadata(groupName, repoName, groupName, repoName+trashedMetadataSuffix)
+}
+
+// restoreRepositoryMetadata はゴミ箱用の名前に移したメタデータを元の名前に戻す
+// 以前のバージョンでゴミ箱へ移動したリポジトリはメタデータが元の名前のまま残っているため、そのままにする
+func restoreRepositoryMetadata(groupName, repoName string) error {
+	if !hasRepositoryMetadata(groupName, repoName+trashedMetadataSuffix) {
+		return nil
+	}
+	if err := deleteRepositoryMetadata(groupName, repoName); err != nil {
+		return err
+	}
+	return moveRepositoryMetadata(groupName, repoName+trashedMetadataSuffix, groupName, repoName)
+}
+
+// trashedRepositoryPath はゴミ箱内のリポジトリの実パスを返す
+func trashedRepositoryPath(name string) (string, string, error) {
+	groupName, baseName := splitRepositoryName(name)
+
+	// パス走査を防ぐためグループ名とリポジトリ名を検証
+	if !isValidGroupName(groupName) || baseName == "" || baseName == "." || baseName == ".." {
+		return "", "", fmt.Errorf("無効なリポジトリパス: %s", name)
+	}
+
+	repoPath := filepath.Join(GitRepositoryHome, groupName, baseName+".git")
+	return repoPath, repoPath + ".deleted", nil
+}
+
+// restoreRepository は論理削除されたリポジトリを元の名前に戻し、権限を復旧する
+func restoreRepository(name string) error {
+	repoPath, deletedPath, err := trashedRepositoryPath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(deletedPath); os.IsNotExist(err) {
+		return fmt.Errorf("ゴミ箱にリポジトリ '%s' は存在しません", name)
+	}
+
+	// 同名のリポジトリが既にある場合は上書きしない
+	if _, err := os.Stat(repoPath); err == nil {
+		return fmt.Errorf("リポジトリ '%s' は既に存在します", name)
+	}
+
+	// 削除時に chmod 000 しているので、まず権限を戻す
+	if err := os.Chmod(deletedPath, 0755); err != nil {
+		return fmt.Errorf("リポジトリのアクセス権限変更に失敗しました: %w", err)
+	}
+
+	if err := os.Rename(deletedPath, repoPath); err != nil {
+		return fmt.Errorf("リポジトリの名前変更に失敗しました: %w", err)
+	}
+	invalidateRepositoryIndex(repoPath)
+
+	groupName, baseName := splitRepositoryName(name)
+	if err := restoreRepositoryMetadata(groupName, baseName); err != nil {
+		log.Printf("警告: リポジトリ '%s' のメタデータの復元に失敗しました: %v", name, err)
+	}
+
+	// 一緒に削除した Wiki も元に戻す
+	restoreWikiRepository(name)
+
+	return nil
+}
+
+// purgeRepository は論理削除されたリポジトリを完全に削除する
+func purgeRepository(name string) error {
+	_, deletedPath, err := trashedRepositoryPath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(deletedPath); os.IsNotExist(err) {
+		return fmt.Errorf("ゴミ箱にリポジトリ '%s' は存在しません", name)
+	}
+
+	if err := removeDeletedRepository(deletedPath); err != nil {
+		return err
+	}
+	purgeWikiRepository(name)
+
+	// 完全に削除したリポジトリのメタデータも削除する
+	groupName, baseName := splitRepositoryName(name)
+	if err := deleteRepositoryMetadata(groupName, baseName+trashedMetadataSuffix); err != nil {
+		log.Printf("警告: リポジトリ '%s' のメタデータ削除に失敗しました: %v", name, err)
+	}
+
+	// 以前のバージョンでゴミ箱へ移動したリポジトリはメタデータが元の名前のまま残っている
+	// 同じ名前のリポジトリを作り直している場合は、そのメタデータなので削除しない
+	if _, exists := findRepository(groupName, baseName); !exists {
+		if err := deleteRepositoryMetadata(groupName, baseName); err != nil {
+			log.Printf("警告: リポジトリ '%s' のメタデータ削除に失敗しました: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// removeDeletedRepository は削除済みディレクトリの権限を戻してから完全に削除する
+func removeDeletedRepository(deletedPath string) error {
+	// 削除する前にアクセス権を変更（chmod 755）して読み書き可能にする
+	if err := os.Chmod(deletedPath, 0755); err != nil {
+		return fmt.Errorf("削除済みリポジトリの権限変更に失敗しました: %w", err)
+	}
+
+	if err := os.RemoveAll(deletedPath); err != nil {
+		return fmt.Errorf("削除済みリポジトリの削除に失敗しました: %w", err)
+	}
+
+	return nil
+}
+
+// startTrashPurger は保持期間を過ぎた論理削除済みリポジトリを定期的に完全削除する
+func startTrashPurger() {
+	if DeletedRepositoryRetention <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			purgeExpiredRepositories(time.Now().Add(-DeletedRepositoryRetention), recordSystemAudit)
+			time.Sleep(TrashPurgeInterval)
+		}
+	}()
+}
+
+// purgeExpiredRepositories は cutoff より前に削除されたリポジトリを完全に削除し、削除した数と失敗した数を返す
+// 削除したリポジトリは record で監査ログに記録する
+func purgeExpiredRepositories(cutoff time.Time, record auditRecorder) (purged, failed int) {
+	trashed, err := getTrashedRepositories()
+	if err != nil {
+		log.Printf("警告: ゴミ箱の取得に失敗しました: %v", err)
+		return 0, 1
+	}
+
+	for _, repo := range trashed {
+		if !repo.DeletedAt.Before(cutoff) {
+			continue
+		}
+
+		if err := purgeRepository(repo.Path); err != nil {
+			log.Printf("警告: 削除済みリポジトリ '%s' の完全削除に失敗しました: %v", repo.Path, err)
+			failed++
+			continue
+		}
+		log.Printf("削除済みリポジトリ '%s' を完全に削除しました（削除日時: %s）", repo.Path, repo.DeletedAt.Format(time.RFC3339))
+		groupName, repoName := splitRepositoryName(repo.Path)
+		record("repository.purge", groupName, repoName, map[string]string{"deletedAt": repo.DeletedAt.Format(time.RFC3339)})
+		purged++
+	}
+	return purged, failed
+}
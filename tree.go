@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SymlinkMode はシンボリックリンクを表す git のファイルモード
+const SymlinkMode = "120000"
+
+// TreeEntry は git ls-tree が返すツリーの1エントリを表す
+type TreeEntry struct {
+	Mode string
+	Type string // "blob"、"tree" または "commit"
+	SHA  string
+	Path string
+}
+
+// getTreeEntry は指定したリビジョンのパスのエントリを取得する
+func getTreeEntry(ctx context.Context, repoPath, rev, filePath string) (*TreeEntry, error) {
+	return getGitBackend().TreeEntry(ctx, repoPath, rev, filePath)
+}
+
+func (commandGitBackend) TreeEntry(ctx context.Context, repoPath, rev, filePath string) (*TreeEntry, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "ls-tree", "-z", rev, "--", filePath)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := parseTreeEntry(strings.TrimSuffix(string(output), "\x00"))
+	if !ok {
+		return nil, fmt.Errorf("'%s' が見つかりません", filePath)
+	}
+
+	return &entry, nil
+}
+
+// listTree はツリー直下のエントリ一覧を取得する
+func listTree(ctx context.Context, repoPath, treeish string) ([]TreeEntry, error) {
+	return getGitBackend().ListTree(ctx, repoPath, treeish)
+}
+
+// ListTree は git ls-tree -z でツリー直下のエントリ一覧を取得する
+// -z を使うことで空白・タブ・非ASCII文字を含むパスもクォートされずにそのまま得られる
+func (commandGitBackend) ListTree(ctx context.Context, repoPath, treeish string) ([]TreeEntry, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "ls-tree", "-z", treeish)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTreeEntries(string(output)), nil
+}
+
+// parseTreeEntries は ls-tree -z の出力を NUL で区切ってエントリ一覧にする（解析できないレコードは読み飛ばす）
+func parseTreeEntries(output string) []TreeEntry {
+	entries := []TreeEntry{}
+	for _, record := range strings.Split(output, "\x00") {
+		if entry, ok := parseTreeEntry(record); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// parseTreeEntry は ls-tree -z の1レコードを解析する
+// レコードの形式: <mode> SP <type> SP <object> TAB <file>
+func parseTreeEntry(record string) (TreeEntry, bool) {
+	meta, name, ok := strings.Cut(record, "\t")
+	if !ok || name == "" {
+		return TreeEntry{}, false
+	}
+
+	parts := strings.Fields(meta)
+	if len(parts) != 3 {
+		return TreeEntry{}, false
+	}
+
+	return TreeEntry{Mode: parts[0], Type: parts[1], SHA: parts[2], Path: name}, true
+}
+
+// getSymlinkTarget はシンボリックリンクのリンク先を取得する（リンク先は blob の内容として保存されている）
+func getSymlinkTarget(ctx context.Context, repoPath, objectHash string) (string, error) {
+	return readBlob(ctx, repoPath, objectHash)
+}
+
+// readBlob は blob の内容を読み込む
+func readBlob(ctx context.Context, repoPath, objectHash string) (string, error) {
+	return getGitBackend().ReadBlob(ctx, repoPath, objectHash)
+}
+
+// ReadBlob は git cat-file blob で blob の内容を読み込む
+func (commandGitBackend) ReadBlob(ctx context.Context, repoPath, objectHash string) (string, error) {
+	cmd, cancel := gitCommand(ctx, "--git-dir="+repoPath, "cat-file", "blob", objectHash)
+	defer cancel()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// getLastModifiedTimes は rev 時点の dirPath 直下の names（ファイル名またはディレクトリ名）の最終更新日時を取得する
+// エントリごとに履歴をたどるとファイル数だけ時間がかかるため、履歴を1回だけ新しい順にたどり、すべてのエントリの日時が分かった時点でやめる
+// 履歴から見つからなかったエントリ（マージでだけ変更されたものなど）は getFileLastModified で個別に取得する
+func getLastModifiedTimes(ctx context.Context, repoPath, rev, dirPath string, names []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(names))
+	remaining := make(map[string]bool, len(names))
+	for _, name := range names {
+		remaining[name] = true
+	}
+
+	prefix := ""
+	if dirPath != "" {
+		prefix = strings.TrimSuffix(dirPath, "/") + "/"
+	}
+
+	if len(remaining) > 0 {
+		getGitBackend().LastModifiedTimes(ctx, repoPath, rev, prefix, remaining, times)
+	}
+
+	for name := range remaining {
+		times[name] = getFileLastModified(ctx, repoPath, rev, path.Join(dirPath, name))
+	}
+	return times
+}
+
+// LastModifiedTimes は git log --name-only の出力を読み、すべてのエントリの日時が分かった時点で git を停止する
+// 出力の形式: \x01<author time> LF <path> NUL <path> NUL ... NUL \x01<author time> ...
+func (commandGitBackend) LastModifiedTimes(ctx context.Context, repoPath, rev, prefix string, remaining map[string]bool, times map[string]time.Time) {
+	args := []string{"--git-dir=" + repoPath, "log", "--format=format:%x01%at", "--name-only", "-z", "--no-renames", rev, "--"}
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+
+	cmd, cancel := gitCommand(ctx, args...)
+	defer cancel()
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	// すべて見つかった時点で読むのをやめるため、cancel で git を停止してから終了を待つ
+	defer cmd.Wait()
+	defer cancel()
+
+	reader := bufio.NewReader(stdout)
+	var commitTime time.Time
+	for len(remaining) > 0 {
+		record, err := reader.ReadString(0)
+		record = strings.TrimSuffix(record, "\x00")
+		record = strings.TrimPrefix(record, "\n")
+		if strings.HasPrefix(record, "\x01") {
+			header, filePath, _ := strings.Cut(record[1:], "\n")
+			unixTime, parseErr := strconv.ParseInt(strings.TrimSpace(header), 10, 64)
+			if parseErr != nil {
+				return
+			}
+			commitTime = time.Unix(unixTime, 0)
+			record = filePath
+		}
+
+		if name, ok := strings.CutPrefix(record, prefix); ok && name != "" {
+			// dirPath 直下のエントリ名（サブディレクトリ内の変更はディレクトリの変更として扱う）
+			name, _, _ = strings.Cut(name, "/")
+			if remaining[name] {
+				times[name] = commitTime
+				delete(remaining, name)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// DefaultDirectoryEntriesPerPage はディレクトリの内容で limit を指定しない場合に返す件数
+var DefaultDirectoryEntriesPerPage = 100
+
+// MaxDirectoryEntriesPerPage はディレクトリの内容の limit に指定できる件数の上限
+var MaxDirectoryEntriesPerPage = 1000
+
+// parseDirectoryPage はディレクトリの内容の offset と limit のパラメータを解析する
+// 大きなディレクトリで一度にすべてのエントリを返さないよう、limit を指定しない場合は DefaultDirectoryEntriesPerPage 件にする
+func parseDirectoryPage(query url.Values) (offset int, limit int, err error) {
+	limit = DefaultDirectoryEntriesPerPage
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset は0以上の整数で指定してください")
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxDirectoryEntriesPerPage {
+			return 0, 0, fmt.Errorf("limit は1から%dの整数で指定してください", MaxDirectoryEntriesPerPage)
+		}
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTreeEntry(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+
+	tests := []struct {
+		name   string
+		record string
+		ok     bool
+		want   TreeEntry
+	}{
+		{"ファイル", "100644 blob " + sha + "\tREADME.md", true, TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Path: "README.md"}},
+		{"ディレクトリ", "040000 tree " + sha + "\tsrc", true, TreeEntry{Mode: "040000", Type: "tree", SHA: sha, Path: "src"}},
+		{"サブモジュール", "160000 commit " + sha + "\tvendor/lib", true, TreeEntry{Mode: "160000", Type: "commit", SHA: sha, Path: "vendor/lib"}},
+		{"シンボリックリンク", SymlinkMode + " blob " + sha + "\tlink", true, TreeEntry{Mode: SymlinkMode, Type: "blob", SHA: sha, Path: "link"}},
+		{"空白を含む名前", "100644 blob " + sha + "\tmy file .txt", true, TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Path: "my file .txt"}},
+		{"タブを含む名前", "100644 blob " + sha + "\ta\tb", true, TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Path: "a\tb"}},
+		{"改行と引用符を含む名前", "100644 blob " + sha + "\t\"x\"\ny", true, TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Path: "\"x\"\ny"}},
+		{"非ASCIIの名前", "100644 blob " + sha + "\t日本語.md", true, TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Path: "日本語.md"}},
+
+		{"空のレコード", "", false, TreeEntry{}},
+		{"タブがない", "100644 blob " + sha + " README.md", false, TreeEntry{}},
+		{"名前が空", "100644 blob " + sha + "\t", false, TreeEntry{}},
+		{"フィールドが足りない", "100644 " + sha + "\tREADME.md", false, TreeEntry{}},
+		{"フィールドが多い", "100644 blob " + sha + " 12\tREADME.md", false, TreeEntry{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseTreeEntry(tt.record)
+			if ok != tt.ok {
+				t.Fatalf("parseTreeEntry(%q) ok = %v, want %v", tt.record, ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("parseTreeEntry(%q) = %+v, want %+v", tt.record, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTreeEntries(t *testing.T) {
+	sha := strings.Repeat("b", 40)
+
+	tests := []struct {
+		name   string
+		output string
+		want   []TreeEntry
+	}{
+		{"空の出力", "", []TreeEntry{}},
+		{"末尾の NUL", "100644 blob " + sha + "\ta\x00040000 tree " + sha + "\tb c\x00",
+			[]TreeEntry{{Mode: "100644", Type: "blob", SHA: sha, Path: "a"}, {Mode: "040000", Type: "tree", SHA: sha, Path: "b c"}}},
+		{"改行を含む名前は分割しない", "100644 blob " + sha + "\tline1\nline2\x00",
+			[]TreeEntry{{Mode: "100644", Type: "blob", SHA: sha, Path: "line1\nline2"}}},
+		{"解析できないレコードは読み飛ばす", "garbage\x00100644 blob " + sha + "\tok\x00",
+			[]TreeEntry{{Mode: "100644", Type: "blob", SHA: sha, Path: "ok"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTreeEntries(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTreeEntries(%q) = %+v, want %+v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchRepositories はリポジトリのディレクトリを監視して、APIを通さない変更（SSH での git push、サーバー上での git init など）をすぐに反映するかどうか
+// 参照が変わるとキャッシュと一覧の索引を更新し、通知用の post-receive フックがないリポジトリではプッシュの通知も送る
+var WatchRepositories = true
+
+// repositoryWatchDelay は変更を検知してから処理するまでの待ち時間
+// 1回のプッシュで複数の参照が更新されるため、続けて届く変更をまとめて1回だけ処理する
+const repositoryWatchDelay = 500 * time.Millisecond
+
+// repositoryWatcher はグループのディレクトリ、リポジトリのディレクトリ（HEAD と packed-refs）、refs/ 以下のディレクトリを監視する
+// inotify などはサブディレクトリを監視しないため、refs/heads/feature/ のようなディレクトリは作られたときに追加する
+type repositoryWatcher struct {
+	watcher *fsnotify.Watcher
+	mutex   sync.Mutex
+	refs    map[string]map[string]string // リポジトリのパスごとの参照とオブジェクト名（プッシュされた参照を求めるため）
+	timers  map[string]*time.Timer       // リポジトリのパスごとの処理待ちのタイマー
+}
+
+// isWatchedRepositoryDirectory はグループ直下のディレクトリが監視するリポジトリかどうかを返す
+// 復元中の一時ディレクトリ（.restore-*）とゴミ箱のリポジトリ（*.deleted）は対象にしない
+func isWatchedRepositoryDirectory(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".deleted") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(path, "HEAD"))
+	return err == nil
+}
+
+// readRefs は packed-refs とルーズな参照から、参照ごとのオブジェクト名を git を実行せずに読む
+// ルーズな参照がある場合は packed-refs より優先する（シンボリック参照は含めない）
+func readRefs(repoPath string) map[string]string {
+	refs := map[string]string{}
+
+	if file, err := os.Open(filepath.Join(repoPath, "packed-refs")); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			// コメント（# pack-refs with: ...）と注釈付きタグの剥いたコミット（^...）は参照ではない
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+				continue
+			}
+			if object, ref, ok := strings.Cut(line, " "); ok {
+				refs[ref] = object
+			}
+		}
+		file.Close()
+	}
+
+	filepath.WalkDir(filepath.Join(repoPath, "refs"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		object := strings.TrimSpace(string(content))
+		if object == "" || strings.HasPrefix(object, "ref: ") {
+			return nil
+		}
+		rel, _ := filepath.Rel(repoPath, path)
+		refs[filepath.ToSlash(rel)] = object
+		return nil
+	})
+
+	return refs
+}
+
+// refUpdatesInput は参照の変化を post-receive フックの標準入力と同じ形式（"古い 新しい 参照" の行）で返す
+func refUpdatesInput(before, after map[string]string) string {
+	var lines []string
+	zero := func(object string) string {
+		return strings.Repeat("0", len(object))
+	}
+	for ref, newObject := range after {
+		oldObject, ok := before[ref]
+		if !ok {
+			oldObject = zero(newObject)
+		}
+		if oldObject != newObject {
+			lines = append(lines, oldObject+" "+newObject+" "+ref)
+		}
+	}
+	for ref, oldObject := range before {
+		if _, ok := after[ref]; !ok {
+			lines = append(lines, oldObject+" "+zero(oldObject)+" "+ref)
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// hasManagedPostReceiveHook はguiltyが設置した通知用の post-receive フックがあるかどうかを返す
+// フックがある場合、プッシュの通知はフックから送られる
+func hasManagedPostReceiveHook(repoPath string) bool {
+	content, err := os.ReadFile(filepath.Join(repoPath, "hooks", "post-receive"))
+	return err == nil && strings.Contains(string(content), managedPostReceiveHookMarker)
+}
+
+// add はディレクトリを監視対象に加える
+func (w *repositoryWatcher) add(path string) {
+	if err := w.watcher.Add(path); err != nil {
+		log.Printf("警告: %s を監視できません: %v", path, err)
+	}
+}
+
+// watchGroup はグループのディレクトリと、その中のリポジトリを監視する
+func (w *repositoryWatcher) watchGroup(groupPath string) {
+	w.add(groupPath)
+	entries, err := getDirectories(groupPath)
+	if err != nil {
+		return
+	}
+	for _, repoPath := range entries {
+		if isWatchedRepositoryDirectory(repoPath) {
+			w.watchRepository(repoPath)
+		}
+	}
+}
+
+// watchRepository はリポジトリのディレクトリと refs/ 以下のディレクトリを監視し、現在の参照を記録する
+func (w *repositoryWatcher) watchRepository(repoPath string) {
+	w.add(repoPath)
+	w.watchRefsDirectory(filepath.Join(repoPath, "refs"))
+
+	refs := readRefs(repoPath)
+	w.mutex.Lock()
+	w.refs[repoPath] = refs
+	w.mutex.Unlock()
+}
+
+// watchRefsDirectory は refs/ 以下のディレクトリをすべて監視する
+func (w *repositoryWatcher) watchRefsDirectory(dir string) {
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			w.add(path)
+		}
+		return nil
+	})
+}
+
+// forgetRepository はなくなったリポジトリ（削除、名前の変更など）の監視をやめる
+// 名前を変更したディレクトリは監視が残るため、監視しているパスから取り除く
+func (w *repositoryWatcher) forgetRepository(repoPath string) {
+	for _, path := range w.watcher.WatchList() {
+		if path == repoPath || strings.HasPrefix(path, repoPath+string(filepath.Separator)) {
+			w.watcher.Remove(path)
+		}
+	}
+	w.mutex.Lock()
+	delete(w.refs, repoPath)
+	w.mutex.Unlock()
+}
+
+// schedule はリポジトリの処理を repositoryWatchDelay 後に行う（待っている間に届いた変更はまとめる）
+func (w *repositoryWatcher) schedule(repoPath string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if timer, ok := w.timers[repoPath]; ok {
+		timer.Reset(repositoryWatchDelay)
+		return
+	}
+	w.timers[repoPath] = time.AfterFunc(repositoryWatchDelay, func() {
+		w.mutex.Lock()
+		delete(w.timers, repoPath)
+		w.mutex.Unlock()
+		w.process(repoPath)
+	})
+}
+
+// process は変更のあったリポジトリのキャッシュと索引を更新し、必要であればプッシュの通知を送る
+func (w *repositoryWatcher) process(repoPath string) {
+	groupName := filepath.Base(filepath.Dir(repoPath))
+	repoName := strings.TrimSuffix(filepath.Base(repoPath), ".git")
+
+	w.mutex.Lock()
+	before, known := w.refs[repoPath]
+	w.mutex.Unlock()
+
+	if !isWatchedRepositoryDirectory(repoPath) {
+		if known {
+			w.forgetRepository(repoPath)
+			invalidateRepositoryCache(repoPath)
+		}
+		return
+	}
+
+	if !known {
+		// 新しいリポジトリは、その後のプッシュを検知できるよう監視を始める
+		w.watchRepository(repoPath)
+		log.Printf("リポジトリ %s/%s を検出しました", groupName, repoName)
+	}
+
+	// プッシュで作られた refs/heads/feature/ などのディレクトリは、中の参照ごと監視に加える
+	w.watchRefsDirectory(filepath.Join(repoPath, "refs"))
+
+	after := readRefs(repoPath)
+	w.mutex.Lock()
+	w.refs[repoPath] = after
+	w.mutex.Unlock()
+
+	invalidateRepositoryCache(repoPath)
+	if repositoryIndexAvailable() {
+		indexRepository(serverContext, groupName, repoName, repoPath, true)
+	}
+
+	// フックから通知を受け取るリポジトリと、新しく検出したリポジトリ（以前の参照がわからない）は通知しない
+	if !known || hasManagedPostReceiveHook(repoPath) || !hasPushNotifications(serverContext, repoPath) {
+		return
+	}
+	input := refUpdatesInput(before, after)
+	if input == "" {
+		// git gc で参照が packed-refs にまとめられた場合など
+		return
+	}
+	event := buildPushEvent(serverContext, groupName, repoName, repoPath, input)
+	dispatchPushEvent(serverContext, repoPath, event)
+}
+
+// handleEvent はファイルシステムの変更を、グループ、リポジトリ、参照の変更に振り分ける
+func (w *repositoryWatcher) handleEvent(event fsnotify.Event) {
+	rel, err := filepath.Rel(GitRepositoryHome, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+
+	switch {
+	case len(parts) == 1:
+		// 新しいグループ
+		if event.Has(fsnotify.Create) && isValidGroupName(parts[0]) {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				w.watchGroup(event.Name)
+			}
+		}
+	case len(parts) == 2:
+		// リポジトリの作成、削除、名前の変更（git init の途中で HEAD がまだない場合もあるため、待ってから確認する）
+		if !strings.HasPrefix(parts[1], ".") {
+			w.schedule(event.Name)
+		}
+	default:
+		// HEAD、packed-refs、refs/ 以下の変更（objects などの変更は参照が変わるまで一覧に影響しない）
+		switch parts[2] {
+		case "HEAD", "packed-refs", "refs":
+			w.schedule(filepath.Join(GitRepositoryHome, parts[0], parts[1]))
+		}
+	}
+}
+
+// run はサーバーが終了するまでファイルシステムの変更を処理する
+func (w *repositoryWatcher) run() {
+	defer w.watcher.Close()
+	for {
+		select {
+		case <-serverContext.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// 取りこぼした変更（ErrEventOverflow など）は、キャッシュの参照の確認と索引の定期更新で反映される
+			log.Printf("警告: リポジトリの監視でエラーが発生しました: %v", err)
+		}
+	}
+}
+
+// startRepositoryWatcher はリポジトリのディレクトリの監視を開始する
+// 監視できない環境（inotify の上限に達した場合など）では警告を出し、これまでどおり参照の確認と定期更新で反映する
+func startRepositoryWatcher() {
+	if !WatchRepositories {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("警告: リポジトリの監視を開始できません: %v", err)
+		return
+	}
+	w := &repositoryWatcher{watcher: watcher, refs: map[string]map[string]string{}, timers: map[string]*time.Timer{}}
+
+	w.add(GitRepositoryHome)
+	entries, _ := getDirectories(GitRepositoryHome)
+	for _, groupPath := range entries {
+		if isValidGroupName(filepath.Base(groupPath)) {
+			w.watchGroup(groupPath)
+		}
+	}
+
+	go w.run()
+}
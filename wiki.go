@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WikiRepositorySuffix は Wiki 用のリポジトリ名に付ける接尾辞（{name}.wiki.git）
+const WikiRepositorySuffix = ".wiki"
+
+// WikiHomePage はページ名を省略したときに表示するページ
+var WikiHomePage = "Home"
+
+// wikiPageExtensions は Wiki のページとして扱うファイルの拡張子（先頭が新規作成時に使う拡張子）
+var wikiPageExtensions = []string{".md", ".markdown"}
+
+// WikiPageInfo は Wiki のページ一覧の1件を表す
+type WikiPageInfo struct {
+	Name string `json:"name"` // ページ名（拡張子を除いたパス）
+	Path string `json:"path"` // Wiki リポジトリ内のファイルパス
+}
+
+// WikiIndex は Wiki の状態とページ一覧を表す
+type WikiIndex struct {
+	Exists     bool           `json:"exists"`     // Wiki リポジトリが作成済みかどうか
+	Repository string         `json:"repository"` // Wiki リポジトリの名前（contents API で編集するときに使う）
+	CloneURL   string         `json:"cloneUrl"`
+	CloneURLs  []CloneURL     `json:"cloneUrls"`
+	Pages      []WikiPageInfo `json:"pages"`
+}
+
+// WikiPage は Wiki の1ページを表す
+type WikiPage struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	SHA          string    `json:"sha"`     // blob の SHA（contents API で更新するときに指定する）
+	Content      string    `json:"content"` // Markdown 形式の本文
+	HTML         string    `json:"html"`    // 本文を HTML に変換したもの
+	LastModified time.Time `json:"lastModified"`
+}
+
+// isWikiRepositoryName はリポジトリ名が Wiki 用のリポジトリの名前かどうかを返す
+func isWikiRepositoryName(repoName string) bool {
+	return strings.HasSuffix(repoName, WikiRepositorySuffix)
+}
+
+// wikiRepositoryPath はリポジトリに対応する Wiki リポジトリのパスを返す
+func wikiRepositoryPath(groupName, repoName string) string {
+	return filepath.Join(GitRepositoryHome, groupName, repoName+WikiRepositorySuffix+".git")
+}
+
+// excludeWikiRepositories はリポジトリ一覧から Wiki 用のリポジトリを除く
+func excludeWikiRepositories(repos []GitRepository) []GitRepository {
+	filtered := []GitRepository{}
+	for _, repo := range repos {
+		if !isWikiRepositoryName(repo.Name) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered
+}
+
+// ensureWikiRepository は Wiki リポジトリがなければ作成する
+// 作成した場合は true を返す
+func ensureWikiRepository(ctx context.Context, groupName, repoName string) (bool, error) {
+	if _, ok := findRepository(groupName, repoName); !ok || isWikiRepositoryName(repoName) {
+		return false, fmt.Errorf("リポジトリが見つかりません")
+	}
+
+	if _, err := os.Stat(wikiRepositoryPath(groupName, repoName)); err == nil {
+		return false, nil
+	}
+
+	if err := createRepository(ctx, repoName+WikiRepositorySuffix, groupName, "", RepositoryTemplate{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// wikiPageName はファイルパスから拡張子を除いたページ名を返す（ページでない場合は空文字）
+func wikiPageName(filePath string) string {
+	for _, ext := range wikiPageExtensions {
+		if strings.HasSuffix(strings.ToLower(filePath), ext) {
+			return filePath[:len(filePath)-len(ext)]
+		}
+	}
+	return ""
+}
+
+// getWikiPages は Wiki リポジトリのデフォルトブランチにあるページの一覧を返す
+func getWikiPages(ctx context.Context, wikiPath string) ([]WikiPageInfo, error) {
+	pages := []WikiPageInfo{}
+	if !hasCommits(ctx, wikiPath) {
+		return pages, nil
+	}
+
+	cmd, cancel := gitCommand(ctx, "--git-dir="+wikiPath, "ls-tree", "-r", "-z", "HEAD")
+	defer cancel()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ページ一覧の取得に失敗しました: %w", err)
+	}
+
+	for _, record := range strings.Split(string(output), "\x00") {
+		entry, ok := parseTreeEntry(record)
+		if !ok || entry.Type != "blob" || entry.Mode == SymlinkMode {
+			continue
+		}
+		if name := wikiPageName(entry.Path); name != "" {
+			pages = append(pages, WikiPageInfo{Name: name, Path: entry.Path})
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return strings.ToLower(pages[i].Name) < strings.ToLower(pages[j].Name)
+	})
+
+	return pages, nil
+}
+
+// getWikiPage はページ名からページを取得する（見つからない場合は nil）
+func getWikiPage(ctx context.Context, wikiPath, name string) (*WikiPage, error) {
+	if !hasCommits(ctx, wikiPath) {
+		return nil, nil
+	}
+
+	for _, ext := range wikiPageExtensions {
+		filePath := name + ext
+		entry, err := getTreeEntry(ctx, wikiPath, "HEAD", filePath)
+		if err != nil || entry.Type != "blob" || entry.Mode == SymlinkMode {
+			continue
+		}
+
+		content, err := readBlob(ctx, wikiPath, entry.SHA)
+		if err != nil {
+			return nil, err
+		}
+
+		return &WikiPage{
+			Name:         name,
+			Path:         filePath,
+			SHA:          entry.SHA,
+			Content:      content,
+			HTML:         renderMarkdown(content),
+			LastModified: getFileLastModified(ctx, wikiPath, "HEAD", filePath),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// trashWikiRepository はリポジトリと一緒に Wiki リポジトリも論理削除する
+func trashWikiRepository(name string) {
+	groupName, baseName := splitRepositoryName(name)
+	if isWikiRepositoryName(baseName) {
+		return
+	}
+	if _, err := os.Stat(wikiRepositoryPath(groupName, baseName)); err != nil {
+		return
+	}
+	if err := deleteRepository(name + WikiRepositorySuffix); err != nil {
+		log.Printf("警告: リポジトリ '%s' の Wiki の削除に失敗しました: %v", name, err)
+	}
+}
+
+// restoreWikiRepository はリポジトリと一緒に論理削除された Wiki リポジトリを復元する
+func restoreWikiRepository(name string) {
+	groupName, baseName := splitRepositoryName(name)
+	if isWikiRepositoryName(baseName) {
+		return
+	}
+	if _, err := os.Lstat(wikiRepositoryPath(groupName, baseName) + ".deleted"); err != nil {
+		return
+	}
+	if err := restoreRepository(name + WikiRepositorySuffix); err != nil {
+		log.Printf("警告: リポジトリ '%s' の Wiki の復元に失敗しました: %v", name, err)
+	}
+}
+
+// purgeWikiRepository はリポジトリと一緒に論理削除された Wiki リポジトリを完全に削除する
+func purgeWikiRepository(name string) {
+	groupName, baseName := splitRepositoryName(name)
+	if isWikiRepositoryName(baseName) {
+		return
+	}
+	deletedPath := wikiRepositoryPath(groupName, baseName) + ".deleted"
+	if _, err := os.Lstat(deletedPath); err != nil {
+		return
+	}
+	if err := removeDeletedRepository(deletedPath); err != nil {
+		log.Printf("警告: リポジトリ '%s' の Wiki の完全削除に失敗しました: %v", name, err)
+	}
+}
+
+// wikiHandler はリポジトリの Wiki のページ一覧とページを返す
+// ページの作成・更新は Wiki リポジトリ（{repo}.wiki）に対する contents API で行う
+//
+//	GET  /api/repository/{group}/{repo}/wiki         ページ一覧
+//	POST /api/repository/{group}/{repo}/wiki         Wiki リポジトリを作成
+//	GET  /api/repository/{group}/{repo}/wiki/{page}  ページ
+func wikiHandler(w http.ResponseWriter, r *http.Request, groupName, repoName, pageName string) {
+	if isWikiRepositoryName(repoName) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Wiki のリポジトリに Wiki は作成できません"})
+		return
+	}
+
+	wikiPath := wikiRepositoryPath(groupName, repoName)
+	_, statErr := os.Stat(wikiPath)
+	exists := statErr == nil
+
+	if pageName == "" {
+		switch r.Method {
+		case http.MethodGet:
+			index := WikiIndex{
+				Exists:     exists,
+				Repository: repoName + WikiRepositorySuffix,
+				CloneURL:   repositoryCloneURL(cloneHostName(r), groupName, repoName+WikiRepositorySuffix),
+				CloneURLs:  repositoryCloneURLs(cloneHostName(r), groupName, repoName+WikiRepositorySuffix),
+				Pages:      []WikiPageInfo{},
+			}
+			if exists {
+				pages, err := getWikiPages(r.Context(), wikiPath)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+					return
+				}
+				index.Pages = pages
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(index)
+
+		case http.MethodPost:
+			created, err := ensureWikiRepository(r.Context(), groupName, repoName)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			if created {
+				recordAudit(r, "wiki.create", groupName, repoName, nil)
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Wiki を作成しました"})
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Wiki は既に作成されています"})
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "サポートされていないメソッドです"})
+		return
+	}
+
+	if err := validateContentsPath(pageName); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var page *WikiPage
+	if exists {
+		var err error
+		page, err = getWikiPage(r.Context(), wikiPath, pageName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if page == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("ページ '%s' が見つかりません", pageName)})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}